@@ -7,20 +7,34 @@ import (
 	"github.com/google/wire"
 	"goflare.io/nexus"
 
+	"goflare.io/auth/internal/admin"
+	"goflare.io/auth/internal/apikey"
+	"goflare.io/auth/internal/audit"
 	"goflare.io/auth/internal/authentication"
 	"goflare.io/auth/internal/authorization"
+	"goflare.io/auth/internal/config"
 	"goflare.io/auth/internal/firebase"
 	"goflare.io/auth/internal/handler"
+	"goflare.io/auth/internal/identity"
 	"goflare.io/auth/internal/middleware"
+	"goflare.io/auth/internal/migrations"
+	"goflare.io/auth/internal/oauth"
+	"goflare.io/auth/internal/outbox"
+	"goflare.io/auth/internal/permission"
+	"goflare.io/auth/internal/policy"
+	"goflare.io/auth/internal/relation"
+	"goflare.io/auth/internal/resource"
 	"goflare.io/auth/internal/role"
 	"goflare.io/auth/internal/server"
+	"goflare.io/auth/internal/serviceaccount"
+	"goflare.io/auth/internal/token"
 	"goflare.io/auth/internal/user"
 )
 
 func InitializeAuthService() (*server.Server, error) {
 
 	wire.Build(
-		//config.ProvideApplicationConfig,
+		config.ProvideApplicationConfig,
 		//config.NewLogger,
 		//config.ProvidePostgresConn,
 		//config.ProvideEnforcer,
@@ -29,13 +43,49 @@ func InitializeAuthService() (*server.Server, error) {
 		nexus.ProvidePostgresPool,
 		nexus.ProvideConfig,
 		nexus.ProvideEnforcer,
+		policy.ProvideDecisionCache,
 		user.NewRepository,
 		role.NewRepository,
+		resource.NewRepository,
+		resource.NewService,
+		relation.NewRepository,
+		relation.NewService,
+		identity.ProvideRegistry,
+		identity.ProvideTokenCipher,
+		identity.ProvideStateStore,
+		identity.ProvideFlow,
 		firebase.NewService,
 		authorization.NewService,
+		token.ProvideManager,
+		audit.NewRepository,
+		audit.NewService,
+		apikey.NewRepository,
+		apikey.NewService,
 		authentication.NewService,
+		serviceaccount.NewRepository,
+		serviceaccount.NewService,
+		oauth.NewRepository,
+		oauth.NewService,
+		oauth.ProvideSigner,
+		permission.NewRepository,
+		permission.NewService,
+		admin.NewService,
+		admin.NewBootstrap,
 		middleware.NewAuthenticationMiddleware,
 		handler.NewUserHandler,
+		handler.NewAuthorizationHandler,
+		handler.NewResourceHandler,
+		handler.NewIdentityHandler,
+		handler.NewOAuthHandler,
+		handler.NewServiceAccountHandler,
+		handler.NewAPIKeyHandler,
+		handler.NewAdminHandler,
+		handler.NewAuditHandler,
+		handler.NewGRPCHandler,
+		migrations.NewMigrator,
+		outbox.NewRepository,
+		outbox.ProvidePublisher,
+		outbox.NewRelay,
 		server.NewServer,
 	)
 
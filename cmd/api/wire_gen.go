@@ -7,13 +7,27 @@
 package main
 
 import (
+	"goflare.io/auth/internal/admin"
+	"goflare.io/auth/internal/apikey"
+	"goflare.io/auth/internal/audit"
 	"goflare.io/auth/internal/authentication"
 	"goflare.io/auth/internal/authorization"
+	"goflare.io/auth/internal/config"
 	"goflare.io/auth/internal/firebase"
 	"goflare.io/auth/internal/handler"
+	"goflare.io/auth/internal/identity"
 	"goflare.io/auth/internal/middleware"
+	"goflare.io/auth/internal/migrations"
+	"goflare.io/auth/internal/oauth"
+	"goflare.io/auth/internal/outbox"
+	"goflare.io/auth/internal/permission"
+	"goflare.io/auth/internal/policy"
+	"goflare.io/auth/internal/relation"
+	"goflare.io/auth/internal/resource"
 	"goflare.io/auth/internal/role"
 	"goflare.io/auth/internal/server"
+	"goflare.io/auth/internal/serviceaccount"
+	"goflare.io/auth/internal/token"
 	"goflare.io/auth/internal/user"
 	"goflare.io/nexus"
 )
@@ -25,20 +39,81 @@ func InitializeAuthService() (*server.Server, error) {
 	postgresPool := nexus.ProvidePostgresPool(core)
 	logger := nexus.ProvideLogger(core)
 	repository := user.NewRepository(postgresPool, logger)
-	config := nexus.ProvideConfig(core)
+	relationRepository := relation.NewRepository(postgresPool, logger)
+	resourceRepository := resource.NewRepository(postgresPool, logger)
+	resourceService := resource.NewService(resourceRepository)
+	nexusConfig := nexus.ProvideConfig(core)
 	enforcer, err := nexus.ProvideEnforcer(core)
 	if err != nil {
 		return nil, err
 	}
-	service := authentication.NewService(repository, config, enforcer, logger)
+	configConfig, err := config.ProvideApplicationConfig()
+	if err != nil {
+		return nil, err
+	}
+	auditRepository := audit.NewRepository(postgresPool, logger)
+	auditService := audit.NewService(auditRepository, configConfig, logger)
+	relationService := relation.NewService(relationRepository, resourceService, auditService)
+	tokenCipher, err := identity.ProvideTokenCipher(configConfig, logger)
+	if err != nil {
+		return nil, err
+	}
+	decisionCache := policy.ProvideDecisionCache()
+	manager, err := token.ProvideManager(nexusConfig, configConfig)
+	if err != nil {
+		return nil, err
+	}
+	apikeyRepository := apikey.NewRepository(postgresPool, logger)
+	apikeyService := apikey.NewService(apikeyRepository, enforcer, decisionCache, auditService, logger)
+	service := authentication.NewService(repository, relationService, tokenCipher, manager, apikeyService, enforcer, decisionCache, auditService, logger)
 	authenticationMiddleware := middleware.NewAuthenticationMiddleware(service)
-	firebaseService, err := firebase.NewService(repository, config, logger)
+	registry, err := identity.ProvideRegistry(configConfig, logger)
+	if err != nil {
+		return nil, err
+	}
+	stateStore, err := identity.ProvideStateStore(configConfig)
+	if err != nil {
+		return nil, err
+	}
+	flow := identity.ProvideFlow(registry, stateStore, configConfig)
+	firebaseService, err := firebase.NewService(repository, registry, configConfig, nexusConfig, logger)
 	if err != nil {
 		return nil, err
 	}
 	userHandler := handler.NewUserHandler(service, firebaseService, logger)
+	resourceHandler := handler.NewResourceHandler(service, resourceService, logger)
 	roleRepository := role.NewRepository(postgresPool, logger)
-	authorizationService := authorization.NewService(repository, roleRepository, enforcer, logger)
-	serverServer := server.NewServer(authenticationMiddleware, userHandler, service, authorizationService, logger)
+	serviceaccountRepository := serviceaccount.NewRepository(postgresPool, logger)
+	authorizationService := authorization.NewService(repository, roleRepository, serviceaccountRepository, enforcer, decisionCache, logger)
+	authorizationHandler := handler.NewAuthorizationHandler(service, authorizationService, relationService, logger)
+	identityHandler := handler.NewIdentityHandler(flow, service, logger)
+	oauthRepository := oauth.NewRepository(postgresPool, logger)
+	serviceaccountService := serviceaccount.NewService(serviceaccountRepository, enforcer, decisionCache, configConfig, auditService, logger)
+	signer, err := oauth.ProvideSigner(configConfig, logger)
+	if err != nil {
+		return nil, err
+	}
+	oauthService := oauth.NewService(oauthRepository, serviceaccountService, repository, manager, signer, configConfig, auditService, logger)
+	oAuthHandler := handler.NewOAuthHandler(oauthService, service, signer, manager, configConfig, logger)
+	serviceAccountHandler := handler.NewServiceAccountHandler(service, serviceaccountService, logger)
+	apiKeyHandler := handler.NewAPIKeyHandler(apikeyService, logger)
+	permissionRepository := permission.NewRepository(postgresPool, logger)
+	permissionService := permission.NewService(permissionRepository, resourceService)
+	adminService := admin.NewService(repository, roleRepository, permissionService, enforcer, decisionCache, auditService, logger)
+	bootstrap := admin.NewBootstrap(adminService, repository, configConfig, logger)
+	adminHandler := handler.NewAdminHandler(service, adminService, bootstrap, logger)
+	auditHandler := handler.NewAuditHandler(service, auditService, logger)
+	grpcHandler := handler.NewGRPCHandler(service, authorizationService, resourceService, repository, logger)
+	migrator, err := migrations.NewMigrator(postgresPool, configConfig, logger)
+	if err != nil {
+		return nil, err
+	}
+	outboxRepository := outbox.NewRepository(postgresPool, logger)
+	publisher, err := outbox.ProvidePublisher(configConfig, logger)
+	if err != nil {
+		return nil, err
+	}
+	relay := outbox.NewRelay(outboxRepository, publisher, configConfig, logger)
+	serverServer := server.NewServer(authenticationMiddleware, userHandler, authorizationHandler, resourceHandler, identityHandler, oAuthHandler, serviceAccountHandler, apiKeyHandler, adminHandler, auditHandler, grpcHandler, service, authorizationService, migrator, bootstrap, auditService, relay, configConfig, logger)
 	return serverServer, nil
 }
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"goflare.io/auth/internal/admin"
+)
+
+func TestAPIService(t *testing.T) {
+	tests := []struct {
+		name     string
+		call     func(ctx context.Context, s *apiService) error
+		wantReq  string
+		wantBody string
+	}{
+		{
+			name: "create user",
+			call: func(ctx context.Context, s *apiService) error {
+				_, err := s.CreateUser(ctx, "bob", "bob@example.com", "secret")
+				return err
+			},
+			wantReq:  "POST /admin/users",
+			wantBody: `{"email":"bob@example.com","password":"secret","username":"bob"}`,
+		},
+		{
+			name:    "disable user",
+			call:    func(ctx context.Context, s *apiService) error { return s.DisableUser(ctx, 3) },
+			wantReq: "POST /admin/users/3/disable",
+		},
+		{
+			name:    "delete user",
+			call:    func(ctx context.Context, s *apiService) error { return s.DeleteUser(ctx, 3) },
+			wantReq: "DELETE /admin/users/3",
+		},
+		{
+			name:    "assign role with an escaped name",
+			call:    func(ctx context.Context, s *apiService) error { return s.AssignRole(ctx, 3, "order editor") },
+			wantReq: "PUT /admin/users/3/roles/order%20editor",
+		},
+		{
+			name:    "revoke permission",
+			call:    func(ctx context.Context, s *apiService) error { return s.RevokePermission(ctx, "editor", 9) },
+			wantReq: "DELETE /admin/roles/editor/permissions/9",
+		},
+		{
+			name: "policies of a user",
+			call: func(ctx context.Context, s *apiService) error {
+				_, err := s.Policies(ctx, 3)
+				return err
+			},
+			wantReq: "GET /admin/policies?user_id=3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotReq, gotBody, gotAuth string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotReq = r.Method + " " + r.URL.RequestURI()
+				gotAuth = r.Header.Get("Authorization")
+				body, _ := io.ReadAll(r.Body)
+				gotBody = string(body)
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = io.WriteString(w, `{}`)
+			}))
+			defer srv.Close()
+
+			if err := tt.call(context.Background(), newAPIService(srv.URL+"/", "admin-token")); err != nil {
+				t.Fatalf("call error = %v", err)
+			}
+			if gotReq != tt.wantReq {
+				t.Errorf("request = %q, want %q", gotReq, tt.wantReq)
+			}
+			if gotAuth != "Bearer admin-token" {
+				t.Errorf("Authorization = %q, want %q", gotAuth, "Bearer admin-token")
+			}
+			if strings.TrimSpace(gotBody) != tt.wantBody {
+				t.Errorf("body = %q, want %q", gotBody, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestAPIServiceErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         int
+		wantUserExists bool
+	}{
+		{"conflict", http.StatusConflict, true},
+		{"forbidden", http.StatusForbidden, false},
+		{"server error", http.StatusInternalServerError, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				http.Error(w, `{"error":"failed"}`, tt.status)
+			}))
+			defer srv.Close()
+
+			_, err := newAPIService(srv.URL, "admin-token").CreateUser(context.Background(), "bob", "bob@example.com", "secret")
+			if err == nil {
+				t.Fatal("CreateUser() succeeded, want an error")
+			}
+			if got := errors.Is(err, admin.ErrUserExists); got != tt.wantUserExists {
+				t.Errorf("errors.Is(err, ErrUserExists) = %v, want %v (%v)", got, tt.wantUserExists, err)
+			}
+		})
+	}
+}
+
+func TestAPIServiceDecodes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `[{"id":1,"name":"admin"},{"id":2,"name":"editor"}]`)
+	}))
+	defer srv.Close()
+
+	roles, err := newAPIService(srv.URL, "admin-token").ListRoles(context.Background())
+	if err != nil {
+		t.Fatalf("ListRoles() error = %v", err)
+	}
+	if len(roles) != 2 || roles[1].Name != "editor" {
+		t.Errorf("ListRoles() = %v, want admin and editor", roles)
+	}
+}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"goflare.io/auth/internal/admin"
+	appconfig "goflare.io/auth/internal/config"
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/models/enum"
+	"goflare.io/auth/internal/policy"
+)
+
+// errUsage is returned for a command that is unknown or given the wrong arguments.
+var errUsage = errors.New("invalid command, run authctl -h for usage")
+
+// cli runs the commands that go through the admin service.
+type cli struct {
+	admin  admin.Service
+	out    io.Writer
+	asJSON bool
+}
+
+// newCLI creates a cli that prints to standard output.
+func newCLI(service admin.Service, asJSON bool) *cli {
+	return &cli{
+		admin:  service,
+		out:    os.Stdout,
+		asJSON: asJSON,
+	}
+}
+
+// run executes the command in args.
+func (c *cli) run(ctx context.Context, args []string) error {
+	if len(args) < 1 {
+		return errUsage
+	}
+	if args[0] == "bootstrap" {
+		return c.bootstrap(ctx, args[1:])
+	}
+	if len(args) < 2 {
+		return errUsage
+	}
+
+	command, rest := args[0]+" "+args[1], args[2:]
+	switch command {
+	case "users list":
+		return c.listUsers(ctx)
+	case "users create":
+		return c.createUser(ctx, rest)
+	case "users disable", "users enable":
+		if len(rest) != 1 {
+			return errUsage
+		}
+		userID, err := parseID(rest[0])
+		if err != nil {
+			return err
+		}
+		if args[1] == "disable" {
+			return c.admin.DisableUser(ctx, userID)
+		}
+		return c.admin.EnableUser(ctx, userID)
+	case "users delete":
+		if len(rest) != 1 {
+			return errUsage
+		}
+		userID, err := parseID(rest[0])
+		if err != nil {
+			return err
+		}
+		return c.admin.DeleteUser(ctx, userID)
+	case "roles list":
+		return c.listRoles(ctx)
+	case "roles assign", "roles remove":
+		if len(rest) != 2 {
+			return errUsage
+		}
+		userID, err := parseID(rest[0])
+		if err != nil {
+			return err
+		}
+		if args[1] == "assign" {
+			return c.admin.AssignRole(ctx, userID, rest[1])
+		}
+		return c.admin.RemoveRole(ctx, userID, rest[1])
+	case "permissions list":
+		return c.listPermissions(ctx)
+	case "permissions create":
+		return c.createPermission(ctx, rest)
+	case "permissions delete":
+		if len(rest) != 1 {
+			return errUsage
+		}
+		permissionID, err := parseID(rest[0])
+		if err != nil {
+			return err
+		}
+		return c.admin.DeletePermission(ctx, permissionID)
+	case "permissions grant", "permissions revoke":
+		if len(rest) != 2 {
+			return errUsage
+		}
+		permissionID, err := parseID(rest[1])
+		if err != nil {
+			return err
+		}
+		if args[1] == "grant" {
+			return c.admin.GrantPermission(ctx, rest[0], permissionID)
+		}
+		return c.admin.RevokePermission(ctx, rest[0], permissionID)
+	case "policies dump":
+		return c.dumpPolicies(ctx, rest)
+	case "fixtures load":
+		return c.loadFixtures(ctx, rest)
+	}
+
+	return errUsage
+}
+
+// listUsers prints every user.
+func (c *cli) listUsers(ctx context.Context) error {
+	users, err := c.admin.ListUsers(ctx)
+	if err != nil {
+		return err
+	}
+	if c.asJSON {
+		return c.printJSON(users)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tUSERNAME\tEMAIL\tSTATUS\tCREATED")
+	for _, u := range users {
+		status := "active"
+		if u.IsDisabled() {
+			status = "disabled"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", u.ID, u.Username, u.Email, status, u.CreatedAt.Format(time.RFC3339))
+	}
+	return w.Flush()
+}
+
+// createUser creates a user and assigns the roles given with -role.
+func (c *cli) createUser(ctx context.Context, args []string) error {
+	var roles stringList
+	flags := flag.NewFlagSet("users create", flag.ContinueOnError)
+	username := flags.String("username", "", "username of the user")
+	email := flags.String("email", "", "email the user signs in with")
+	flags.Var(&roles, "role", "role to assign, can be repeated")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" || *email == "" {
+		return errUsage
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+
+	created, err := c.admin.CreateUser(ctx, *username, *email, password)
+	if err != nil {
+		return err
+	}
+	for _, roleName := range roles {
+		if err = c.admin.AssignRole(ctx, created.ID, roleName); err != nil {
+			return fmt.Errorf("created user %d but failed to assign role %s: %w", created.ID, roleName, err)
+		}
+	}
+
+	fmt.Fprintf(c.out, "created user %d\n", created.ID)
+	return nil
+}
+
+// listRoles prints every role.
+func (c *cli) listRoles(ctx context.Context) error {
+	roles, err := c.admin.ListRoles(ctx)
+	if err != nil {
+		return err
+	}
+	if c.asJSON {
+		return c.printJSON(roles)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tDESCRIPTION")
+	for _, r := range roles {
+		fmt.Fprintf(w, "%d\t%s\t%s\n", r.ID, r.Name, r.Description)
+	}
+	return w.Flush()
+}
+
+// listPermissions prints every permission.
+func (c *cli) listPermissions(ctx context.Context) error {
+	permissions, err := c.admin.ListPermissions(ctx)
+	if err != nil {
+		return err
+	}
+	if c.asJSON {
+		return c.printJSON(permissions)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tOBJECT\tACTION\tEFFECT\tCONDITION\tTIME WINDOW")
+	for _, p := range permissions {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", p.ID, p.Name, p.Object(), p.Action, p.Effect, p.Condition, p.TimeWindow)
+	}
+	return w.Flush()
+}
+
+// createPermission creates a permission from the flags in args.
+func (c *cli) createPermission(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("permissions create", flag.ContinueOnError)
+	name := flags.String("name", "", "name of the permission")
+	description := flags.String("description", "", "description of the permission")
+	resource := flags.String("resource", "", "resource type, e.g. ORDER")
+	action := flags.String("action", "", "action, e.g. READ")
+	condition := flags.String("condition", "", "attribute condition")
+	timeWindow := flags.String("time-window", "", "time window the permission applies in")
+	pattern := flags.String("pattern", "", "pattern of the resource IDs the permission applies to")
+	deny := flags.Bool("deny", false, "deny the action, overriding every grant of it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" || *resource == "" || *action == "" {
+		return errUsage
+	}
+
+	created := &models.Permission{
+		Name:            *name,
+		Description:     *description,
+		Resource:        enum.ResourceType(*resource),
+		Action:          enum.ActionType(*action),
+		Condition:       *condition,
+		TimeWindow:      *timeWindow,
+		ResourcePattern: *pattern,
+	}
+	if *deny {
+		created.Effect = policy.EffectDeny
+	}
+	if err := c.admin.CreatePermission(ctx, created); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.out, "created permission %d\n", created.ID)
+	return nil
+}
+
+// dumpPolicies prints the policies of the enforcer, or those that apply to the user given with -user, as JSON.
+func (c *cli) dumpPolicies(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("policies dump", flag.ContinueOnError)
+	userID := flags.Uint64("user", 0, "only dump the policies that apply to this user")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	policies, err := c.admin.Policies(ctx, *userID)
+	if err != nil {
+		return err
+	}
+	return c.printJSON(policies)
+}
+
+// loadFixtures creates the users in the fixtures of an environment, by default the one of the configuration.
+func (c *cli) loadFixtures(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("fixtures load", flag.ContinueOnError)
+	environment := flags.String("env", "", "environment whose fixtures to load, defaults to environment in the config")
+	path := flags.String("file", "", "fixtures file to load instead of the one of the environment")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *path == "" {
+		if *environment == "" {
+			cfg, err := appconfig.ProvideApplicationConfig()
+			if err != nil {
+				return err
+			}
+			*environment = cfg.Environment
+		}
+		if *environment == "" {
+			return fmt.Errorf("no environment given and none in the config")
+		}
+		*path = admin.FixturesPath(*environment)
+	}
+
+	fixtures, err := admin.LoadFixtures(*path)
+	if err != nil {
+		return err
+	}
+	created, err := admin.ApplyFixtures(ctx, c.admin, fixtures)
+	for _, username := range created {
+		fmt.Fprintf(c.out, "created user %s\n", username)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.out, "loaded %s, %d of %d users were new\n", *path, len(created), len(fixtures.Users))
+	return nil
+}
+
+// bootstrap creates the first administrator.
+func (c *cli) bootstrap(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("bootstrap", flag.ContinueOnError)
+	username := flags.String("username", "", "username of the administrator")
+	email := flags.String("email", "", "email the administrator signs in with")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" || *email == "" {
+		return errUsage
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+
+	created, err := c.admin.BootstrapAdmin(ctx, *username, *email, password)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.out, "created administrator %d\n", created.ID)
+	return nil
+}
+
+// printJSON prints v as indented JSON.
+func (c *cli) printJSON(v any) error {
+	encoder := json.NewEncoder(c.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// readPassword reads the password from AUTHCTL_PASSWORD, or from the first line of standard input so that it
+// does not show up in the process list or shell history.
+func readPassword() (string, error) {
+	if password := os.Getenv("AUTHCTL_PASSWORD"); password != "" {
+		return password, nil
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", fmt.Errorf("no password given")
+	}
+	return password, nil
+}
+
+// parseID parses a user or permission ID.
+func parseID(value string) (uint64, error) {
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid id %q", value)
+	}
+	return id, nil
+}
+
+// stringList is a flag that can be repeated.
+type stringList []string
+
+// String returns the values joined by commas.
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set appends a value.
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"goflare.io/auth/internal/admin"
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/policy"
+)
+
+// recordingAdmin records the calls of the admin service the commands make.
+type recordingAdmin struct {
+	admin.Service
+	calls       []string
+	permissions []*models.Permission
+}
+
+func (a *recordingAdmin) record(format string, args ...any) {
+	a.calls = append(a.calls, fmt.Sprintf(format, args...))
+}
+
+func (a *recordingAdmin) ListUsers(context.Context) ([]*models.User, error) {
+	a.record("ListUsers")
+	return []*models.User{{ID: 1, Username: "alice", Email: "alice@example.com"}}, nil
+}
+
+func (a *recordingAdmin) CreateUser(_ context.Context, username, email, password string) (*models.User, error) {
+	a.record("CreateUser %s %s %s", username, email, password)
+	return &models.User{ID: 7, Username: username, Email: email}, nil
+}
+
+func (a *recordingAdmin) DisableUser(_ context.Context, userID uint64) error {
+	a.record("DisableUser %d", userID)
+	return nil
+}
+
+func (a *recordingAdmin) EnableUser(_ context.Context, userID uint64) error {
+	a.record("EnableUser %d", userID)
+	return nil
+}
+
+func (a *recordingAdmin) DeleteUser(_ context.Context, userID uint64) error {
+	a.record("DeleteUser %d", userID)
+	return nil
+}
+
+func (a *recordingAdmin) AssignRole(_ context.Context, userID uint64, roleName string) error {
+	a.record("AssignRole %d %s", userID, roleName)
+	return nil
+}
+
+func (a *recordingAdmin) RemoveRole(_ context.Context, userID uint64, roleName string) error {
+	a.record("RemoveRole %d %s", userID, roleName)
+	return nil
+}
+
+func (a *recordingAdmin) CreatePermission(_ context.Context, permission *models.Permission) error {
+	a.record("CreatePermission %s %s:%s %s", permission.Name, permission.Resource, permission.Action, permission.Effect)
+	permission.ID = 9
+	a.permissions = append(a.permissions, permission)
+	return nil
+}
+
+func (a *recordingAdmin) DeletePermission(_ context.Context, permissionID uint64) error {
+	a.record("DeletePermission %d", permissionID)
+	return nil
+}
+
+func (a *recordingAdmin) GrantPermission(_ context.Context, roleName string, permissionID uint64) error {
+	a.record("GrantPermission %s %d", roleName, permissionID)
+	return nil
+}
+
+func (a *recordingAdmin) RevokePermission(_ context.Context, roleName string, permissionID uint64) error {
+	a.record("RevokePermission %s %d", roleName, permissionID)
+	return nil
+}
+
+func (a *recordingAdmin) Policies(_ context.Context, userID uint64) (*models.PolicySet, error) {
+	a.record("Policies %d", userID)
+	return &models.PolicySet{}, nil
+}
+
+func (a *recordingAdmin) BootstrapAdmin(_ context.Context, username, email, password string) (*models.User, error) {
+	a.record("BootstrapAdmin %s %s %s", username, email, password)
+	return &models.User{ID: 1, Username: username, Email: email}, nil
+}
+
+func TestCLIRun(t *testing.T) {
+	t.Setenv("AUTHCTL_PASSWORD", "secret")
+
+	tests := []struct {
+		name      string
+		args      string
+		wantCalls []string
+		wantOut   string
+		wantErr   bool
+	}{
+		{name: "list users", args: "users list", wantCalls: []string{"ListUsers"}, wantOut: "alice@example.com"},
+		{
+			name:      "create user with roles",
+			args:      "users create -username bob -email bob@example.com -role editor -role viewer",
+			wantCalls: []string{"CreateUser bob bob@example.com secret", "AssignRole 7 editor", "AssignRole 7 viewer"},
+			wantOut:   "created user 7",
+		},
+		{name: "create user without email", args: "users create -username bob", wantErr: true},
+		{name: "disable user", args: "users disable 3", wantCalls: []string{"DisableUser 3"}},
+		{name: "enable user", args: "users enable 3", wantCalls: []string{"EnableUser 3"}},
+		{name: "delete user", args: "users delete 3", wantCalls: []string{"DeleteUser 3"}},
+		{name: "delete user with invalid id", args: "users delete 0", wantErr: true},
+		{name: "assign role", args: "roles assign 3 editor", wantCalls: []string{"AssignRole 3 editor"}},
+		{name: "remove role", args: "roles remove 3 editor", wantCalls: []string{"RemoveRole 3 editor"}},
+		{name: "assign role without a role", args: "roles assign 3", wantErr: true},
+		{
+			name:      "create permission",
+			args:      "permissions create -name read-orders -resource ORDER -action READ",
+			wantCalls: []string{"CreatePermission read-orders ORDER:READ "},
+			wantOut:   "created permission 9",
+		},
+		{
+			name:      "create deny permission",
+			args:      "permissions create -name no-deletes -resource ORDER -action DELETE -deny",
+			wantCalls: []string{"CreatePermission no-deletes ORDER:DELETE " + policy.EffectDeny},
+		},
+		{name: "delete permission", args: "permissions delete 9", wantCalls: []string{"DeletePermission 9"}},
+		{name: "grant permission", args: "permissions grant editor 9", wantCalls: []string{"GrantPermission editor 9"}},
+		{name: "revoke permission", args: "permissions revoke editor 9", wantCalls: []string{"RevokePermission editor 9"}},
+		{name: "grant permission with invalid id", args: "permissions grant editor nine", wantErr: true},
+		{name: "dump policies of a user", args: "policies dump -user 3", wantCalls: []string{"Policies 3"}},
+		{
+			name:      "bootstrap",
+			args:      "bootstrap -username root -email root@example.com",
+			wantCalls: []string{"BootstrapAdmin root root@example.com secret"},
+			wantOut:   "created administrator 1",
+		},
+		{name: "unknown command", args: "users rename 3", wantErr: true},
+		{name: "missing subcommand", args: "users", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &recordingAdmin{}
+			out := &bytes.Buffer{}
+			c := &cli{admin: service, out: out}
+
+			err := c.run(context.Background(), strings.Fields(tt.args))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if strings.Join(service.calls, "\n") != strings.Join(tt.wantCalls, "\n") {
+				t.Errorf("calls = %q, want %q", service.calls, tt.wantCalls)
+			}
+			if !strings.Contains(out.String(), tt.wantOut) {
+				t.Errorf("output = %q, want it to contain %q", out.String(), tt.wantOut)
+			}
+		})
+	}
+}
+
+func TestRunAPIRestrictions(t *testing.T) {
+	t.Setenv("AUTHCTL_TOKEN", "")
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"migrate", []string{"migrate", "up"}},
+		{"bootstrap", []string{"bootstrap", "-username", "root", "-email", "root@example.com"}},
+		{"without a token", []string{"users", "list"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := run(context.Background(), "https://auth.example.com", false, tt.args); err == nil {
+				t.Error("run() succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestParseID(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    uint64
+		wantErr bool
+	}{
+		{"42", 42, false},
+		{"0", 0, true},
+		{"-1", 0, true},
+		{"abc", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseID(tt.value)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("parseID() = %d, %v, want %d, wantErr %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunKeysUsage(t *testing.T) {
+	for _, args := range [][]string{nil, {"generate", "extra"}, {"import"}} {
+		if err := runKeys(args); !errors.Is(err, errUsage) {
+			t.Errorf("runKeys(%q) error = %v, want errUsage", args, err)
+		}
+	}
+}
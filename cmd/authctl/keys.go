@@ -0,0 +1,109 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	appconfig "goflare.io/auth/internal/config"
+)
+
+// keyConfig is the part of the configuration file that holds the PASETO keys.
+type keyConfig struct {
+	Paseto struct {
+		PublicKey  string `yaml:"public_key"`
+		PrivateKey string `yaml:"private_key"`
+	} `yaml:"paseto"`
+	Tokens appconfig.TokensConfig `yaml:"tokens,omitempty"`
+}
+
+// runKeys executes a keys command.
+func runKeys(args []string) error {
+	if len(args) < 1 {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "generate":
+		if len(args) != 1 {
+			return errUsage
+		}
+		generated, err := generateKeys()
+		if err != nil {
+			return err
+		}
+		return printKeys(generated)
+	case "rotate":
+		return rotateKeys(args[1:])
+	}
+
+	return errUsage
+}
+
+// rotateKeys generates a key pair to replace the current one in the configuration file, and retires the current
+// public key to tokens.previous_public_keys so that the tokens it signed stay valid until they expire.
+func rotateKeys(args []string) error {
+	flags := flag.NewFlagSet("keys rotate", flag.ContinueOnError)
+	path := flags.String("config", configPath(), "configuration file with the current key pair")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(*path)
+	if err != nil {
+		return fmt.Errorf("failed to read config %s: %w", *path, err)
+	}
+	current := &keyConfig{}
+	if err = yaml.Unmarshal([]byte(os.ExpandEnv(string(data))), current); err != nil {
+		return fmt.Errorf("failed to parse config %s: %w", *path, err)
+	}
+	if current.Paseto.PublicKey == "" {
+		return fmt.Errorf("config %s has no paseto.public_key to rotate", *path)
+	}
+
+	generated, err := generateKeys()
+	if err != nil {
+		return err
+	}
+	generated.Tokens.PreviousPublicKeys = append([]string{current.Paseto.PublicKey}, current.Tokens.PreviousPublicKeys...)
+
+	fmt.Fprintln(os.Stderr, "Replace these settings in the configuration of every instance. Remove a previous key")
+	fmt.Fprintln(os.Stderr, "once the access tokens it signed have expired.")
+	return printKeys(generated)
+}
+
+// generateKeys generates an Ed25519 key pair in the encoding of the configuration file.
+func generateKeys() (*keyConfig, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key pair: %w", err)
+	}
+
+	generated := &keyConfig{}
+	generated.Paseto.PublicKey = base64.StdEncoding.EncodeToString(publicKey)
+	generated.Paseto.PrivateKey = base64.StdEncoding.EncodeToString(privateKey)
+	return generated, nil
+}
+
+// printKeys prints keys as YAML to paste into the configuration file.
+func printKeys(keys *keyConfig) error {
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(keys); err != nil {
+		return fmt.Errorf("failed to print keys: %w", err)
+	}
+	return encoder.Close()
+}
+
+// configPath returns the path of the configuration file the service reads.
+func configPath() string {
+	if path := os.Getenv(appconfig.PathEnv); path != "" {
+		return path
+	}
+	return appconfig.DefaultPath
+}
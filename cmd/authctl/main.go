@@ -0,0 +1,104 @@
+// Command authctl administers the auth service: it creates, lists, disables and deletes users, assigns roles,
+// manages permissions, dumps effective policies, generates and rotates PASETO key pairs, bootstraps the first admin,
+// and applies the database migrations.
+//
+// By default it works on the database of the nexus configuration. Changes made this way reach running instances
+// of the service when they restart; pass -api to make them through the admin API of a running instance instead,
+// with an access token of an administrator in AUTHCTL_TOKEN.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+)
+
+const usage = `Usage: authctl [-api URL] [-json] <command> <subcommand> [arguments]
+
+Commands:
+  users list
+  users create -username NAME -email EMAIL [-role ROLE]...
+  users disable USER_ID
+  users enable USER_ID
+  users delete USER_ID
+  roles list
+  roles assign USER_ID ROLE
+  roles remove USER_ID ROLE
+  permissions list
+  permissions create -name NAME -resource RESOURCE -action ACTION [-description TEXT]
+                     [-condition EXPR] [-time-window WINDOW] [-pattern PATTERN] [-deny]
+  permissions delete PERMISSION_ID
+  permissions grant ROLE PERMISSION_ID
+  permissions revoke ROLE PERMISSION_ID
+  policies dump [-user USER_ID]
+  keys generate
+  keys rotate [-config PATH]
+  bootstrap -username NAME -email EMAIL
+  fixtures load [-env ENV] [-file PATH]
+  migrate up
+  migrate down [-steps N]
+  migrate status
+
+Passwords are read from AUTHCTL_PASSWORD, or from the first line of standard input.
+bootstrap and migrate work on the database only.
+
+Flags:
+`
+
+func main() {
+	flags := flag.NewFlagSet("authctl", flag.ExitOnError)
+	api := flags.String("api", os.Getenv("AUTHCTL_API"), "base URL of the admin API, e.g. https://auth.example.com")
+	asJSON := flags.Bool("json", false, "print lists as JSON")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(os.Args[1:])
+
+	args := flags.Args()
+	if len(args) < 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(context.Background(), *api, *asJSON, args); err != nil {
+		fmt.Fprintln(os.Stderr, "authctl:", err)
+		os.Exit(1)
+	}
+}
+
+// run executes the command in args.
+func run(ctx context.Context, api string, asJSON bool, args []string) error {
+
+	// Key pairs are generated locally and need no service.
+	if args[0] == "keys" {
+		return runKeys(args[1:])
+	}
+	if args[0] == "migrate" {
+		if api != "" {
+			return fmt.Errorf("migrate works on the database only")
+		}
+		return runMigrate(ctx, args[1:])
+	}
+
+	var c *cli
+	if api != "" {
+		if args[0] == "bootstrap" {
+			return fmt.Errorf("bootstrap works on the database only, since the admin API needs an admin")
+		}
+		token := os.Getenv("AUTHCTL_TOKEN")
+		if token == "" {
+			return fmt.Errorf("AUTHCTL_TOKEN must hold an access token to use the admin API")
+		}
+		c = newCLI(newAPIService(api, token), asJSON)
+	} else {
+		service, err := InitializeAdminService()
+		if err != nil {
+			return fmt.Errorf("failed to initialize: %w", err)
+		}
+		c = newCLI(service, asJSON)
+	}
+
+	return c.run(ctx, args)
+}
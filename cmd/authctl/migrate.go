@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"goflare.io/auth/internal/migrations"
+)
+
+// runMigrate executes a migrate command on the database of the nexus configuration.
+func runMigrate(ctx context.Context, args []string) error {
+	if len(args) < 1 {
+		return errUsage
+	}
+
+	migrator, err := InitializeMigrator()
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+
+	switch args[0] {
+	case "up":
+		if len(args) != 1 {
+			return errUsage
+		}
+		applied, err := migrator.Up(ctx)
+		printMigrations("applied", applied)
+		return err
+	case "down":
+		flags := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		steps := flags.Int("steps", 1, "number of migrations to undo")
+		if err = flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		undone, err := migrator.Down(ctx, *steps)
+		printMigrations("undid", undone)
+		return err
+	case "status":
+		if len(args) != 1 {
+			return errUsage
+		}
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		return printStatus(status)
+	}
+
+	return errUsage
+}
+
+// printMigrations prints the migrations that were applied or undone.
+func printMigrations(verb string, done []*migrations.Migration) {
+	for _, migration := range done {
+		fmt.Printf("%s %06d_%s\n", verb, migration.Version, migration.Title)
+	}
+	if len(done) == 0 {
+		fmt.Println("no change")
+	}
+}
+
+// printStatus prints the schema version and whether each migration is applied.
+func printStatus(status *migrations.Status) error {
+	fmt.Printf("version %d of %d", status.Version, status.Latest())
+	if status.Dirty {
+		fmt.Print(" (dirty)")
+	}
+	if status.Version > status.Latest() {
+		fmt.Print(" (newer than this release)")
+	}
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tTITLE\tSTATE")
+	for _, migration := range status.Migrations {
+		state := "applied"
+		if migration.Version > status.Version {
+			state = "pending"
+		}
+		fmt.Fprintf(w, "%06d\t%s\t%s\n", migration.Version, migration.Title, state)
+	}
+	return w.Flush()
+}
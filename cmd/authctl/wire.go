@@ -0,0 +1,57 @@
+//go:build wireinject
+// +build wireinject
+
+package main
+
+import (
+	"github.com/google/wire"
+	"goflare.io/nexus"
+
+	"goflare.io/auth/internal/admin"
+	"goflare.io/auth/internal/audit"
+	"goflare.io/auth/internal/config"
+	"goflare.io/auth/internal/migrations"
+	"goflare.io/auth/internal/permission"
+	"goflare.io/auth/internal/policy"
+	"goflare.io/auth/internal/resource"
+	"goflare.io/auth/internal/role"
+	"goflare.io/auth/internal/user"
+)
+
+// InitializeAdminService creates the admin service on the database and enforcer of the nexus configuration.
+func InitializeAdminService() (admin.Service, error) {
+
+	wire.Build(
+		nexus.NewCore,
+		nexus.ProvideLogger,
+		nexus.ProvidePostgresPool,
+		nexus.ProvideEnforcer,
+		config.ProvideApplicationConfig,
+		policy.ProvideDecisionCache,
+		audit.NewRepository,
+		audit.NewService,
+		user.NewRepository,
+		role.NewRepository,
+		resource.NewRepository,
+		resource.NewService,
+		permission.NewRepository,
+		permission.NewService,
+		admin.NewService,
+	)
+
+	return nil, nil
+}
+
+// InitializeMigrator creates the migrator of the embedded migrations on the database of the nexus configuration.
+func InitializeMigrator() (migrations.Migrator, error) {
+
+	wire.Build(
+		nexus.NewCore,
+		nexus.ProvideLogger,
+		nexus.ProvidePostgresPool,
+		config.ProvideApplicationConfig,
+		migrations.NewMigrator,
+	)
+
+	return nil, nil
+}
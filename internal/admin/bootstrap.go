@@ -0,0 +1,160 @@
+package admin
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"go.uber.org/zap"
+
+	appconfig "goflare.io/auth/internal/config"
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/user"
+)
+
+// ErrInvalidBootstrapToken is returned by Redeem for a wrong token, or when no bootstrap is pending.
+var ErrInvalidBootstrapToken = errors.New("invalid bootstrap token")
+
+// _ ensures that *bootstrap implements the Bootstrap interface at compile time.
+var _ Bootstrap = (*bootstrap)(nil)
+
+// Bootstrap creates the first administrator of a new installation, since no account exists before it.
+type Bootstrap interface {
+	// Prepare runs when the service starts. While no user has the admin role, it creates the administrator from
+	// the configured credentials, or else arms the one-time token that Redeem accepts.
+	Prepare(ctx context.Context) error
+	// Redeem creates the first administrator with the one-time token, which cannot be used again.
+	Redeem(ctx context.Context, token, username, email, password string) (*models.User, error)
+}
+
+type bootstrap struct {
+	admin  Service
+	users  user.Repository
+	config appconfig.BootstrapConfig
+	logger *zap.Logger
+
+	mu        sync.Mutex
+	tokenHash []byte
+}
+
+// NewBootstrap creates a new Bootstrap.
+func NewBootstrap(admin Service, users user.Repository, cfg *appconfig.Config, logger *zap.Logger) Bootstrap {
+	return &bootstrap{
+		admin:  admin,
+		users:  users,
+		config: cfg.Bootstrap,
+		logger: logger,
+	}
+}
+
+// Prepare creates the first administrator or arms the one-time token.
+func (b *bootstrap) Prepare(ctx context.Context) error {
+	admins, err := b.users.CountUsersWithRole(ctx, AdminRole)
+	if err != nil {
+		return err
+	}
+	if admins > 0 {
+		return nil
+	}
+
+	if b.config.Username != "" || b.config.Email != "" || b.config.Password != "" {
+		_, err = b.admin.BootstrapAdmin(ctx, b.config.Username, b.config.Email, b.config.Password)
+		// A replica starting at the same time may have created the administrator first.
+		if errors.Is(err, ErrAlreadyBootstrapped) || errors.Is(err, ErrUserExists) {
+			b.logger.Info("the first administrator was created by another instance")
+			return nil
+		}
+		return err
+	}
+
+	token := b.config.Token
+	if token == "" {
+		if token, err = b.generateToken(); err != nil {
+			return err
+		}
+	} else {
+		b.logger.Warn("no administrator exists; create one with POST /bootstrap and the configured token")
+	}
+
+	hash := sha256.Sum256([]byte(token))
+	b.mu.Lock()
+	b.tokenHash = hash[:]
+	b.mu.Unlock()
+
+	return nil
+}
+
+// generateToken generates a one-time token and hands it to the operator through the token file, or else through
+// stderr if it is a terminal. Logs are often collected and kept where others can read them, so the token is never
+// logged, and without a way to hand it over, bootstrap.token is required.
+func (b *bootstrap) generateToken() (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(random)
+
+	if b.config.TokenFile != "" {
+		if err := writeTokenFile(b.config.TokenFile, token); err != nil {
+			return "", fmt.Errorf("failed to write the bootstrap token file: %w", err)
+		}
+		b.logger.Warn("no administrator exists; create one with POST /bootstrap and the one-time token in the token file",
+			zap.String("token_file", b.config.TokenFile))
+		return token, nil
+	}
+
+	if info, err := os.Stderr.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprintf(os.Stderr, "No administrator exists. Create one with POST /bootstrap and this one-time token:\n\n\t%s\n\n", token)
+		b.logger.Warn("no administrator exists; create one with POST /bootstrap and the one-time token shown on stderr")
+		return token, nil
+	}
+
+	return "", errors.New("no administrator exists and stderr is not a terminal to show a one-time token on: " +
+		"set bootstrap.token or bootstrap.token_file, or the credentials of the first administrator")
+}
+
+// writeTokenFile writes token to a file only its owner can read, replacing any earlier one.
+func writeTokenFile(path, token string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	// The mode of OpenFile only applies to new files.
+	if err = file.Chmod(0o600); err != nil {
+		return errors.Join(err, file.Close())
+	}
+	if _, err = file.WriteString(token + "\n"); err != nil {
+		return errors.Join(err, file.Close())
+	}
+	return file.Close()
+}
+
+// Redeem creates the first administrator with the one-time token.
+func (b *bootstrap) Redeem(ctx context.Context, token, username, email, password string) (*models.User, error) {
+	hash := sha256.Sum256([]byte(token))
+
+	// The lock is held throughout, so that the token cannot be redeemed twice at once.
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokenHash == nil || subtle.ConstantTimeCompare(b.tokenHash, hash[:]) != 1 {
+		return nil, ErrInvalidBootstrapToken
+	}
+
+	administrator, err := b.admin.BootstrapAdmin(ctx, username, email, password)
+	if err == nil || errors.Is(err, ErrAlreadyBootstrapped) {
+		b.tokenHash = nil
+		if b.config.TokenFile != "" && b.config.Token == "" {
+			if removeErr := os.Remove(b.config.TokenFile); removeErr != nil && !errors.Is(removeErr, os.ErrNotExist) {
+				b.logger.Warn("failed to remove the bootstrap token file", zap.Error(removeErr))
+			}
+		}
+	}
+	return administrator, err
+}
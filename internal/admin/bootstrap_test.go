@@ -0,0 +1,34 @@
+package admin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteTokenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bootstrap-token")
+	// An earlier file others can read must not keep its mode.
+	if err := os.WriteFile(path, []byte("old token, longer than the new one\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeTokenFile(path, "token"); err != nil {
+		t.Fatalf("writeTokenFile failed: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o600 {
+		t.Errorf("mode = %v, want 0600", mode)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "token\n" {
+		t.Errorf("content = %q, want %q", data, "token\n")
+	}
+}
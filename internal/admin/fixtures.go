@@ -0,0 +1,67 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fixtures is data loaded on request into a database of a given environment, such as the accounts developers
+// sign in with. Unlike migrations it is never applied on its own.
+type Fixtures struct {
+	Users []FixtureUser `yaml:"users"`
+}
+
+// FixtureUser is a user created by fixtures.
+type FixtureUser struct {
+	Username string   `yaml:"username"`
+	Email    string   `yaml:"email"`
+	Password string   `yaml:"password"`
+	Roles    []string `yaml:"roles"`
+}
+
+// FixturesPath returns the fixtures file of an environment.
+func FixturesPath(environment string) string {
+	return "configs/fixtures/" + environment + ".yaml"
+}
+
+// LoadFixtures reads a fixtures file. ${VAR} references are expanded from the environment, as in the configuration.
+func LoadFixtures(path string) (*Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures %s: %w", path, err)
+	}
+
+	fixtures := &Fixtures{}
+	if err = yaml.Unmarshal([]byte(os.ExpandEnv(string(data))), fixtures); err != nil {
+		return nil, fmt.Errorf("failed to parse fixtures %s: %w", path, err)
+	}
+
+	return fixtures, nil
+}
+
+// ApplyFixtures creates the users of fixtures with their roles through service, skipping those whose username or
+// email is taken so that fixtures can be applied again. It returns the usernames of the users it created.
+func ApplyFixtures(ctx context.Context, service Service, fixtures *Fixtures) ([]string, error) {
+	var created []string
+	for _, fixture := range fixtures.Users {
+		u, err := service.CreateUser(ctx, fixture.Username, fixture.Email, fixture.Password)
+		if errors.Is(err, ErrUserExists) {
+			continue
+		}
+		if err != nil {
+			return created, fmt.Errorf("failed to create user %s: %w", fixture.Username, err)
+		}
+		for _, roleName := range fixture.Roles {
+			if err = service.AssignRole(ctx, u.ID, roleName); err != nil {
+				return created, fmt.Errorf("created user %s but failed to assign role %s: %w", fixture.Username, roleName, err)
+			}
+		}
+		created = append(created, fixture.Username)
+	}
+
+	return created, nil
+}
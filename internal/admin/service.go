@@ -0,0 +1,497 @@
+// Package admin manages users, their roles, and the permissions of roles, keeping the enforcer in step with the
+// database. It backs the admin API and the authctl command.
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/casbin/casbin/v2"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+
+	"goflare.io/auth/internal/audit"
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/permission"
+	"goflare.io/auth/internal/policy"
+	"goflare.io/auth/internal/role"
+	"goflare.io/auth/internal/user"
+)
+
+const (
+	// AdminRole is the role BootstrapAdmin assigns to the first administrator.
+	AdminRole = "admin"
+
+	// MinPasswordLength is the minimum length of the passwords of users created by an administrator.
+	MinPasswordLength = 8
+)
+
+var (
+	// ErrAlreadyBootstrapped is returned by BootstrapAdmin once a user has the admin role.
+	ErrAlreadyBootstrapped = errors.New("an administrator already exists")
+
+	// ErrUserExists is returned when creating a user whose username or email is taken.
+	ErrUserExists = errors.New("a user with this username or email already exists")
+
+	// ErrWeakPassword is returned for a password shorter than MinPasswordLength.
+	ErrWeakPassword = fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+)
+
+// _ ensures that *service implements the Service interface at compile time.
+var _ Service = (*service)(nil)
+
+// Service manages users, roles and permissions on behalf of an administrator.
+type Service interface {
+	// ListUsers lists every user.
+	ListUsers(ctx context.Context) ([]*models.User, error)
+	// CreateUser creates a user who signs in with email and password.
+	CreateUser(ctx context.Context, username, email, password string) (*models.User, error)
+	// DisableUser keeps a user from signing in and withdraws the permissions of their roles.
+	DisableUser(ctx context.Context, userID uint64) error
+	// EnableUser lets a disabled user sign in again and restores the permissions of their roles.
+	EnableUser(ctx context.Context, userID uint64) error
+	// DeleteUser deletes a user with their roles, identities and keys.
+	DeleteUser(ctx context.Context, userID uint64) error
+	// AssignRole assigns a role to a user.
+	AssignRole(ctx context.Context, userID uint64, roleName string) error
+	// RemoveRole removes a role from a user.
+	RemoveRole(ctx context.Context, userID uint64, roleName string) error
+	// ListRoles lists every role.
+	ListRoles(ctx context.Context) ([]*models.Role, error)
+	// ListPermissions lists every permission.
+	ListPermissions(ctx context.Context) ([]*models.Permission, error)
+	// CreatePermission creates a permission, which has no effect until it is granted to a role.
+	CreatePermission(ctx context.Context, permission *models.Permission) error
+	// DeletePermission deletes a permission and revokes it from every role.
+	DeletePermission(ctx context.Context, permissionID uint64) error
+	// GrantPermission grants a permission to a role.
+	GrantPermission(ctx context.Context, roleName string, permissionID uint64) error
+	// RevokePermission revokes a permission from a role.
+	RevokePermission(ctx context.Context, roleName string, permissionID uint64) error
+	// Policies returns the policies of the enforcer, or only those that apply to a user when userID is not zero.
+	Policies(ctx context.Context, userID uint64) (*models.PolicySet, error)
+	// BootstrapAdmin creates the first administrator, unless a user has the admin role already.
+	BootstrapAdmin(ctx context.Context, username, email, password string) (*models.User, error)
+}
+
+// service is the implementation of Service.
+type service struct {
+	users       user.Repository
+	roles       role.Repository
+	permissions permission.Service
+	enforcer    *casbin.Enforcer
+	decisions   *policy.DecisionCache
+	audit       audit.Service
+	logger      *zap.Logger
+}
+
+// NewService creates a new Service with the user and role repositories, permission service, enforcer,
+// decision cache, audit service, and logger.
+func NewService(
+	users user.Repository,
+	roles role.Repository,
+	permissions permission.Service,
+	enforcer *casbin.Enforcer,
+	decisions *policy.DecisionCache,
+	audit audit.Service,
+	logger *zap.Logger,
+) Service {
+	return &service{
+		users:       users,
+		roles:       roles,
+		permissions: permissions,
+		enforcer:    enforcer,
+		decisions:   decisions,
+		audit:       audit,
+		logger:      logger,
+	}
+}
+
+// ListUsers lists every user.
+func (s *service) ListUsers(ctx context.Context) ([]*models.User, error) {
+	return s.users.ListAllUsers(ctx)
+}
+
+// CreateUser creates a user who signs in with email and password.
+func (s *service) CreateUser(ctx context.Context, username, email, password string) (created *models.User, err error) {
+	defer func() {
+		s.record(ctx, audit.ActionUserCreate, audit.TargetUser, userTargetID(created), nil, userState(username, email), err)
+	}()
+
+	if len(password) < MinPasswordLength {
+		return nil, ErrWeakPassword
+	}
+	if _, err := s.users.FindUserByUsername(ctx, username); err == nil {
+		return nil, ErrUserExists
+	}
+	if _, err := s.users.FindUserByEmail(ctx, email); err == nil {
+		return nil, ErrUserExists
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	created = &models.User{Username: username, Email: email, PasswordHash: string(hashedPassword)}
+	if created.ID, err = s.users.CreateUser(ctx, created); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	s.logger.Info("created user", zap.Uint64("userID", created.ID), zap.String("username", username))
+	return s.users.FindUserByID(ctx, created.ID)
+}
+
+// DisableUser marks a user as disabled, so that they cannot sign in, and removes their role assignments from the
+// enforcer. The roles stay assigned in the database and come back with EnableUser. Tokens that were issued before
+// keep authenticating the user until they expire, but without the permissions of any role.
+func (s *service) DisableUser(ctx context.Context, userID uint64) (err error) {
+	defer func() {
+		s.record(ctx, audit.ActionUserDisable, audit.TargetUser, formatID(userID), disabledState(false), disabledState(true), err)
+	}()
+
+	if err = s.users.SetDisabled(ctx, userID, true); err != nil {
+		return err
+	}
+
+	defer s.decisions.Invalidate()
+	if _, err = s.enforcer.RemoveFilteredGroupingPolicy(0, policy.UserSubject(userID)); err != nil {
+		return fmt.Errorf("failed to remove grouping policies: %w", err)
+	}
+
+	s.logger.Info("disabled user", zap.Uint64("userID", userID))
+	return nil
+}
+
+// EnableUser clears the disabled mark of a user and assigns their roles in the enforcer again.
+func (s *service) EnableUser(ctx context.Context, userID uint64) (err error) {
+	defer func() {
+		s.record(ctx, audit.ActionUserEnable, audit.TargetUser, formatID(userID), disabledState(true), disabledState(false), err)
+	}()
+
+	if err = s.users.SetDisabled(ctx, userID, false); err != nil {
+		return err
+	}
+
+	userRoles, err := s.users.FindUserRoles(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	defer s.decisions.Invalidate()
+	for _, userRole := range userRoles {
+		if _, err = s.enforcer.AddGroupingPolicy(policy.UserSubject(userID), userRole.Name); err != nil {
+			return fmt.Errorf("failed to add grouping policy: %w", err)
+		}
+	}
+
+	s.logger.Info("enabled user", zap.Uint64("userID", userID))
+	return nil
+}
+
+// DeleteUser deletes a user, whose roles, identities, API keys and OAuth grants go with them, and removes their
+// role assignments from the enforcer.
+func (s *service) DeleteUser(ctx context.Context, userID uint64) (err error) {
+	var deleted *models.User
+	defer func() {
+		var before any
+		if deleted != nil {
+			before = userState(deleted.Username, deleted.Email)
+		}
+		s.record(ctx, audit.ActionUserDelete, audit.TargetUser, formatID(userID), before, nil, err)
+	}()
+
+	if deleted, err = s.users.FindUserByID(ctx, userID); err != nil {
+		return err
+	}
+	if err = s.users.DeleteUser(ctx, userID); err != nil {
+		return err
+	}
+
+	defer s.decisions.Invalidate()
+	if _, err = s.enforcer.RemoveFilteredGroupingPolicy(0, policy.UserSubject(userID)); err != nil {
+		return fmt.Errorf("failed to remove grouping policies: %w", err)
+	}
+
+	s.logger.Info("deleted user", zap.Uint64("userID", userID), zap.String("username", deleted.Username))
+	return nil
+}
+
+// AssignRole assigns a role to a user, and in the enforcer unless the user is disabled.
+func (s *service) AssignRole(ctx context.Context, userID uint64, roleName string) (err error) {
+	defer func() {
+		s.record(ctx, audit.ActionRoleAssign, audit.TargetUser, formatID(userID), nil, roleState(roleName), err)
+	}()
+
+	target, err := s.users.FindUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err = s.users.AssignRole(ctx, userID, roleName); err != nil {
+		return err
+	}
+	if target.IsDisabled() {
+		return nil
+	}
+
+	defer s.decisions.Invalidate()
+	if _, err = s.enforcer.AddGroupingPolicy(policy.UserSubject(userID), roleName); err != nil {
+		return fmt.Errorf("failed to add grouping policy: %w", err)
+	}
+
+	s.logger.Info("assigned role", zap.Uint64("userID", userID), zap.String("role", roleName))
+	return nil
+}
+
+// RemoveRole removes a role from a user with its grouping policy.
+func (s *service) RemoveRole(ctx context.Context, userID uint64, roleName string) (err error) {
+	defer func() {
+		s.record(ctx, audit.ActionRoleRemove, audit.TargetUser, formatID(userID), roleState(roleName), nil, err)
+	}()
+
+	if err = s.users.RemoveRole(ctx, userID, roleName); err != nil {
+		return err
+	}
+
+	defer s.decisions.Invalidate()
+	if _, err = s.enforcer.RemoveGroupingPolicy(policy.UserSubject(userID), roleName); err != nil {
+		return fmt.Errorf("failed to remove grouping policy: %w", err)
+	}
+
+	s.logger.Info("removed role", zap.Uint64("userID", userID), zap.String("role", roleName))
+	return nil
+}
+
+// ListRoles lists every role.
+func (s *service) ListRoles(ctx context.Context) ([]*models.Role, error) {
+	return s.roles.ListAllRoles(ctx)
+}
+
+// ListPermissions lists every permission.
+func (s *service) ListPermissions(ctx context.Context) ([]*models.Permission, error) {
+	return s.permissions.List(ctx)
+}
+
+// CreatePermission creates a permission after validating it, see permission.Service.
+func (s *service) CreatePermission(ctx context.Context, created *models.Permission) (err error) {
+	defer func() {
+		s.record(ctx, audit.ActionPermissionCreate, audit.TargetPermission, formatID(created.ID), nil, created, err)
+	}()
+
+	if err = s.permissions.Create(ctx, created); err != nil {
+		return err
+	}
+
+	s.logger.Info("created permission", zap.String("name", created.Name))
+	return nil
+}
+
+// DeletePermission deletes a permission, which revokes it from every role, and removes its policy rules.
+func (s *service) DeletePermission(ctx context.Context, permissionID uint64) (err error) {
+	var deleted *models.Permission
+	defer func() {
+		s.record(ctx, audit.ActionPermissionDelete, audit.TargetPermission, formatID(permissionID), deleted, nil, err)
+	}()
+
+	deleted, err = s.permissions.GetByID(ctx, permissionID)
+	if err != nil {
+		return err
+	}
+	roleNames, err := s.roles.FindPermissionRoles(ctx, permissionID)
+	if err != nil {
+		return err
+	}
+
+	if err = s.permissions.Delete(ctx, permissionID); err != nil {
+		return fmt.Errorf("failed to delete permission: %w", err)
+	}
+
+	defer s.decisions.Invalidate()
+	for _, roleName := range roleNames {
+		if _, err = s.enforcer.RemovePolicy(rule(roleName, deleted)...); err != nil {
+			return fmt.Errorf("failed to remove policy for role %s: %w", roleName, err)
+		}
+	}
+
+	s.logger.Info("deleted permission", zap.Uint64("permissionID", permissionID), zap.Strings("roles", roleNames))
+	return nil
+}
+
+// GrantPermission grants a permission to a role and adds its policy rule.
+func (s *service) GrantPermission(ctx context.Context, roleName string, permissionID uint64) (err error) {
+	defer func() {
+		s.record(ctx, audit.ActionPermissionGrant, audit.TargetRole, roleName, nil, permissionState(permissionID), err)
+	}()
+
+	granted, err := s.permissions.GetByID(ctx, permissionID)
+	if err != nil {
+		return err
+	}
+	if err = policy.Validate(granted.Condition, granted.TimeWindow); err != nil {
+		return err
+	}
+
+	if err = s.roles.GrantPermission(ctx, roleName, permissionID); err != nil {
+		return err
+	}
+
+	defer s.decisions.Invalidate()
+	if _, err = s.enforcer.AddPolicy(rule(roleName, granted)...); err != nil {
+		return fmt.Errorf("failed to add policy: %w", err)
+	}
+
+	s.logger.Info("granted permission", zap.String("role", roleName), zap.Uint64("permissionID", permissionID))
+	return nil
+}
+
+// RevokePermission revokes a permission from a role and removes its policy rule.
+func (s *service) RevokePermission(ctx context.Context, roleName string, permissionID uint64) (err error) {
+	defer func() {
+		s.record(ctx, audit.ActionPermissionRevoke, audit.TargetRole, roleName, permissionState(permissionID), nil, err)
+	}()
+
+	revoked, err := s.permissions.GetByID(ctx, permissionID)
+	if err != nil {
+		return err
+	}
+
+	if err = s.roles.RevokePermission(ctx, roleName, permissionID); err != nil {
+		return err
+	}
+
+	defer s.decisions.Invalidate()
+	if _, err = s.enforcer.RemovePolicy(rule(roleName, revoked)...); err != nil {
+		return fmt.Errorf("failed to remove policy: %w", err)
+	}
+
+	s.logger.Info("revoked permission", zap.String("role", roleName), zap.Uint64("permissionID", permissionID))
+	return nil
+}
+
+// Policies returns the policy rules and role assignments of the enforcer. For a user, the rules are those granted
+// through their roles, including inherited ones, and the assignments are their own.
+func (s *service) Policies(ctx context.Context, userID uint64) (*models.PolicySet, error) {
+	var rules, groupingRules [][]string
+	var err error
+	if userID == 0 {
+		if rules, err = s.enforcer.GetPolicy(); err != nil {
+			return nil, fmt.Errorf("failed to get policies: %w", err)
+		}
+		if groupingRules, err = s.enforcer.GetGroupingPolicy(); err != nil {
+			return nil, fmt.Errorf("failed to get grouping policies: %w", err)
+		}
+	} else {
+		if _, err = s.users.FindUserByID(ctx, userID); err != nil {
+			return nil, err
+		}
+		subject := policy.UserSubject(userID)
+		if rules, err = s.enforcer.GetImplicitPermissionsForUser(subject); err != nil {
+			return nil, fmt.Errorf("failed to get policies of user %d: %w", userID, err)
+		}
+		if groupingRules, err = s.enforcer.GetFilteredGroupingPolicy(0, subject); err != nil {
+			return nil, fmt.Errorf("failed to get grouping policies of user %d: %w", userID, err)
+		}
+	}
+
+	set := &models.PolicySet{
+		Rules:         make([]models.PolicyRule, 0, len(rules)),
+		GroupingRules: make([]models.GroupingRule, 0, len(groupingRules)),
+	}
+	for _, fields := range rules {
+		set.Rules = append(set.Rules, models.NewPolicyRule(fields))
+	}
+	for _, fields := range groupingRules {
+		if len(fields) >= 2 {
+			set.GroupingRules = append(set.GroupingRules, models.GroupingRule{Subject: fields[0], Role: fields[1]})
+		}
+	}
+	return set, nil
+}
+
+// BootstrapAdmin creates the first administrator and assigns them AdminRole. It fails with ErrAlreadyBootstrapped
+// once any user has the role, so that it cannot be used to add administrators later.
+func (s *service) BootstrapAdmin(ctx context.Context, username, email, password string) (administrator *models.User, err error) {
+	defer func() {
+		s.record(ctx, audit.ActionAdminBootstrap, audit.TargetUser, userTargetID(administrator), nil, userState(username, email), err)
+	}()
+
+	admins, err := s.users.CountUsersWithRole(ctx, AdminRole)
+	if err != nil {
+		return nil, err
+	}
+	if admins > 0 {
+		return nil, ErrAlreadyBootstrapped
+	}
+
+	if administrator, err = s.CreateUser(ctx, username, email, password); err != nil {
+		return nil, err
+	}
+	if err = s.AssignRole(ctx, administrator.ID, AdminRole); err != nil {
+		return nil, fmt.Errorf("created user %d but failed to assign the %s role: %w", administrator.ID, AdminRole, err)
+	}
+
+	s.logger.Info("bootstrapped administrator", zap.Uint64("userID", administrator.ID))
+	return administrator, nil
+}
+
+// rule returns the policy rule with which a role is granted a permission, as LoadPolicies writes it.
+// Permissions without an effect allow, as in models.PolicyRule.
+func rule(roleName string, granted *models.Permission) []any {
+	effect := granted.Effect
+	if effect == "" {
+		effect = policy.EffectAllow
+	}
+	return policy.Rule(roleName, granted.Object(), string(granted.Action), granted.Condition, effect, granted.TimeWindow)
+}
+
+// record records a change made by the actor in ctx, with the state of the target before and after it.
+func (s *service) record(ctx context.Context, action, targetType, targetID string, before, after any, err error) {
+	event := &models.AuditEvent{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Before:     audit.State(before),
+		After:      audit.State(after),
+	}
+	event.Outcome, event.Reason = audit.Outcome(err)
+	s.audit.Record(ctx, event)
+}
+
+// formatID formats the ID of a target, leaving it empty when the target was not created.
+func formatID(id uint64) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.FormatUint(id, 10)
+}
+
+// userTargetID returns the ID of a user as a target, empty when there is no user.
+func userTargetID(target *models.User) string {
+	if target == nil {
+		return ""
+	}
+	return formatID(target.ID)
+}
+
+// userState is what the audit log records of a created user, leaving out the password.
+func userState(username, email string) map[string]string {
+	return map[string]string{"username": username, "email": email}
+}
+
+// disabledState is the state of a user disabled or enabled.
+func disabledState(disabled bool) map[string]bool {
+	return map[string]bool{"disabled": disabled}
+}
+
+// roleState is the state of a role assignment.
+func roleState(roleName string) map[string]string {
+	return map[string]string{"role": roleName}
+}
+
+// permissionState is the state of a permission grant.
+func permissionState(permissionID uint64) map[string]uint64 {
+	return map[string]uint64{"permission_id": permissionID}
+}
@@ -0,0 +1,137 @@
+package apikey
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/sqlc"
+	"goflare.io/nexus/driver"
+)
+
+// _ is a type assertion to ensure that the repository implements the Repository interface.
+var _ Repository = (*repository)(nil)
+
+// Repository is the interface for the API key repository.
+type Repository interface {
+
+	// CreateAPIKey stores an API key.
+	CreateAPIKey(ctx context.Context, key *models.APIKey) (uint64, error)
+
+	// FindAPIKeyByTokenHash finds an API key by the hash of its token.
+	FindAPIKeyByTokenHash(ctx context.Context, tokenHash string) (*models.APIKey, error)
+
+	// ListAPIKeys lists the API keys of a user, including revoked ones.
+	ListAPIKeys(ctx context.Context, userID uint64) ([]*models.APIKey, error)
+
+	// RenameAPIKey renames an active API key of a user.
+	RenameAPIKey(ctx context.Context, userID, id uint64, name string) error
+
+	// RevokeAPIKey revokes an active API key of a user.
+	RevokeAPIKey(ctx context.Context, userID, id uint64) error
+
+	// TouchAPIKey records that an API key was used.
+	TouchAPIKey(ctx context.Context, id uint64) error
+}
+
+// repository is the implementation of the Repository interface.
+type repository struct {
+	conn   driver.PostgresPool
+	logger *zap.Logger
+}
+
+// NewRepository creates a new repository.
+func NewRepository(conn driver.PostgresPool, logger *zap.Logger) Repository {
+	return &repository{
+		conn:   conn,
+		logger: logger,
+	}
+}
+
+// CreateAPIKey stores an API key. A zero expiry is stored as NULL, for a key that does not expire.
+func (r *repository) CreateAPIKey(ctx context.Context, key *models.APIKey) (uint64, error) {
+	id, err := sqlc.New(r.conn).CreateAPIKey(ctx, sqlc.CreateAPIKeyParams{
+		UserID:      key.UserID,
+		Name:        key.Name,
+		TokenPrefix: key.TokenPrefix,
+		TokenHash:   key.TokenHash,
+		Scopes:      key.Scopes,
+		ExpiresAt:   pgtype.Timestamptz{Time: key.ExpiresAt, Valid: !key.ExpiresAt.IsZero()},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	return id, nil
+}
+
+// FindAPIKeyByTokenHash finds an API key by the hash of its token.
+func (r *repository) FindAPIKeyByTokenHash(ctx context.Context, tokenHash string) (*models.APIKey, error) {
+	sqlcKey, err := sqlc.New(r.conn).GetAPIKeyByTokenHash(ctx, tokenHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	return new(models.APIKey).ConvertFromSQLCAPIKey(sqlcKey), nil
+}
+
+// ListAPIKeys lists the API keys of a user, newest first.
+func (r *repository) ListAPIKeys(ctx context.Context, userID uint64) ([]*models.APIKey, error) {
+	sqlcKeys, err := sqlc.New(r.conn).ListAPIKeys(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+
+	keys := make([]*models.APIKey, 0, len(sqlcKeys))
+	for _, sqlcKey := range sqlcKeys {
+		keys = append(keys, new(models.APIKey).ConvertFromSQLCAPIKey(sqlcKey))
+	}
+
+	return keys, nil
+}
+
+// RenameAPIKey renames an active API key of a user.
+func (r *repository) RenameAPIKey(ctx context.Context, userID, id uint64, name string) error {
+	renamed, err := sqlc.New(r.conn).RenameAPIKey(ctx, sqlc.RenameAPIKeyParams{
+		ID:     id,
+		UserID: userID,
+		Name:   name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rename API key %d: %w", id, err)
+	}
+	if renamed == 0 {
+		return fmt.Errorf("no active API key %d: %w", id, pgx.ErrNoRows)
+	}
+
+	return nil
+}
+
+// RevokeAPIKey revokes an active API key of a user.
+func (r *repository) RevokeAPIKey(ctx context.Context, userID, id uint64) error {
+	revoked, err := sqlc.New(r.conn).RevokeAPIKey(ctx, sqlc.RevokeAPIKeyParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key %d: %w", id, err)
+	}
+	if revoked == 0 {
+		return fmt.Errorf("no active API key %d: %w", id, pgx.ErrNoRows)
+	}
+
+	return nil
+}
+
+// TouchAPIKey records that an API key was used.
+func (r *repository) TouchAPIKey(ctx context.Context, id uint64) error {
+	if err := sqlc.New(r.conn).TouchAPIKey(ctx, id); err != nil {
+		return fmt.Errorf("failed to touch API key %d: %w", id, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,255 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/audit"
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/policy"
+)
+
+const (
+	// TokenPrefix starts every API key, so that keys are recognizable in configuration and by secret scanners.
+	TokenPrefix = "flare_pat_"
+
+	// displayLength is the number of characters after TokenPrefix kept to show which key is which.
+	displayLength = 6
+
+	// touchInterval is how often the last use of a key is recorded, to spare a write on every request.
+	touchInterval = time.Minute
+)
+
+var (
+	// ErrInvalidAPIKey is returned for an API key that is unknown, revoked or expired.
+	ErrInvalidAPIKey = errors.New("invalid API key")
+
+	// ErrInvalidScope is returned for a scope that is malformed or not granted to the owner of the key.
+	ErrInvalidScope = errors.New("invalid scope")
+
+	// ErrNameTaken is returned when the user already has an active key with the name.
+	ErrNameTaken = errors.New("an API key with this name already exists")
+)
+
+// _ is used to ensure that *service implements the Service interface at compile time.
+var _ Service = (*service)(nil)
+
+// Service manages API keys, the personal access tokens users create for scripts. A key acts for its owner, but only
+// for the permissions in its scopes, which must be a subset of the owner's permissions.
+type Service interface {
+
+	// CreateAPIKey creates an API key and returns it. Only its hash is stored, so it cannot be retrieved again.
+	CreateAPIKey(ctx context.Context, key *models.APIKey) (string, error)
+
+	// ListAPIKeys lists the API keys of a user.
+	ListAPIKeys(ctx context.Context, userID uint64) ([]*models.APIKey, error)
+
+	// RenameAPIKey renames an active API key of a user.
+	RenameAPIKey(ctx context.Context, userID, id uint64, name string) error
+
+	// RevokeAPIKey revokes an active API key of a user.
+	RevokeAPIKey(ctx context.Context, userID, id uint64) error
+
+	// Authenticate finds the active API key of a token.
+	Authenticate(ctx context.Context, token string) (*models.APIKey, error)
+}
+
+// service is the implementation of the Service interface.
+type service struct {
+	repository Repository
+	enforcer   *casbin.Enforcer
+	decisions  *policy.DecisionCache
+	audit      audit.Service
+	logger     *zap.Logger
+}
+
+// NewService creates a new API key service.
+func NewService(
+	repository Repository,
+	enforcer *casbin.Enforcer,
+	decisions *policy.DecisionCache,
+	audit audit.Service,
+	logger *zap.Logger,
+) Service {
+	return &service{
+		repository: repository,
+		enforcer:   enforcer,
+		decisions:  decisions,
+		audit:      audit,
+		logger:     logger,
+	}
+}
+
+// CreateAPIKey creates an API key for key.UserID. Every scope must be a permission the user holds now; the key
+// loses a permission again when its owner does. Keys without an expiry never expire.
+func (s *service) CreateAPIKey(ctx context.Context, key *models.APIKey) (token string, err error) {
+	defer func() {
+		s.record(ctx, audit.ActionAPIKeyCreate, key.ID, nil, key, err)
+	}()
+
+	key.Name = strings.TrimSpace(key.Name)
+	if key.Name == "" {
+		return "", errors.New("name is required")
+	}
+	if len(key.Scopes) == 0 {
+		return "", fmt.Errorf("%w: at least one scope is required", ErrInvalidScope)
+	}
+	if !key.ExpiresAt.IsZero() && !key.ExpiresAt.After(time.Now()) {
+		return "", errors.New("expiry must be in the future")
+	}
+
+	for _, scope := range key.Scopes {
+		resource, action, ok := ParseScope(scope)
+		if !ok {
+			return "", fmt.Errorf("%w %q: scopes have the form RESOURCE:ACTION", ErrInvalidScope, scope)
+		}
+		allowed, err := s.decisions.Enforce(s.enforcer, policy.Request(policy.UserSubject(key.UserID), resource, action, nil, time.Now()))
+		if err != nil {
+			return "", err
+		}
+		if !allowed {
+			return "", fmt.Errorf("%w %q: the permission is not granted to you", ErrInvalidScope, scope)
+		}
+	}
+
+	keys, err := s.repository.ListAPIKeys(ctx, key.UserID)
+	if err != nil {
+		return "", err
+	}
+	if slices.ContainsFunc(keys, func(k *models.APIKey) bool { return k.RevokedAt.IsZero() && k.Name == key.Name }) {
+		return "", ErrNameTaken
+	}
+
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	secret := base64.RawURLEncoding.EncodeToString(b)
+	token = TokenPrefix + secret
+
+	key.TokenPrefix = TokenPrefix + secret[:displayLength]
+	key.TokenHash = hashToken(token)
+	if key.ID, err = s.repository.CreateAPIKey(ctx, key); err != nil {
+		return "", err
+	}
+	key.CreatedAt = time.Now()
+
+	s.logger.Info("created API key", zap.Uint64("user_id", key.UserID), zap.Uint64("id", key.ID), zap.Strings("scopes", key.Scopes))
+	return token, nil
+}
+
+// ListAPIKeys lists the API keys of a user.
+func (s *service) ListAPIKeys(ctx context.Context, userID uint64) ([]*models.APIKey, error) {
+	return s.repository.ListAPIKeys(ctx, userID)
+}
+
+// RenameAPIKey renames an active API key of a user.
+func (s *service) RenameAPIKey(ctx context.Context, userID, id uint64, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("name is required")
+	}
+
+	keys, err := s.repository.ListAPIKeys(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if slices.ContainsFunc(keys, func(k *models.APIKey) bool { return k.RevokedAt.IsZero() && k.Name == name && k.ID != id }) {
+		return ErrNameTaken
+	}
+
+	return s.repository.RenameAPIKey(ctx, userID, id, name)
+}
+
+// RevokeAPIKey revokes an active API key of a user. It stops working at once.
+func (s *service) RevokeAPIKey(ctx context.Context, userID, id uint64) (err error) {
+	defer func() {
+		s.record(ctx, audit.ActionAPIKeyRevoke, id, map[string]uint64{"user_id": userID}, nil, err)
+	}()
+
+	if err = s.repository.RevokeAPIKey(ctx, userID, id); err != nil {
+		return err
+	}
+
+	s.logger.Info("revoked API key", zap.Uint64("user_id", userID), zap.Uint64("id", id))
+	return nil
+}
+
+// Authenticate finds the active API key of a token. Every failure is reported as ErrInvalidAPIKey.
+func (s *service) Authenticate(ctx context.Context, token string) (*models.APIKey, error) {
+	if !IsAPIKey(token) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.repository.FindAPIKeyByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	if !key.RevokedAt.IsZero() || (!key.ExpiresAt.IsZero() && time.Now().After(key.ExpiresAt)) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if time.Since(key.LastUsedAt) > touchInterval {
+		if err = s.repository.TouchAPIKey(ctx, key.ID); err != nil {
+			s.logger.Warn("failed to record API key use", zap.Uint64("id", key.ID), zap.Error(err))
+		}
+	}
+
+	return key, nil
+}
+
+// IsAPIKey reports whether a bearer token is an API key rather than a PASETO token.
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, TokenPrefix)
+}
+
+// ParseScope splits an API key scope into its resource and action.
+func ParseScope(scope string) (string, string, bool) {
+	resource, action, ok := strings.Cut(scope, ":")
+	if !ok || resource == "" || action == "" || strings.ContainsAny(scope, " \"\\") {
+		return "", "", false
+	}
+	return resource, action, true
+}
+
+// Allows reports whether the scopes of an API key cover an action on a resource.
+func Allows(scopes []string, resource, action string) bool {
+	return slices.Contains(scopes, resource+":"+action)
+}
+
+// record records a change to an API key, made by the actor in ctx.
+func (s *service) record(ctx context.Context, action string, id uint64, before, after any, err error) {
+	event := &models.AuditEvent{
+		Action:     action,
+		TargetType: audit.TargetAPIKey,
+		Before:     audit.State(before),
+		After:      audit.State(after),
+	}
+	if id != 0 {
+		event.TargetID = strconv.FormatUint(id, 10)
+	}
+	event.Outcome, event.Reason = audit.Outcome(err)
+	s.audit.Record(ctx, event)
+}
+
+// hashToken returns the hex SHA-256 hash under which an API key is stored.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
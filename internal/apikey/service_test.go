@@ -0,0 +1,253 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/audit"
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/policy"
+)
+
+func TestParseScope(t *testing.T) {
+	tests := []struct {
+		scope        string
+		wantResource string
+		wantAction   string
+		wantOK       bool
+	}{
+		{"USER:READ", "USER", "READ", true},
+		{"ORDER:*", "ORDER", "*", true},
+		{"USER", "", "", false},
+		{":READ", "", "", false},
+		{"USER:", "", "", false},
+		{"USER:READ openid", "", "", false},
+		{`USER:"READ"`, "", "", false},
+		{`USER:READ\`, "", "", false},
+		{"", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scope, func(t *testing.T) {
+			resource, action, ok := ParseScope(tt.scope)
+			if resource != tt.wantResource || action != tt.wantAction || ok != tt.wantOK {
+				t.Errorf("ParseScope(%q) = %q, %q, %v, want %q, %q, %v",
+					tt.scope, resource, action, ok, tt.wantResource, tt.wantAction, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestAllows(t *testing.T) {
+	scopes := []string{"USER:READ", "ORDER:UPDATE"}
+
+	tests := []struct {
+		resource string
+		action   string
+		want     bool
+	}{
+		{"USER", "READ", true},
+		{"ORDER", "UPDATE", true},
+		{"USER", "UPDATE", false},
+		{"user", "READ", false},
+		{"USER:READ", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.resource+":"+tt.action, func(t *testing.T) {
+			if got := Allows(scopes, tt.resource, tt.action); got != tt.want {
+				t.Errorf("Allows(%s, %s) = %v, want %v", tt.resource, tt.action, got, tt.want)
+			}
+		})
+	}
+
+	if Allows(nil, "USER", "READ") {
+		t.Error("Allows() with no scopes = true, want false")
+	}
+}
+
+func TestHashToken(t *testing.T) {
+	tests := []struct {
+		token string
+		want  string
+	}{
+		{"flare_pat_secret", "e137e9415a6babc215e1ef75f5eca7bb7c40882e17839fd4ce7e3d2ce85be8e5"},
+		{"", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.token, func(t *testing.T) {
+			if got := hashToken(tt.token); got != tt.want {
+				t.Errorf("hashToken(%q) = %s, want %s", tt.token, got, tt.want)
+			}
+		})
+	}
+}
+
+// memoryRepository is an API key repository holding keys in memory.
+type memoryRepository struct {
+	Repository
+	keys    []*models.APIKey
+	touched []uint64
+}
+
+func (r *memoryRepository) CreateAPIKey(_ context.Context, key *models.APIKey) (uint64, error) {
+	stored := *key
+	stored.ID = uint64(len(r.keys) + 1)
+	r.keys = append(r.keys, &stored)
+	return stored.ID, nil
+}
+
+func (r *memoryRepository) FindAPIKeyByTokenHash(_ context.Context, tokenHash string) (*models.APIKey, error) {
+	for _, key := range r.keys {
+		if key.TokenHash == tokenHash {
+			return key, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *memoryRepository) ListAPIKeys(_ context.Context, userID uint64) ([]*models.APIKey, error) {
+	var keys []*models.APIKey
+	for _, key := range r.keys {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (r *memoryRepository) TouchAPIKey(_ context.Context, id uint64) error {
+	r.touched = append(r.touched, id)
+	return nil
+}
+
+// discardAudit is an audit service that drops every event.
+type discardAudit struct {
+	audit.Service
+}
+
+func (discardAudit) Record(context.Context, *models.AuditEvent) {}
+
+func newTestService(t *testing.T, repository *memoryRepository) *service {
+	t.Helper()
+
+	enforcer, err := casbin.NewEnforcer("../../configs/casbin/casbin.conf")
+	if err != nil {
+		t.Fatalf("failed to load model: %v", err)
+	}
+	policy.RegisterFunctions(enforcer)
+	if _, err = enforcer.AddPolicy("editor", "USER", "READ", "", policy.EffectAllow, ""); err != nil {
+		t.Fatalf("failed to add policy: %v", err)
+	}
+	if _, err = enforcer.AddGroupingPolicy(policy.UserSubject(1), "editor"); err != nil {
+		t.Fatalf("failed to add grouping policy: %v", err)
+	}
+
+	return &service{
+		repository: repository,
+		enforcer:   enforcer,
+		decisions:  policy.NewDecisionCache(time.Minute, 100),
+		audit:      discardAudit{},
+		logger:     zap.NewNop(),
+	}
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     models.APIKey
+		wantErr error
+	}{
+		{"valid", models.APIKey{UserID: 1, Name: "ci", Scopes: []string{"USER:READ"}}, nil},
+		{"expiring", models.APIKey{UserID: 1, Name: "ci", Scopes: []string{"USER:READ"}, ExpiresAt: time.Now().Add(time.Hour)}, nil},
+		{"no scopes", models.APIKey{UserID: 1, Name: "ci"}, ErrInvalidScope},
+		{"malformed scope", models.APIKey{UserID: 1, Name: "ci", Scopes: []string{"USER"}}, ErrInvalidScope},
+		{"scope the owner lacks", models.APIKey{UserID: 1, Name: "ci", Scopes: []string{"USER:DELETE"}}, ErrInvalidScope},
+		{"name taken", models.APIKey{UserID: 1, Name: "taken", Scopes: []string{"USER:READ"}}, ErrNameTaken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository := &memoryRepository{keys: []*models.APIKey{{ID: 100, UserID: 1, Name: "taken", TokenHash: "hash"}}}
+			s := newTestService(t, repository)
+
+			token, err := s.CreateAPIKey(context.Background(), &tt.key)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("CreateAPIKey() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateAPIKey failed: %v", err)
+			}
+
+			if !IsAPIKey(token) || !strings.HasPrefix(token, tt.key.TokenPrefix) {
+				t.Errorf("token %s does not start with %s", token, tt.key.TokenPrefix)
+			}
+			stored := repository.keys[len(repository.keys)-1]
+			if stored.TokenHash != hashToken(token) || strings.Contains(stored.TokenHash, token) {
+				t.Error("the stored key is not the hash of the token")
+			}
+		})
+	}
+
+	t.Run("expiry in the past", func(t *testing.T) {
+		s := newTestService(t, &memoryRepository{})
+		key := &models.APIKey{UserID: 1, Name: "ci", Scopes: []string{"USER:READ"}, ExpiresAt: time.Now().Add(-time.Minute)}
+		if _, err := s.CreateAPIKey(context.Background(), key); err == nil {
+			t.Error("CreateAPIKey accepted an expired key")
+		}
+	})
+}
+
+func TestAuthenticate(t *testing.T) {
+	repository := &memoryRepository{}
+	s := newTestService(t, repository)
+
+	token, err := s.CreateAPIKey(context.Background(), &models.APIKey{UserID: 1, Name: "ci", Scopes: []string{"USER:READ"}})
+	if err != nil {
+		t.Fatalf("CreateAPIKey failed: %v", err)
+	}
+
+	key, err := s.Authenticate(context.Background(), token)
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if key.UserID != 1 || len(repository.touched) != 1 {
+		t.Errorf("Authenticate() = user %d touched %d times, want user 1 touched once", key.UserID, len(repository.touched))
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		modify func(key *models.APIKey)
+	}{
+		{"unknown key", TokenPrefix + "unknown", nil},
+		{"not an API key", "v2.public.token", nil},
+		{"revoked", token, func(key *models.APIKey) { key.RevokedAt = time.Now() }},
+		{"expired", token, func(key *models.APIKey) { key.ExpiresAt = time.Now().Add(-time.Second) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.modify != nil {
+				stored := *repository.keys[0]
+				tt.modify(repository.keys[0])
+				defer func() { *repository.keys[0] = stored }()
+			}
+
+			if _, err := s.Authenticate(context.Background(), tt.token); !errors.Is(err, ErrInvalidAPIKey) {
+				t.Errorf("Authenticate() error = %v, want ErrInvalidAPIKey", err)
+			}
+		})
+	}
+}
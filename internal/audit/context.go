@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/models/enum"
+)
+
+// RequestIDHeader carries the ID of a request. An ID sent by a proxy is kept; otherwise one is generated. It is
+// returned in the response, so that callers can refer to the events of their requests.
+const RequestIDHeader = "X-Request-ID"
+
+// contextKey is the type of the context keys of this package, so that they cannot collide with others.
+type contextKey int
+
+const (
+	// requestKey is the context key of the request metadata.
+	requestKey contextKey = iota
+
+	// actorKey is the context key of the authenticated principal.
+	actorKey
+)
+
+// request is what events record of the request that caused them.
+type request struct {
+	ip string
+	id string
+}
+
+// WithActor returns a copy of ctx whose events are recorded as done by principal.
+func WithActor(ctx context.Context, principal *models.Principal) context.Context {
+	return context.WithValue(ctx, actorKey, principal)
+}
+
+// Middleware puts the IP address and ID of requests into their context, for events to record.
+func (s *service) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		ctx := context.WithValue(r.Context(), requestKey, &request{
+			ip: clientIP(r, s.trustForwardedFor),
+			id: id,
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// fromContext fills the actor, IP address and request ID of event from ctx, where the event leaves them empty.
+func fromContext(ctx context.Context, event *models.AuditEvent) {
+	if principal, ok := ctx.Value(actorKey).(*models.Principal); ok && principal != nil && event.ActorType == "" {
+		event.ActorType = string(principal.Type)
+		if event.ActorType == "" {
+			event.ActorType = string(enum.SubjectUser)
+		}
+		event.ActorID = strconv.FormatUint(principal.ID, 10)
+	}
+	if req, ok := ctx.Value(requestKey).(*request); ok {
+		if event.IPAddress == "" {
+			event.IPAddress = req.ip
+		}
+		if event.RequestID == "" {
+			event.RequestID = req.id
+		}
+	}
+}
+
+// clientIP returns the address of the client of r.
+func clientIP(r *http.Request, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// newRequestID returns a random request ID.
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,159 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/sqlc"
+	"goflare.io/nexus/driver"
+)
+
+// chainLockKey is the transaction-level advisory lock that serializes appends, so that every event chains to the
+// one appended just before it, across instances.
+const chainLockKey = 0x61756469745f6576 // "audit_ev"
+
+// _ is a type assertion to ensure that the repository implements the Repository interface.
+var _ Repository = (*repository)(nil)
+
+// Repository is the interface for the audit event repository.
+type Repository interface {
+
+	// AppendEvents stores events at the end of the chain in their order, setting their IDs and hashes, and their
+	// times where they have none.
+	AppendEvents(ctx context.Context, events []*models.AuditEvent) error
+
+	// ListEvents lists the events matching a filter, newest first.
+	ListEvents(ctx context.Context, filter *models.AuditFilter) ([]*models.AuditEvent, error)
+
+	// ListEventsAfter lists up to limit events following the event with an ID, oldest first.
+	ListEventsAfter(ctx context.Context, afterID uint64, limit int) ([]*models.AuditEvent, error)
+}
+
+// repository is the implementation of the Repository interface.
+type repository struct {
+	conn   driver.PostgresPool
+	logger *zap.Logger
+}
+
+// NewRepository creates a new repository.
+func NewRepository(conn driver.PostgresPool, logger *zap.Logger) Repository {
+	return &repository{
+		conn:   conn,
+		logger: logger,
+	}
+}
+
+// AppendEvents stores events at the end of the chain in one transaction, which holds the chain lock once for all
+// of them.
+func (r *repository) AppendEvents(ctx context.Context, events []*models.AuditEvent) (err error) {
+	tx, err := r.conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				r.logger.Error("failed to rollback transaction", zap.Error(rbErr))
+			}
+		}
+	}()
+
+	if _, err = tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", int64(chainLockKey)); err != nil {
+		return fmt.Errorf("failed to lock audit chain: %w", err)
+	}
+
+	queries := sqlc.New(r.conn).WithTx(tx)
+	prevHash, err := queries.GetLastAuditEventHash(ctx)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to get last audit event: %w", err)
+	}
+
+	for _, event := range events {
+		if event.OccurredAt.IsZero() {
+			event.OccurredAt = time.Now()
+		}
+		// Postgres keeps microseconds; the hash must cover the time as it will be read back.
+		event.OccurredAt = event.OccurredAt.UTC().Truncate(time.Microsecond)
+		event.PrevHash = prevHash
+		if event.Hash, err = event.ComputeHash(prevHash); err != nil {
+			return fmt.Errorf("failed to hash audit event: %w", err)
+		}
+
+		if event.ID, err = queries.CreateAuditEvent(ctx, sqlc.CreateAuditEventParams{
+			OccurredAt: pgtype.Timestamptz{Time: event.OccurredAt, Valid: true},
+			ActorType:  event.ActorType,
+			ActorID:    event.ActorID,
+			Action:     event.Action,
+			TargetType: event.TargetType,
+			TargetID:   event.TargetID,
+			Outcome:    event.Outcome,
+			Reason:     event.Reason,
+			IpAddress:  event.IPAddress,
+			RequestID:  event.RequestID,
+			Before:     event.Before,
+			After:      event.After,
+			PrevHash:   event.PrevHash,
+			Hash:       event.Hash,
+		}); err != nil {
+			return fmt.Errorf("failed to create audit event: %w", err)
+		}
+		prevHash = event.Hash
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ListEvents lists the events matching a filter, newest first.
+func (r *repository) ListEvents(ctx context.Context, filter *models.AuditFilter) ([]*models.AuditEvent, error) {
+	params := sqlc.ListAuditEventsParams{
+		ActorType:  sqlc.Optional(filter.ActorType),
+		ActorID:    sqlc.Optional(filter.ActorID),
+		Action:     sqlc.Optional(filter.Action),
+		TargetType: sqlc.Optional(filter.TargetType),
+		TargetID:   sqlc.Optional(filter.TargetID),
+		Outcome:    sqlc.Optional(filter.Outcome),
+		Since:      pgtype.Timestamptz{Time: filter.Since, Valid: !filter.Since.IsZero()},
+		Until:      pgtype.Timestamptz{Time: filter.Until, Valid: !filter.Until.IsZero()},
+		RowLimit:   int32(filter.Limit),
+	}
+	if filter.BeforeID != 0 {
+		beforeID := int64(filter.BeforeID)
+		params.BeforeID = &beforeID
+	}
+
+	sqlcEvents, err := sqlc.New(r.conn).ListAuditEvents(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+
+	return convertEvents(sqlcEvents), nil
+}
+
+// ListEventsAfter lists up to limit events following the event with an ID, oldest first.
+func (r *repository) ListEventsAfter(ctx context.Context, afterID uint64, limit int) ([]*models.AuditEvent, error) {
+	sqlcEvents, err := sqlc.New(r.conn).ListAuditEventsAfter(ctx, sqlc.ListAuditEventsAfterParams{
+		ID:    afterID,
+		Limit: int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+
+	return convertEvents(sqlcEvents), nil
+}
+
+// convertEvents converts SQLC audit events.
+func convertEvents(sqlcEvents []*sqlc.AuditEvent) []*models.AuditEvent {
+	events := make([]*models.AuditEvent, 0, len(sqlcEvents))
+	for _, sqlcEvent := range sqlcEvents {
+		events = append(events, new(models.AuditEvent).ConvertFromSQLCAuditEvent(sqlcEvent))
+	}
+	return events
+}
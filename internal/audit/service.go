@@ -0,0 +1,285 @@
+// Package audit records security-relevant events, such as logins and changes to roles and permissions, in an
+// append-only log whose events are chained by their hashes, so that altering or removing one is evident.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	appconfig "goflare.io/auth/internal/config"
+	"goflare.io/auth/internal/models"
+)
+
+const (
+	// DefaultLimit is the number of events listed when the filter sets no limit.
+	DefaultLimit = 50
+
+	// MaxLimit is the largest number of events listed at once.
+	MaxLimit = 500
+
+	// DefaultBufferSize is the number of events that wait for the background writer unless configured otherwise.
+	DefaultBufferSize = 1024
+
+	// verifyBatchSize is the number of events Verify reads at once.
+	verifyBatchSize = 1000
+
+	// writeBatchSize is the largest number of events the background writer appends in one transaction.
+	writeBatchSize = 100
+)
+
+// Actions recorded in the audit log.
+const (
+	ActionLogin            = "user.login"
+	ActionLogout           = "user.logout"
+	ActionRegister         = "user.register"
+	ActionIdentityLink     = "identity.link"
+	ActionIdentityUnlink   = "identity.unlink"
+	ActionAccessDenied     = "authorization.deny"
+	ActionUserCreate       = "user.create"
+	ActionUserDisable      = "user.disable"
+	ActionUserEnable       = "user.enable"
+	ActionUserDelete       = "user.delete"
+	ActionPasswordChange   = "user.password_change"
+	ActionAdminBootstrap   = "admin.bootstrap"
+	ActionRoleAssign       = "role.assign"
+	ActionRoleRemove       = "role.remove"
+	ActionPermissionCreate = "permission.create"
+	ActionPermissionDelete = "permission.delete"
+	ActionPermissionGrant  = "permission.grant"
+	ActionPermissionRevoke = "permission.revoke"
+	ActionRelationGrant    = "relation.grant"
+	ActionRelationRevoke   = "relation.revoke"
+	ActionAPIKeyCreate     = "api_key.create"
+	ActionAPIKeyRevoke     = "api_key.revoke"
+	ActionConsentRevoke    = "consent.revoke"
+
+	ActionServiceAccountCreate       = "service_account.create"
+	ActionServiceAccountUpdate       = "service_account.update"
+	ActionServiceAccountDisable      = "service_account.disable"
+	ActionServiceAccountEnable       = "service_account.enable"
+	ActionServiceAccountRotateSecret = "service_account.rotate_secret"
+	ActionServiceAccountDelete       = "service_account.delete"
+	ActionOAuthClientRegister        = "oauth_client.register"
+	ActionOAuthClientDelete          = "oauth_client.delete"
+)
+
+// Target types of audit events.
+const (
+	TargetUser           = "user"
+	TargetRole           = "role"
+	TargetPermission     = "permission"
+	TargetResource       = "resource"
+	TargetAPIKey         = "api_key"
+	TargetOAuthClient    = "oauth_client"
+	TargetServiceAccount = "service_account"
+)
+
+// _ ensures that *service implements the Service interface at compile time.
+var _ Service = (*service)(nil)
+
+// Service records and reads the audit log.
+type Service interface {
+	// Record appends an event, taking the actor, IP address and request ID from ctx where the event leaves them
+	// empty. Failures are logged rather than returned, so that auditing cannot break the operation it records.
+	// While Run runs, the event is handed to it instead of appended by the caller.
+	Record(ctx context.Context, event *models.AuditEvent)
+	// Run appends recorded events in batches until ctx is done, then appends those still waiting. Appending takes
+	// a lock on the chain across instances, which a request then no longer waits for.
+	Run(ctx context.Context) error
+	// List lists the events matching a filter, newest first.
+	List(ctx context.Context, filter *models.AuditFilter) (*models.AuditPage, error)
+	// Verify checks the hash chain of the whole log.
+	Verify(ctx context.Context) (*models.AuditVerification, error)
+	// Middleware puts the IP address and ID of requests into their context, for events to record.
+	Middleware(next http.Handler) http.Handler
+}
+
+type service struct {
+	repository        Repository
+	trustForwardedFor bool
+	logger            *zap.Logger
+
+	// running is set while Run takes the events of the buffer; mu keeps Record from buffering an event once Run
+	// stopped taking them.
+	mu      sync.RWMutex
+	running bool
+	buffer  chan *models.AuditEvent
+}
+
+// NewService creates a new Service.
+func NewService(repository Repository, cfg *appconfig.Config, logger *zap.Logger) Service {
+	bufferSize := cfg.Audit.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+
+	return &service{
+		repository:        repository,
+		trustForwardedFor: cfg.Audit.TrustForwardedFor,
+		logger:            logger,
+		buffer:            make(chan *models.AuditEvent, bufferSize),
+	}
+}
+
+// Record appends an event, or hands it to Run.
+func (s *service) Record(ctx context.Context, event *models.AuditEvent) {
+	fromContext(ctx, event)
+	if event.Outcome == "" {
+		event.Outcome = models.AuditSuccess
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	s.mu.RLock()
+	if s.running {
+		select {
+		case s.buffer <- event:
+			s.mu.RUnlock()
+			return
+		default:
+		}
+	}
+	s.mu.RUnlock()
+
+	// The event is recorded even when the request that caused it was cancelled, e.g. by a client hanging up.
+	s.append(context.WithoutCancel(ctx), []*models.AuditEvent{event})
+}
+
+// Run appends the buffered events in batches.
+func (s *service) Run(ctx context.Context) error {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return errors.New("the audit writer is already running")
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.mu.Lock()
+			s.running = false
+			s.mu.Unlock()
+
+			// Nothing is buffered anymore, so the buffer can be drained to the last event.
+			for {
+				batch := s.take(nil)
+				if len(batch) == 0 {
+					return nil
+				}
+				s.append(context.WithoutCancel(ctx), batch)
+			}
+		case event := <-s.buffer:
+			s.append(context.WithoutCancel(ctx), s.take([]*models.AuditEvent{event}))
+		}
+	}
+}
+
+// take adds the buffered events to batch, without waiting for more, until it holds writeBatchSize events.
+func (s *service) take(batch []*models.AuditEvent) []*models.AuditEvent {
+	for len(batch) < writeBatchSize {
+		select {
+		case event := <-s.buffer:
+			batch = append(batch, event)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+// append appends events to the chain, logging the events that could not be.
+func (s *service) append(ctx context.Context, events []*models.AuditEvent) {
+	if err := s.repository.AppendEvents(ctx, events); err != nil {
+		for _, event := range events {
+			s.logger.Error("failed to record audit event",
+				zap.String("action", event.Action),
+				zap.String("target", event.TargetType+":"+event.TargetID),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// List lists the events matching a filter, newest first.
+func (s *service) List(ctx context.Context, filter *models.AuditFilter) (*models.AuditPage, error) {
+	query := *filter
+	if query.Limit <= 0 {
+		query.Limit = DefaultLimit
+	}
+	if query.Limit > MaxLimit {
+		query.Limit = MaxLimit
+	}
+
+	// One more event than asked for tells whether there is a next page.
+	query.Limit++
+	events, err := s.repository.ListEvents(ctx, &query)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.AuditPage{Events: events}
+	if len(events) == query.Limit {
+		page.Events = events[:len(events)-1]
+		page.NextBeforeID = page.Events[len(page.Events)-1].ID
+	}
+	return page, nil
+}
+
+// Verify checks the hash chain of the whole log, from the first event on.
+func (s *service) Verify(ctx context.Context) (*models.AuditVerification, error) {
+	verification := &models.AuditVerification{Valid: true}
+
+	var afterID uint64
+	for {
+		events, err := s.repository.ListEventsAfter(ctx, afterID, verifyBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, event := range events {
+			hash, err := event.ComputeHash(verification.LastHash)
+			if err != nil || event.PrevHash != verification.LastHash || hash != event.Hash {
+				verification.Valid = false
+				verification.BrokenAt = event.ID
+				return verification, nil
+			}
+			verification.Checked++
+			verification.LastHash = event.Hash
+			afterID = event.ID
+		}
+
+		if len(events) < verifyBatchSize {
+			return verification, nil
+		}
+	}
+}
+
+// Outcome returns the outcome and reason of an operation that returned err.
+func Outcome(err error) (string, string) {
+	if err != nil {
+		return models.AuditFailure, err.Error()
+	}
+	return models.AuditSuccess, ""
+}
+
+// State encodes the state of a target for the Before and After of an event. It returns nil for a nil state, or one
+// that cannot be encoded.
+func State(v any) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return data
+}
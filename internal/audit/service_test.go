@@ -0,0 +1,229 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	appconfig "goflare.io/auth/internal/config"
+	"goflare.io/auth/internal/models"
+)
+
+// memoryRepository keeps the chain in memory, chaining events as the Postgres repository does.
+type memoryRepository struct {
+	mu      sync.Mutex
+	events  []*models.AuditEvent
+	batches []int
+}
+
+func (r *memoryRepository) AppendEvents(_ context.Context, events []*models.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var prevHash string
+	if len(r.events) > 0 {
+		prevHash = r.events[len(r.events)-1].Hash
+	}
+	for _, event := range events {
+		var err error
+		event.ID = uint64(len(r.events) + 1)
+		event.OccurredAt = event.OccurredAt.UTC().Truncate(time.Microsecond)
+		event.PrevHash = prevHash
+		if event.Hash, err = event.ComputeHash(prevHash); err != nil {
+			return err
+		}
+		r.events = append(r.events, event)
+		prevHash = event.Hash
+	}
+	r.batches = append(r.batches, len(events))
+	return nil
+}
+
+func (r *memoryRepository) ListEvents(context.Context, *models.AuditFilter) ([]*models.AuditEvent, error) {
+	return nil, nil
+}
+
+func (r *memoryRepository) ListEventsAfter(_ context.Context, afterID uint64, limit int) ([]*models.AuditEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var events []*models.AuditEvent
+	for _, event := range r.events {
+		if event.ID > afterID && len(events) < limit {
+			copied := *event
+			events = append(events, &copied)
+		}
+	}
+	return events, nil
+}
+
+// newChain returns a service over a chain of n events.
+func newChain(t *testing.T, n int) (*service, *memoryRepository) {
+	t.Helper()
+
+	repository := &memoryRepository{}
+	s := NewService(repository, &appconfig.Config{}, zap.NewNop()).(*service)
+	for i := range n {
+		s.Record(context.Background(), &models.AuditEvent{
+			Action:   ActionLogin,
+			TargetID: fmt.Sprint(i),
+			Before:   State(map[string]int{"attempt": i}),
+		})
+	}
+	return s, repository
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name         string
+		tamper       func(r *memoryRepository)
+		wantValid    bool
+		wantBrokenAt uint64
+	}{
+		{
+			name:      "intact chain",
+			tamper:    func(r *memoryRepository) {},
+			wantValid: true,
+		},
+		{
+			name:         "altered field",
+			tamper:       func(r *memoryRepository) { r.events[2].Outcome = models.AuditFailure },
+			wantBrokenAt: 3,
+		},
+		{
+			name:         "altered state",
+			tamper:       func(r *memoryRepository) { r.events[2].After = []byte(`{"attempt":99}`) },
+			wantBrokenAt: 3,
+		},
+		{
+			name: "altered and rehashed event",
+			tamper: func(r *memoryRepository) {
+				r.events[2].Action = ActionLogout
+				r.events[2].Hash, _ = r.events[2].ComputeHash(r.events[2].PrevHash)
+			},
+			wantBrokenAt: 4,
+		},
+		{
+			name:         "removed event",
+			tamper:       func(r *memoryRepository) { r.events = append(r.events[:2], r.events[3:]...) },
+			wantBrokenAt: 4,
+		},
+		{
+			name:         "removed first event",
+			tamper:       func(r *memoryRepository) { r.events = r.events[1:] },
+			wantBrokenAt: 2,
+		},
+		{
+			name: "swapped events",
+			tamper: func(r *memoryRepository) {
+				r.events[1], r.events[2] = r.events[2], r.events[1]
+				r.events[1].ID, r.events[2].ID = 2, 3
+			},
+			wantBrokenAt: 2,
+		},
+		{
+			name:      "removed last event",
+			tamper:    func(r *memoryRepository) { r.events = r.events[:len(r.events)-1] },
+			wantValid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repository := newChain(t, 5)
+			tt.tamper(repository)
+
+			verification, err := s.Verify(context.Background())
+			if err != nil {
+				t.Fatalf("Verify failed: %v", err)
+			}
+			if verification.Valid != tt.wantValid || verification.BrokenAt != tt.wantBrokenAt {
+				t.Errorf("Verify = valid %v, broken at %d; want valid %v, broken at %d",
+					verification.Valid, verification.BrokenAt, tt.wantValid, tt.wantBrokenAt)
+			}
+		})
+	}
+}
+
+func TestVerifyAcrossBatches(t *testing.T) {
+	s, repository := newChain(t, verifyBatchSize+10)
+
+	verification, err := s.Verify(context.Background())
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if !verification.Valid || verification.Checked != verifyBatchSize+10 {
+		t.Fatalf("Verify = valid %v, checked %d; want valid, checked %d",
+			verification.Valid, verification.Checked, verifyBatchSize+10)
+	}
+
+	repository.events[verifyBatchSize+4].Reason = "tampered"
+	if verification, err = s.Verify(context.Background()); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if verification.Valid || verification.BrokenAt != verifyBatchSize+5 {
+		t.Errorf("Verify = valid %v, broken at %d; want broken at %d",
+			verification.Valid, verification.BrokenAt, verifyBatchSize+5)
+	}
+}
+
+func TestRunAppendsInBatches(t *testing.T) {
+	repository := &memoryRepository{}
+	s := NewService(repository, &appconfig.Config{}, zap.NewNop()).(*service)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	// Record only hands events to Run once it runs.
+	for {
+		s.mu.RLock()
+		running := s.running
+		s.mu.RUnlock()
+		if running {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	const recorded = 3 * writeBatchSize
+	for i := range recorded {
+		s.Record(context.Background(), &models.AuditEvent{Action: ActionLogin, TargetID: fmt.Sprint(i)})
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(repository.events) != recorded {
+		t.Fatalf("appended %d events, want %d", len(repository.events), recorded)
+	}
+	for i, event := range repository.events {
+		if event.TargetID != fmt.Sprint(i) {
+			t.Fatalf("event %d has target %s, want the events in the order they were recorded", i, event.TargetID)
+		}
+	}
+	for _, size := range repository.batches {
+		if size > writeBatchSize {
+			t.Errorf("appended a batch of %d events, want at most %d", size, writeBatchSize)
+		}
+	}
+
+	// Once Run returned, events are appended by the caller.
+	s.Record(context.Background(), &models.AuditEvent{Action: ActionLogout})
+	if len(repository.events) != recorded+1 {
+		t.Errorf("appended %d events after Run returned, want %d", len(repository.events), recorded+1)
+	}
+
+	verification, err := s.Verify(context.Background())
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if !verification.Valid {
+		t.Errorf("Verify broken at %d, want a valid chain", verification.BrokenAt)
+	}
+}
@@ -0,0 +1,292 @@
+package authentication
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+
+	"goflare.io/auth/internal/identity"
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/user"
+)
+
+// memoryUsers is a user repository holding users and their linked identities in memory.
+type memoryUsers struct {
+	user.Repository
+	users      map[uint64]*models.User
+	identities []*models.UserIdentity
+	profiles   map[uint64]*models.User
+	synced     map[uint64]*models.UserIdentity
+}
+
+func newMemoryUsers(users ...*models.User) *memoryUsers {
+	r := &memoryUsers{
+		users:    make(map[uint64]*models.User),
+		profiles: make(map[uint64]*models.User),
+		synced:   make(map[uint64]*models.UserIdentity),
+	}
+	for _, u := range users {
+		r.users[u.ID] = u
+	}
+	return r
+}
+
+func (r *memoryUsers) CreateUser(_ context.Context, u *models.User) (uint64, error) {
+	id := uint64(len(r.users) + 1)
+	created := *u
+	created.ID = id
+	r.users[id] = &created
+	return id, nil
+}
+
+func (r *memoryUsers) FindUserByID(_ context.Context, id uint64) (*models.User, error) {
+	if u, ok := r.users[id]; ok {
+		return u, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *memoryUsers) FindUserByEmail(_ context.Context, email string) (*models.User, error) {
+	for _, u := range r.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *memoryUsers) FindUserByUsername(_ context.Context, username string) (*models.User, error) {
+	for _, u := range r.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *memoryUsers) FindIdentity(_ context.Context, provider, subject string) (*models.UserIdentity, error) {
+	for _, linked := range r.identities {
+		if linked.Provider == provider && linked.Subject == subject {
+			return linked, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *memoryUsers) ListUserIdentities(_ context.Context, userID uint64) ([]*models.UserIdentity, error) {
+	var identities []*models.UserIdentity
+	for _, linked := range r.identities {
+		if linked.UserID == userID {
+			identities = append(identities, linked)
+		}
+	}
+	return identities, nil
+}
+
+func (r *memoryUsers) LinkIdentity(_ context.Context, linked *models.UserIdentity) (uint64, error) {
+	linked.ID = uint64(len(r.identities) + 1)
+	r.identities = append(r.identities, linked)
+	return linked.ID, nil
+}
+
+func (r *memoryUsers) UpsertFromProvider(_ context.Context, profile *models.User, linked *models.UserIdentity) error {
+	r.profiles[profile.ID] = profile
+	r.synced[profile.ID] = linked
+	return nil
+}
+
+// newIdentityService returns a service resolving identities against users, sealing provider tokens with cipher.
+func newIdentityService(t *testing.T, users *memoryUsers, cipher *identity.TokenCipher) *service {
+	t.Helper()
+
+	if cipher == nil {
+		var err error
+		if cipher, err = identity.NewTokenCipher(nil); err != nil {
+			t.Fatalf("NewTokenCipher failed: %v", err)
+		}
+	}
+	return &service{userStore: users, tokens: cipher, logger: zap.NewNop()}
+}
+
+func TestResolveIdentity(t *testing.T) {
+	existing := func() *memoryUsers {
+		users := newMemoryUsers(
+			&models.User{ID: 1, Username: "alice", Email: "alice@example.com", PasswordHash: "hash"},
+			&models.User{ID: 2, Username: "bob", Email: "bob@example.com"},
+		)
+		users.identities = []*models.UserIdentity{
+			{ID: 1, UserID: 2, Provider: "google", Subject: "bob-google", Email: "bob@example.com"},
+		}
+		return users
+	}
+
+	tests := []struct {
+		name         string
+		identity     models.ExternalIdentity
+		wantUserID   uint64
+		wantUsername string
+		wantErr      error
+		wantAnyErr   bool
+	}{
+		{
+			name:       "linked identity",
+			identity:   models.ExternalIdentity{Provider: "google", Subject: "bob-google", Email: "other@example.com"},
+			wantUserID: 2,
+		},
+		{
+			name:       "verified email of an existing user",
+			identity:   models.ExternalIdentity{Provider: "github", Subject: "alice-github", Email: "alice@example.com", EmailVerified: true},
+			wantUserID: 1,
+		},
+		{
+			name:       "unverified email of an existing user",
+			identity:   models.ExternalIdentity{Provider: "github", Subject: "alice-github", Email: "alice@example.com"},
+			wantAnyErr: true,
+		},
+		{
+			name:       "no email",
+			identity:   models.ExternalIdentity{Provider: "github", Subject: "carol-github"},
+			wantAnyErr: true,
+		},
+		{
+			name:       "no subject",
+			identity:   models.ExternalIdentity{Provider: "github", Email: "alice@example.com", EmailVerified: true},
+			wantAnyErr: true,
+		},
+		{
+			name:         "verified email of a new user",
+			identity:     models.ExternalIdentity{Provider: "github", Subject: "carol-github", Email: "carol@example.com", EmailVerified: true},
+			wantUserID:   3,
+			wantUsername: "carol",
+		},
+		{
+			name:         "username taken",
+			identity:     models.ExternalIdentity{Provider: "github", Subject: "alice-github", Username: "alice", Email: "alice@example.org", EmailVerified: true},
+			wantUserID:   3,
+			wantUsername: "alice_2",
+		},
+		{
+			name:     "second identity of a provider",
+			identity: models.ExternalIdentity{Provider: "google", Subject: "bob-google-2", Email: "bob@example.com", EmailVerified: true},
+			wantErr:  ErrIdentityLinked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := existing()
+			s := newIdentityService(t, users, nil)
+
+			userID, err := s.ResolveIdentity(context.Background(), &tt.identity)
+			if tt.wantErr != nil || tt.wantAnyErr {
+				if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+					t.Fatalf("ResolveIdentity() error = %v, want %v", err, tt.wantErr)
+				}
+				if len(users.users) != 2 || len(users.identities) != 1 {
+					t.Errorf("ResolveIdentity created %d users and %d identities, want none", len(users.users)-2, len(users.identities)-1)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveIdentity failed: %v", err)
+			}
+			if userID != tt.wantUserID {
+				t.Errorf("ResolveIdentity() = %d, want %d", userID, tt.wantUserID)
+			}
+
+			linked, err := users.FindIdentity(context.Background(), tt.identity.Provider, tt.identity.Subject)
+			if err != nil || linked.UserID != tt.wantUserID {
+				t.Errorf("identity linked to %v (%v), want user %d", linked, err, tt.wantUserID)
+			}
+			if tt.wantUsername != "" {
+				if created := users.users[userID]; created.Username != tt.wantUsername || created.PasswordHash != "" {
+					t.Errorf("created user %q with password %q, want %q without password", created.Username, created.PasswordHash, tt.wantUsername)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveIdentitySyncsProfile(t *testing.T) {
+	cipher, err := identity.NewTokenCipher([]byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("NewTokenCipher failed: %v", err)
+	}
+	disabled, err := identity.NewTokenCipher(nil)
+	if err != nil {
+		t.Fatalf("NewTokenCipher failed: %v", err)
+	}
+
+	expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+	externalIdentity := &models.ExternalIdentity{
+		Provider:      "google",
+		Subject:       "subject",
+		Email:         "alice@example.com",
+		EmailVerified: true,
+		Name:          "Alice",
+		PhotoURL:      "https://example.com/alice.png",
+		Phone:         "+886900000000",
+		Token:         &oauth2.Token{AccessToken: "access", RefreshToken: "refresh", Expiry: expiry},
+	}
+
+	tests := []struct {
+		name       string
+		cipher     *identity.TokenCipher
+		linked     bool
+		wantTokens bool
+	}{
+		{"first sign-in", cipher, false, true},
+		{"later sign-in", cipher, true, true},
+		{"without token encryption key", disabled, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := newMemoryUsers(&models.User{ID: 1, Username: "alice", Email: "alice@example.com"})
+			if tt.linked {
+				users.identities = []*models.UserIdentity{{ID: 1, UserID: 1, Provider: "google", Subject: "subject"}}
+			}
+			s := newIdentityService(t, users, tt.cipher)
+
+			if _, err := s.ResolveIdentity(context.Background(), externalIdentity); err != nil {
+				t.Fatalf("ResolveIdentity failed: %v", err)
+			}
+
+			profile := users.profiles[1]
+			if profile == nil {
+				t.Fatal("ResolveIdentity did not store the profile")
+			}
+			if profile.DisplayName != "Alice" || profile.PhotoURL != externalIdentity.PhotoURL || profile.Phone != externalIdentity.Phone {
+				t.Errorf("profile = %+v, want the name, photo and phone of the provider", profile)
+			}
+
+			synced := users.synced[1]
+			if !tt.wantTokens {
+				if synced.AccessToken != nil || synced.RefreshToken != nil {
+					t.Error("provider tokens stored without an encryption key")
+				}
+				return
+			}
+			if string(synced.AccessToken) == "access" || string(synced.RefreshToken) == "refresh" {
+				t.Fatal("provider tokens stored in plaintext")
+			}
+			if access, err := cipher.Open(synced.AccessToken, "google", "subject"); err != nil || access != "access" {
+				t.Errorf("sealed access token opens to %q (%v), want access", access, err)
+			}
+			if refresh, err := cipher.Open(synced.RefreshToken, "google", "subject"); err != nil || refresh != "refresh" {
+				t.Errorf("sealed refresh token opens to %q (%v), want refresh", refresh, err)
+			}
+			if _, err := cipher.Open(synced.AccessToken, "google", "other"); err == nil {
+				t.Error("sealed access token opens for another subject")
+			}
+			if !synced.TokenExpiresAt.Equal(expiry) {
+				t.Errorf("TokenExpiresAt = %v, want %v", synced.TokenExpiresAt, expiry)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package authentication
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/audit"
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/models/enum"
+	"goflare.io/auth/internal/policy"
+)
+
+// recordingAudit is an audit service keeping the recorded events.
+type recordingAudit struct {
+	audit.Service
+	events []*models.AuditEvent
+}
+
+func (a *recordingAudit) Record(_ context.Context, event *models.AuditEvent) {
+	a.events = append(a.events, event)
+}
+
+func TestCheckPrincipalPermission(t *testing.T) {
+	enforcer := newEnforcer(t,
+		[][]string{
+			{"editor", "USER", "READ", "", policy.EffectAllow, ""},
+			{"editor", "USER", "UPDATE", "", policy.EffectAllow, ""},
+		},
+		[][]string{
+			{policy.UserSubject(1), "editor"},
+			{policy.PrincipalSubject(enum.SubjectServiceAccount, 2), "editor"},
+		},
+	)
+
+	tests := []struct {
+		name      string
+		principal *models.Principal
+		action    enum.ActionType
+		want      bool
+	}{
+		{
+			name:      "user",
+			principal: &models.Principal{Type: enum.SubjectUser, ID: 1},
+			action:    enum.ActionUpdate,
+			want:      true,
+		},
+		{
+			name:      "user without the permission",
+			principal: &models.Principal{Type: enum.SubjectUser, ID: 1},
+			action:    enum.ActionDelete,
+		},
+		{
+			name:      "API key within its scopes",
+			principal: &models.Principal{Type: enum.SubjectUser, ID: 1, APIKeyID: 1, Scopes: []string{"USER:READ"}},
+			action:    enum.ActionRead,
+			want:      true,
+		},
+		{
+			name:      "API key outside its scopes",
+			principal: &models.Principal{Type: enum.SubjectUser, ID: 1, APIKeyID: 1, Scopes: []string{"USER:READ"}},
+			action:    enum.ActionUpdate,
+		},
+		{
+			name:      "client token within its scopes",
+			principal: &models.Principal{Type: enum.SubjectUser, ID: 1, ClientID: "client", Scopes: []string{"USER:READ"}},
+			action:    enum.ActionRead,
+			want:      true,
+		},
+		{
+			name:      "client token outside its scopes",
+			principal: &models.Principal{Type: enum.SubjectUser, ID: 1, ClientID: "client", Scopes: []string{"USER:READ", "openid"}},
+			action:    enum.ActionUpdate,
+		},
+		{
+			name:      "client token without scopes",
+			principal: &models.Principal{Type: enum.SubjectUser, ID: 1, ClientID: "client"},
+			action:    enum.ActionRead,
+		},
+		{
+			name: "delegated token outside its scopes",
+			principal: &models.Principal{
+				Type: enum.SubjectUser, ID: 1, ClientID: "service", Scopes: []string{"USER:READ"},
+				Actor: &models.Actor{Subject: "service", ServiceAccountID: 2},
+			},
+			action: enum.ActionUpdate,
+		},
+		{
+			name:      "service account within its scopes",
+			principal: &models.Principal{Type: enum.SubjectServiceAccount, ID: 2, ClientID: "service", Scopes: []string{"USER:READ"}},
+			action:    enum.ActionRead,
+			want:      true,
+		},
+		{
+			name:      "service account outside its scopes",
+			principal: &models.Principal{Type: enum.SubjectServiceAccount, ID: 2, ClientID: "service", Scopes: []string{"USER:READ"}},
+			action:    enum.ActionUpdate,
+		},
+		{
+			name:      "service account without scopes",
+			principal: &models.Principal{Type: enum.SubjectServiceAccount, ID: 2, ClientID: "service"},
+			action:    enum.ActionRead,
+		},
+		{
+			name:      "service account scopes beyond its roles",
+			principal: &models.Principal{Type: enum.SubjectServiceAccount, ID: 2, ClientID: "service", Scopes: []string{"USER:DELETE"}},
+			action:    enum.ActionDelete,
+		},
+		{
+			name:      "scopes beyond the roles of the user",
+			principal: &models.Principal{Type: enum.SubjectUser, ID: 1, ClientID: "client", Scopes: []string{"USER:DELETE"}},
+			action:    enum.ActionDelete,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &recordingAudit{}
+			s := &service{
+				enforcer:  enforcer,
+				decisions: policy.NewDecisionCache(time.Minute, 100),
+				audit:     recorder,
+				logger:    zap.NewNop(),
+			}
+
+			allowed, err := s.CheckPrincipalPermission(context.Background(), tt.principal, enum.ResourceUser, tt.action)
+			if err != nil {
+				t.Fatalf("CheckPrincipalPermission failed: %v", err)
+			}
+			if allowed != tt.want {
+				t.Errorf("CheckPrincipalPermission() = %v, want %v", allowed, tt.want)
+			}
+			if denials := len(recorder.events); (denials == 1) == tt.want || denials > 1 {
+				t.Errorf("recorded %d denials, want one per denied check", denials)
+			}
+		})
+	}
+}
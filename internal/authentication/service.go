@@ -4,7 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"goflare.io/nexus"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
@@ -12,12 +15,36 @@ import (
 	"github.com/casbin/casbin/v2"
 	"go.uber.org/zap"
 
+	"goflare.io/auth/internal/apikey"
+	"goflare.io/auth/internal/audit"
+	"goflare.io/auth/internal/identity"
 	"goflare.io/auth/internal/models"
 	"goflare.io/auth/internal/models/enum"
+	"goflare.io/auth/internal/policy"
+	"goflare.io/auth/internal/relation"
+	"goflare.io/auth/internal/sqlc"
 	"goflare.io/auth/internal/token"
 	"goflare.io/auth/internal/user"
 )
 
+// MaxBatchChecks is the maximum number of checks accepted by a single BatchCheckPermission call.
+const MaxBatchChecks = 100
+
+var (
+	// ErrIdentityLinked is returned when linking an identity that is linked to another user,
+	// or a second identity of the same provider.
+	ErrIdentityLinked = errors.New("identity is already linked")
+
+	// ErrLastSignInMethod is returned when unlinking the only way a user without a password can sign in.
+	ErrLastSignInMethod = errors.New("cannot unlink the last sign-in method")
+
+	// ErrUserDisabled is returned when a disabled user signs in.
+	ErrUserDisabled = errors.New("user is disabled")
+
+	// ErrIncorrectPassword is returned when changing a password with a wrong current password.
+	ErrIncorrectPassword = errors.New("incorrect password")
+)
+
 // _ is used to ensure that *service implements the Service interface at compile time.
 var _ Service = (*service)(nil)
 
@@ -29,40 +56,90 @@ type Service interface {
 	Logout(ctx context.Context, token string) error
 	// Register registers a new user with username, password, email, and phone.
 	Register(ctx context.Context, username, password, email, phone string) (*models.PASETOToken, error)
-	// ValidateToken validates a token.
-	ValidateToken(token string) (uint64, error)
+	// LoginWithIdentity logs in the user an external identity is linked to, linking or creating one on first login.
+	LoginWithIdentity(ctx context.Context, externalIdentity *models.ExternalIdentity) (*models.PASETOToken, error)
+	// ResolveIdentity returns the user an external identity is linked to, linking or creating one on first login.
+	ResolveIdentity(ctx context.Context, externalIdentity *models.ExternalIdentity) (uint64, error)
+	// LinkIdentity links an external identity to a signed-in user.
+	LinkIdentity(ctx context.Context, userID uint64, externalIdentity *models.ExternalIdentity) error
+	// UnlinkIdentity removes the identity of a provider from a user.
+	UnlinkIdentity(ctx context.Context, userID uint64, provider string) error
+	// ChangePassword changes the password of a user who knows their current password.
+	ChangePassword(ctx context.Context, userID uint64, currentPassword, newPassword string) error
+	// ListIdentities lists the identities linked to a user.
+	ListIdentities(ctx context.Context, userID uint64) ([]*models.UserIdentity, error)
+	// ValidateToken validates a user token or API key and returns the ID of the user.
+	ValidateToken(ctx context.Context, token string) (uint64, error)
+	// ValidatePrincipal validates a user or service account token or an API key and returns the principal it was issued to.
+	ValidatePrincipal(ctx context.Context, token string) (*models.Principal, error)
+	// CheckPrincipalPermission checks if a user or service account has a permission for a resource and action.
+	CheckPrincipalPermission(ctx context.Context, principal *models.Principal, resource enum.ResourceType, action enum.ActionType) (bool, error)
 	// CheckPermission checks if a user has a permission for a resource and action.
 	CheckPermission(ctx context.Context, userID uint64, resource enum.ResourceType, action enum.ActionType) (bool, error)
+	// CheckPermissionWithAttributes checks a permission against the attribute and time-window conditions of the policies.
+	CheckPermissionWithAttributes(ctx context.Context, userID uint64, resource enum.ResourceType, action enum.ActionType, attributes map[string]any, at time.Time) (bool, error)
+	// CheckResourcePermission checks if a user has a permission for an action on a single resource instance.
+	CheckResourcePermission(ctx context.Context, userID uint64, resource models.ResourceID, action enum.ActionType, attributes map[string]any) (bool, error)
+	// ExplainPermission checks a permission and reports the policy rule and role chain that decided it.
+	ExplainPermission(ctx context.Context, userID uint64, resource enum.ResourceType, action enum.ActionType, attributes map[string]any, at time.Time) (*policy.Explanation, error)
+	// BatchCheckPermission checks many resource and action pairs for a user in one call.
+	BatchCheckPermission(ctx context.Context, userID uint64, checks []models.PermissionCheck, attributes map[string]any) ([]models.PermissionDecision, error)
+	// ListPermissions lists every object and action a user is granted through their roles.
+	ListPermissions(ctx context.Context, userID uint64) ([]*models.GrantedPermission, error)
 }
 
 // service represents the core service implementation for user management and authorization.
 // It integrates various components such as the user repository, token manager, policy enforcer, and logger.
 type service struct {
 	userStore    user.Repository
+	relations    relation.Service
 	tokenManager token.Manager
+	tokens       *identity.TokenCipher
+	apiKeys      apikey.Service
 	enforcer     *casbin.Enforcer
+	decisions    *policy.DecisionCache
+	audit        audit.Service
 	logger       *zap.Logger
 }
 
-// NewService creates a new instance of Service with a provided user repository, configuration, enforcer, and logger.
-func NewService(userStore user.Repository, config *nexus.Config, enforcer *casbin.Enforcer, logger *zap.Logger) Service {
-
-	tokenManager := token.NewPasetoManager(config.Paseto.PublicKey, config.Paseto.PrivateKey, 24*time.Hour)
+// NewService creates a new instance of Service with a provided user repository, relation service, provider token
+// cipher, token manager, API key service, enforcer, decision cache, audit service, and logger.
+func NewService(
+	userStore user.Repository,
+	relations relation.Service,
+	tokens *identity.TokenCipher,
+	tokenManager token.Manager,
+	apiKeys apikey.Service,
+	enforcer *casbin.Enforcer,
+	decisions *policy.DecisionCache,
+	audit audit.Service,
+	logger *zap.Logger,
+) Service {
 	return &service{
 		userStore:    userStore,
+		relations:    relations,
 		tokenManager: tokenManager,
+		tokens:       tokens,
+		apiKeys:      apiKeys,
 		enforcer:     enforcer,
+		decisions:    decisions,
+		audit:        audit,
 		logger:       logger,
 	}
 }
 
 // Login authenticates a user with the provided email and password,
 // returning a PASETO token upon successful authentication.
-func (s *service) Login(ctx context.Context, email, password string) (*models.PASETOToken, error) {
+func (s *service) Login(ctx context.Context, email, password string) (token *models.PASETOToken, err error) {
 	s.logger.Info("login user", zap.String("email", email))
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
+	var userID uint64
+	defer func() {
+		s.recordSignIn(ctx, audit.ActionLogin, userID, map[string]string{"email": email}, err)
+	}()
+
 	user, err := s.userStore.FindUserByEmail(ctx, email)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -70,10 +147,14 @@ func (s *service) Login(ctx context.Context, email, password string) (*models.PA
 		}
 		return nil, errors.Join(err, errors.New("failed to get user"))
 	}
+	userID = user.ID
 
 	if err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
 		return nil, errors.New("incorrect password")
 	}
+	if user.IsDisabled() {
+		return nil, ErrUserDisabled
+	}
 
 	return s.tokenManager.GenerateToken(user.ID)
 }
@@ -81,15 +162,28 @@ func (s *service) Login(ctx context.Context, email, password string) (*models.PA
 // Logout revokes the provided token, effectively logging the user out.
 func (s *service) Logout(ctx context.Context, token string) error {
 	s.logger.Info("logout user", zap.String("token", token))
-	return s.tokenManager.RevokeToken(token)
+
+	var userID uint64
+	if claims, err := s.tokenManager.ParseToken(token); err == nil {
+		userID = claims.UserID
+	}
+
+	err := s.tokenManager.RevokeToken(token)
+	s.recordSignIn(ctx, audit.ActionLogout, userID, nil, err)
+	return err
 }
 
 // Register registers a new user, hashes the password, stores the user, and generates a PASETO token.
-func (s *service) Register(ctx context.Context, username, password, email, phone string) (*models.PASETOToken, error) {
+func (s *service) Register(ctx context.Context, username, password, email, phone string) (token *models.PASETOToken, err error) {
 
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
+	var userID uint64
+	defer func() {
+		s.recordSignIn(ctx, audit.ActionRegister, userID, map[string]string{"username": username, "email": email}, err)
+	}()
+
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, errors.Join(err, errors.New("failed to hash password"))
@@ -104,29 +198,593 @@ func (s *service) Register(ctx context.Context, username, password, email, phone
 		UpdatedAt:    time.Now(),
 	}
 
-	if _, err := s.userStore.CreateUser(ctx, user); err != nil {
+	if user.ID, err = s.userStore.CreateUser(ctx, user); err != nil {
 		return nil, errors.Join(err, errors.New("failed to create user"))
 	}
+	userID = user.ID
 
 	return s.tokenManager.GenerateToken(user.ID)
 }
 
-// ValidateToken validates the given token and returns the associated user ID if valid, otherwise an error.
-func (s *service) ValidateToken(token string) (uint64, error) {
-	s.logger.Info("validate token", zap.String("token", token))
+// LoginWithIdentity signs in the user an external identity is linked to.
+// An identity that is not linked yet is linked automatically to the user with the same email, but only when the
+// provider verified the email; otherwise a new user is created for it.
+func (s *service) LoginWithIdentity(ctx context.Context, externalIdentity *models.ExternalIdentity) (token *models.PASETOToken, err error) {
+	s.logger.Info("login with identity",
+		zap.String("provider", externalIdentity.Provider),
+		zap.String("subject", externalIdentity.Subject),
+	)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var userID uint64
+	defer func() {
+		s.recordSignIn(ctx, audit.ActionLogin, userID, identityState(externalIdentity), err)
+	}()
+
+	if userID, err = s.ResolveIdentity(ctx, externalIdentity); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userStore.FindUserByID(ctx, userID)
+	if err != nil {
+		return nil, errors.Join(err, errors.New("failed to get user"))
+	}
+	if user.IsDisabled() {
+		return nil, ErrUserDisabled
+	}
+
+	return s.tokenManager.GenerateToken(userID)
+}
+
+// ResolveIdentity returns the ID of the user an external identity is linked to, linking or creating one first
+// as described for LoginWithIdentity.
+func (s *service) ResolveIdentity(ctx context.Context, externalIdentity *models.ExternalIdentity) (uint64, error) {
+	if externalIdentity.Provider == "" || externalIdentity.Subject == "" {
+		return 0, errors.New("identity has no provider or subject")
+	}
+
+	linked, err := s.userStore.FindIdentity(ctx, externalIdentity.Provider, externalIdentity.Subject)
+	if err == nil {
+		return linked.UserID, s.syncIdentity(ctx, linked.UserID, externalIdentity)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, errors.Join(err, errors.New("failed to get identity"))
+	}
+
+	if externalIdentity.Email == "" {
+		return 0, errors.New("identity provider did not return an email")
+	}
+	if !externalIdentity.EmailVerified {
+		return 0, errors.New("identity provider did not verify the email")
+	}
+
+	var userID uint64
+	existing, err := s.userStore.FindUserByEmail(ctx, externalIdentity.Email)
+	switch {
+	case err == nil:
+		userID = existing.ID
+		s.logger.Info("linking identity to user with verified email",
+			zap.String("provider", externalIdentity.Provider),
+			zap.Uint64("userID", userID),
+		)
+	case errors.Is(err, sql.ErrNoRows):
+		if userID, err = s.createIdentityUser(ctx, externalIdentity); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, errors.Join(err, errors.New("failed to get user"))
+	}
+
+	if err = s.linkIdentity(ctx, userID, externalIdentity); err != nil {
+		return 0, err
+	}
+
+	return userID, s.syncIdentity(ctx, userID, externalIdentity)
+}
+
+// createIdentityUser creates a user without a password for an external externalIdentity.
+func (s *service) createIdentityUser(ctx context.Context, externalIdentity *models.ExternalIdentity) (uint64, error) {
+	provider := sqlc.ProviderType(externalIdentity.Provider)
+	if !provider.Valid() {
+		provider = sqlc.ProviderTypeOidc
+	}
+
+	username, err := s.availableUsername(ctx, externalIdentity)
+	if err != nil {
+		return 0, err
+	}
+
+	user := &models.User{
+		Username:    username,
+		Email:       externalIdentity.Email,
+		Phone:       externalIdentity.Phone,
+		Provider:    string(provider),
+		DisplayName: externalIdentity.Name,
+		PhotoURL:    externalIdentity.PhotoURL,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	if user.ID, err = s.userStore.CreateUser(ctx, user); err != nil {
+		return 0, errors.Join(err, errors.New("failed to create user"))
+	}
+
+	return user.ID, nil
+}
+
+// LinkIdentity links an external identity to a user and stores its profile and tokens. Linking an identity the
+// user already has only refreshes them; an identity linked to another user, or a second identity of the same
+// provider, is refused.
+func (s *service) LinkIdentity(ctx context.Context, userID uint64, externalIdentity *models.ExternalIdentity) (err error) {
+	s.logger.Info("link identity",
+		zap.Uint64("userID", userID),
+		zap.String("provider", externalIdentity.Provider),
+	)
+	defer func() {
+		s.recordUserChange(ctx, audit.ActionIdentityLink, userID, nil, identityState(externalIdentity), err)
+	}()
+
+	if err = s.linkIdentity(ctx, userID, externalIdentity); err != nil {
+		return err
+	}
+
+	return s.syncIdentity(ctx, userID, externalIdentity)
+}
+
+// linkIdentity links an external identity to a user unless it is already linked to them.
+func (s *service) linkIdentity(ctx context.Context, userID uint64, externalIdentity *models.ExternalIdentity) error {
+	linked, err := s.userStore.FindIdentity(ctx, externalIdentity.Provider, externalIdentity.Subject)
+	if err == nil {
+		if linked.UserID != userID {
+			return ErrIdentityLinked
+		}
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return errors.Join(err, errors.New("failed to get identity"))
+	}
+
+	identities, err := s.userStore.ListUserIdentities(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, existing := range identities {
+		if existing.Provider == externalIdentity.Provider {
+			return fmt.Errorf("%w: a %s identity is already linked", ErrIdentityLinked, externalIdentity.Provider)
+		}
+	}
+
+	if _, err = s.userStore.LinkIdentity(ctx, &models.UserIdentity{
+		UserID:   userID,
+		Provider: externalIdentity.Provider,
+		Subject:  externalIdentity.Subject,
+		Email:    externalIdentity.Email,
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// syncIdentity stores the profile a provider returned for a user, and the provider tokens sealed.
+func (s *service) syncIdentity(ctx context.Context, userID uint64, externalIdentity *models.ExternalIdentity) error {
+	linked := &models.UserIdentity{
+		UserID:   userID,
+		Provider: externalIdentity.Provider,
+		Subject:  externalIdentity.Subject,
+		Email:    externalIdentity.Email,
+	}
+
+	if externalIdentity.Token != nil && s.tokens.Enabled() {
+		var err error
+		if linked.AccessToken, err = s.tokens.Seal(externalIdentity.Token.AccessToken, externalIdentity.Provider, externalIdentity.Subject); err != nil {
+			return err
+		}
+		if linked.RefreshToken, err = s.tokens.Seal(externalIdentity.Token.RefreshToken, externalIdentity.Provider, externalIdentity.Subject); err != nil {
+			return err
+		}
+		linked.TokenExpiresAt = externalIdentity.Token.Expiry
+	}
+
+	profile := &models.User{
+		ID:          userID,
+		Phone:       externalIdentity.Phone,
+		DisplayName: externalIdentity.Name,
+		PhotoURL:    externalIdentity.PhotoURL,
+	}
+
+	if err := s.userStore.UpsertFromProvider(ctx, profile, linked); err != nil {
+		return errors.Join(err, errors.New("failed to store identity profile"))
+	}
+
+	return nil
+}
+
+// UnlinkIdentity removes the identity of a provider from a user.
+// The last identity of a user without a password cannot be removed, since the user could not sign in anymore.
+func (s *service) UnlinkIdentity(ctx context.Context, userID uint64, provider string) (err error) {
+	s.logger.Info("unlink identity",
+		zap.Uint64("userID", userID),
+		zap.String("provider", provider),
+	)
+	defer func() {
+		s.recordUserChange(ctx, audit.ActionIdentityUnlink, userID, map[string]string{"provider": provider}, nil, err)
+	}()
+
+	user, err := s.userStore.FindUserByID(ctx, userID)
+	if err != nil {
+		return errors.Join(err, errors.New("failed to get user"))
+	}
+
+	if user.PasswordHash == "" {
+		identities, err := s.userStore.ListUserIdentities(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if len(identities) <= 1 {
+			return ErrLastSignInMethod
+		}
+	}
+
+	return s.userStore.UnlinkIdentity(ctx, userID, provider)
+}
+
+// ChangePassword checks the current password of a user and replaces it. Users without a password, who sign in
+// with a provider only, cannot set one here.
+func (s *service) ChangePassword(ctx context.Context, userID uint64, currentPassword, newPassword string) (err error) {
+	s.logger.Info("change password", zap.Uint64("userID", userID))
+	defer func() {
+		s.recordUserChange(ctx, audit.ActionPasswordChange, userID, nil, nil, err)
+	}()
+
+	if newPassword == "" {
+		return errors.New("new password is required")
+	}
+
+	user, err := s.userStore.FindUserByID(ctx, userID)
+	if err != nil {
+		return errors.Join(err, errors.New("failed to get user"))
+	}
+	if err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(currentPassword)); err != nil {
+		return ErrIncorrectPassword
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.Join(err, errors.New("failed to hash password"))
+	}
+
+	return s.userStore.UpdatePassword(ctx, userID, string(hashedPassword))
+}
+
+// ListIdentities lists the identities linked to a user.
+func (s *service) ListIdentities(ctx context.Context, userID uint64) ([]*models.UserIdentity, error) {
+	return s.userStore.ListUserIdentities(ctx, userID)
+}
+
+// availableUsername derives an unused username from the preferred username or the email of an externalIdentity.
+func (s *service) availableUsername(ctx context.Context, externalIdentity *models.ExternalIdentity) (string, error) {
+	base := externalIdentity.Username
+	if len(base) < 2 {
+		base, _, _ = strings.Cut(externalIdentity.Email, "@")
+	}
+	if len(base) < 2 {
+		base = externalIdentity.Provider + "_user"
+	}
+	if len(base) > 90 {
+		base = base[:90]
+	}
+
+	for i := 0; i < 10; i++ {
+		candidate := base
+		if i > 0 {
+			candidate = fmt.Sprintf("%s_%d", base, i+1)
+		}
+
+		_, err := s.userStore.FindUserByUsername(ctx, candidate)
+		if errors.Is(err, sql.ErrNoRows) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", errors.Join(err, errors.New("failed to get user"))
+		}
+	}
+
+	return "", fmt.Errorf("no available username for %s", base)
+}
+
+// ValidateToken validates the given user token or API key and returns the associated user ID if valid,
+// otherwise an error.
+func (s *service) ValidateToken(ctx context.Context, token string) (uint64, error) {
+	if apikey.IsAPIKey(token) {
+		key, err := s.apiKeys.Authenticate(ctx, token)
+		if err != nil {
+			return 0, err
+		}
+		return key.UserID, nil
+	}
 	return s.tokenManager.ValidateToken(token)
 }
 
+// ValidatePrincipal validates a user or service account token and returns the principal it was issued to.
+// An API key acts for its owner with the scopes of the key.
+func (s *service) ValidatePrincipal(ctx context.Context, token string) (*models.Principal, error) {
+	if apikey.IsAPIKey(token) {
+		key, err := s.apiKeys.Authenticate(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		return &models.Principal{
+			Type:      enum.SubjectUser,
+			ID:        key.UserID,
+			Scopes:    key.Scopes,
+			APIKeyID:  key.ID,
+			ExpiresAt: key.ExpiresAt,
+		}, nil
+	}
+
+	claims, err := s.tokenManager.ParseToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	principal := &models.Principal{
+		Type:      enum.SubjectUser,
+		ID:        claims.UserID,
+		ClientID:  claims.ClientID,
+		Scopes:    claims.Scopes,
+		Audience:  claims.Audience,
+		Actor:     claims.Actor,
+		ExpiresAt: claims.ExpiresAt,
+	}
+	if claims.SubjectType == enum.SubjectServiceAccount {
+		principal.Type = enum.SubjectServiceAccount
+		principal.ID = claims.ServiceAccountID
+	}
+	if principal.ID == 0 {
+		return nil, errors.New("token has no subject")
+	}
+
+	return principal, nil
+}
+
+// CheckPrincipalPermission verifies if a user or service account has permission to perform a specific action
+// on a resource. Service accounts are checked against the roles assigned to their own subject, and are allowed
+// only what is also among the scopes of their token. An API key, a delegated token or a token issued to a client
+// likewise has the permissions of its user that are also among its scopes.
+// Denials are recorded in the audit log.
+func (s *service) CheckPrincipalPermission(ctx context.Context, principal *models.Principal, resource enum.ResourceType, action enum.ActionType) (bool, error) {
+	allowed := true
+	if principal.Restricted() && !apikey.Allows(principal.Scopes, string(resource), string(action)) {
+		allowed = false
+	} else {
+		subject := policy.PrincipalSubject(principal.Type, principal.ID)
+		var err error
+		if allowed, err = s.decisions.Enforce(s.enforcer, policy.Request(subject, string(resource), string(action), nil, time.Now())); err != nil {
+			return false, err
+		}
+	}
+
+	if !allowed {
+		s.audit.Record(audit.WithActor(ctx, principal), &models.AuditEvent{
+			Action:     audit.ActionAccessDenied,
+			TargetType: audit.TargetResource,
+			TargetID:   string(resource) + ":" + string(action),
+			Outcome:    models.AuditFailure,
+		})
+	}
+	return allowed, nil
+}
+
 // CheckPermission verifies if the user has permission to perform a specific action on a resource.
+// Policies with an attribute condition never match, since the request carries no attributes.
 func (s *service) CheckPermission(ctx context.Context, userID uint64, resource enum.ResourceType, action enum.ActionType) (bool, error) {
-	s.logger.Info("check permission", zap.Uint64("userID", userID), zap.Any("resource", resource), zap.Any("action", action))
+	return s.CheckPermissionWithAttributes(ctx, userID, resource, action, nil, time.Now())
+}
+
+// CheckPermissionWithAttributes verifies if the user has permission to perform a specific action on a resource,
+// evaluating policy conditions against the request attributes and time windows against the given time.
+// Policies are keyed by user ID, so the check is answered from memory, and repeated checks from the decision cache.
+func (s *service) CheckPermissionWithAttributes(
+	_ context.Context,
+	userID uint64,
+	resource enum.ResourceType,
+	action enum.ActionType,
+	attributes map[string]any,
+	at time.Time,
+) (bool, error) {
+	s.logger.Debug("check permission",
+		zap.Uint64("userID", userID),
+		zap.Any("resource", resource),
+		zap.Any("action", action),
+		zap.Any("attributes", attributes),
+		zap.Time("at", at),
+	)
+
+	return s.decisions.Enforce(s.enforcer, policy.Request(policy.UserSubject(userID), string(resource), string(action), attributes, at))
+}
+
+// ExplainPermission evaluates the same request as CheckPermissionWithAttributes and explains the decision:
+// the policy rule that matched, if any, and the chain of roles from the user to that rule.
+func (s *service) ExplainPermission(
+	_ context.Context,
+	userID uint64,
+	resource enum.ResourceType,
+	action enum.ActionType,
+	attributes map[string]any,
+	at time.Time,
+) (*policy.Explanation, error) {
+	s.logger.Info("explain permission",
+		zap.Uint64("userID", userID),
+		zap.Any("resource", resource),
+		zap.Any("action", action),
+	)
+
+	return policy.Explain(s.enforcer, policy.Request(policy.UserSubject(userID), string(resource), string(action), attributes, at))
+}
+
+// CheckResourcePermission verifies if the user has permission to perform an action on a single resource instance,
+// such as `order/42`. Instance policies are matched by keyMatch patterns like `order/*`, and the user's relations
+// to the instance are exposed to conditions as the `relations` attribute, e.g. `'owner' IN relations`.
+func (s *service) CheckResourcePermission(
+	ctx context.Context,
+	userID uint64,
+	resource models.ResourceID,
+	action enum.ActionType,
+	attributes map[string]any,
+) (bool, error) {
+	s.logger.Info("check resource permission",
+		zap.Uint64("userID", userID),
+		zap.Stringer("resource", resource),
+		zap.Any("action", action),
+	)
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	user, err := s.userStore.FindUserByID(ctx, userID)
+	relations, err := s.relations.Relations(ctx, userID, resource)
+	if err != nil {
+		return false, errors.Join(err, errors.New("failed to get resource relations"))
+	}
+
+	requestAttributes := make(map[string]any, len(attributes)+2)
+	for key, value := range attributes {
+		requestAttributes[key] = value
+	}
+	relationValues := make([]any, len(relations))
+	for i, name := range relations {
+		relationValues[i] = name
+	}
+	requestAttributes["relations"] = relationValues
+	requestAttributes["resource_id"] = resource.ID
+
+	return s.decisions.Enforce(s.enforcer, policy.Request(policy.UserSubject(userID), resource.String(), string(action), requestAttributes, time.Now()))
+}
+
+// BatchCheckPermission verifies many resource and action pairs for a user at once.
+// Every pair is evaluated in memory by the enforcer against the same attributes and evaluation time.
+func (s *service) BatchCheckPermission(
+	_ context.Context,
+	userID uint64,
+	checks []models.PermissionCheck,
+	attributes map[string]any,
+) ([]models.PermissionDecision, error) {
+	if len(checks) > MaxBatchChecks {
+		return nil, fmt.Errorf("too many checks: %d exceeds the limit of %d", len(checks), MaxBatchChecks)
+	}
+	s.logger.Info("batch check permission",
+		zap.Uint64("userID", userID),
+		zap.Int("checks", len(checks)),
+	)
+
+	at := time.Now()
+	subject := policy.UserSubject(userID)
+	requests := make([][]any, len(checks))
+	for i, check := range checks {
+		requests[i] = policy.Request(subject, string(check.Resource), string(check.Action), attributes, at)
+	}
+
+	results, err := s.enforcer.BatchEnforce(requests)
+	if err != nil {
+		return nil, err
+	}
+
+	decisions := make([]models.PermissionDecision, len(checks))
+	for i, check := range checks {
+		decisions[i] = models.PermissionDecision{Resource: check.Resource, Action: check.Action, Allowed: results[i]}
+	}
+
+	return decisions, nil
+}
+
+// ListPermissions lists every object and action a user is granted through their roles, including inherited ones.
+// Grants outside their time window are left out, as are grants overridden by a deny rule without a condition;
+// grants with an attribute condition are returned with it.
+func (s *service) ListPermissions(_ context.Context, userID uint64) ([]*models.GrantedPermission, error) {
+	s.logger.Info("list permissions", zap.Uint64("userID", userID))
+
+	subject := policy.UserSubject(userID)
+	rules, err := s.enforcer.GetImplicitPermissionsForUser(subject)
 	if err != nil {
-		return false, errors.Join(err, errors.New("failed to get user"))
+		return nil, fmt.Errorf("failed to get permissions for user %d: %w", userID, err)
+	}
+
+	at := time.Now()
+	seen := make(map[models.GrantedPermission]bool, len(rules))
+	permissions := make([]*models.GrantedPermission, 0, len(rules))
+	for _, rule := range rules {
+		// rule is sub, obj, act, attr, eft, time.
+		if len(rule) != policy.RuleLength || rule[4] != policy.EffectAllow {
+			continue
+		}
+		if inWindow, err := policy.MatchTimeWindow(rule[5], at); err != nil || !inWindow {
+			continue
+		}
+
+		granted := models.GrantedPermission{Object: rule[1], Action: rule[2], Condition: rule[3], TimeWindow: rule[5]}
+		if seen[granted] {
+			continue
+		}
+		seen[granted] = true
+
+		// The grant is enforced as a request, so that deny rules are matched the way the model matches them,
+		// e.g. order/42 by a deny on order/*. Without attributes, only deny rules without a condition match.
+		_, decisive, err := s.enforcer.EnforceEx(policy.Request(subject, rule[1], rule[2], nil, at)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check permissions for user %d: %w", userID, err)
+		}
+		if len(decisive) == policy.RuleLength && decisive[4] == policy.EffectDeny {
+			continue
+		}
+		permissions = append(permissions, &granted)
+	}
+
+	sort.Slice(permissions, func(i, j int) bool {
+		if permissions[i].Object != permissions[j].Object {
+			return permissions[i].Object < permissions[j].Object
+		}
+		return permissions[i].Action < permissions[j].Action
+	})
+
+	return permissions, nil
+}
+
+// recordSignIn records a login, logout or registration of a user. The user is the actor only when it succeeded;
+// a failed attempt is anonymous and records what was tried, e.g. the email, in After.
+func (s *service) recordSignIn(ctx context.Context, action string, userID uint64, attempt any, err error) {
+	event := &models.AuditEvent{
+		Action:     action,
+		TargetType: audit.TargetUser,
+		After:      audit.State(attempt),
+	}
+	event.Outcome, event.Reason = audit.Outcome(err)
+	if userID != 0 {
+		event.TargetID = strconv.FormatUint(userID, 10)
+		if err == nil {
+			event.ActorType, event.ActorID = string(enum.SubjectUser), event.TargetID
+		}
+	}
+	s.audit.Record(ctx, event)
+}
+
+// recordUserChange records a change to a user, made by the actor in ctx.
+func (s *service) recordUserChange(ctx context.Context, action string, userID uint64, before, after any, err error) {
+	event := &models.AuditEvent{
+		Action:     action,
+		TargetType: audit.TargetUser,
+		TargetID:   strconv.FormatUint(userID, 10),
+		Before:     audit.State(before),
+		After:      audit.State(after),
 	}
+	event.Outcome, event.Reason = audit.Outcome(err)
+	s.audit.Record(ctx, event)
+}
 
-	return s.enforcer.Enforce(user.Username, string(resource), string(action))
+// identityState is what the audit log records of an external identity, leaving out its tokens.
+func identityState(externalIdentity *models.ExternalIdentity) map[string]string {
+	return map[string]string{
+		"provider": externalIdentity.Provider,
+		"subject":  externalIdentity.Subject,
+		"email":    externalIdentity.Email,
+	}
 }
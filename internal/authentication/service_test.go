@@ -0,0 +1,253 @@
+package authentication
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/models/enum"
+	"goflare.io/auth/internal/policy"
+	"goflare.io/auth/internal/relation"
+)
+
+// newEnforcer returns an enforcer of casbin.conf with the given policies and role assignments.
+func newEnforcer(t *testing.T, rules, groupingRules [][]string) *casbin.Enforcer {
+	t.Helper()
+
+	enforcer, err := casbin.NewEnforcer("../../configs/casbin/casbin.conf")
+	if err != nil {
+		t.Fatalf("failed to load model: %v", err)
+	}
+	policy.RegisterFunctions(enforcer)
+
+	if _, err = enforcer.AddPolicies(rules); err != nil {
+		t.Fatalf("failed to add policies: %v", err)
+	}
+	if _, err = enforcer.AddGroupingPolicies(groupingRules); err != nil {
+		t.Fatalf("failed to add grouping policies: %v", err)
+	}
+	return enforcer
+}
+
+func TestListPermissions(t *testing.T) {
+	rules := [][]string{
+		{"editor", "ARTICLE", "READ", "", policy.EffectAllow, ""},
+		{"editor", "ARTICLE", "UPDATE", "", policy.EffectAllow, ""},
+		{"editor", "ARTICLE", "DELETE", "owner == true", policy.EffectAllow, ""},
+		{"editor", "order/42", "READ", "", policy.EffectAllow, ""},
+		{"editor", "order/*", "UPDATE", "", policy.EffectAllow, ""},
+		{"editor", "COMMENT", "*", "", policy.EffectAllow, ""},
+		{"suspended", "ARTICLE", "UPDATE", "", policy.EffectDeny, ""},
+		{"suspended", "ARTICLE", "DELETE", "", policy.EffectDeny, ""},
+		{"suspended", "order/*", "READ", "", policy.EffectDeny, ""},
+		{"suspended", "order/7", "UPDATE", "", policy.EffectDeny, ""},
+		{"suspended", "COMMENT", "READ", "", policy.EffectDeny, ""},
+		{"restricted", "ARTICLE", "*", "", policy.EffectDeny, ""},
+		{"restricted", "COMMENT", "*", "draft == true", policy.EffectDeny, ""},
+	}
+	groupingRules := [][]string{
+		{policy.UserSubject(1), "editor"},
+		{policy.UserSubject(2), "editor"},
+		{policy.UserSubject(2), "suspended"},
+		{policy.UserSubject(3), "editor"},
+		{policy.UserSubject(3), "restricted"},
+	}
+
+	s := &service{enforcer: newEnforcer(t, rules, groupingRules), logger: zap.NewNop()}
+
+	tests := []struct {
+		name   string
+		userID uint64
+		want   []models.GrantedPermission
+	}{
+		{
+			name:   "without deny rules",
+			userID: 1,
+			want: []models.GrantedPermission{
+				{Object: "ARTICLE", Action: "DELETE", Condition: "owner == true"},
+				{Object: "ARTICLE", Action: "READ"},
+				{Object: "ARTICLE", Action: "UPDATE"},
+				{Object: "COMMENT", Action: "*"},
+				{Object: "order/*", Action: "UPDATE"},
+				{Object: "order/42", Action: "READ"},
+			},
+		},
+		{
+			// A deny on one instance or action leaves the wider grant in the list, as it still applies elsewhere.
+			name:   "deny rules hide what they cover",
+			userID: 2,
+			want: []models.GrantedPermission{
+				{Object: "ARTICLE", Action: "READ"},
+				{Object: "COMMENT", Action: "*"},
+				{Object: "order/*", Action: "UPDATE"},
+			},
+		},
+		{
+			name:   "deny on every action and conditional deny",
+			userID: 3,
+			want: []models.GrantedPermission{
+				{Object: "COMMENT", Action: "*"},
+				{Object: "order/*", Action: "UPDATE"},
+				{Object: "order/42", Action: "READ"},
+			},
+		},
+		{
+			name:   "no roles",
+			userID: 4,
+			want:   []models.GrantedPermission{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			permissions, err := s.ListPermissions(context.Background(), tt.userID)
+			if err != nil {
+				t.Fatalf("ListPermissions failed: %v", err)
+			}
+
+			if len(permissions) != len(tt.want) {
+				t.Fatalf("ListPermissions returned %v, want %v", permissions, tt.want)
+			}
+			for i, permission := range permissions {
+				if *permission != tt.want[i] {
+					t.Errorf("permission %d is %+v, want %+v", i, *permission, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// memoryRelations holds the relations of users to resource instances.
+type memoryRelations struct {
+	relation.Service
+	relations map[uint64]map[string][]string
+}
+
+func (r memoryRelations) Relations(_ context.Context, userID uint64, resource models.ResourceID) ([]string, error) {
+	return r.relations[userID][resource.String()], nil
+}
+
+func TestCheckResourcePermission(t *testing.T) {
+	rules := [][]string{
+		{"customer", "order/*", "READ", "'owner' IN relations", policy.EffectAllow, ""},
+		{"customer", "order/*", "UPDATE", "'owner' IN relations && amount < 100", policy.EffectAllow, ""},
+		{"support", "order/*", "READ", "", policy.EffectAllow, ""},
+		{"support", "order/archive-*", "READ", "", policy.EffectDeny, ""},
+		{"auditor", "order/42", "*", "", policy.EffectAllow, ""},
+	}
+	groupingRules := [][]string{
+		{policy.UserSubject(1), "customer"},
+		{policy.UserSubject(2), "customer"},
+		{policy.UserSubject(3), "support"},
+		{policy.UserSubject(4), "auditor"},
+	}
+	relations := memoryRelations{relations: map[uint64]map[string][]string{
+		1: {"order/42": {"owner"}},
+		2: {"order/42": {"viewer"}},
+	}}
+
+	s := &service{
+		enforcer:  newEnforcer(t, rules, groupingRules),
+		relations: relations,
+		decisions: policy.NewDecisionCache(time.Minute, 100),
+		logger:    zap.NewNop(),
+	}
+
+	tests := []struct {
+		name       string
+		userID     uint64
+		resource   string
+		action     enum.ActionType
+		attributes map[string]any
+		want       bool
+	}{
+		{"owner reads", 1, "order/42", enum.ActionRead, nil, true},
+		{"owner reads another order", 1, "order/43", enum.ActionRead, nil, false},
+		{"owner updates within the condition", 1, "order/42", enum.ActionUpdate, map[string]any{"amount": 50}, true},
+		{"owner updates outside the condition", 1, "order/42", enum.ActionUpdate, map[string]any{"amount": 500}, false},
+		{"owner updates without attributes", 1, "order/42", enum.ActionUpdate, nil, false},
+		{"other relation", 2, "order/42", enum.ActionRead, nil, false},
+		{"wildcard grant", 3, "order/7", enum.ActionRead, nil, true},
+		{"deny on a narrower pattern", 3, "order/archive-7", enum.ActionRead, nil, false},
+		{"grant on one instance", 4, "order/42", enum.ActionDelete, nil, true},
+		{"grant on one instance only", 4, "order/421", enum.ActionDelete, nil, false},
+		{"other resource type", 3, "product/7", enum.ActionRead, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resourceID, err := models.ParseResourceID(tt.resource)
+			if err != nil {
+				t.Fatalf("ParseResourceID(%q) failed: %v", tt.resource, err)
+			}
+
+			allowed, err := s.CheckResourcePermission(context.Background(), tt.userID, resourceID, tt.action, tt.attributes)
+			if err != nil {
+				t.Fatalf("CheckResourcePermission failed: %v", err)
+			}
+			if allowed != tt.want {
+				t.Errorf("CheckResourcePermission(%d, %s, %s) = %v, want %v", tt.userID, tt.resource, tt.action, allowed, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatchCheckPermission(t *testing.T) {
+	rules := [][]string{
+		{"editor", "ARTICLE", "READ", "", policy.EffectAllow, ""},
+		{"editor", "ARTICLE", "UPDATE", "draft == true", policy.EffectAllow, ""},
+		{"editor", "COMMENT", "*", "", policy.EffectAllow, ""},
+		{"editor", "COMMENT", "DELETE", "", policy.EffectDeny, ""},
+	}
+	groupingRules := [][]string{{policy.UserSubject(1), "editor"}}
+	s := &service{enforcer: newEnforcer(t, rules, groupingRules), logger: zap.NewNop()}
+
+	checks := []models.PermissionCheck{
+		{Resource: "ARTICLE", Action: enum.ActionRead},
+		{Resource: "ARTICLE", Action: enum.ActionUpdate},
+		{Resource: "ARTICLE", Action: enum.ActionDelete},
+		{Resource: "COMMENT", Action: enum.ActionCreate},
+		{Resource: "COMMENT", Action: enum.ActionDelete},
+	}
+
+	tests := []struct {
+		name       string
+		userID     uint64
+		attributes map[string]any
+		want       []bool
+	}{
+		{"without attributes", 1, nil, []bool{true, false, false, true, false}},
+		{"attributes apply to every check", 1, map[string]any{"draft": true}, []bool{true, true, false, true, false}},
+		{"no roles", 2, nil, []bool{false, false, false, false, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decisions, err := s.BatchCheckPermission(context.Background(), tt.userID, checks, tt.attributes)
+			if err != nil {
+				t.Fatalf("BatchCheckPermission failed: %v", err)
+			}
+			if len(decisions) != len(checks) {
+				t.Fatalf("BatchCheckPermission returned %d decisions, want %d", len(decisions), len(checks))
+			}
+			for i, decision := range decisions {
+				if decision.Resource != checks[i].Resource || decision.Action != checks[i].Action {
+					t.Errorf("decision %d is for %s %s, want %s %s", i, decision.Resource, decision.Action, checks[i].Resource, checks[i].Action)
+				}
+				if decision.Allowed != tt.want[i] {
+					t.Errorf("decision %d (%s %s) = %v, want %v", i, decision.Resource, decision.Action, decision.Allowed, tt.want[i])
+				}
+			}
+		})
+	}
+
+	t.Run("too many checks", func(t *testing.T) {
+		if _, err := s.BatchCheckPermission(context.Background(), 1, make([]models.PermissionCheck, MaxBatchChecks+1), nil); err == nil {
+			t.Errorf("BatchCheckPermission accepted %d checks, want an error", MaxBatchChecks+1)
+		}
+	})
+}
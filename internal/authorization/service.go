@@ -4,14 +4,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/casbin/casbin/v2"
 	"go.uber.org/zap"
 
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/policy"
 	"goflare.io/auth/internal/role"
+	"goflare.io/auth/internal/serviceaccount"
 	"goflare.io/auth/internal/user"
 )
 
+// MaxDryRunRequests is the maximum number of test requests accepted by a single DryRun call.
+const MaxDryRunRequests = 500
+
 // _ ensures a service type implements the Service interface at compile-time.
 var _ Service = (*service)(nil)
 
@@ -19,75 +26,252 @@ var _ Service = (*service)(nil)
 type Service interface {
 	// LoadPolicies loads all policies.
 	LoadPolicies(ctx context.Context) error
+	// DryRun evaluates test requests against a proposed policy set and the current policies without applying anything.
+	DryRun(ctx context.Context, proposal *models.PolicySet, requests []models.TestRequest) ([]*models.DryRunResult, error)
 }
 
 // service represents the service layer for user and role management, policy enforcement, and logging.
 type service struct {
 	userStore user.Repository
 	roleStore role.Repository
+	accounts  serviceaccount.Repository
 	enforcer  *casbin.Enforcer
+	decisions *policy.DecisionCache
 	logger    *zap.Logger
 }
 
-// NewService creates a new instance of the Service with the provided repositories, enforcer, and logger.
+// NewService creates a new instance of the Service with the provided repositories, enforcer, decision cache, and logger.
+// It registers the attribute and time-window matcher functions on the enforcer.
 func NewService(
 	userStore user.Repository,
 	roleStore role.Repository,
+	accounts serviceaccount.Repository,
 	enforcer *casbin.Enforcer,
+	decisions *policy.DecisionCache,
 	logger *zap.Logger,
 ) Service {
+	policy.RegisterFunctions(enforcer)
 	return &service{
 		userStore: userStore,
 		roleStore: roleStore,
+		accounts:  accounts,
 		enforcer:  enforcer,
+		decisions: decisions,
 		logger:    logger,
 	}
 }
 
-// LoadPolicies loads the roles, permissions,
-// and user-role associations into the policy enforcer based on data from stores.
+// LoadPolicies rebuilds the policies of the enforcer from the roles, permissions, and role assignments in the
+// database. Missing rules and grouping policies are added, and those no row backs anymore, such as the roles of a
+// disabled user or rules written before subjects were user IDs, are removed, so the enforcer matches the database
+// after a restart. Enabled users are assigned to roles by their ID subject, see policy.UserSubject, and enabled
+// service accounts by theirs, see policy.ServiceAccountSubject. Cached decisions are dropped afterwards.
 func (s *service) LoadPolicies(ctx context.Context) error {
+	defer s.decisions.Invalidate()
+
+	rules, skipped, err := s.roleRules(ctx)
+	if err != nil {
+		return err
+	}
+	groupingRules, err := s.groupingRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	current, err := s.enforcer.GetPolicy()
+	if err != nil {
+		return fmt.Errorf("failed to get policies: %w", err)
+	}
+	stale, missing := diffRules(current, rules)
+	if len(stale) > 0 {
+		if _, err = s.enforcer.RemovePolicies(stale); err != nil {
+			return fmt.Errorf("failed to remove stale policies: %w", err)
+		}
+		s.logger.Info("removed stale policies", zap.Int("count", len(stale)))
+	}
+	if len(missing) > 0 {
+		if _, err = s.enforcer.AddPolicies(missing); err != nil {
+			return fmt.Errorf("failed to add policies: %w", err)
+		}
+	}
+
+	currentGrouping, err := s.enforcer.GetGroupingPolicy()
+	if err != nil {
+		return fmt.Errorf("failed to get grouping policies: %w", err)
+	}
+	stale, missing = diffRules(currentGrouping, groupingRules)
+	if len(stale) > 0 {
+		if _, err = s.enforcer.RemoveGroupingPolicies(stale); err != nil {
+			return fmt.Errorf("failed to remove stale grouping policies: %w", err)
+		}
+		s.logger.Info("removed stale grouping policies", zap.Int("count", len(stale)))
+	}
+	if len(missing) > 0 {
+		if _, err = s.enforcer.AddGroupingPolicies(missing); err != nil {
+			return fmt.Errorf("failed to add grouping policies: %w", err)
+		}
+	}
+
+	return errors.Join(skipped...)
+}
+
+// roleRules returns the rules granting or denying the permissions of every role. Permissions with an invalid
+// condition or time window are left out and reported in skipped.
+func (s *service) roleRules(ctx context.Context) (rules [][]string, skipped []error, err error) {
 	roleModels, err := s.roleStore.ListAllRoles(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to list roles: %w", err)
+		return nil, nil, fmt.Errorf("failed to list roles: %w", err)
 	}
 
-	var errs []error
 	for _, roleModel := range roleModels {
 		permissions, err := s.roleStore.FindRolePermissions(ctx, roleModel.ID)
 		if err != nil {
-			errs = append(errs, fmt.Errorf("failed to get permissions for role %s: %w", roleModel.Name, err))
-			continue
+			return nil, nil, fmt.Errorf("failed to get permissions for role %s: %w", roleModel.Name, err)
 		}
 
 		for _, perm := range permissions {
-			if _, err = s.enforcer.AddPolicy(roleModel.Name, string(perm.Resource), string(perm.Action)); err != nil {
-				errs = append(errs, fmt.Errorf("failed to add policy for role %s: %w", roleModel.Name, err))
+			if err = policy.Validate(perm.Condition, perm.TimeWindow); err != nil {
+				skipped = append(skipped, fmt.Errorf("skipping permission %s for role %s: %w", perm.Name, roleModel.Name, err))
+				continue
 			}
+
+			rules = append(rules, models.PolicyRule{
+				Subject:    roleModel.Name,
+				Object:     perm.Object(),
+				Action:     string(perm.Action),
+				Condition:  perm.Condition,
+				Effect:     perm.Effect,
+				TimeWindow: perm.TimeWindow,
+			}.Fields())
 		}
 	}
 
-	// Load all users and roles
+	return rules, skipped, nil
+}
+
+// groupingRules returns the role assignments of the enabled users and service accounts.
+func (s *service) groupingRules(ctx context.Context) ([][]string, error) {
 	userModels, err := s.userStore.ListAllUsers(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to list users: %w", err)
+		return nil, fmt.Errorf("failed to list users: %w", err)
 	}
 
+	var groupingRules [][]string
 	for _, userModel := range userModels {
+		if userModel.IsDisabled() {
+			continue
+		}
+
 		userRoles, err := s.userStore.FindUserRoles(ctx, userModel.ID)
 		if err != nil {
-			return fmt.Errorf("failed to get roles for user %s: %w", userModel.Username, err)
+			return nil, fmt.Errorf("failed to get roles for user %s: %w", userModel.Username, err)
 		}
 
 		for _, userRole := range userRoles {
-			if _, err = s.enforcer.AddGroupingPolicy(userModel.Username, userRole.Name); err != nil {
-				return fmt.Errorf("failed to add grouping policy for user %s: %w", userModel.Username, err)
-			}
+			groupingRules = append(groupingRules, []string{policy.UserSubject(userModel.ID), userRole.Name})
 		}
 	}
 
-	if len(errs) > 0 {
-		return errors.Join(errs...)
+	assignments, err := s.accounts.ListRoleAssignments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service account roles: %w", err)
 	}
-	return nil
+
+	for serviceAccountID, roleNames := range assignments {
+		for _, roleName := range roleNames {
+			groupingRules = append(groupingRules, []string{policy.ServiceAccountSubject(serviceAccountID), roleName})
+		}
+	}
+
+	return groupingRules, nil
+}
+
+// diffRules returns the current rules that are not wanted, and the wanted rules that are not current, without
+// duplicates.
+func diffRules(current, wanted [][]string) (stale, missing [][]string) {
+	key := func(rule []string) string {
+		return strings.Join(rule, "\x00")
+	}
+
+	wantedKeys := make(map[string]bool, len(wanted))
+	for _, rule := range wanted {
+		wantedKeys[key(rule)] = true
+	}
+
+	currentKeys := make(map[string]bool, len(current))
+	for _, rule := range current {
+		k := key(rule)
+		if currentKeys[k] {
+			continue
+		}
+		currentKeys[k] = true
+		if !wantedKeys[k] {
+			stale = append(stale, rule)
+		}
+	}
+
+	for _, rule := range wanted {
+		k := key(rule)
+		if currentKeys[k] {
+			continue
+		}
+		currentKeys[k] = true
+		missing = append(missing, rule)
+	}
+
+	return stale, missing
+}
+
+// DryRun evaluates each test request against both the current policies and a sandbox holding only the proposed
+// policy set, so the impact of a change can be reviewed before it is saved.
+func (s *service) DryRun(_ context.Context, proposal *models.PolicySet, requests []models.TestRequest) ([]*models.DryRunResult, error) {
+	if len(requests) > MaxDryRunRequests {
+		return nil, fmt.Errorf("too many test requests: %d exceeds the limit of %d", len(requests), MaxDryRunRequests)
+	}
+
+	rules := make([][]string, len(proposal.Rules))
+	for i, rule := range proposal.Rules {
+		rules[i] = rule.Fields()
+	}
+
+	var groupingRules [][]string
+	if proposal.GroupingRules == nil {
+		current, err := s.enforcer.GetGroupingPolicy()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get grouping policies: %w", err)
+		}
+		groupingRules = current
+	} else {
+		for _, groupingRule := range proposal.GroupingRules {
+			groupingRules = append(groupingRules, []string{groupingRule.Subject, groupingRule.Role})
+		}
+	}
+
+	sandbox, err := policy.NewSandbox(s.enforcer, rules, groupingRules)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*models.DryRunResult, 0, len(requests))
+	for _, testRequest := range requests {
+		request := policy.Request(policy.UserSubject(testRequest.UserID), testRequest.Object, testRequest.Action, testRequest.Attributes, testRequest.At)
+
+		current, err := policy.Explain(s.enforcer, request)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate current policies: %w", err)
+		}
+		proposed, err := policy.Explain(sandbox, request)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate proposed policies: %w", err)
+		}
+
+		results = append(results, &models.DryRunResult{
+			Request:  testRequest,
+			Current:  current,
+			Proposed: proposed,
+			Changed:  current.Allowed != proposed.Allowed,
+		})
+	}
+
+	return results, nil
 }
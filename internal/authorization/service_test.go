@@ -0,0 +1,31 @@
+package authorization
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffRules(t *testing.T) {
+	current := [][]string{
+		{"user:1", "editor"},
+		{"user:2", "editor"},
+		{"user:2", "editor"},
+		{"alice", "admin"},
+	}
+	wanted := [][]string{
+		{"user:1", "editor"},
+		{"user:3", "viewer"},
+		{"user:3", "viewer"},
+	}
+
+	stale, missing := diffRules(current, wanted)
+
+	wantStale := [][]string{{"user:2", "editor"}, {"alice", "admin"}}
+	if !reflect.DeepEqual(stale, wantStale) {
+		t.Errorf("stale = %v, want %v", stale, wantStale)
+	}
+	wantMissing := [][]string{{"user:3", "viewer"}}
+	if !reflect.DeepEqual(missing, wantMissing) {
+		t.Errorf("missing = %v, want %v", missing, wantMissing)
+	}
+}
@@ -0,0 +1,333 @@
+// Package config loads the settings of the auth service that are not part of the shared nexus configuration.
+// They are read from the same YAML file, and ${VAR} references are expanded from the environment so that
+// secrets can stay out of the file.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// PathEnv is the environment variable holding the path of the configuration file.
+	PathEnv = "AUTH_CONFIG_PATH"
+
+	// DefaultPath is the configuration file used when PathEnv is not set.
+	DefaultPath = "configs/config.yaml"
+)
+
+// Config is the configuration of the auth service.
+type Config struct {
+
+	// Environment names the deployment, e.g. development or production. authctl fixtures load reads the fixtures
+	// of this environment.
+	Environment string `yaml:"environment"`
+
+	// GRPC configures the gRPC server of AuthService.
+	GRPC GRPCConfig `yaml:"grpc"`
+
+	// Bootstrap configures how the first administrator is created.
+	Bootstrap BootstrapConfig `yaml:"bootstrap"`
+
+	// Identity configures the external identity providers users can sign in with.
+	Identity IdentityConfig `yaml:"identity"`
+
+	// OAuthServer configures the OAuth 2.0 authorization server through which registered clients obtain tokens.
+	OAuthServer OAuthServerConfig `yaml:"oauth_server"`
+
+	// ServiceAccounts configures the service accounts that authenticate with the client credentials grant.
+	ServiceAccounts ServiceAccountsConfig `yaml:"service_accounts"`
+
+	// Tokens configures the PASETO access tokens, whose current key pair is part of the nexus configuration.
+	Tokens TokensConfig `yaml:"tokens"`
+
+	// Audit configures the audit log.
+	Audit AuditConfig `yaml:"audit"`
+
+	// NATS is the server domain events are published to.
+	NATS NATSConfig `yaml:"nats"`
+
+	// Events configures the publication of domain events from the outbox.
+	Events EventsConfig `yaml:"events"`
+
+	// Migration configures the database migrations embedded in the binary.
+	Migration MigrationConfig `yaml:"migration"`
+
+	// Postgres holds the database settings the service reads itself; the connection is part of the nexus
+	// configuration.
+	Postgres PostgresConfig `yaml:"postgres"`
+}
+
+// GRPCConfig configures the gRPC server, which serves AuthService next to the HTTP endpoints.
+type GRPCConfig struct {
+
+	// Address is the address the gRPC server listens on, e.g. :50051. Without it, AuthService is not served.
+	Address string `yaml:"address"`
+}
+
+// BootstrapConfig configures the creation of the first administrator, which happens when the service starts and
+// no user has the admin role. With credentials the administrator is created right away; otherwise POST /bootstrap
+// creates it with a one-time token. Token sets it, as replicas behind a load balancer must share it; otherwise one is
+// generated and written to TokenFile, or to stderr when it is a terminal. The token is never logged.
+type BootstrapConfig struct {
+
+	// Username, Email and Password are the credentials of the first administrator. They are ignored once an
+	// administrator exists, and are best removed then.
+	Username string `yaml:"username"`
+	Email    string `yaml:"email"`
+	Password string `yaml:"password"`
+
+	// Token is the one-time token that POST /bootstrap accepts.
+	Token string `yaml:"token"`
+
+	// TokenFile is where a generated token is written, readable by the owner only. It is removed once the token
+	// was redeemed.
+	TokenFile string `yaml:"token_file"`
+}
+
+// NATSConfig configures the connection to NATS.
+type NATSConfig struct {
+
+	// URL is the URL of the NATS server, e.g. nats://nats:4222.
+	URL string `yaml:"url"`
+}
+
+// EventsConfig configures the relay that publishes the domain events of the outbox.
+type EventsConfig struct {
+
+	// Publisher is where events are published: nats, or memory to keep them in the process for tests. It defaults
+	// to nats when a NATS URL is configured. Without a publisher, events stay in the outbox until one is configured.
+	Publisher string `yaml:"publisher"`
+
+	// JetStream publishes events to JetStream, which acknowledges them once a stream stored them, instead of core
+	// NATS, which loses the events of subjects no one subscribes to.
+	JetStream bool `yaml:"jetstream"`
+
+	// SubjectPrefix starts the subjects events are published on, auth.events by default.
+	SubjectPrefix string `yaml:"subject_prefix"`
+
+	// PollInterval is how often the outbox is checked for pending events, every second by default.
+	PollInterval time.Duration `yaml:"poll_interval"`
+
+	// BatchSize is the number of events published in one transaction, 100 by default.
+	BatchSize int `yaml:"batch_size"`
+
+	// Retention is how long published events are kept in the outbox, a week by default.
+	Retention time.Duration `yaml:"retention"`
+}
+
+// AuditConfig configures the audit log.
+type AuditConfig struct {
+
+	// TrustForwardedFor records the client address of the X-Forwarded-For header instead of the address of the
+	// connection. Set it only behind a proxy that sets the header, since clients can send it too.
+	TrustForwardedFor bool `yaml:"trust_forwarded_for"`
+
+	// BufferSize is the number of events that wait for the background writer, 1024 by default. Events recorded
+	// while the buffer is full are written by the request that records them.
+	BufferSize int `yaml:"buffer_size"`
+}
+
+// MigrationConfig configures the database migrations.
+type MigrationConfig struct {
+
+	// Auto applies pending migrations when the service starts. Otherwise they are applied with authctl migrate up,
+	// and the service only refuses to start against a schema that is newer than itself or left dirty.
+	Auto bool `yaml:"auto"`
+}
+
+// PostgresConfig holds the database settings the service reads itself.
+type PostgresConfig struct {
+
+	// MigrationsTable is the table recording the schema version, optionally qualified with its schema. It has the
+	// layout of golang-migrate, so databases migrated with it carry on from their version. It defaults to
+	// schema_migrations.
+	MigrationsTable string `yaml:"x-migrations-table"`
+}
+
+// TokensConfig configures the PASETO access tokens.
+type TokensConfig struct {
+
+	// PreviousPublicKeys are the base64-encoded Ed25519 public keys of retired key pairs. Tokens signed with them
+	// are still accepted, and the keys are still published, so that a key pair can be rotated without signing
+	// everyone out. A key can be removed once the tokens it signed have expired.
+	PreviousPublicKeys []string `yaml:"previous_public_keys"`
+}
+
+// ServiceAccountsConfig configures service accounts.
+type ServiceAccountsConfig struct {
+
+	// TokenTTL is the lifetime of the tokens issued to service accounts. It defaults to one hour.
+	TokenTTL time.Duration `yaml:"token_ttl"`
+
+	// SecretGracePeriod is how long the previous secret keeps working after a rotation, so that deployments
+	// can pick up the new one. It defaults to 24 hours.
+	SecretGracePeriod time.Duration `yaml:"secret_grace_period"`
+}
+
+// OAuthServerConfig configures the OAuth 2.0 authorization server.
+type OAuthServerConfig struct {
+
+	// LoginURL is the page that signs the user in and asks for consent. The authorization endpoint redirects
+	// to it with the original authorization request in the query string.
+	LoginURL string `yaml:"login_url"`
+
+	// CodeTTL is how long an authorization code can be redeemed. It defaults to five minutes.
+	CodeTTL time.Duration `yaml:"code_ttl"`
+
+	// AccessTokenTTL is the lifetime of the access tokens issued to clients. It defaults to one hour.
+	AccessTokenTTL time.Duration `yaml:"access_token_ttl"`
+
+	// RefreshTokenTTL is the lifetime of the refresh tokens issued to clients. It defaults to thirty days.
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl"`
+
+	// Issuer is the public base URL of the service, e.g. https://auth.example.com. It is the iss claim of ID tokens
+	// and the base of the endpoints in the discovery document. OpenID Connect is disabled unless it is set.
+	Issuer string `yaml:"issuer"`
+
+	// SigningKeyFile is a PEM encoded RSA private key that signs ID tokens. Without it a key is generated at
+	// startup, which invalidates issued ID tokens on every restart and cannot be shared between replicas.
+	SigningKeyFile string `yaml:"signing_key_file"`
+
+	// IDTokenTTL is the lifetime of ID tokens. It defaults to one hour.
+	IDTokenTTL time.Duration `yaml:"id_token_ttl"`
+
+	// VerificationURL is the page where users enter the user code of the device authorization grant and approve
+	// the device. The grant is disabled unless it is set.
+	VerificationURL string `yaml:"verification_url"`
+
+	// DeviceCodeTTL is how long a device authorization can be approved and redeemed. It defaults to ten minutes.
+	DeviceCodeTTL time.Duration `yaml:"device_code_ttl"`
+
+	// DevicePollInterval is the minimum time between two token requests of a device. It defaults to five seconds.
+	DevicePollInterval time.Duration `yaml:"device_poll_interval"`
+
+	// ExchangeTokenTTL is the lifetime of the tokens issued by token exchange, capped at the remaining lifetime of
+	// the exchanged token. It defaults to five minutes.
+	ExchangeTokenTTL time.Duration `yaml:"exchange_token_ttl"`
+}
+
+// IdentityConfig configures the external identity providers.
+type IdentityConfig struct {
+
+	// Firebase configures Firebase Authentication. It is disabled unless explicitly enabled.
+	Firebase FirebaseConfig `yaml:"firebase"`
+
+	// Providers configures the OAuth 2.0 providers by name, e.g. google or github.
+	// Well-known providers only need client credentials; others also need their endpoints.
+	Providers map[string]ProviderConfig `yaml:"providers"`
+
+	// State configures where the state of logins in progress is kept.
+	State StateConfig `yaml:"state"`
+
+	// TokenEncryptionKey is the base64-encoded 32-byte AES key sealing the provider tokens stored at sign-in.
+	// Provider tokens are not stored when it is empty.
+	TokenEncryptionKey string `yaml:"token_encryption_key"`
+}
+
+// StateConfig configures the store of logins in progress between the redirect to a provider and its callback.
+type StateConfig struct {
+
+	// Store is memory or redis. The memory store only works with a single instance of the service.
+	Store string `yaml:"store"`
+
+	// TTL is how long a login may take before it has to be restarted. It defaults to ten minutes.
+	TTL time.Duration `yaml:"ttl"`
+
+	// RedisAddr is the address of the Redis server used by the redis store.
+	RedisAddr string `yaml:"redis_addr"`
+
+	// RedisPassword is the password of the Redis server.
+	RedisPassword string `yaml:"redis_password"`
+
+	// RedisDB is the Redis database number.
+	RedisDB int `yaml:"redis_db"`
+}
+
+// FirebaseConfig configures Firebase Authentication.
+type FirebaseConfig struct {
+
+	// Enabled turns on the Firebase login, registration and ID-token endpoints.
+	Enabled bool `yaml:"enabled"`
+
+	// ServiceAccountKeyPath is the path of the service-account key file.
+	// When empty, the path from the nexus configuration is used.
+	ServiceAccountKeyPath string `yaml:"service_account_key_path"`
+}
+
+// ProviderConfig configures a single OAuth 2.0 or OpenID Connect identity provider.
+type ProviderConfig struct {
+
+	// Disabled keeps a configured provider from being registered.
+	Disabled bool `yaml:"disabled"`
+
+	// Type is oauth2 or oidc. It defaults to oidc when IssuerURL is set and to oauth2 otherwise.
+	Type string `yaml:"type"`
+
+	// IssuerURL is the issuer of an OpenID Connect provider; its endpoints and keys are discovered from
+	// IssuerURL/.well-known/openid-configuration.
+	IssuerURL string `yaml:"issuer_url"`
+
+	// ClientID is the client ID registered with the provider.
+	ClientID string `yaml:"client_id"`
+
+	// ClientSecret is the client secret registered with the provider.
+	ClientSecret string `yaml:"client_secret"`
+
+	// RedirectURL is the callback URL registered with the provider.
+	RedirectURL string `yaml:"redirect_url"`
+
+	// Scopes overrides the default scopes of a well-known provider, or openid, profile and email for OpenID Connect.
+	Scopes []string `yaml:"scopes"`
+
+	// AuthURL overrides the authorization endpoint of a well-known provider.
+	AuthURL string `yaml:"auth_url"`
+
+	// TokenURL overrides the token endpoint of a well-known provider.
+	TokenURL string `yaml:"token_url"`
+
+	// UserInfoURL overrides the user info endpoint of a well-known provider.
+	UserInfoURL string `yaml:"user_info_url"`
+}
+
+// IsOIDC reports whether the provider is an OpenID Connect provider.
+func (c ProviderConfig) IsOIDC() bool {
+	if c.Type != "" {
+		return c.Type == "oidc"
+	}
+	return c.IssuerURL != ""
+}
+
+// Load reads the configuration file at path. A missing file yields the default configuration.
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+
+	if err = yaml.Unmarshal([]byte(os.ExpandEnv(string(data))), cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// ProvideApplicationConfig loads the configuration from the file named by PathEnv, or DefaultPath.
+func ProvideApplicationConfig() (*Config, error) {
+	path := os.Getenv(PathEnv)
+	if path == "" {
+		path = DefaultPath
+	}
+
+	return Load(path)
+}
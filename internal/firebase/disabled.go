@@ -0,0 +1,29 @@
+package firebase
+
+import (
+	"context"
+
+	"goflare.io/auth/internal/models"
+)
+
+// _ is a type assertion to ensure that disabledService implements the Service interface.
+var _ Service = disabledService{}
+
+// disabledService stands in for the Firebase service when Firebase is not enabled,
+// so the rest of the service boots without a service-account key.
+type disabledService struct{}
+
+// Login returns ErrNotConfigured.
+func (disabledService) Login(context.Context, string, string) (*models.FirebaseToken, error) {
+	return nil, ErrNotConfigured
+}
+
+// Register returns ErrNotConfigured.
+func (disabledService) Register(context.Context, string, string, string, string) (*models.FirebaseToken, error) {
+	return nil, ErrNotConfigured
+}
+
+// OauthLogin returns ErrNotConfigured.
+func (disabledService) OauthLogin(context.Context, string, string) (*models.FirebaseToken, error) {
+	return nil, ErrNotConfigured
+}
@@ -0,0 +1,63 @@
+package firebase
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/oauth2"
+
+	firebaseauth "firebase.google.com/go/v4/auth"
+
+	"goflare.io/auth/internal/identity"
+	"goflare.io/auth/internal/models"
+)
+
+// ProviderName is the name Firebase is registered under in the identity registry.
+const ProviderName = "firebase"
+
+// _ is a type assertion to ensure that provider implements the identity.Provider interface.
+var _ identity.Provider = (*provider)(nil)
+
+// provider exposes Firebase Authentication as an identity provider. Clients sign in with the Firebase SDK
+// and present the resulting ID token, so only VerifyToken is supported.
+type provider struct {
+	client *firebaseauth.Client
+}
+
+// Name returns the name Firebase is registered under.
+func (p *provider) Name() string {
+	return ProviderName
+}
+
+// AuthCodeURL is not supported; Firebase sign-in happens in the client SDK.
+func (p *provider) AuthCodeURL(string, string, ...oauth2.AuthCodeOption) (string, error) {
+	return "", identity.ErrUnsupportedFlow
+}
+
+// Exchange is not supported; Firebase sign-in happens in the client SDK.
+func (p *provider) Exchange(context.Context, string, string, ...oauth2.AuthCodeOption) (*models.ExternalIdentity, error) {
+	return nil, identity.ErrUnsupportedFlow
+}
+
+// VerifyToken verifies a Firebase ID token and returns the identity of the Firebase user.
+func (p *provider) VerifyToken(ctx context.Context, token string) (*models.ExternalIdentity, error) {
+	decodedToken, err := p.client.VerifyIDToken(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID Token: %w", err)
+	}
+
+	firebaseUser, err := p.client.GetUser(ctx, decodedToken.UID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Firebase user: %w", err)
+	}
+
+	return &models.ExternalIdentity{
+		Provider:      ProviderName,
+		Subject:       firebaseUser.UID,
+		Email:         firebaseUser.Email,
+		EmailVerified: firebaseUser.EmailVerified,
+		Name:          firebaseUser.DisplayName,
+		PhotoURL:      firebaseUser.PhotoURL,
+		Phone:         firebaseUser.PhoneNumber,
+	}, nil
+}
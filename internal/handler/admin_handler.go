@@ -0,0 +1,416 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/admin"
+	"goflare.io/auth/internal/authentication"
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/models/enum"
+)
+
+// AdminHandler handles the admin API, through which administrators manage users, their roles, and the
+// permissions of roles. Role and permission management need the manage_roles and manage_permissions permissions,
+// i.e. UPDATE on ROLE and PERMISSION.
+type AdminHandler struct {
+	authentication authentication.Service
+	admin          admin.Service
+	bootstrap      admin.Bootstrap
+	logger         *zap.Logger
+}
+
+// NewAdminHandler creates a new AdminHandler.
+func NewAdminHandler(
+	authentication authentication.Service,
+	admin admin.Service,
+	bootstrap admin.Bootstrap,
+	logger *zap.Logger,
+) *AdminHandler {
+	return &AdminHandler{
+		authentication: authentication,
+		admin:          admin,
+		bootstrap:      bootstrap,
+		logger:         logger,
+	}
+}
+
+// createUserRequest is the body of a user creation. Roles are assigned after the user was created.
+type createUserRequest struct {
+	Username string   `json:"username"`
+	Email    string   `json:"email"`
+	Password string   `json:"password"`
+	Roles    []string `json:"roles"`
+}
+
+// bootstrapRequest is the body of the creation of the first administrator.
+type bootstrapRequest struct {
+	Token    string `json:"token"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// Bootstrap creates the first administrator with the one-time token of the bootstrap. It needs no session,
+// since no account exists yet.
+func (h *AdminHandler) Bootstrap(w http.ResponseWriter, r *http.Request) {
+	var req bootstrapRequest
+	if err := readJSON(w, r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	created, err := h.bootstrap.Redeem(r.Context(), req.Token, req.Username, req.Email, req.Password)
+	switch {
+	case errors.Is(err, admin.ErrInvalidBootstrapToken):
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	case errors.Is(err, admin.ErrAlreadyBootstrapped):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		h.writeError(w, "failed to create administrator", err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, created, h.logger)
+}
+
+// ListUsers lists every user.
+func (h *AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourceUser, enum.ActionList, h.logger) {
+		return
+	}
+
+	users, err := h.admin.ListUsers(r.Context())
+	if err != nil {
+		h.writeError(w, "failed to list users", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, users, h.logger)
+}
+
+// CreateUser creates a user with a password and assigns the requested roles.
+func (h *AdminHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourceUser, enum.ActionCreate, h.logger) {
+		return
+	}
+	if _, ok := sessionUserFromContext(w, r); !ok {
+		return
+	}
+
+	var req createUserRequest
+	if err := readJSON(w, r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(req.Roles) > 0 && !requirePermission(w, r, h.authentication, enum.ResourceRole, enum.ActionUpdate, h.logger) {
+		return
+	}
+
+	created, err := h.admin.CreateUser(r.Context(), req.Username, req.Email, req.Password)
+	if err != nil {
+		h.writeError(w, "failed to create user", err)
+		return
+	}
+	for _, roleName := range req.Roles {
+		if err = h.admin.AssignRole(r.Context(), created.ID, roleName); err != nil {
+			h.writeError(w, "created the user but failed to assign role "+roleName, err)
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusCreated, created, h.logger)
+}
+
+// DisableUser disables the user in the path.
+func (h *AdminHandler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	h.setDisabled(w, r, true)
+}
+
+// EnableUser enables the user in the path.
+func (h *AdminHandler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	h.setDisabled(w, r, false)
+}
+
+// setDisabled disables or enables the user in the path. Administrators cannot disable themselves.
+func (h *AdminHandler) setDisabled(w http.ResponseWriter, r *http.Request, disabled bool) {
+	if !requirePermission(w, r, h.authentication, enum.ResourceUser, enum.ActionUpdate, h.logger) {
+		return
+	}
+
+	userID, ok := adminUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var err error
+	if disabled {
+		if principal, ok := principalFromContext(r); ok && principal.IsUser() && principal.ID == userID {
+			http.Error(w, "cannot disable yourself", http.StatusBadRequest)
+			return
+		}
+		err = h.admin.DisableUser(r.Context(), userID)
+	} else {
+		err = h.admin.EnableUser(r.Context(), userID)
+	}
+	if err != nil {
+		h.writeError(w, "failed to update user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteUser deletes the user in the path. Administrators cannot delete themselves.
+func (h *AdminHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourceUser, enum.ActionDelete, h.logger) {
+		return
+	}
+
+	userID, ok := adminUserID(w, r)
+	if !ok {
+		return
+	}
+	if principal, ok := principalFromContext(r); ok && principal.IsUser() && principal.ID == userID {
+		http.Error(w, "cannot delete yourself", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.admin.DeleteUser(r.Context(), userID); err != nil {
+		h.writeError(w, "failed to delete user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AssignRole assigns the role in the path to the user in the path.
+func (h *AdminHandler) AssignRole(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourceRole, enum.ActionUpdate, h.logger) {
+		return
+	}
+	if _, ok := sessionUserFromContext(w, r); !ok {
+		return
+	}
+
+	userID, ok := adminUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.admin.AssignRole(r.Context(), userID, r.PathValue("role")); err != nil {
+		h.writeError(w, "failed to assign role", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveRole removes the role in the path from the user in the path.
+func (h *AdminHandler) RemoveRole(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourceRole, enum.ActionUpdate, h.logger) {
+		return
+	}
+
+	userID, ok := adminUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.admin.RemoveRole(r.Context(), userID, r.PathValue("role")); err != nil {
+		h.writeError(w, "failed to remove role", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListRoles lists every role.
+func (h *AdminHandler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourceRole, enum.ActionUpdate, h.logger) {
+		return
+	}
+
+	roles, err := h.admin.ListRoles(r.Context())
+	if err != nil {
+		h.writeError(w, "failed to list roles", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, roles, h.logger)
+}
+
+// ListPermissions lists every permission.
+func (h *AdminHandler) ListPermissions(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourcePermission, enum.ActionUpdate, h.logger) {
+		return
+	}
+
+	permissions, err := h.admin.ListPermissions(r.Context())
+	if err != nil {
+		h.writeError(w, "failed to list permissions", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, permissions, h.logger)
+}
+
+// CreatePermission creates a permission.
+func (h *AdminHandler) CreatePermission(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourcePermission, enum.ActionUpdate, h.logger) {
+		return
+	}
+
+	var req models.Permission
+	if err := readJSON(w, r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	created := &models.Permission{
+		Name:            req.Name,
+		Description:     req.Description,
+		Resource:        req.Resource,
+		Action:          req.Action,
+		Condition:       req.Condition,
+		TimeWindow:      req.TimeWindow,
+		ResourcePattern: req.ResourcePattern,
+		Effect:          req.Effect,
+	}
+
+	if err := h.admin.CreatePermission(r.Context(), created); err != nil {
+		h.logger.Warn("failed to create permission", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, created, h.logger)
+}
+
+// DeletePermission deletes the permission in the path and revokes it from every role.
+func (h *AdminHandler) DeletePermission(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourcePermission, enum.ActionUpdate, h.logger) {
+		return
+	}
+
+	permissionID, ok := adminPermissionID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.admin.DeletePermission(r.Context(), permissionID); err != nil {
+		h.writeError(w, "failed to delete permission", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GrantPermission grants the permission in the path to the role in the path.
+func (h *AdminHandler) GrantPermission(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourcePermission, enum.ActionUpdate, h.logger) {
+		return
+	}
+	if _, ok := sessionUserFromContext(w, r); !ok {
+		return
+	}
+
+	permissionID, ok := adminPermissionID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.admin.GrantPermission(r.Context(), r.PathValue("role"), permissionID); err != nil {
+		h.writeError(w, "failed to grant permission", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokePermission revokes the permission in the path from the role in the path.
+func (h *AdminHandler) RevokePermission(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourcePermission, enum.ActionUpdate, h.logger) {
+		return
+	}
+
+	permissionID, ok := adminPermissionID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.admin.RevokePermission(r.Context(), r.PathValue("role"), permissionID); err != nil {
+		h.writeError(w, "failed to revoke permission", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Policies returns the policies of the enforcer, or those that apply to the user in the user_id query parameter.
+// The response can be edited and passed to the policy dry run.
+func (h *AdminHandler) Policies(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourcePermission, enum.ActionUpdate, h.logger) {
+		return
+	}
+
+	var userID uint64
+	if value := r.URL.Query().Get("user_id"); value != "" {
+		var err error
+		if userID, err = strconv.ParseUint(value, 10, 64); err != nil || userID == 0 {
+			http.Error(w, "invalid user id", http.StatusBadRequest)
+			return
+		}
+	}
+
+	policies, err := h.admin.Policies(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, "failed to get policies", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, policies, h.logger)
+}
+
+// writeError writes the response of a failed admin call: 404 for a missing user, role or permission, 409 for a
+// taken username or email, 400 for a weak password, and 500 otherwise.
+func (h *AdminHandler) writeError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	case errors.Is(err, admin.ErrUserExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, admin.ErrWeakPassword):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.logger.Error(message, zap.Error(err))
+	http.Error(w, message, http.StatusInternalServerError)
+}
+
+// adminUserID parses the user ID in the path, writing an error response if it is invalid.
+func adminUserID(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || id == 0 {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// adminPermissionID parses the permission ID in the path, writing an error response if it is invalid.
+func adminPermissionID(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	id, err := strconv.ParseUint(r.PathValue("permission"), 10, 64)
+	if err != nil || id == 0 {
+		http.Error(w, "invalid permission id", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
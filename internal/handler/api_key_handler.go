@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/apikey"
+	"goflare.io/auth/internal/models"
+)
+
+// APIKeyHandler handles the HTTP endpoints through which users manage their API keys.
+type APIKeyHandler struct {
+	apiKeys apikey.Service
+	logger  *zap.Logger
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler.
+func NewAPIKeyHandler(apiKeys apikey.Service, logger *zap.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeys: apiKeys,
+		logger:  logger,
+	}
+}
+
+// apiKeyRequest is the body of an API key creation. ExpiresAt is optional.
+type apiKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// apiKeyResponse is an API key with its token, which is only returned when the key is created.
+type apiKeyResponse struct {
+	*models.APIKey
+	Token string `json:"token"`
+}
+
+// ListAPIKeys lists the API keys of the authenticated user.
+func (h *APIKeyHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(r)
+	if !ok {
+		http.Error(w, "missing user", http.StatusUnauthorized)
+		return
+	}
+
+	keys, err := h.apiKeys.ListAPIKeys(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("failed to list API keys", zap.Error(err))
+		http.Error(w, "failed to list API keys", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, keys, h.logger)
+}
+
+// CreateAPIKey creates an API key for the authenticated user. The response holds the token, which cannot be
+// retrieved again.
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := sessionUserFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	var req apiKeyRequest
+	if err := readJSON(w, r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	key := &models.APIKey{UserID: userID, Name: req.Name, Scopes: req.Scopes}
+	if req.ExpiresAt != nil {
+		key.ExpiresAt = *req.ExpiresAt
+	}
+
+	token, err := h.apiKeys.CreateAPIKey(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, apikey.ErrNameTaken) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		h.logger.Error("failed to create API key", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusCreated, &apiKeyResponse{APIKey: key, Token: token}, h.logger)
+}
+
+// RenameAPIKey renames the API key in the path.
+func (h *APIKeyHandler) RenameAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(r)
+	if !ok {
+		http.Error(w, "missing user", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := apiKeyID(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := readJSON(w, r, &req); err != nil || strings.TrimSpace(req.Name) == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.apiKeys.RenameAPIKey(r.Context(), userID, id, req.Name); err != nil {
+		h.writeError(w, "failed to rename API key", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeAPIKey revokes the API key in the path.
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(r)
+	if !ok {
+		http.Error(w, "missing user", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := apiKeyID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.apiKeys.RevokeAPIKey(r.Context(), userID, id); err != nil {
+		h.writeError(w, "failed to revoke API key", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeError answers a missing key with 404 and a taken name with 409, and logs anything else.
+func (h *APIKeyHandler) writeError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "API key not found", http.StatusNotFound)
+		return
+	case errors.Is(err, apikey.ErrNameTaken):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	h.logger.Error(message, zap.Error(err))
+	http.Error(w, message, http.StatusInternalServerError)
+}
+
+// apiKeyID parses the API key ID in the path, writing an error response if it is invalid.
+func apiKeyID(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || id == 0 {
+		http.Error(w, "invalid API key id", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
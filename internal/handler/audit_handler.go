@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/audit"
+	"goflare.io/auth/internal/authentication"
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/models/enum"
+)
+
+// AuditHandler handles the audit log API. Reading the log needs the read_audit permission, i.e. READ on AUDIT.
+type AuditHandler struct {
+	authentication authentication.Service
+	audit          audit.Service
+	logger         *zap.Logger
+}
+
+// NewAuditHandler creates a new AuditHandler.
+func NewAuditHandler(authentication authentication.Service, audit audit.Service, logger *zap.Logger) *AuditHandler {
+	return &AuditHandler{
+		authentication: authentication,
+		audit:          audit,
+		logger:         logger,
+	}
+}
+
+// ListEvents lists the audit events matching the query parameters actor_type, actor_id, action, target_type,
+// target_id and outcome, newest first. since and until bound the time in RFC 3339; before continues from the
+// next_before_id of the previous page; limit caps the number of events, up to audit.MaxLimit.
+func (h *AuditHandler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourceAudit, enum.ActionRead, h.logger) {
+		return
+	}
+
+	filter, err := auditFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.audit.List(r.Context(), filter)
+	if err != nil {
+		h.logger.Error("failed to list audit events", zap.Error(err))
+		http.Error(w, "failed to list audit events", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, page, h.logger)
+}
+
+// Verify checks the hash chain of the audit log.
+func (h *AuditHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourceAudit, enum.ActionRead, h.logger) {
+		return
+	}
+
+	verification, err := h.audit.Verify(r.Context())
+	if err != nil {
+		h.logger.Error("failed to verify audit log", zap.Error(err))
+		http.Error(w, "failed to verify audit log", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, verification, h.logger)
+}
+
+// auditFilter reads the filter of an audit event listing from the query parameters of r.
+func auditFilter(r *http.Request) (*models.AuditFilter, error) {
+	query := r.URL.Query()
+	filter := &models.AuditFilter{
+		ActorType:  query.Get("actor_type"),
+		ActorID:    query.Get("actor_id"),
+		Action:     query.Get("action"),
+		TargetType: query.Get("target_type"),
+		TargetID:   query.Get("target_id"),
+		Outcome:    query.Get("outcome"),
+	}
+
+	var err error
+	if value := query.Get("since"); value != "" {
+		if filter.Since, err = time.Parse(time.RFC3339, value); err != nil {
+			return nil, fmt.Errorf("invalid since")
+		}
+	}
+	if value := query.Get("until"); value != "" {
+		if filter.Until, err = time.Parse(time.RFC3339, value); err != nil {
+			return nil, fmt.Errorf("invalid until")
+		}
+	}
+	if value := query.Get("before"); value != "" {
+		if filter.BeforeID, err = strconv.ParseUint(value, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid before")
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil || filter.Limit < 1 {
+			return nil, fmt.Errorf("invalid limit")
+		}
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,255 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/authentication"
+	"goflare.io/auth/internal/authorization"
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/models/enum"
+	"goflare.io/auth/internal/relation"
+)
+
+// AuthorizationHandler handles the HTTP endpoints for resource-level authorization.
+type AuthorizationHandler struct {
+	authentication authentication.Service
+	authorization  authorization.Service
+	relations      relation.Service
+	logger         *zap.Logger
+}
+
+// NewAuthorizationHandler creates a new AuthorizationHandler.
+func NewAuthorizationHandler(
+	authentication authentication.Service,
+	authorization authorization.Service,
+	relations relation.Service,
+	logger *zap.Logger,
+) *AuthorizationHandler {
+	return &AuthorizationHandler{
+		authentication: authentication,
+		authorization:  authorization,
+		relations:      relations,
+		logger:         logger,
+	}
+}
+
+// resourcePermissionRequest is the body of a resource permission check.
+type resourcePermissionRequest struct {
+	Resource   string          `json:"resource"`
+	Action     enum.ActionType `json:"action"`
+	Attributes map[string]any  `json:"attributes"`
+}
+
+// batchCheckRequest is the body of a batch permission check.
+type batchCheckRequest struct {
+	Checks     []models.PermissionCheck `json:"checks"`
+	Attributes map[string]any           `json:"attributes"`
+}
+
+// explainRequest is the body of a permission explanation. UserID defaults to the authenticated user.
+type explainRequest struct {
+	UserID     uint64            `json:"user_id"`
+	Resource   enum.ResourceType `json:"resource"`
+	Action     enum.ActionType   `json:"action"`
+	Attributes map[string]any    `json:"attributes"`
+	At         time.Time         `json:"at"`
+}
+
+// dryRunRequest is the body of a policy dry run.
+type dryRunRequest struct {
+	Policies models.PolicySet     `json:"policies"`
+	Requests []models.TestRequest `json:"requests"`
+}
+
+// relationRequest is the body of a relation grant or revocation.
+type relationRequest struct {
+	Resource string `json:"resource"`
+	Relation string `json:"relation"`
+	UserID   uint64 `json:"user_id"`
+}
+
+// CheckResourcePermission checks whether the authenticated user may perform an action on a resource instance.
+func (h *AuthorizationHandler) CheckResourcePermission(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(r)
+	if !ok {
+		http.Error(w, "missing user", http.StatusUnauthorized)
+		return
+	}
+
+	var req resourcePermissionRequest
+	if err := readJSON(w, r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	resource, err := models.ParseResourceID(req.Resource)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	allowed, err := h.authentication.CheckResourcePermission(r.Context(), userID, resource, req.Action, req.Attributes)
+	if err != nil {
+		h.logger.Error("failed to check resource permission", zap.Error(err))
+		http.Error(w, "failed to check permission", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]bool{"allowed": allowed}, h.logger)
+}
+
+// BatchCheckPermission checks many resource and action pairs for the authenticated user in one request.
+func (h *AuthorizationHandler) BatchCheckPermission(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(r)
+	if !ok {
+		http.Error(w, "missing user", http.StatusUnauthorized)
+		return
+	}
+
+	var req batchCheckRequest
+	if err := readJSON(w, r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(req.Checks) > authentication.MaxBatchChecks {
+		http.Error(w, "too many checks", http.StatusBadRequest)
+		return
+	}
+
+	decisions, err := h.authentication.BatchCheckPermission(r.Context(), userID, req.Checks, req.Attributes)
+	if err != nil {
+		h.logger.Error("failed to batch check permissions", zap.Error(err))
+		http.Error(w, "failed to check permissions", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]models.PermissionDecision{"decisions": decisions}, h.logger)
+}
+
+// ListPermissions lists every object and action the authenticated user is granted.
+func (h *AuthorizationHandler) ListPermissions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(r)
+	if !ok {
+		http.Error(w, "missing user", http.StatusUnauthorized)
+		return
+	}
+
+	permissions, err := h.authentication.ListPermissions(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("failed to list permissions", zap.Error(err))
+		http.Error(w, "failed to list permissions", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]*models.GrantedPermission{"permissions": permissions}, h.logger)
+}
+
+// ExplainPermission checks a permission and explains the decision. Explaining the decision for another user
+// requires permission to read permissions.
+func (h *AuthorizationHandler) ExplainPermission(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(r)
+	if !ok {
+		http.Error(w, "missing user", http.StatusUnauthorized)
+		return
+	}
+
+	var req explainRequest
+	if err := readJSON(w, r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.UserID == 0 {
+		req.UserID = userID
+	}
+	if req.UserID != userID && !requirePermission(w, r, h.authentication, enum.ResourcePermission, enum.ActionRead, h.logger) {
+		return
+	}
+
+	explanation, err := h.authentication.ExplainPermission(r.Context(), req.UserID, req.Resource, req.Action, req.Attributes, req.At)
+	if err != nil {
+		h.logger.Error("failed to explain permission", zap.Error(err))
+		http.Error(w, "failed to explain permission", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, explanation, h.logger)
+}
+
+// DryRunPolicies evaluates test requests against a proposed policy set without applying it.
+func (h *AuthorizationHandler) DryRunPolicies(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourcePermission, enum.ActionUpdate, h.logger) {
+		return
+	}
+
+	var req dryRunRequest
+	if err := readJSON(w, r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.authorization.DryRun(r.Context(), &req.Policies, req.Requests)
+	if err != nil {
+		h.logger.Error("failed to dry run policies", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]*models.DryRunResult{"results": results}, h.logger)
+}
+
+// GrantRelation relates a user to a resource instance, for example as its owner.
+func (h *AuthorizationHandler) GrantRelation(w http.ResponseWriter, r *http.Request) {
+	relationModel, ok := h.readRelation(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.relations.Grant(r.Context(), relationModel); err != nil {
+		h.logger.Error("failed to grant relation", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeRelation removes the relation between a user and a resource instance.
+func (h *AuthorizationHandler) RevokeRelation(w http.ResponseWriter, r *http.Request) {
+	relationModel, ok := h.readRelation(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.relations.Revoke(r.Context(), relationModel); err != nil {
+		h.logger.Error("failed to revoke relation", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// readRelation authorizes the caller to manage relations and decodes the relation from the request body.
+func (h *AuthorizationHandler) readRelation(w http.ResponseWriter, r *http.Request) (*models.Relation, bool) {
+	if !requirePermission(w, r, h.authentication, enum.ResourcePermission, enum.ActionUpdate, h.logger) {
+		return nil, false
+	}
+
+	var req relationRequest
+	if err := readJSON(w, r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return nil, false
+	}
+
+	resource, err := models.ParseResourceID(req.Resource)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	return &models.Relation{Resource: resource, Relation: req.Relation, UserID: req.UserID}, true
+}
@@ -1 +1,487 @@
 package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"goflare.io/auth/internal/authentication"
+	"goflare.io/auth/internal/authorization"
+	"goflare.io/auth/internal/middleware"
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/models/enum"
+	"goflare.io/auth/internal/policy"
+	"goflare.io/auth/internal/resource"
+	"goflare.io/auth/internal/user"
+	"goflare.io/auth/pkg/interceptor"
+	authpb "goflare.io/auth/proto/pb/proto/auth"
+)
+
+// PublicGRPCMethods are the AuthService methods that are called without a token.
+var PublicGRPCMethods = []string{
+	authpb.AuthService_Login_FullMethodName,
+	authpb.AuthService_Register_FullMethodName,
+	authpb.AuthService_OAuthLogin_FullMethodName,
+	authpb.AuthService_OAuthCallback_FullMethodName,
+	authpb.AuthService_ValidateToken_FullMethodName,
+}
+
+// GRPCPermissions are the permissions the AuthService methods that manage the authorization model require.
+var GRPCPermissions = map[string]interceptor.Permission{
+	authpb.AuthService_DryRunPolicies_FullMethodName:       {Resource: string(enum.ResourcePermission), Action: string(enum.ActionUpdate)},
+	authpb.AuthService_ListResourceTypes_FullMethodName:    {Resource: string(enum.ResourcePermission), Action: string(enum.ActionRead)},
+	authpb.AuthService_RegisterResourceType_FullMethodName: {Resource: string(enum.ResourcePermission), Action: string(enum.ActionUpdate)},
+	authpb.AuthService_ListActionTypes_FullMethodName:      {Resource: string(enum.ResourcePermission), Action: string(enum.ActionRead)},
+	authpb.AuthService_RegisterActionType_FullMethodName:   {Resource: string(enum.ResourcePermission), Action: string(enum.ActionUpdate)},
+}
+
+// GRPCHandler serves AuthService over gRPC with the same rules as the HTTP endpoints: checks are for the
+// authenticated user unless a user ID is given, and checking the permissions of someone else requires permission
+// to read permissions.
+type GRPCHandler struct {
+	authpb.UnimplementedAuthServiceServer
+	authentication authentication.Service
+	authorization  authorization.Service
+	resources      resource.Service
+	users          user.Repository
+	logger         *zap.Logger
+}
+
+// NewGRPCHandler creates a new GRPCHandler.
+func NewGRPCHandler(
+	authentication authentication.Service,
+	authorization authorization.Service,
+	resources resource.Service,
+	users user.Repository,
+	logger *zap.Logger,
+) *GRPCHandler {
+	return &GRPCHandler{
+		authentication: authentication,
+		authorization:  authorization,
+		resources:      resources,
+		users:          users,
+		logger:         logger,
+	}
+}
+
+// Login logs in a user with email and password.
+func (h *GRPCHandler) Login(ctx context.Context, req *authpb.LoginRequest) (*authpb.LoginResponse, error) {
+	token, err := h.authentication.Login(ctx, req.GetEmail(), req.GetPassword())
+	if err != nil {
+		if errors.Is(err, authentication.ErrUserDisabled) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		h.logger.Warn("failed to login", zap.Error(err))
+		return nil, status.Error(codes.Unauthenticated, "invalid email or password")
+	}
+
+	userInfo, err := h.userInfo(ctx, token.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &authpb.LoginResponse{Token: token.Token, User: userInfo}, nil
+}
+
+// Register registers a new user and logs them in.
+func (h *GRPCHandler) Register(ctx context.Context, req *authpb.RegisterRequest) (*authpb.RegisterResponse, error) {
+	if req.GetEmail() == "" || req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "email and password are required")
+	}
+
+	token, err := h.authentication.Register(ctx, req.GetUsername(), req.GetPassword(), req.GetEmail(), "")
+	if err != nil {
+		h.logger.Error("failed to register user", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to register user")
+	}
+
+	userInfo, err := h.userInfo(ctx, token.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &authpb.RegisterResponse{Token: token.Token, User: userInfo}, nil
+}
+
+// OAuthLogin is not served over gRPC: signing in with an identity provider redirects a browser, which GET
+// /login/{provider} does.
+func (h *GRPCHandler) OAuthLogin(context.Context, *authpb.OAuthLoginRequest) (*authpb.OAuthLoginResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "sign in with an identity provider through GET /login/{provider}")
+}
+
+// OAuthCallback is not served over gRPC: the callback of an identity provider is GET /login/{provider}/callback,
+// which checks the state against a cookie of the browser.
+func (h *GRPCHandler) OAuthCallback(context.Context, *authpb.OAuthCallbackRequest) (*authpb.OAuthCallbackResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "identity providers call back GET /login/{provider}/callback")
+}
+
+// CheckPermission checks whether a user or service account may perform an action on a resource type.
+func (h *GRPCHandler) CheckPermission(ctx context.Context, req *authpb.CheckPermissionRequest) (*authpb.CheckPermissionResponse, error) {
+	subjectType := enum.SubjectType(req.GetSubjectType())
+	if subjectType == "" {
+		subjectType = enum.SubjectUser
+	}
+	if subjectType != enum.SubjectUser && subjectType != enum.SubjectServiceAccount {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown subject type %q", subjectType)
+	}
+
+	subjectID, err := h.subject(ctx, subjectType, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	resourceType, action := enum.ResourceType(req.GetResource()), enum.ActionType(req.GetAction())
+	var allowed bool
+	if subjectType == enum.SubjectServiceAccount {
+		principal := &models.Principal{Type: enum.SubjectServiceAccount, ID: subjectID}
+		allowed, err = h.authentication.CheckPrincipalPermission(ctx, principal, resourceType, action)
+	} else {
+		allowed, err = h.authentication.CheckPermissionWithAttributes(
+			ctx, subjectID, resourceType, action, req.GetAttributes().AsMap(), evaluatedAt(req.GetEvaluatedAt()),
+		)
+	}
+	if err != nil {
+		h.logger.Error("failed to check permission", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to check permission")
+	}
+
+	return &authpb.CheckPermissionResponse{Allowed: allowed}, nil
+}
+
+// CheckResourcePermission checks whether a user may perform an action on a resource instance.
+func (h *GRPCHandler) CheckResourcePermission(ctx context.Context, req *authpb.CheckResourcePermissionRequest) (*authpb.CheckPermissionResponse, error) {
+	userID, err := h.subject(ctx, enum.SubjectUser, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	resourceID, err := models.ParseResourceID(req.GetResource())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	allowed, err := h.authentication.CheckResourcePermission(
+		ctx, userID, resourceID, enum.ActionType(req.GetAction()), req.GetAttributes().AsMap(),
+	)
+	if err != nil {
+		h.logger.Error("failed to check resource permission", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to check permission")
+	}
+
+	return &authpb.CheckPermissionResponse{Allowed: allowed}, nil
+}
+
+// BatchCheckPermission checks many resource and action pairs for a user in one call.
+func (h *GRPCHandler) BatchCheckPermission(ctx context.Context, req *authpb.BatchCheckPermissionRequest) (*authpb.BatchCheckPermissionResponse, error) {
+	if len(req.GetChecks()) > authentication.MaxBatchChecks {
+		return nil, status.Error(codes.InvalidArgument, "too many checks")
+	}
+
+	userID, err := h.subject(ctx, enum.SubjectUser, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	checks := make([]models.PermissionCheck, 0, len(req.GetChecks()))
+	for _, check := range req.GetChecks() {
+		checks = append(checks, models.PermissionCheck{
+			Resource: enum.ResourceType(check.GetResource()),
+			Action:   enum.ActionType(check.GetAction()),
+		})
+	}
+
+	decisions, err := h.authentication.BatchCheckPermission(ctx, userID, checks, req.GetAttributes().AsMap())
+	if err != nil {
+		h.logger.Error("failed to batch check permissions", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to check permissions")
+	}
+
+	resp := &authpb.BatchCheckPermissionResponse{Decisions: make([]*authpb.PermissionDecision, 0, len(decisions))}
+	for _, decision := range decisions {
+		resp.Decisions = append(resp.Decisions, &authpb.PermissionDecision{
+			Resource: string(decision.Resource),
+			Action:   string(decision.Action),
+			Allowed:  decision.Allowed,
+		})
+	}
+
+	return resp, nil
+}
+
+// ListPermissions lists every object and action a user is granted.
+func (h *GRPCHandler) ListPermissions(ctx context.Context, req *authpb.ListPermissionsRequest) (*authpb.ListPermissionsResponse, error) {
+	userID, err := h.subject(ctx, enum.SubjectUser, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := h.authentication.ListPermissions(ctx, userID)
+	if err != nil {
+		h.logger.Error("failed to list permissions", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to list permissions")
+	}
+
+	resp := &authpb.ListPermissionsResponse{Permissions: make([]*authpb.GrantedPermission, 0, len(permissions))}
+	for _, permission := range permissions {
+		resp.Permissions = append(resp.Permissions, &authpb.GrantedPermission{
+			Object:     permission.Object,
+			Action:     permission.Action,
+			Condition:  permission.Condition,
+			TimeWindow: permission.TimeWindow,
+		})
+	}
+
+	return resp, nil
+}
+
+// ExplainPermission checks a permission and explains the decision.
+func (h *GRPCHandler) ExplainPermission(ctx context.Context, req *authpb.CheckPermissionRequest) (*authpb.ExplainPermissionResponse, error) {
+	if req.GetSubjectType() != "" && enum.SubjectType(req.GetSubjectType()) != enum.SubjectUser {
+		return nil, status.Error(codes.InvalidArgument, "only the permissions of users can be explained")
+	}
+
+	userID, err := h.subject(ctx, enum.SubjectUser, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	explanation, err := h.authentication.ExplainPermission(
+		ctx,
+		userID,
+		enum.ResourceType(req.GetResource()),
+		enum.ActionType(req.GetAction()),
+		req.GetAttributes().AsMap(),
+		evaluatedAt(req.GetEvaluatedAt()),
+	)
+	if err != nil {
+		h.logger.Error("failed to explain permission", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to explain permission")
+	}
+
+	return explanationToProto(explanation), nil
+}
+
+// DryRunPolicies evaluates test requests against a proposed policy set without applying it.
+func (h *GRPCHandler) DryRunPolicies(ctx context.Context, req *authpb.DryRunPoliciesRequest) (*authpb.DryRunPoliciesResponse, error) {
+	policies := &models.PolicySet{Rules: make([]models.PolicyRule, 0, len(req.GetRules()))}
+	for _, rule := range req.GetRules() {
+		policies.Rules = append(policies.Rules, models.PolicyRule{
+			Subject:    rule.GetSubject(),
+			Object:     rule.GetObject(),
+			Action:     rule.GetAction(),
+			Condition:  rule.GetCondition(),
+			Effect:     rule.GetEffect(),
+			TimeWindow: rule.GetTimeWindow(),
+		})
+	}
+	if !req.GetKeepGroupingRules() {
+		policies.GroupingRules = make([]models.GroupingRule, 0, len(req.GetGroupingRules()))
+		for _, rule := range req.GetGroupingRules() {
+			policies.GroupingRules = append(policies.GroupingRules, models.GroupingRule{
+				Subject: rule.GetSubject(),
+				Role:    rule.GetRole(),
+			})
+		}
+	}
+
+	requests := make([]models.TestRequest, 0, len(req.GetRequests()))
+	for _, request := range req.GetRequests() {
+		requests = append(requests, models.TestRequest{
+			UserID:     request.GetUserId(),
+			Object:     request.GetObject(),
+			Action:     request.GetAction(),
+			Attributes: request.GetAttributes().AsMap(),
+			At:         evaluatedAt(request.GetAt()),
+		})
+	}
+
+	results, err := h.authorization.DryRun(ctx, policies, requests)
+	if err != nil {
+		h.logger.Error("failed to dry run policies", zap.Error(err))
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	resp := &authpb.DryRunPoliciesResponse{Results: make([]*authpb.DryRunResult, 0, len(results))}
+	for i, result := range results {
+		resp.Results = append(resp.Results, &authpb.DryRunResult{
+			Request:  req.GetRequests()[i],
+			Current:  explanationToProto(result.Current),
+			Proposed: explanationToProto(result.Proposed),
+			Changed:  result.Changed,
+		})
+	}
+
+	return resp, nil
+}
+
+// ListResourceTypes lists every registered resource type.
+func (h *GRPCHandler) ListResourceTypes(ctx context.Context, _ *authpb.ListResourceTypesRequest) (*authpb.ListResourceTypesResponse, error) {
+	resourceTypes, err := h.resources.ListResourceTypes(ctx)
+	if err != nil {
+		h.logger.Error("failed to list resource types", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to list resource types")
+	}
+
+	resp := &authpb.ListResourceTypesResponse{ResourceTypes: make([]*authpb.ResourceTypeInfo, 0, len(resourceTypes))}
+	for _, resourceType := range resourceTypes {
+		resp.ResourceTypes = append(resp.ResourceTypes, &authpb.ResourceTypeInfo{
+			Name:        string(resourceType.Name),
+			Description: resourceType.Description,
+			CreatedAt:   timestamppb.New(resourceType.CreatedAt),
+		})
+	}
+
+	return resp, nil
+}
+
+// RegisterResourceType registers a new resource type.
+func (h *GRPCHandler) RegisterResourceType(ctx context.Context, req *authpb.RegisterResourceTypeRequest) (*authpb.ResourceTypeInfo, error) {
+	resourceType := &models.ResourceType{Name: enum.ResourceType(req.GetName()), Description: req.GetDescription()}
+	if err := h.resources.RegisterResourceType(ctx, resourceType); err != nil {
+		h.logger.Error("failed to register resource type", zap.Error(err))
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return &authpb.ResourceTypeInfo{
+		Name:        string(resourceType.Name),
+		Description: resourceType.Description,
+		CreatedAt:   timestamppb.New(resourceType.CreatedAt),
+	}, nil
+}
+
+// ListActionTypes lists every registered action type.
+func (h *GRPCHandler) ListActionTypes(ctx context.Context, _ *authpb.ListActionTypesRequest) (*authpb.ListActionTypesResponse, error) {
+	actionTypes, err := h.resources.ListActionTypes(ctx)
+	if err != nil {
+		h.logger.Error("failed to list action types", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to list action types")
+	}
+
+	resp := &authpb.ListActionTypesResponse{ActionTypes: make([]*authpb.ActionTypeInfo, 0, len(actionTypes))}
+	for _, actionType := range actionTypes {
+		resp.ActionTypes = append(resp.ActionTypes, &authpb.ActionTypeInfo{
+			Name:        string(actionType.Name),
+			Description: actionType.Description,
+			CreatedAt:   timestamppb.New(actionType.CreatedAt),
+		})
+	}
+
+	return resp, nil
+}
+
+// RegisterActionType registers a new action type.
+func (h *GRPCHandler) RegisterActionType(ctx context.Context, req *authpb.RegisterActionTypeRequest) (*authpb.ActionTypeInfo, error) {
+	actionType := &models.ActionType{Name: enum.ActionType(req.GetName()), Description: req.GetDescription()}
+	if err := h.resources.RegisterActionType(ctx, actionType); err != nil {
+		h.logger.Error("failed to register action type", zap.Error(err))
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return &authpb.ActionTypeInfo{
+		Name:        string(actionType.Name),
+		Description: actionType.Description,
+		CreatedAt:   timestamppb.New(actionType.CreatedAt),
+	}, nil
+}
+
+// ValidateToken validates a user token or API key. An invalid token is reported in the response, not as an error.
+func (h *GRPCHandler) ValidateToken(ctx context.Context, req *authpb.ValidateTokenRequest) (*authpb.ValidateTokenResponse, error) {
+	userID, err := h.authentication.ValidateToken(ctx, req.GetToken())
+	if err != nil {
+		return &authpb.ValidateTokenResponse{Valid: false}, nil
+	}
+
+	userInfo, err := h.userInfo(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &authpb.ValidateTokenResponse{Valid: true, User: userInfo}, nil
+}
+
+// subject returns the ID of the user or service account a call is about: the authenticated principal when id is
+// zero, or id, if the principal may read permissions or is that subject.
+func (h *GRPCHandler) subject(ctx context.Context, subjectType enum.SubjectType, id uint64) (uint64, error) {
+	principal, ok := middleware.GRPCPrincipalFromContext(ctx)
+	if !ok {
+		return 0, status.Error(codes.Unauthenticated, "missing token")
+	}
+
+	if id == 0 {
+		if principal.Type != subjectType {
+			return 0, status.Error(codes.InvalidArgument, "user_id is required")
+		}
+		return principal.ID, nil
+	}
+	if principal.Type == subjectType && principal.ID == id {
+		return id, nil
+	}
+
+	if err := h.requirePermission(ctx, enum.ResourcePermission, enum.ActionRead); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// requirePermission checks that the authenticated user or service account may perform action on resource.
+func (h *GRPCHandler) requirePermission(ctx context.Context, resource enum.ResourceType, action enum.ActionType) error {
+	principal, ok := middleware.GRPCPrincipalFromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "missing token")
+	}
+
+	allowed, err := h.authentication.CheckPrincipalPermission(ctx, principal, resource, action)
+	if err != nil {
+		h.logger.Error("failed to check permission", zap.Error(err))
+		return status.Error(codes.Internal, "failed to check permission")
+	}
+	if !allowed {
+		return status.Error(codes.PermissionDenied, "forbidden")
+	}
+
+	return nil
+}
+
+// userInfo returns the public profile of a user.
+func (h *GRPCHandler) userInfo(ctx context.Context, userID uint64) (*authpb.UserInfo, error) {
+	u, err := h.users.FindUserByID(ctx, userID)
+	if err != nil {
+		h.logger.Error("failed to find user", zap.Uint64("user_id", userID), zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to find user")
+	}
+
+	return &authpb.UserInfo{
+		Id:        u.ID,
+		Username:  u.Username,
+		Email:     u.Email,
+		PhotoUrl:  u.PhotoURL,
+		Provider:  u.Provider,
+		CreatedAt: timestamppb.New(u.CreatedAt),
+		UpdatedAt: timestamppb.New(u.UpdatedAt),
+	}, nil
+}
+
+// explanationToProto converts a policy explanation to its protobuf message.
+func explanationToProto(explanation *policy.Explanation) *authpb.ExplainPermissionResponse {
+	if explanation == nil {
+		return nil
+	}
+	return &authpb.ExplainPermissionResponse{
+		Allowed:   explanation.Allowed,
+		Rule:      explanation.Rule,
+		RoleChain: explanation.RoleChain,
+	}
+}
+
+// evaluatedAt returns the time of a request, or the zero time, which the checks take as now.
+func evaluatedAt(at *timestamppb.Timestamp) (t time.Time) {
+	if at == nil {
+		return t
+	}
+	return at.AsTime()
+}
@@ -0,0 +1,301 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/authentication"
+	"goflare.io/auth/internal/identity"
+	"goflare.io/auth/internal/models"
+)
+
+// loginCookieName is the cookie binding a provider login to the browser that started it.
+const loginCookieName = "auth_login"
+
+// IdentityHandler handles logins through the registered identity providers.
+type IdentityHandler struct {
+	flow           *identity.Flow
+	authentication authentication.Service
+	logger         *zap.Logger
+}
+
+// NewIdentityHandler creates a new IdentityHandler.
+func NewIdentityHandler(
+	flow *identity.Flow,
+	authentication authentication.Service,
+	logger *zap.Logger,
+) *IdentityHandler {
+	return &IdentityHandler{
+		flow:           flow,
+		authentication: authentication,
+		logger:         logger,
+	}
+}
+
+// Login redirects the browser to the login page of the provider named in the path.
+// The login is bound to the browser by a random secret in a short-lived cookie, which Callback checks.
+func (h *IdentityHandler) Login(w http.ResponseWriter, r *http.Request) {
+	providerName := r.PathValue("provider")
+
+	binding, err := identity.NewBinding()
+	if err != nil {
+		h.logger.Error("failed to generate login binding", zap.Error(err))
+		http.Error(w, "failed to start login", http.StatusInternalServerError)
+		return
+	}
+
+	authURL, err := h.flow.Begin(r.Context(), providerName, binding)
+	if err != nil {
+		switch {
+		case errors.Is(err, identity.ErrUnknownProvider):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, identity.ErrUnsupportedFlow):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			h.logger.Error("failed to start login", zap.String("provider", providerName), zap.Error(err))
+			http.Error(w, "failed to start login", http.StatusBadGateway)
+		}
+		return
+	}
+
+	h.setBindingCookie(w, r, binding)
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// Callback completes a provider login: the state must be one issued by Login or LinkIdentity to the same browser
+// and is accepted only once. It exchanges the code for the user's identity and returns a token for the local user,
+// or links the identity to the user that started the link.
+func (h *IdentityHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	providerName := r.PathValue("provider")
+	query := r.URL.Query()
+
+	var binding string
+	if cookie, err := r.Cookie(loginCookieName); err == nil {
+		binding = cookie.Value
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     loginCookieName,
+		Path:     "/login/",
+		MaxAge:   -1,
+		Secure:   r.TLS != nil,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	if providerError := query.Get("error"); providerError != "" {
+		http.Error(w, "login failed: "+providerError, http.StatusUnauthorized)
+		return
+	}
+
+	externalIdentity, login, err := h.flow.Complete(r.Context(), providerName, query.Get("state"), query.Get("code"), binding)
+	if err != nil {
+		if errors.Is(err, identity.ErrInvalidState) {
+			http.Error(w, "invalid or expired login", http.StatusBadRequest)
+			return
+		}
+		h.logger.Warn("failed to complete login", zap.String("provider", providerName), zap.Error(err))
+		http.Error(w, "login failed", http.StatusUnauthorized)
+		return
+	}
+
+	if login.UserID != 0 {
+		h.completeLink(w, r, login.UserID, externalIdentity)
+		return
+	}
+
+	token, err := h.authentication.LoginWithIdentity(r.Context(), externalIdentity)
+	if err != nil {
+		h.logger.Warn("failed to login with identity", zap.String("provider", providerName), zap.Error(err))
+		http.Error(w, "login failed", http.StatusUnauthorized)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, token, h.logger)
+}
+
+// tokenLoginRequest is the body of a login with a token issued by an identity provider.
+type tokenLoginRequest struct {
+	Token string `json:"token"`
+}
+
+// TokenLogin signs in with a token the provider named in the path issued to a client, e.g. a Firebase or
+// OpenID Connect ID token obtained by a mobile app, and returns a token for the local user.
+func (h *IdentityHandler) TokenLogin(w http.ResponseWriter, r *http.Request) {
+	providerName := r.PathValue("provider")
+
+	var req tokenLoginRequest
+	if err := readJSON(w, r, &req); err != nil || req.Token == "" {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	externalIdentity, err := h.flow.Verify(r.Context(), providerName, req.Token)
+	if err != nil {
+		switch {
+		case errors.Is(err, identity.ErrUnknownProvider):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, identity.ErrUnsupportedFlow):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			h.logger.Warn("failed to verify provider token", zap.String("provider", providerName), zap.Error(err))
+			http.Error(w, "login failed", http.StatusUnauthorized)
+		}
+		return
+	}
+
+	token, err := h.authentication.LoginWithIdentity(r.Context(), externalIdentity)
+	if err != nil {
+		h.logger.Warn("failed to login with identity", zap.String("provider", providerName), zap.Error(err))
+		http.Error(w, "login failed", http.StatusUnauthorized)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, token, h.logger)
+}
+
+// ListIdentities lists the identities linked to the signed-in user.
+func (h *IdentityHandler) ListIdentities(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(r)
+	if !ok {
+		http.Error(w, "missing user", http.StatusUnauthorized)
+		return
+	}
+
+	identities, err := h.authentication.ListIdentities(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("failed to list identities", zap.Error(err))
+		http.Error(w, "failed to list identities", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, identities, h.logger)
+}
+
+// LinkIdentity starts linking an identity of the provider named in the path to the signed-in user.
+// It responds with the URL of the provider's login page; the browser opening it must send the cookie set here,
+// and the link is completed by Callback.
+func (h *IdentityHandler) LinkIdentity(w http.ResponseWriter, r *http.Request) {
+	userID, ok := sessionUserFromContext(w, r)
+	if !ok {
+		return
+	}
+	providerName := r.PathValue("provider")
+
+	binding, err := identity.NewBinding()
+	if err != nil {
+		h.logger.Error("failed to generate login binding", zap.Error(err))
+		http.Error(w, "failed to start link", http.StatusInternalServerError)
+		return
+	}
+
+	authURL, err := h.flow.BeginLink(r.Context(), providerName, binding, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, identity.ErrUnknownProvider):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, identity.ErrUnsupportedFlow):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			h.logger.Error("failed to start link", zap.String("provider", providerName), zap.Error(err))
+			http.Error(w, "failed to start link", http.StatusBadGateway)
+		}
+		return
+	}
+
+	h.setBindingCookie(w, r, binding)
+	writeJSON(w, http.StatusOK, map[string]string{"url": authURL}, h.logger)
+}
+
+// UnlinkIdentity removes the identity of the provider named in the path from the signed-in user.
+func (h *IdentityHandler) UnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(r)
+	if !ok {
+		http.Error(w, "missing user", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.authentication.UnlinkIdentity(r.Context(), userID, r.PathValue("provider")); err != nil {
+		switch {
+		case errors.Is(err, authentication.ErrLastSignInMethod):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "identity not linked", http.StatusNotFound)
+		default:
+			h.logger.Error("failed to unlink identity", zap.Error(err))
+			http.Error(w, "failed to unlink identity", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// changePasswordRequest is the body of a password change.
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// ChangePassword changes the password of the signed-in user, who must send their current password.
+func (h *IdentityHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := sessionUserFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	var req changePasswordRequest
+	if err := readJSON(w, r, &req); err != nil || req.NewPassword == "" {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authentication.ChangePassword(r.Context(), userID, req.CurrentPassword, req.NewPassword); err != nil {
+		if errors.Is(err, authentication.ErrIncorrectPassword) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		h.logger.Error("failed to change password", zap.Error(err))
+		http.Error(w, "failed to change password", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// completeLink links the identity returned by a provider to the user that started the link.
+func (h *IdentityHandler) completeLink(w http.ResponseWriter, r *http.Request, userID uint64, externalIdentity *models.ExternalIdentity) {
+	if err := h.authentication.LinkIdentity(r.Context(), userID, externalIdentity); err != nil {
+		if errors.Is(err, authentication.ErrIdentityLinked) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		h.logger.Error("failed to link identity", zap.Uint64("userID", userID), zap.Error(err))
+		http.Error(w, "failed to link identity", http.StatusInternalServerError)
+		return
+	}
+
+	identities, err := h.authentication.ListIdentities(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("failed to list identities", zap.Error(err))
+		http.Error(w, "failed to list identities", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, identities, h.logger)
+}
+
+// setBindingCookie keeps the browser binding of a login in a cookie sent only to the callback.
+func (h *IdentityHandler) setBindingCookie(w http.ResponseWriter, r *http.Request, binding string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     loginCookieName,
+		Value:    binding,
+		Path:     "/login/",
+		MaxAge:   int(h.flow.TTL().Seconds()),
+		Secure:   r.TLS != nil,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
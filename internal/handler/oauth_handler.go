@@ -0,0 +1,476 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/authentication"
+	"goflare.io/auth/internal/config"
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/models/enum"
+	"goflare.io/auth/internal/oauth"
+	"goflare.io/auth/internal/token"
+)
+
+// OAuthHandler handles the endpoints of the OAuth 2.0 authorization server.
+type OAuthHandler struct {
+	oauth          oauth.Service
+	authentication authentication.Service
+	signer         *oauth.Signer
+	tokens         token.Manager
+	loginURL       string
+	logger         *zap.Logger
+}
+
+// NewOAuthHandler creates a new OAuthHandler.
+func NewOAuthHandler(
+	oauth oauth.Service,
+	authentication authentication.Service,
+	signer *oauth.Signer,
+	tokens token.Manager,
+	cfg *config.Config,
+	logger *zap.Logger,
+) *OAuthHandler {
+	return &OAuthHandler{
+		oauth:          oauth,
+		authentication: authentication,
+		signer:         signer,
+		tokens:         tokens,
+		loginURL:       cfg.OAuthServer.LoginURL,
+		logger:         logger,
+	}
+}
+
+// clientRequest is the body of a client registration.
+type clientRequest struct {
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Scopes       []string `json:"scopes"`
+	Public       bool     `json:"public"`
+}
+
+// clientResponse is a registered client with its secret, which is only ever returned at registration.
+type clientResponse struct {
+	*models.OAuthClient
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// Authorize is the authorization endpoint. It checks the request and sends the user to the login page,
+// which signs the user in and submits the request to AuthorizeUser.
+func (h *OAuthHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	req := &models.AuthorizationRequest{
+		ResponseType:        query.Get("response_type"),
+		ClientID:            query.Get("client_id"),
+		RedirectURI:         query.Get("redirect_uri"),
+		Scope:               query.Get("scope"),
+		State:               query.Get("state"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+		Nonce:               query.Get("nonce"),
+	}
+
+	if _, _, err := h.oauth.ValidateAuthorizationRequest(r.Context(), req); err != nil {
+		oauthErr := oauth.AsError(err)
+		if oauthErr.RedirectURI != "" {
+			http.Redirect(w, r, oauth.RedirectError(oauthErr), http.StatusFound)
+			return
+		}
+		if oauthErr.Code == oauth.ErrorServerError {
+			h.logger.Error("failed to validate authorization request", zap.Error(err))
+		}
+		http.Error(w, oauthErr.Error(), oauthErr.Status)
+		return
+	}
+
+	if h.loginURL == "" {
+		h.logger.Error("oauth_server.login_url is not configured")
+		http.Error(w, "authorization is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	loginURL, err := url.Parse(h.loginURL)
+	if err != nil {
+		h.logger.Error("invalid oauth_server.login_url", zap.Error(err))
+		http.Error(w, "authorization is not available", http.StatusServiceUnavailable)
+		return
+	}
+	loginURL.RawQuery = r.URL.RawQuery
+	http.Redirect(w, r, loginURL.String(), http.StatusFound)
+}
+
+// AuthorizeUser answers an authorization request for the signed-in user. It returns the client's redirect URI with
+// the code or error to send the user to, or the client and scopes the user has to consent to first.
+func (h *OAuthHandler) AuthorizeUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := sessionUserFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.AuthorizationRequest
+	if err := readJSON(w, r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.oauth.Authorize(r.Context(), userID, &req)
+	if err != nil {
+		oauthErr := oauth.AsError(err)
+		if oauthErr.RedirectURI != "" {
+			writeJSON(w, http.StatusOK, &models.AuthorizationResult{RedirectTo: oauth.RedirectError(oauthErr)}, h.logger)
+			return
+		}
+		if oauthErr.Code == oauth.ErrorServerError {
+			h.logger.Error("failed to authorize client", zap.Error(err))
+		}
+		writeJSON(w, oauthErr.Status, oauthErr, h.logger)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result, h.logger)
+}
+
+// Token is the token endpoint. Clients authenticate with HTTP Basic or with client_id and client_secret
+// in the form body.
+func (h *OAuthHandler) Token(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, &oauth.Error{Code: oauth.ErrorInvalidRequest, Description: "invalid form body"}, h.logger)
+		return
+	}
+
+	req := &models.TokenRequest{
+		GrantType:    r.PostForm.Get("grant_type"),
+		ClientID:     r.PostForm.Get("client_id"),
+		ClientSecret: r.PostForm.Get("client_secret"),
+		Code:         r.PostForm.Get("code"),
+		RedirectURI:  r.PostForm.Get("redirect_uri"),
+		CodeVerifier: r.PostForm.Get("code_verifier"),
+		RefreshToken: r.PostForm.Get("refresh_token"),
+		DeviceCode:   r.PostForm.Get("device_code"),
+		Scope:        r.PostForm.Get("scope"),
+
+		SubjectToken:       r.PostForm.Get("subject_token"),
+		SubjectTokenType:   r.PostForm.Get("subject_token_type"),
+		RequestedTokenType: r.PostForm.Get("requested_token_type"),
+		ActorToken:         r.PostForm.Get("actor_token"),
+		Audience:           r.PostForm.Get("audience"),
+	}
+
+	basic := clientCredentials(r, &req.ClientID, &req.ClientSecret)
+
+	response, err := h.oauth.Token(r.Context(), req)
+	if err != nil {
+		oauthErr := oauth.AsError(err)
+		if oauthErr.Code == oauth.ErrorServerError {
+			h.logger.Error("failed to issue token", zap.Error(err))
+		}
+		if oauthErr.Code == oauth.ErrorInvalidClient && basic {
+			w.Header().Set("WWW-Authenticate", `Basic realm="oauth2"`)
+		}
+		writeJSON(w, oauthErr.Status, oauthErr, h.logger)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response, h.logger)
+}
+
+// ListClients lists every registered client.
+func (h *OAuthHandler) ListClients(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourceOAuthClient, enum.ActionRead, h.logger) {
+		return
+	}
+
+	clients, err := h.oauth.ListClients(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list OAuth clients", zap.Error(err))
+		http.Error(w, "failed to list OAuth clients", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, clients, h.logger)
+}
+
+// RegisterClient registers a client. The response holds the client secret, which cannot be retrieved again.
+func (h *OAuthHandler) RegisterClient(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourceOAuthClient, enum.ActionCreate, h.logger) {
+		return
+	}
+
+	var req clientRequest
+	if err := readJSON(w, r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	client := &models.OAuthClient{
+		Name:         req.Name,
+		RedirectURIs: req.RedirectURIs,
+		Scopes:       req.Scopes,
+		Public:       req.Public,
+	}
+	if client.Scopes == nil {
+		client.Scopes = []string{}
+	}
+
+	secret, err := h.oauth.RegisterClient(r.Context(), client)
+	if err != nil {
+		h.logger.Error("failed to register OAuth client", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusCreated, &clientResponse{OAuthClient: client, ClientSecret: secret}, h.logger)
+}
+
+// DeleteClient removes a client with its consents and tokens.
+func (h *OAuthHandler) DeleteClient(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourceOAuthClient, enum.ActionDelete, h.logger) {
+		return
+	}
+
+	if err := h.oauth.DeleteClient(r.Context(), r.PathValue("client_id")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "client not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("failed to delete OAuth client", zap.Error(err))
+		http.Error(w, "failed to delete OAuth client", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListConsents lists the clients the authenticated user gave consent to.
+func (h *OAuthHandler) ListConsents(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(r)
+	if !ok {
+		http.Error(w, "missing user", http.StatusUnauthorized)
+		return
+	}
+
+	consents, err := h.oauth.ListConsents(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("failed to list OAuth consents", zap.Error(err))
+		http.Error(w, "failed to list consents", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, consents, h.logger)
+}
+
+// RevokeConsent withdraws the consent the authenticated user gave a client.
+func (h *OAuthHandler) RevokeConsent(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(r)
+	if !ok {
+		http.Error(w, "missing user", http.StatusUnauthorized)
+		return
+	}
+
+	clientID := strings.TrimSpace(r.PathValue("client_id"))
+	if err := h.oauth.RevokeConsent(r.Context(), userID, clientID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "consent not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("failed to revoke OAuth consent", zap.Error(err))
+		http.Error(w, "failed to revoke consent", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Discovery serves the OpenID Provider metadata. It is not found while OpenID Connect is disabled.
+func (h *OAuthHandler) Discovery(w http.ResponseWriter, _ *http.Request) {
+	if !h.signer.Enabled() {
+		http.Error(w, "OpenID Connect is not enabled", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	writeJSON(w, http.StatusOK, h.signer.Discovery(), h.logger)
+}
+
+// KeySet serves the JSON Web Key Set that verifies ID tokens.
+func (h *OAuthHandler) KeySet(w http.ResponseWriter, _ *http.Request) {
+	if !h.signer.Enabled() {
+		http.Error(w, "OpenID Connect is not enabled", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	writeJSON(w, http.StatusOK, h.signer.KeySet(), h.logger)
+}
+
+// TokenKeySet serves the JSON Web Key Set that verifies access tokens, so that services can verify tokens locally.
+func (h *OAuthHandler) TokenKeySet(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	writeJSON(w, http.StatusOK, h.tokens.KeySet(), h.logger)
+}
+
+// Principal returns the principal the access token or API key was issued to, so that services that do not verify
+// tokens locally can validate them with a single call.
+func (h *OAuthHandler) Principal(w http.ResponseWriter, r *http.Request) {
+	principal, ok := principalFromContext(r)
+	if !ok {
+		http.Error(w, "missing principal", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, principal, h.logger)
+}
+
+// UserInfo is the userinfo endpoint. It returns the claims about the user of the access token that were
+// released for its scopes.
+func (h *OAuthHandler) UserInfo(w http.ResponseWriter, r *http.Request) {
+	principal, ok := principalFromContext(r)
+	if !ok {
+		http.Error(w, "missing principal", http.StatusUnauthorized)
+		return
+	}
+
+	info, err := h.oauth.UserInfo(r.Context(), principal)
+	if err != nil {
+		switch {
+		case errors.Is(err, oauth.ErrInsufficientScope):
+			w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="openid"`)
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, sql.ErrNoRows):
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(w, "user not found", http.StatusUnauthorized)
+		default:
+			h.logger.Error("failed to get user info", zap.Error(err))
+			http.Error(w, "failed to get user info", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, info, h.logger)
+}
+
+// DeviceAuthorization is the device authorization endpoint (RFC 8628 section 3.1). Clients authenticate as at the
+// token endpoint.
+func (h *OAuthHandler) DeviceAuthorization(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, &oauth.Error{Code: oauth.ErrorInvalidRequest, Description: "invalid form body"}, h.logger)
+		return
+	}
+
+	clientID, clientSecret := r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	basic := clientCredentials(r, &clientID, &clientSecret)
+
+	response, err := h.oauth.AuthorizeDevice(r.Context(), clientID, clientSecret, r.PostForm.Get("scope"))
+	if err != nil {
+		oauthErr := oauth.AsError(err)
+		if oauthErr.Code == oauth.ErrorServerError {
+			h.logger.Error("failed to authorize device", zap.Error(err))
+		}
+		if oauthErr.Code == oauth.ErrorInvalidClient && basic {
+			w.Header().Set("WWW-Authenticate", `Basic realm="oauth2"`)
+		}
+		writeJSON(w, oauthErr.Status, oauthErr, h.logger)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response, h.logger)
+}
+
+// deviceDecisionRequest is the body of a user's answer to a device authorization.
+type deviceDecisionRequest struct {
+	UserCode string `json:"user_code"`
+	Consent  string `json:"consent"`
+}
+
+// VerifyDevice returns the client and scopes of the device authorization of the user_code query parameter,
+// for the verification page to show the signed-in user.
+func (h *OAuthHandler) VerifyDevice(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromContext(r)
+	if !ok {
+		http.Error(w, "missing user", http.StatusUnauthorized)
+		return
+	}
+
+	verification, err := h.oauth.VerifyDevice(r.Context(), userID, r.URL.Query().Get("user_code"))
+	if err != nil {
+		h.writeDeviceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, verification, h.logger)
+}
+
+// DecideDevice records the signed-in user's approval or denial of a device authorization.
+func (h *OAuthHandler) DecideDevice(w http.ResponseWriter, r *http.Request) {
+	userID, ok := sessionUserFromContext(w, r)
+	if !ok {
+		return
+	}
+
+	var req deviceDecisionRequest
+	if err := readJSON(w, r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.oauth.DecideDevice(r.Context(), userID, req.UserCode, req.Consent); err != nil {
+		h.writeDeviceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeDeviceError answers an unknown user code with 404, too many of them with 429, and an invalid answer with
+// 400, and logs anything else.
+func (h *OAuthHandler) writeDeviceError(w http.ResponseWriter, err error) {
+	var oauthErr *oauth.Error
+	switch {
+	case errors.Is(err, oauth.ErrInvalidUserCode):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, oauth.ErrTooManyUserCodeAttempts):
+		w.Header().Set("Retry-After", strconv.Itoa(int(oauth.UserCodeFailureWindow/time.Second)))
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	case errors.As(err, &oauthErr):
+		writeJSON(w, oauthErr.Status, oauthErr, h.logger)
+	default:
+		h.logger.Error("failed to handle device authorization", zap.Error(err))
+		http.Error(w, "failed to handle device authorization", http.StatusInternalServerError)
+	}
+}
+
+// clientCredentials replaces the client ID and secret from the form body with those of HTTP Basic authentication,
+// if present, and reports whether it was.
+func clientCredentials(r *http.Request, clientID, clientSecret *string) bool {
+	id, secret, basic := r.BasicAuth()
+	if !basic {
+		return false
+	}
+
+	// RFC 6749 section 2.3.1 form-encodes the credentials before they are base64-encoded.
+	if unescaped, err := url.QueryUnescape(id); err == nil {
+		id = unescaped
+	}
+	if unescaped, err := url.QueryUnescape(secret); err == nil {
+		secret = unescaped
+	}
+	*clientID, *clientSecret = id, secret
+	return true
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/authentication"
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/models/enum"
+	"goflare.io/auth/internal/resource"
+)
+
+// ResourceHandler handles the HTTP endpoints of the resource and action type registry.
+type ResourceHandler struct {
+	authentication authentication.Service
+	resources      resource.Service
+	logger         *zap.Logger
+}
+
+// NewResourceHandler creates a new ResourceHandler.
+func NewResourceHandler(
+	authentication authentication.Service,
+	resources resource.Service,
+	logger *zap.Logger,
+) *ResourceHandler {
+	return &ResourceHandler{
+		authentication: authentication,
+		resources:      resources,
+		logger:         logger,
+	}
+}
+
+// typeRequest is the body of a resource or action type registration.
+type typeRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// ListResourceTypes lists every registered resource type.
+func (h *ResourceHandler) ListResourceTypes(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourcePermission, enum.ActionRead, h.logger) {
+		return
+	}
+
+	resourceTypes, err := h.resources.ListResourceTypes(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list resource types", zap.Error(err))
+		http.Error(w, "failed to list resource types", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resourceTypes, h.logger)
+}
+
+// RegisterResourceType registers a new resource type.
+func (h *ResourceHandler) RegisterResourceType(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourcePermission, enum.ActionUpdate, h.logger) {
+		return
+	}
+
+	var req typeRequest
+	if err := readJSON(w, r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	resourceType := &models.ResourceType{Name: enum.ResourceType(req.Name), Description: req.Description}
+	if err := h.resources.RegisterResourceType(r.Context(), resourceType); err != nil {
+		h.logger.Error("failed to register resource type", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, resourceType, h.logger)
+}
+
+// DeleteResourceType removes a resource type that is no longer referenced.
+func (h *ResourceHandler) DeleteResourceType(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourcePermission, enum.ActionDelete, h.logger) {
+		return
+	}
+
+	if err := h.resources.DeleteResourceType(r.Context(), enum.ResourceType(r.PathValue("name"))); err != nil {
+		h.logger.Error("failed to delete resource type", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListActionTypes lists every registered action type.
+func (h *ResourceHandler) ListActionTypes(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourcePermission, enum.ActionRead, h.logger) {
+		return
+	}
+
+	actionTypes, err := h.resources.ListActionTypes(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list action types", zap.Error(err))
+		http.Error(w, "failed to list action types", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, actionTypes, h.logger)
+}
+
+// RegisterActionType registers a new action type.
+func (h *ResourceHandler) RegisterActionType(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourcePermission, enum.ActionUpdate, h.logger) {
+		return
+	}
+
+	var req typeRequest
+	if err := readJSON(w, r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	actionType := &models.ActionType{Name: enum.ActionType(req.Name), Description: req.Description}
+	if err := h.resources.RegisterActionType(r.Context(), actionType); err != nil {
+		h.logger.Error("failed to register action type", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, actionType, h.logger)
+}
+
+// DeleteActionType removes an action type that is no longer referenced.
+func (h *ResourceHandler) DeleteActionType(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourcePermission, enum.ActionDelete, h.logger) {
+		return
+	}
+
+	if err := h.resources.DeleteActionType(r.Context(), enum.ActionType(r.PathValue("name"))); err != nil {
+		h.logger.Error("failed to delete action type", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
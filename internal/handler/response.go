@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/authentication"
+	"goflare.io/auth/internal/middleware"
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/models/enum"
+)
+
+// writeJSON writes v as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v any, logger *zap.Logger) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Warn("failed to write response", zap.Error(err))
+	}
+}
+
+// readJSON decodes the JSON request body into v, rejecting unknown fields.
+func readJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// userIDFromContext returns the ID of the user authenticated by the middleware.
+func userIDFromContext(r *http.Request) (uint64, bool) {
+	return middleware.UserIDFromContext(r.Context())
+}
+
+// sessionUserFromContext returns the ID of the user authenticated by the middleware, writing an error response
+// if the user authenticated with an API key, a delegated token or a token issued to a client. None of them can
+// create credentials or grant access to clients, so that a leaked key cannot outlive its revocation and a service
+// or client cannot widen what it was granted.
+func sessionUserFromContext(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	if principal, ok := principalFromContext(r); ok && principal.IsAPIKey() {
+		http.Error(w, "not allowed with an API key", http.StatusForbidden)
+		return 0, false
+	}
+	if principal, ok := principalFromContext(r); ok && principal.IsDelegated() {
+		http.Error(w, "not allowed with a delegated token", http.StatusForbidden)
+		return 0, false
+	}
+	if principal, ok := principalFromContext(r); ok && principal.IsClient() {
+		http.Error(w, "not allowed with a client token", http.StatusForbidden)
+		return 0, false
+	}
+
+	userID, ok := userIDFromContext(r)
+	if !ok {
+		http.Error(w, "missing user", http.StatusUnauthorized)
+		return 0, false
+	}
+	return userID, true
+}
+
+// principalFromContext returns the principal authenticated by the middleware, which is a user unless the route
+// also accepts service accounts.
+func principalFromContext(r *http.Request) (*models.Principal, bool) {
+	return middleware.PrincipalFromContext(r.Context())
+}
+
+// requirePermission checks that the authenticated user or service account may perform action on resource,
+// writing an error response and returning false otherwise.
+func requirePermission(
+	w http.ResponseWriter,
+	r *http.Request,
+	authentication authentication.Service,
+	resource enum.ResourceType,
+	action enum.ActionType,
+	logger *zap.Logger,
+) bool {
+	principal, ok := principalFromContext(r)
+	if !ok {
+		http.Error(w, "missing user", http.StatusUnauthorized)
+		return false
+	}
+
+	allowed, err := authentication.CheckPrincipalPermission(r.Context(), principal, resource, action)
+	if err != nil {
+		logger.Error("failed to check permission", zap.Error(err))
+		http.Error(w, "failed to check permission", http.StatusInternalServerError)
+		return false
+	}
+	if !allowed {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
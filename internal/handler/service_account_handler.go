@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/authentication"
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/models/enum"
+	"goflare.io/auth/internal/serviceaccount"
+)
+
+// ServiceAccountHandler handles the HTTP endpoints that manage service accounts.
+type ServiceAccountHandler struct {
+	authentication authentication.Service
+	accounts       serviceaccount.Service
+	logger         *zap.Logger
+}
+
+// NewServiceAccountHandler creates a new ServiceAccountHandler.
+func NewServiceAccountHandler(
+	authentication authentication.Service,
+	accounts serviceaccount.Service,
+	logger *zap.Logger,
+) *ServiceAccountHandler {
+	return &ServiceAccountHandler{
+		authentication: authentication,
+		accounts:       accounts,
+		logger:         logger,
+	}
+}
+
+// serviceAccountRequest is the body of a service account creation or update.
+type serviceAccountRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Scopes      []string `json:"scopes"`
+	Disabled    bool     `json:"disabled"`
+}
+
+// serviceAccountResponse is a service account with its client secret, which is only returned when it is issued.
+type serviceAccountResponse struct {
+	*models.ServiceAccount
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// ListServiceAccounts lists every service account.
+func (h *ServiceAccountHandler) ListServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourceServiceAccount, enum.ActionRead, h.logger) {
+		return
+	}
+
+	accounts, err := h.accounts.ListServiceAccounts(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list service accounts", zap.Error(err))
+		http.Error(w, "failed to list service accounts", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, accounts, h.logger)
+}
+
+// CreateServiceAccount creates a service account. The response holds the client secret, which cannot be retrieved again.
+func (h *ServiceAccountHandler) CreateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourceServiceAccount, enum.ActionCreate, h.logger) {
+		return
+	}
+
+	var req serviceAccountRequest
+	if err := readJSON(w, r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	account := &models.ServiceAccount{Name: req.Name, Description: req.Description, Scopes: req.Scopes}
+	secret, err := h.accounts.CreateServiceAccount(r.Context(), account)
+	if err != nil {
+		h.logger.Error("failed to create service account", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusCreated, &serviceAccountResponse{ServiceAccount: account, ClientSecret: secret}, h.logger)
+}
+
+// GetServiceAccount gets the service account in the path.
+func (h *ServiceAccountHandler) GetServiceAccount(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourceServiceAccount, enum.ActionRead, h.logger) {
+		return
+	}
+
+	id, ok := serviceAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	account, err := h.accounts.GetServiceAccount(r.Context(), id)
+	if err != nil {
+		h.writeError(w, "failed to get service account", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, account, h.logger)
+}
+
+// UpdateServiceAccount updates the description, scopes and disabled flag of the service account in the path.
+func (h *ServiceAccountHandler) UpdateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourceServiceAccount, enum.ActionUpdate, h.logger) {
+		return
+	}
+
+	id, ok := serviceAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	var req serviceAccountRequest
+	if err := readJSON(w, r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	account := &models.ServiceAccount{ID: id, Description: req.Description, Scopes: req.Scopes, Disabled: req.Disabled}
+	if err := h.accounts.UpdateServiceAccount(r.Context(), account); err != nil {
+		h.writeError(w, "failed to update service account", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteServiceAccount deletes the service account in the path.
+func (h *ServiceAccountHandler) DeleteServiceAccount(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourceServiceAccount, enum.ActionDelete, h.logger) {
+		return
+	}
+
+	id, ok := serviceAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.accounts.DeleteServiceAccount(r.Context(), id); err != nil {
+		h.writeError(w, "failed to delete service account", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RotateSecret issues a new client secret. The previous secret keeps working for the configured grace period
+// unless the revoke_previous query parameter is true.
+func (h *ServiceAccountHandler) RotateSecret(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourceServiceAccount, enum.ActionUpdate, h.logger) {
+		return
+	}
+
+	id, ok := serviceAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	revokePrevious, _ := strconv.ParseBool(r.URL.Query().Get("revoke_previous"))
+	secret, err := h.accounts.RotateSecret(r.Context(), id, revokePrevious)
+	if err != nil {
+		h.writeError(w, "failed to rotate service account secret", err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, map[string]string{"client_secret": secret}, h.logger)
+}
+
+// ListRoles lists the roles of the service account in the path.
+func (h *ServiceAccountHandler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourceServiceAccount, enum.ActionRead, h.logger) {
+		return
+	}
+
+	id, ok := serviceAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	roles, err := h.accounts.ListRoles(r.Context(), id)
+	if err != nil {
+		h.writeError(w, "failed to list service account roles", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, roles, h.logger)
+}
+
+// AssignRole assigns the role in the path to the service account in the path.
+func (h *ServiceAccountHandler) AssignRole(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourceServiceAccount, enum.ActionUpdate, h.logger) {
+		return
+	}
+
+	id, ok := serviceAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.accounts.AssignRole(r.Context(), id, r.PathValue("role")); err != nil {
+		h.writeError(w, "failed to assign role", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveRole removes the role in the path from the service account in the path.
+func (h *ServiceAccountHandler) RemoveRole(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, h.authentication, enum.ResourceServiceAccount, enum.ActionUpdate, h.logger) {
+		return
+	}
+
+	id, ok := serviceAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.accounts.RemoveRole(r.Context(), id, r.PathValue("role")); err != nil {
+		h.writeError(w, "failed to remove role", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeError answers a missing service account or role with 404, an invalid scope with 400, and logs anything else.
+func (h *ServiceAccountHandler) writeError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, serviceaccount.ErrInvalidScope):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.logger.Error(message, zap.Error(err))
+	http.Error(w, message, http.StatusInternalServerError)
+}
+
+// serviceAccountID parses the service account ID in the path, writing an error response if it is invalid.
+func serviceAccountID(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || id == 0 {
+		http.Error(w, "invalid service account id", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,102 @@
+package identity
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/config"
+)
+
+// TokenCipher seals the tokens issued by providers before they are stored, with AES-256-GCM.
+// Every token is bound to the provider and subject it was issued for, so a sealed token copied to another
+// identity does not open. A TokenCipher without a key stores nothing: Seal returns nil.
+type TokenCipher struct {
+	aead cipher.AEAD
+}
+
+// NewTokenCipher creates a TokenCipher with a 32-byte key, or one that stores nothing when key is empty.
+func NewTokenCipher(key []byte) (*TokenCipher, error) {
+	if len(key) == 0 {
+		return &TokenCipher{}, nil
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("token encryption key must be 32 bytes, got %d", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenCipher{aead: aead}, nil
+}
+
+// ProvideTokenCipher creates a TokenCipher with the key from the configuration.
+func ProvideTokenCipher(cfg *config.Config, logger *zap.Logger) (*TokenCipher, error) {
+	if cfg.Identity.TokenEncryptionKey == "" {
+		logger.Info("provider tokens are not stored: no token encryption key is configured")
+		return NewTokenCipher(nil)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(cfg.Identity.TokenEncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token encryption key: %w", err)
+	}
+
+	return NewTokenCipher(key)
+}
+
+// Enabled reports whether the cipher has a key and tokens are stored.
+func (c *TokenCipher) Enabled() bool {
+	return c.aead != nil
+}
+
+// Seal encrypts a token of the identity provider/subject. An empty token, or a cipher without a key, seals to nil.
+func (c *TokenCipher) Seal(token, provider, subject string) ([]byte, error) {
+	if token == "" || c.aead == nil {
+		return nil, nil
+	}
+
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(token)+c.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return c.aead.Seal(nonce, nonce, []byte(token), additionalData(provider, subject)), nil
+}
+
+// Open decrypts a token sealed for the identity provider/subject.
+func (c *TokenCipher) Open(sealed []byte, provider, subject string) (string, error) {
+	if len(sealed) == 0 {
+		return "", nil
+	}
+	if c.aead == nil {
+		return "", errors.New("no token encryption key is configured")
+	}
+	if len(sealed) < c.aead.NonceSize() {
+		return "", errors.New("sealed token is too short")
+	}
+
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	token, err := c.aead.Open(nil, nonce, ciphertext, additionalData(provider, subject))
+	if err != nil {
+		return "", fmt.Errorf("failed to open sealed token: %w", err)
+	}
+
+	return string(token), nil
+}
+
+// additionalData binds a sealed token to its identity.
+func additionalData(provider, subject string) []byte {
+	return []byte(provider + "\x00" + subject)
+}
@@ -0,0 +1,176 @@
+package identity
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"goflare.io/auth/internal/config"
+	"goflare.io/auth/internal/models"
+)
+
+// Flow runs the authorization code flow against the registered providers. Every login gets a random state,
+// an OpenID Connect nonce and a PKCE code verifier, which are kept server-side and bound to the browser
+// that started the login, so a callback is only accepted once, from that browser, for that provider.
+type Flow struct {
+	providers *Registry
+	states    StateStore
+	ttl       time.Duration
+}
+
+// NewFlow creates a Flow keeping logins in states for ttl.
+func NewFlow(providers *Registry, states StateStore, ttl time.Duration) *Flow {
+	if ttl <= 0 {
+		ttl = DefaultStateTTL
+	}
+	return &Flow{
+		providers: providers,
+		states:    states,
+		ttl:       ttl,
+	}
+}
+
+// ProvideFlow creates a Flow with the login TTL from the configuration.
+func ProvideFlow(providers *Registry, states StateStore, cfg *config.Config) *Flow {
+	return NewFlow(providers, states, cfg.Identity.State.TTL)
+}
+
+// TTL returns how long a login may take before it has to be restarted.
+func (f *Flow) TTL() time.Duration {
+	return f.ttl
+}
+
+// NewBinding returns a random secret to keep in the browser starting a login, e.g. in an HttpOnly cookie.
+// Only its hash is stored with the login.
+func NewBinding() (string, error) {
+	return randomString()
+}
+
+// Begin starts a login with the named provider and returns the URL of its login page.
+// The binding must be presented again to Complete.
+func (f *Flow) Begin(ctx context.Context, providerName, binding string, opts ...oauth2.AuthCodeOption) (string, error) {
+	return f.begin(ctx, providerName, binding, 0, opts...)
+}
+
+// BeginLink starts a login with the named provider whose identity is to be linked to the signed-in user userID.
+func (f *Flow) BeginLink(ctx context.Context, providerName, binding string, userID uint64) (string, error) {
+	if userID == 0 {
+		return "", errors.New("userID is required")
+	}
+	return f.begin(ctx, providerName, binding, userID)
+}
+
+// begin stores a new login and returns the URL of the provider's login page.
+func (f *Flow) begin(ctx context.Context, providerName, binding string, userID uint64, opts ...oauth2.AuthCodeOption) (string, error) {
+	if binding == "" {
+		return "", fmt.Errorf("%w: missing browser binding", ErrInvalidState)
+	}
+
+	provider, err := f.providers.Provider(providerName)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := randomString()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	authURL, err := provider.AuthCodeURL(state, nonce, append(opts, oauth2.S256ChallengeOption(verifier))...)
+	if err != nil {
+		return "", err
+	}
+
+	login := &LoginState{
+		Provider:     provider.Name(),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		Binding:      hashBinding(binding),
+		UserID:       userID,
+		CreatedAt:    time.Now(),
+	}
+	if err = f.states.Save(ctx, state, login, f.ttl); err != nil {
+		return "", err
+	}
+
+	return authURL, nil
+}
+
+// Complete finishes a login from the provider's callback and returns the identity with the stored login,
+// whose UserID tells a link from a sign-in. The state is consumed whatever the outcome, and rejected when it is
+// unknown, expired, already used, started with another provider or by another browser.
+func (f *Flow) Complete(ctx context.Context, providerName, state, code, binding string) (*models.ExternalIdentity, *LoginState, error) {
+	if state == "" {
+		return nil, nil, ErrInvalidState
+	}
+
+	login, err := f.states.Consume(ctx, state)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !strings.EqualFold(login.Provider, providerName) {
+		return nil, nil, fmt.Errorf("%w: started with another provider", ErrInvalidState)
+	}
+	if subtle.ConstantTimeCompare([]byte(login.Binding), []byte(hashBinding(binding))) != 1 {
+		return nil, nil, fmt.Errorf("%w: started in another browser", ErrInvalidState)
+	}
+	if code == "" {
+		return nil, nil, errors.New("missing authorization code")
+	}
+
+	provider, err := f.providers.Provider(login.Provider)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	externalIdentity, err := provider.Exchange(ctx, code, login.Nonce, oauth2.VerifierOption(login.CodeVerifier))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return externalIdentity, login, nil
+}
+
+// Verify returns the identity asserted by a token the named provider issued to one of our clients, such as
+// an ID token a mobile app got from the provider's SDK. No login state is involved, as no redirect took place.
+func (f *Flow) Verify(ctx context.Context, providerName, token string) (*models.ExternalIdentity, error) {
+	if token == "" {
+		return nil, errors.New("missing token")
+	}
+
+	provider, err := f.providers.Provider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.VerifyToken(ctx, token)
+}
+
+// hashBinding hashes a browser binding for storage.
+func hashBinding(binding string) string {
+	sum := sha256.Sum256([]byte(binding))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// randomString returns 32 random bytes encoded for use in URLs and cookies.
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random string: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,238 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"goflare.io/auth/internal/models"
+)
+
+// recordingProvider is a provider whose login page and code exchange record what the flow sent them.
+type recordingProvider struct {
+	Provider
+	name     string
+	nonce    string
+	verifier string
+}
+
+func (p *recordingProvider) Name() string {
+	return p.name
+}
+
+func (p *recordingProvider) AuthCodeURL(state, nonce string, opts ...oauth2.AuthCodeOption) (string, error) {
+	p.nonce = nonce
+	config := &oauth2.Config{Endpoint: oauth2.Endpoint{AuthURL: "https://id.example.com/authorize"}}
+	return config.AuthCodeURL(state, opts...), nil
+}
+
+func (p *recordingProvider) Exchange(_ context.Context, code, nonce string, opts ...oauth2.AuthCodeOption) (*models.ExternalIdentity, error) {
+	if nonce != p.nonce {
+		return nil, errors.New("nonce does not match")
+	}
+	return &models.ExternalIdentity{Provider: p.name, Subject: code}, nil
+}
+
+// beginLogin starts a login and returns its state.
+func beginLogin(t *testing.T, flow *Flow, providerName, binding string, userID uint64) string {
+	t.Helper()
+
+	var authURL string
+	var err error
+	if userID == 0 {
+		authURL, err = flow.Begin(context.Background(), providerName, binding)
+	} else {
+		authURL, err = flow.BeginLink(context.Background(), providerName, binding, userID)
+	}
+	if err != nil {
+		t.Fatalf("failed to begin login: %v", err)
+	}
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("failed to parse login URL: %v", err)
+	}
+	query := parsed.Query()
+	if query.Get("code_challenge") == "" || query.Get("code_challenge_method") != "S256" {
+		t.Errorf("login URL %s has no PKCE challenge", authURL)
+	}
+	return query.Get("state")
+}
+
+func TestFlow(t *testing.T) {
+	newFlow := func() *Flow {
+		return NewFlow(NewRegistry(&recordingProvider{name: "google"}, &recordingProvider{name: "github"}),
+			NewMemoryStateStore(DefaultMemoryStateSize), time.Minute)
+	}
+
+	tests := []struct {
+		name       string
+		begin      func(t *testing.T, flow *Flow) string
+		provider   string
+		state      func(state string) string
+		binding    string
+		code       string
+		wantErr    error
+		wantUserID uint64
+	}{
+		{
+			name:     "sign-in",
+			begin:    func(t *testing.T, flow *Flow) string { return beginLogin(t, flow, "google", "browser", 0) },
+			provider: "google",
+			binding:  "browser",
+			code:     "subject",
+		},
+		{
+			name:       "link",
+			begin:      func(t *testing.T, flow *Flow) string { return beginLogin(t, flow, "google", "browser", 7) },
+			provider:   "google",
+			binding:    "browser",
+			code:       "subject",
+			wantUserID: 7,
+		},
+		{
+			name:     "unknown state",
+			begin:    func(t *testing.T, flow *Flow) string { return beginLogin(t, flow, "google", "browser", 0) },
+			provider: "google",
+			state:    func(string) string { return "forged" },
+			binding:  "browser",
+			code:     "subject",
+			wantErr:  ErrInvalidState,
+		},
+		{
+			name:     "no state",
+			begin:    func(t *testing.T, flow *Flow) string { return beginLogin(t, flow, "google", "browser", 0) },
+			provider: "google",
+			state:    func(string) string { return "" },
+			binding:  "browser",
+			code:     "subject",
+			wantErr:  ErrInvalidState,
+		},
+		{
+			name:     "other browser",
+			begin:    func(t *testing.T, flow *Flow) string { return beginLogin(t, flow, "google", "browser", 0) },
+			provider: "google",
+			binding:  "attacker",
+			code:     "subject",
+			wantErr:  ErrInvalidState,
+		},
+		{
+			name:     "no browser binding",
+			begin:    func(t *testing.T, flow *Flow) string { return beginLogin(t, flow, "google", "browser", 0) },
+			provider: "google",
+			code:     "subject",
+			wantErr:  ErrInvalidState,
+		},
+		{
+			name:     "other provider",
+			begin:    func(t *testing.T, flow *Flow) string { return beginLogin(t, flow, "google", "browser", 0) },
+			provider: "github",
+			binding:  "browser",
+			code:     "subject",
+			wantErr:  ErrInvalidState,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flow := newFlow()
+			state := tt.begin(t, flow)
+			if tt.state != nil {
+				state = tt.state(state)
+			}
+
+			externalIdentity, login, err := flow.Complete(context.Background(), tt.provider, state, tt.code, tt.binding)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Complete() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Complete failed: %v", err)
+			}
+			if externalIdentity.Subject != tt.code {
+				t.Errorf("Subject = %s, want %s", externalIdentity.Subject, tt.code)
+			}
+			if login.UserID != tt.wantUserID {
+				t.Errorf("UserID = %d, want %d", login.UserID, tt.wantUserID)
+			}
+
+			if _, _, err = flow.Complete(context.Background(), tt.provider, state, tt.code, tt.binding); !errors.Is(err, ErrInvalidState) {
+				t.Errorf("replayed Complete() error = %v, want ErrInvalidState", err)
+			}
+		})
+	}
+
+	t.Run("begin without binding", func(t *testing.T) {
+		if _, err := newFlow().Begin(context.Background(), "google", ""); !errors.Is(err, ErrInvalidState) {
+			t.Errorf("Begin() error = %v, want ErrInvalidState", err)
+		}
+	})
+
+	t.Run("begin with unknown provider", func(t *testing.T) {
+		if _, err := newFlow().Begin(context.Background(), "other", "browser"); !errors.Is(err, ErrUnknownProvider) {
+			t.Errorf("Begin() error = %v, want ErrUnknownProvider", err)
+		}
+	})
+
+	t.Run("state rejected after a failed attempt", func(t *testing.T) {
+		flow := newFlow()
+		state := beginLogin(t, flow, "google", "browser", 0)
+		if _, _, err := flow.Complete(context.Background(), "google", state, "subject", "attacker"); !errors.Is(err, ErrInvalidState) {
+			t.Fatalf("Complete() error = %v, want ErrInvalidState", err)
+		}
+		if _, _, err := flow.Complete(context.Background(), "google", state, "subject", "browser"); !errors.Is(err, ErrInvalidState) {
+			t.Errorf("Complete() error = %v, want the state consumed by the failed attempt", err)
+		}
+	})
+}
+
+func TestMemoryStateStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("expired logins are rejected", func(t *testing.T) {
+		store := NewMemoryStateStore(10)
+		if err := store.Save(ctx, "state", &LoginState{Provider: "google"}, -time.Second); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+		if _, err := store.Consume(ctx, "state"); !errors.Is(err, ErrInvalidState) {
+			t.Errorf("Consume() error = %v, want ErrInvalidState", err)
+		}
+	})
+
+	t.Run("states are not overwritten", func(t *testing.T) {
+		store := NewMemoryStateStore(10)
+		if err := store.Save(ctx, "state", &LoginState{Provider: "google"}, time.Minute); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+		if err := store.Save(ctx, "state", &LoginState{Provider: "github"}, time.Minute); err == nil {
+			t.Error("Save overwrote a login in progress")
+		}
+	})
+
+	t.Run("oldest logins are evicted when full", func(t *testing.T) {
+		store := NewMemoryStateStore(2)
+		for i, state := range []string{"first", "second", "third"} {
+			if err := store.Save(ctx, state, &LoginState{Provider: "google"}, time.Minute+time.Duration(i)*time.Second); err != nil {
+				t.Fatalf("Save failed: %v", err)
+			}
+		}
+
+		if size := len(store.(*memoryStateStore).logins); size != 2 {
+			t.Errorf("store holds %d logins, want 2", size)
+		}
+		if _, err := store.Consume(ctx, "first"); !errors.Is(err, ErrInvalidState) {
+			t.Errorf("Consume(first) error = %v, want it evicted", err)
+		}
+		for _, state := range []string{"second", "third"} {
+			if _, err := store.Consume(ctx, state); err != nil {
+				t.Errorf("Consume(%s) failed: %v", state, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,273 @@
+package identity
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+
+	"goflare.io/auth/internal/config"
+	"goflare.io/auth/internal/models"
+)
+
+// maxUserInfoSize bounds the user info responses read from providers.
+const maxUserInfoSize = 1 << 20
+
+// claimNames are the paths of the identity fields in a provider's user info response.
+// Nested fields are written with dots, e.g. data.id.
+type claimNames struct {
+	subject       string
+	email         string
+	emailVerified string
+	name          string
+	picture       string
+}
+
+// standardClaims are the OpenID Connect standard claim names.
+var standardClaims = claimNames{
+	subject:       "sub",
+	email:         "email",
+	emailVerified: "email_verified",
+	name:          "name",
+	picture:       "picture",
+}
+
+// preset holds the endpoints, default scopes and claim names of a well-known OAuth 2.0 provider.
+type preset struct {
+	endpoint    oauth2.Endpoint
+	userInfoURL string
+	scopes      []string
+	claims      claimNames
+}
+
+// presets are the well-known providers that only need client credentials to be configured.
+var presets = map[string]preset{
+	"google": {
+		endpoint:    google.Endpoint,
+		userInfoURL: "https://www.googleapis.com/oauth2/v3/userinfo",
+		scopes:      []string{"https://www.googleapis.com/auth/userinfo.email", "https://www.googleapis.com/auth/userinfo.profile"},
+		claims:      standardClaims,
+	},
+	"facebook": {
+		endpoint: oauth2.Endpoint{
+			AuthURL:  "https://www.facebook.com/v12.0/dialog/oauth",
+			TokenURL: "https://graph.facebook.com/v12.0/oauth/access_token",
+		},
+		userInfoURL: "https://graph.facebook.com/me?fields=id,name,email",
+		scopes:      []string{"email", "public_profile"},
+		claims:      claimNames{subject: "id", email: "email", name: "name"},
+	},
+	"github": {
+		endpoint: oauth2.Endpoint{
+			AuthURL:  "https://github.com/login/oauth/authorize",
+			TokenURL: "https://github.com/login/oauth/access_token",
+		},
+		userInfoURL: "https://api.github.com/user",
+		scopes:      []string{"user:email"},
+		claims:      claimNames{subject: "id", email: "email", name: "name", picture: "avatar_url"},
+	},
+	"twitter": {
+		endpoint: oauth2.Endpoint{
+			AuthURL:  "https://twitter.com/i/oauth2/authorize",
+			TokenURL: "https://api.twitter.com/2/oauth2/token",
+		},
+		userInfoURL: "https://api.twitter.com/2/users/me?user.fields=profile_image_url",
+		scopes:      []string{"users.read", "tweet.read"},
+		claims:      claimNames{subject: "data.id", name: "data.name", picture: "data.profile_image_url"},
+	},
+}
+
+// oauthProvider signs users in with the OAuth 2.0 authorization code flow and reads their identity
+// from the provider's user info endpoint. Its oauth2.Config is never modified after construction.
+type oauthProvider struct {
+	name        string
+	config      oauth2.Config
+	userInfoURL string
+	claims      claimNames
+	client      *http.Client
+}
+
+// NewOAuthProvider creates an OAuth 2.0 provider. Well-known providers start from their preset,
+// which the configuration may override; other providers must configure every endpoint.
+func NewOAuthProvider(name string, cfg config.ProviderConfig) (Provider, error) {
+	name = strings.ToLower(name)
+	p, known := presets[name]
+	if !known {
+		p.claims = standardClaims
+	}
+
+	if cfg.AuthURL != "" {
+		p.endpoint.AuthURL = cfg.AuthURL
+	}
+	if cfg.TokenURL != "" {
+		p.endpoint.TokenURL = cfg.TokenURL
+	}
+	if cfg.UserInfoURL != "" {
+		p.userInfoURL = cfg.UserInfoURL
+	}
+	if len(cfg.Scopes) > 0 {
+		p.scopes = cfg.Scopes
+	}
+
+	if cfg.ClientID == "" {
+		return nil, errors.New("client_id is required")
+	}
+	if p.endpoint.AuthURL == "" || p.endpoint.TokenURL == "" || p.userInfoURL == "" {
+		return nil, errors.New("auth_url, token_url and user_info_url are required for providers without a preset")
+	}
+
+	return &oauthProvider{
+		name: name,
+		config: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     p.endpoint,
+			Scopes:       p.scopes,
+		},
+		userInfoURL: p.userInfoURL,
+		claims:      p.claims,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Name returns the name the provider is registered under.
+func (p *oauthProvider) Name() string {
+	return p.name
+}
+
+// AuthCodeURL returns the URL of the provider's login page. Plain OAuth 2.0 has no nonce.
+func (p *oauthProvider) AuthCodeURL(state, _ string, opts ...oauth2.AuthCodeOption) (string, error) {
+	return p.config.AuthCodeURL(state, opts...), nil
+}
+
+// Exchange exchanges an authorization code for an access token and reads the user's identity with it.
+func (p *oauthProvider) Exchange(ctx context.Context, code, _ string, opts ...oauth2.AuthCodeOption) (*models.ExternalIdentity, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.client)
+
+	token, err := p.config.Exchange(ctx, code, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange OAuth code: %w", err)
+	}
+
+	claims, err := p.userInfo(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	identity := &models.ExternalIdentity{
+		Provider:      p.name,
+		Subject:       claimString(claims, p.claims.subject),
+		Email:         claimString(claims, p.claims.email),
+		EmailVerified: claimBool(claims, p.claims.emailVerified),
+		Name:          claimString(claims, p.claims.name),
+		PhotoURL:      claimString(claims, p.claims.picture),
+		Token:         token,
+	}
+	if identity.Subject == "" {
+		return nil, errors.New("user info response has no subject")
+	}
+
+	return identity, nil
+}
+
+// VerifyToken is not supported by plain OAuth 2.0 providers, which do not issue verifiable tokens.
+func (p *oauthProvider) VerifyToken(context.Context, string) (*models.ExternalIdentity, error) {
+	return nil, ErrUnsupportedFlow
+}
+
+// userInfo fetches the user info response with the access token.
+func (p *oauthProvider) userInfo(ctx context.Context, token *oauth2.Token) (map[string]any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.userInfoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	token.SetAuthHeader(req)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxUserInfoSize))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("user info endpoint returned %s", resp.Status)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var claims map[string]any
+	if err = decoder.Decode(&claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// claim looks up a dotted claim path in a user info response.
+func claim(claims map[string]any, path string) (any, bool) {
+	if path == "" {
+		return nil, false
+	}
+
+	var value any = claims
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, value != nil
+}
+
+// claimString returns a claim as a string, formatting numeric identifiers.
+func claimString(claims map[string]any, path string) string {
+	value, ok := claim(claims, path)
+	if !ok {
+		return ""
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// claimBool returns a claim as a boolean. Some providers send email_verified as a string.
+func claimBool(claims map[string]any, path string) bool {
+	value, ok := claim(claims, path)
+	if !ok {
+		return false
+	}
+
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(v, "true")
+	default:
+		return false
+	}
+}
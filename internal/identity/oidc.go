@@ -0,0 +1,298 @@
+package identity
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MicahParks/keyfunc"
+	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/oauth2"
+
+	"goflare.io/auth/internal/config"
+	"goflare.io/auth/internal/models"
+)
+
+// discoveryPath is appended to the issuer URL to find the provider metadata.
+const discoveryPath = "/.well-known/openid-configuration"
+
+// idTokenSigningMethods are the ID token algorithms accepted from OpenID Connect providers.
+var idTokenSigningMethods = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "PS256", "PS384", "PS512", "EdDSA"}
+
+// oidcMetadata is the part of the OpenID Connect discovery document the relying party uses.
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// idTokenClaims are the ID token claims mapped to an external identity.
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce             string `json:"nonce"`
+	AuthorizedParty   string `json:"azp"`
+	Email             string `json:"email"`
+	EmailVerified     any    `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+	Picture           string `json:"picture"`
+	PhoneNumber       string `json:"phone_number"`
+}
+
+// oidcProvider is an OpenID Connect relying party. The provider metadata and signing keys are discovered
+// on first use, so the service boots even when the issuer is briefly unreachable.
+type oidcProvider struct {
+	name         string
+	issuerURL    string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	scopes       []string
+	client       *http.Client
+
+	mu       sync.Mutex
+	metadata *oidcMetadata
+	config   *oauth2.Config
+	jwks     *keyfunc.JWKS
+}
+
+// NewOIDCProvider creates an OpenID Connect provider from its issuer URL and client credentials.
+func NewOIDCProvider(name string, cfg config.ProviderConfig) (Provider, error) {
+	if cfg.IssuerURL == "" {
+		return nil, errors.New("issuer_url is required")
+	}
+	if cfg.ClientID == "" {
+		return nil, errors.New("client_id is required")
+	}
+
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"profile", "email"}
+	}
+	if !slices.Contains(scopes, "openid") {
+		scopes = append([]string{"openid"}, scopes...)
+	}
+
+	return &oidcProvider{
+		name:         strings.ToLower(name),
+		issuerURL:    strings.TrimSuffix(cfg.IssuerURL, "/"),
+		clientID:     cfg.ClientID,
+		clientSecret: cfg.ClientSecret,
+		redirectURL:  cfg.RedirectURL,
+		scopes:       scopes,
+		client:       &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Name returns the name the provider is registered under.
+func (p *oidcProvider) Name() string {
+	return p.name
+}
+
+// AuthCodeURL returns the URL of the provider's login page, carrying the nonce the ID token must echo.
+func (p *oidcProvider) AuthCodeURL(state, nonce string, opts ...oauth2.AuthCodeOption) (string, error) {
+	if nonce == "" {
+		return "", errors.New("nonce is required")
+	}
+
+	oauthConfig, err := p.oauthConfig(context.Background())
+	if err != nil {
+		return "", err
+	}
+
+	opts = append(opts, oauth2.SetAuthURLParam("nonce", nonce))
+	return oauthConfig.AuthCodeURL(state, opts...), nil
+}
+
+// Exchange exchanges an authorization code for tokens and returns the identity asserted by the validated ID token.
+func (p *oidcProvider) Exchange(ctx context.Context, code, nonce string, opts ...oauth2.AuthCodeOption) (*models.ExternalIdentity, error) {
+	if nonce == "" {
+		return nil, errors.New("nonce is required")
+	}
+
+	oauthConfig, err := p.oauthConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := oauthConfig.Exchange(context.WithValue(ctx, oauth2.HTTPClient, p.client), code, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange OAuth code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+
+	claims, err := p.verifyIDToken(ctx, rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, errors.New("ID token nonce does not match")
+	}
+
+	externalIdentity := p.identity(claims)
+	externalIdentity.Token = token
+	return externalIdentity, nil
+}
+
+// VerifyToken validates an ID token the provider issued to a client of ours, without a nonce check.
+func (p *oidcProvider) VerifyToken(ctx context.Context, token string) (*models.ExternalIdentity, error) {
+	claims, err := p.verifyIDToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.identity(claims), nil
+}
+
+// verifyIDToken checks the signature, issuer, audience and expiry of an ID token.
+func (p *oidcProvider) verifyIDToken(ctx context.Context, rawIDToken string) (*idTokenClaims, error) {
+	metadata, jwks, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &idTokenClaims{}
+	if _, err = jwt.ParseWithClaims(rawIDToken, claims, jwks.Keyfunc, jwt.WithValidMethods(idTokenSigningMethods)); err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+
+	if claims.Issuer != metadata.Issuer {
+		return nil, fmt.Errorf("ID token issuer %q does not match %q", claims.Issuer, metadata.Issuer)
+	}
+	if !claims.VerifyAudience(p.clientID, true) {
+		return nil, errors.New("ID token was not issued for this client")
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.clientID {
+		return nil, errors.New("ID token authorized party does not match this client")
+	}
+	if claims.ExpiresAt == nil {
+		return nil, errors.New("ID token has no expiry")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("ID token has no subject")
+	}
+
+	return claims, nil
+}
+
+// identity maps ID token claims to an external identity.
+func (p *oidcProvider) identity(claims *idTokenClaims) *models.ExternalIdentity {
+	emailVerified := false
+	switch v := claims.EmailVerified.(type) {
+	case bool:
+		emailVerified = v
+	case string:
+		emailVerified = strings.EqualFold(v, "true")
+	}
+
+	return &models.ExternalIdentity{
+		Provider:      p.name,
+		Subject:       claims.Subject,
+		Username:      claims.PreferredUsername,
+		Email:         claims.Email,
+		EmailVerified: emailVerified,
+		Name:          claims.Name,
+		PhotoURL:      claims.Picture,
+		Phone:         claims.PhoneNumber,
+	}
+}
+
+// oauthConfig returns the OAuth 2.0 configuration built from the discovered endpoints.
+func (p *oidcProvider) oauthConfig(ctx context.Context) (*oauth2.Config, error) {
+	if _, _, err := p.discover(ctx); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.config, nil
+}
+
+// discover fetches the provider metadata and signing keys once. Failures are retried on the next call.
+func (p *oidcProvider) discover(ctx context.Context) (*oidcMetadata, *keyfunc.JWKS, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil {
+		return p.metadata, p.jwks, nil
+	}
+
+	metadata, err := p.fetchMetadata(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to discover OpenID Connect provider %s: %w", p.name, err)
+	}
+
+	jwks, err := keyfunc.Get(metadata.JWKSURI, keyfunc.Options{
+		Client:            p.client,
+		RefreshInterval:   time.Hour,
+		RefreshRateLimit:  5 * time.Minute,
+		RefreshTimeout:    10 * time.Second,
+		RefreshUnknownKID: true,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get signing keys of OpenID Connect provider %s: %w", p.name, err)
+	}
+
+	p.metadata = metadata
+	p.jwks = jwks
+	p.config = &oauth2.Config{
+		ClientID:     p.clientID,
+		ClientSecret: p.clientSecret,
+		RedirectURL:  p.redirectURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  metadata.AuthorizationEndpoint,
+			TokenURL: metadata.TokenEndpoint,
+		},
+		Scopes: p.scopes,
+	}
+
+	return p.metadata, p.jwks, nil
+}
+
+// fetchMetadata fetches and checks the discovery document of the issuer.
+func (p *oidcProvider) fetchMetadata(ctx context.Context) (*oidcMetadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.issuerURL+discoveryPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("discovery endpoint returned %s", resp.Status)
+	}
+
+	var metadata oidcMetadata
+	if err = json.NewDecoder(io.LimitReader(resp.Body, maxUserInfoSize)).Decode(&metadata); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSuffix(metadata.Issuer, "/") != p.issuerURL {
+		return nil, fmt.Errorf("discovered issuer %q does not match %q", metadata.Issuer, p.issuerURL)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, errors.New("discovery document is missing required endpoints")
+	}
+
+	return &metadata, nil
+}
@@ -0,0 +1,329 @@
+package identity
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"goflare.io/auth/internal/config"
+)
+
+// testIssuer is an OpenID Connect provider serving discovery, its signing key and a token endpoint
+// that returns idToken.
+type testIssuer struct {
+	*httptest.Server
+	key     *rsa.PrivateKey
+	idToken string
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	issuer := &testIssuer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+discoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(oidcMetadata{
+			Issuer:                issuer.URL,
+			AuthorizationEndpoint: issuer.URL + "/authorize",
+			TokenEndpoint:         issuer.URL + "/token",
+			JWKSURI:               issuer.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     issuer.idToken,
+		})
+	})
+	issuer.Server = httptest.NewServer(mux)
+	t.Cleanup(issuer.Close)
+
+	return issuer
+}
+
+// sign returns an RS256 ID token of claims signed by the issuer.
+func (i *testIssuer) sign(t *testing.T, claims jwt.Claims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test"
+	signed, err := token.SignedString(i.key)
+	if err != nil {
+		t.Fatalf("failed to sign ID token: %v", err)
+	}
+	return signed
+}
+
+// claims returns valid ID token claims of the issuer for the client, changed by modify.
+func (i *testIssuer) claims(modify func(*idTokenClaims)) *idTokenClaims {
+	claims := &idTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    i.URL,
+			Subject:   "subject",
+			Audience:  jwt.ClaimStrings{"client"},
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		Nonce:         "nonce",
+		Email:         "user@example.com",
+		EmailVerified: "true",
+		Name:          "User",
+	}
+	if modify != nil {
+		modify(claims)
+	}
+	return claims
+}
+
+func newTestOIDCProvider(t *testing.T, issuer *testIssuer) Provider {
+	t.Helper()
+
+	provider, err := NewOIDCProvider("Example", config.ProviderConfig{
+		IssuerURL:    issuer.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "https://auth.example.com/login/example/callback",
+	})
+	if err != nil {
+		t.Fatalf("NewOIDCProvider failed: %v", err)
+	}
+	return provider
+}
+
+func TestOIDCProviderVerifyToken(t *testing.T) {
+	issuer := newTestIssuer(t)
+	provider := newTestOIDCProvider(t, issuer)
+
+	tests := []struct {
+		name    string
+		token   func(t *testing.T) string
+		wantErr string
+	}{
+		{
+			name:  "valid",
+			token: func(t *testing.T) string { return issuer.sign(t, issuer.claims(nil)) },
+		},
+		{
+			name: "other audiences with this client as authorized party",
+			token: func(t *testing.T) string {
+				return issuer.sign(t, issuer.claims(func(c *idTokenClaims) {
+					c.Audience = jwt.ClaimStrings{"client", "other"}
+					c.AuthorizedParty = "client"
+				}))
+			},
+		},
+		{
+			name: "issued by another issuer",
+			token: func(t *testing.T) string {
+				return issuer.sign(t, issuer.claims(func(c *idTokenClaims) { c.Issuer = "https://evil.example.com" }))
+			},
+			wantErr: "issuer",
+		},
+		{
+			name: "issued for another client",
+			token: func(t *testing.T) string {
+				return issuer.sign(t, issuer.claims(func(c *idTokenClaims) { c.Audience = jwt.ClaimStrings{"other"} }))
+			},
+			wantErr: "not issued for this client",
+		},
+		{
+			name: "other audiences with another authorized party",
+			token: func(t *testing.T) string {
+				return issuer.sign(t, issuer.claims(func(c *idTokenClaims) {
+					c.Audience = jwt.ClaimStrings{"client", "other"}
+					c.AuthorizedParty = "other"
+				}))
+			},
+			wantErr: "authorized party",
+		},
+		{
+			name: "expired",
+			token: func(t *testing.T) string {
+				return issuer.sign(t, issuer.claims(func(c *idTokenClaims) {
+					c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+				}))
+			},
+			wantErr: "expired",
+		},
+		{
+			name: "without expiry",
+			token: func(t *testing.T) string {
+				return issuer.sign(t, issuer.claims(func(c *idTokenClaims) { c.ExpiresAt = nil }))
+			},
+			wantErr: "no expiry",
+		},
+		{
+			name: "without subject",
+			token: func(t *testing.T) string {
+				return issuer.sign(t, issuer.claims(func(c *idTokenClaims) { c.Subject = "" }))
+			},
+			wantErr: "no subject",
+		},
+		{
+			name: "signed with a shared secret",
+			token: func(t *testing.T) string {
+				signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, issuer.claims(nil)).SignedString([]byte("secret"))
+				if err != nil {
+					t.Fatalf("failed to sign ID token: %v", err)
+				}
+				return signed
+			},
+			wantErr: "invalid ID token",
+		},
+		{
+			name: "signed with another key",
+			token: func(t *testing.T) string {
+				other := &testIssuer{Server: issuer.Server}
+				other.key, _ = rsa.GenerateKey(rand.Reader, 2048)
+				return other.sign(t, issuer.claims(nil))
+			},
+			wantErr: "invalid ID token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			externalIdentity, err := provider.VerifyToken(context.Background(), tt.token(t))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("VerifyToken() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyToken failed: %v", err)
+			}
+			if externalIdentity.Provider != "example" || externalIdentity.Subject != "subject" {
+				t.Errorf("identity = %s/%s, want example/subject", externalIdentity.Provider, externalIdentity.Subject)
+			}
+			if externalIdentity.Email != "user@example.com" || !externalIdentity.EmailVerified {
+				t.Errorf("email = %q verified %v, want a verified user@example.com", externalIdentity.Email, externalIdentity.EmailVerified)
+			}
+		})
+	}
+}
+
+func TestOIDCProviderExchange(t *testing.T) {
+	issuer := newTestIssuer(t)
+	provider := newTestOIDCProvider(t, issuer)
+
+	authURL, err := provider.AuthCodeURL("state", "nonce")
+	if err != nil {
+		t.Fatalf("AuthCodeURL failed: %v", err)
+	}
+	if !strings.HasPrefix(authURL, issuer.URL+"/authorize?") || !strings.Contains(authURL, "nonce=nonce") {
+		t.Errorf("AuthCodeURL = %s, want the discovered endpoint with the nonce", authURL)
+	}
+	if _, err = provider.AuthCodeURL("state", ""); err == nil {
+		t.Error("AuthCodeURL accepted a login without nonce")
+	}
+
+	tests := []struct {
+		name    string
+		idToken string
+		nonce   string
+		wantErr string
+	}{
+		{"matching nonce", issuer.sign(t, issuer.claims(nil)), "nonce", ""},
+		{"other nonce", issuer.sign(t, issuer.claims(nil)), "other", "nonce does not match"},
+		{"no nonce in token", issuer.sign(t, issuer.claims(func(c *idTokenClaims) { c.Nonce = "" })), "nonce", "nonce does not match"},
+		{"no nonce in login", issuer.sign(t, issuer.claims(nil)), "", "nonce is required"},
+		{"no ID token", "", "nonce", "no id_token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer.idToken = tt.idToken
+
+			externalIdentity, err := provider.Exchange(context.Background(), "code", tt.nonce)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Exchange() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Exchange failed: %v", err)
+			}
+			if externalIdentity.Subject != "subject" || externalIdentity.Token == nil {
+				t.Errorf("Exchange = %+v, want the subject and provider tokens", externalIdentity)
+			}
+		})
+	}
+}
+
+func TestNewOIDCProvider(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        config.ProviderConfig
+		wantErr    bool
+		wantScopes []string
+	}{
+		{"default scopes", config.ProviderConfig{IssuerURL: "https://id.example.com/", ClientID: "client"}, false, []string{"openid", "profile", "email"}},
+		{"openid added", config.ProviderConfig{IssuerURL: "https://id.example.com", ClientID: "client", Scopes: []string{"email"}}, false, []string{"openid", "email"}},
+		{"no issuer", config.ProviderConfig{ClientID: "client"}, true, nil},
+		{"no client", config.ProviderConfig{IssuerURL: "https://id.example.com"}, true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewOIDCProvider("example", tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewOIDCProvider() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			p := provider.(*oidcProvider)
+			if strings.Join(p.scopes, " ") != strings.Join(tt.wantScopes, " ") {
+				t.Errorf("scopes = %v, want %v", p.scopes, tt.wantScopes)
+			}
+			if p.issuerURL != "https://id.example.com" {
+				t.Errorf("issuerURL = %s, want it without trailing slash", p.issuerURL)
+			}
+		})
+	}
+}
+
+func TestFlowVerify(t *testing.T) {
+	issuer := newTestIssuer(t)
+	flow := NewFlow(NewRegistry(newTestOIDCProvider(t, issuer)), NewMemoryStateStore(DefaultMemoryStateSize), time.Minute)
+
+	if _, err := flow.Verify(context.Background(), "EXAMPLE", issuer.sign(t, issuer.claims(nil))); err != nil {
+		t.Errorf("Verify failed: %v", err)
+	}
+	if _, err := flow.Verify(context.Background(), "example", ""); err == nil {
+		t.Error("Verify accepted an empty token")
+	}
+	if _, err := flow.Verify(context.Background(), "other", "token"); err == nil || !strings.Contains(err.Error(), ErrUnknownProvider.Error()) {
+		t.Errorf("Verify() error = %v, want ErrUnknownProvider", err)
+	}
+}
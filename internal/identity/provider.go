@@ -0,0 +1,34 @@
+// Package identity abstracts the external identity providers users can sign in with.
+// Providers are looked up by name in a Registry built from the configuration.
+package identity
+
+import (
+	"context"
+	"errors"
+
+	"golang.org/x/oauth2"
+
+	"goflare.io/auth/internal/models"
+)
+
+var (
+	// ErrUnknownProvider is returned for providers that are not configured.
+	ErrUnknownProvider = errors.New("unknown identity provider")
+
+	// ErrUnsupportedFlow is returned by providers that do not support a sign-in flow.
+	ErrUnsupportedFlow = errors.New("sign-in flow not supported by identity provider")
+)
+
+// Provider is an external identity provider.
+type Provider interface {
+	// Name returns the name the provider is registered under, e.g. google.
+	Name() string
+	// AuthCodeURL returns the URL of the provider's login page for the authorization code flow.
+	// The nonce is sent to OpenID Connect providers, which echo it in the ID token; others ignore it.
+	AuthCodeURL(state, nonce string, opts ...oauth2.AuthCodeOption) (string, error)
+	// Exchange exchanges an authorization code for the identity of the signed-in user.
+	// The nonce must be the one passed to AuthCodeURL for the same login.
+	Exchange(ctx context.Context, code, nonce string, opts ...oauth2.AuthCodeOption) (*models.ExternalIdentity, error)
+	// VerifyToken verifies a token the provider issued to a client and returns the identity it asserts.
+	VerifyToken(ctx context.Context, token string) (*models.ExternalIdentity, error)
+}
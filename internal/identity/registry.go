@@ -0,0 +1,87 @@
+package identity
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/config"
+)
+
+// Registry holds the enabled identity providers by name.
+type Registry struct {
+	mu        sync.RWMutex
+	providers map[string]Provider
+}
+
+// NewRegistry creates a Registry holding the given providers.
+func NewRegistry(providers ...Provider) *Registry {
+	r := &Registry{providers: make(map[string]Provider, len(providers))}
+	for _, provider := range providers {
+		r.Register(provider)
+	}
+	return r
+}
+
+// ProvideRegistry creates a Registry with the OAuth 2.0 and OpenID Connect providers enabled in the configuration.
+// Providers that are not built from the configuration alone, such as Firebase, register themselves.
+func ProvideRegistry(cfg *config.Config, logger *zap.Logger) (*Registry, error) {
+	r := NewRegistry()
+
+	for name, providerConfig := range cfg.Identity.Providers {
+		if providerConfig.Disabled {
+			continue
+		}
+
+		var provider Provider
+		var err error
+		if providerConfig.IsOIDC() {
+			provider, err = NewOIDCProvider(name, providerConfig)
+		} else {
+			provider, err = NewOAuthProvider(name, providerConfig)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure identity provider %s: %w", name, err)
+		}
+		r.Register(provider)
+	}
+
+	logger.Info("identity providers enabled", zap.Strings("providers", r.Names()))
+	return r, nil
+}
+
+// Register adds a provider, replacing any provider registered under the same name.
+func (r *Registry) Register(provider Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.providers[strings.ToLower(provider.Name())] = provider
+}
+
+// Provider returns the provider registered under name.
+func (r *Registry) Provider(name string) (Provider, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	provider, ok := r.providers[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+	return provider, nil
+}
+
+// Names returns the names of the registered providers in alphabetical order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,106 @@
+package identity
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/config"
+)
+
+func TestProvideRegistry(t *testing.T) {
+	tests := []struct {
+		name      string
+		providers map[string]config.ProviderConfig
+		wantNames []string
+		wantOIDC  []string
+		wantErr   bool
+	}{
+		{
+			name:      "no providers",
+			wantNames: []string{},
+		},
+		{
+			name: "presets and OpenID Connect",
+			providers: map[string]config.ProviderConfig{
+				"GitHub":   {ClientID: "client"},
+				"google":   {ClientID: "client"},
+				"keycloak": {IssuerURL: "https://id.example.com/realms/main", ClientID: "client"},
+				"gitlab":   {Type: "oidc", IssuerURL: "https://gitlab.com", ClientID: "client"},
+			},
+			wantNames: []string{"github", "gitlab", "google", "keycloak"},
+			wantOIDC:  []string{"gitlab", "keycloak"},
+		},
+		{
+			name: "disabled providers are skipped",
+			providers: map[string]config.ProviderConfig{
+				"google": {ClientID: "client"},
+				"github": {Disabled: true},
+			},
+			wantNames: []string{"google"},
+		},
+		{
+			name: "unknown OAuth 2.0 provider without endpoints",
+			providers: map[string]config.ProviderConfig{
+				"example": {ClientID: "client"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "OpenID Connect provider without issuer",
+			providers: map[string]config.ProviderConfig{
+				"example": {Type: "oidc", ClientID: "client"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Identity: config.IdentityConfig{Providers: tt.providers}}
+
+			registry, err := ProvideRegistry(cfg, zap.NewNop())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProvideRegistry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if names := registry.Names(); !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("Names() = %v, want %v", names, tt.wantNames)
+			}
+			for _, name := range tt.wantOIDC {
+				provider, err := registry.Provider(name)
+				if err != nil {
+					t.Fatalf("Provider(%s) failed: %v", name, err)
+				}
+				if _, ok := provider.(*oidcProvider); !ok {
+					t.Errorf("Provider(%s) is a %T, want an OpenID Connect provider", name, provider)
+				}
+			}
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry(&recordingProvider{name: "Google"})
+
+	if _, err := registry.Provider("GOOGLE"); err != nil {
+		t.Errorf("Provider() failed: %v, want names matched case-insensitively", err)
+	}
+	if _, err := registry.Provider("github"); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("Provider() error = %v, want ErrUnknownProvider", err)
+	}
+
+	replacement := &recordingProvider{name: "google"}
+	registry.Register(replacement)
+	if provider, _ := registry.Provider("google"); provider != replacement {
+		t.Error("Register did not replace the provider registered under the same name")
+	}
+	if names := registry.Names(); !reflect.DeepEqual(names, []string{"google"}) {
+		t.Errorf("Names() = %v, want [google]", names)
+	}
+}
@@ -0,0 +1,188 @@
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"goflare.io/auth/internal/config"
+	"goflare.io/auth/internal/driver"
+)
+
+const (
+	// DefaultStateTTL is how long a login may take when the configuration does not say otherwise.
+	DefaultStateTTL = 10 * time.Minute
+
+	// DefaultMemoryStateSize is the number of logins in progress the memory state store keeps
+	// before it evicts the oldest.
+	DefaultMemoryStateSize = 10_000
+
+	// redisStatePrefix prefixes the Redis keys of logins in progress.
+	redisStatePrefix = "auth:login-state:"
+)
+
+// ErrInvalidState is returned for a state that is unknown, expired or already used.
+var ErrInvalidState = errors.New("invalid or expired login state")
+
+// LoginState is what is kept of a login between the redirect to a provider and its callback.
+type LoginState struct {
+	// Provider is the name of the provider the login was started with.
+	Provider string `json:"provider"`
+	// Nonce is the nonce the ID token of an OpenID Connect provider must carry.
+	Nonce string `json:"nonce"`
+	// CodeVerifier is the PKCE code verifier sent with the code exchange.
+	CodeVerifier string `json:"code_verifier"`
+	// Binding is the SHA-256 hash of the secret held in the cookie of the browser that started the login.
+	Binding string `json:"binding"`
+	// UserID is the signed-in user the identity is to be linked to, or zero for a sign-in.
+	UserID uint64 `json:"user_id,omitempty"`
+	// CreatedAt is when the login was started.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// StateStore keeps logins in progress by state. Every state can be consumed only once.
+type StateStore interface {
+	// Save stores a login under state until it is consumed or ttl elapses.
+	Save(ctx context.Context, state string, login *LoginState, ttl time.Duration) error
+	// Consume removes and returns the login stored under state, or ErrInvalidState.
+	Consume(ctx context.Context, state string) (*LoginState, error)
+}
+
+// ProvideStateStore creates the state store selected in the configuration.
+func ProvideStateStore(cfg *config.Config) (StateStore, error) {
+	switch cfg.Identity.State.Store {
+	case "", "memory":
+		return NewMemoryStateStore(DefaultMemoryStateSize), nil
+	case "redis":
+		client, err := driver.ConnectRedis(cfg.Identity.State.RedisAddr, cfg.Identity.State.RedisPassword, cfg.Identity.State.RedisDB)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to the login state store: %w", err)
+		}
+		return NewRedisStateStore(client), nil
+	default:
+		return nil, fmt.Errorf("unknown login state store %q", cfg.Identity.State.Store)
+	}
+}
+
+// memoryEntry is a login held by the memory state store.
+type memoryEntry struct {
+	login     *LoginState
+	expiresAt time.Time
+}
+
+// memoryStateStore keeps logins in process memory.
+type memoryStateStore struct {
+	mu     sync.Mutex
+	logins map[string]memoryEntry
+	size   int
+}
+
+// NewMemoryStateStore creates a StateStore that keeps up to size logins in process memory.
+// Anyone can start a login, so once size logins are in progress the oldest is evicted for a new one.
+func NewMemoryStateStore(size int) StateStore {
+	if size <= 0 {
+		size = DefaultMemoryStateSize
+	}
+	return &memoryStateStore{logins: make(map[string]memoryEntry), size: size}
+}
+
+// Save stores a login under state, dropping the logins that expired and, when the store is full, the oldest.
+func (s *memoryStateStore) Save(_ context.Context, state string, login *LoginState, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, entry := range s.logins {
+		if now.After(entry.expiresAt) {
+			delete(s.logins, key)
+		}
+	}
+
+	if _, exists := s.logins[state]; exists {
+		return errors.New("login state already exists")
+	}
+	for len(s.logins) >= s.size {
+		s.evictOldest()
+	}
+	s.logins[state] = memoryEntry{login: login, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+// evictOldest drops the login closest to expiry, which is the oldest as the logins of a flow share one TTL.
+// The caller must hold the lock.
+func (s *memoryStateStore) evictOldest() {
+	var oldest string
+	var oldestExpiry time.Time
+	for key, entry := range s.logins {
+		if oldestExpiry.IsZero() || entry.expiresAt.Before(oldestExpiry) {
+			oldest, oldestExpiry = key, entry.expiresAt
+		}
+	}
+	delete(s.logins, oldest)
+}
+
+// Consume removes and returns the login stored under state.
+func (s *memoryStateStore) Consume(_ context.Context, state string) (*LoginState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.logins[state]
+	if !ok {
+		return nil, ErrInvalidState
+	}
+	delete(s.logins, state)
+
+	if time.Now().After(entry.expiresAt) {
+		return nil, ErrInvalidState
+	}
+	return entry.login, nil
+}
+
+// redisStateStore keeps logins in Redis, so a callback can land on any instance of the service.
+type redisStateStore struct {
+	client *redis.Client
+}
+
+// NewRedisStateStore creates a StateStore that keeps logins in Redis.
+func NewRedisStateStore(client *redis.Client) StateStore {
+	return &redisStateStore{client: client}
+}
+
+// Save stores a login under state with the given expiry.
+func (s *redisStateStore) Save(ctx context.Context, state string, login *LoginState, ttl time.Duration) error {
+	data, err := json.Marshal(login)
+	if err != nil {
+		return err
+	}
+
+	stored, err := s.client.SetNX(ctx, redisStatePrefix+state, data, ttl).Result()
+	if err != nil {
+		return fmt.Errorf("failed to save login state: %w", err)
+	}
+	if !stored {
+		return errors.New("login state already exists")
+	}
+	return nil
+}
+
+// Consume atomically gets and deletes the login stored under state.
+func (s *redisStateStore) Consume(ctx context.Context, state string) (*LoginState, error) {
+	data, err := s.client.GetDel(ctx, redisStatePrefix+state).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrInvalidState
+		}
+		return nil, fmt.Errorf("failed to get login state: %w", err)
+	}
+
+	var login LoginState
+	if err = json.Unmarshal(data, &login); err != nil {
+		return nil, fmt.Errorf("failed to decode login state: %w", err)
+	}
+	return &login, nil
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"context"
+
+	"goflare.io/auth/internal/audit"
+	"goflare.io/auth/internal/models"
+)
+
+// contextKey is the type of the context keys of this package, so that they cannot collide with others.
+type contextKey int
+
+// principalKey is the context key of the authenticated principal.
+const principalKey contextKey = iota
+
+// NewContext returns a copy of ctx that carries the authenticated principal, who is also the actor of the audit
+// events recorded for the request.
+func NewContext(ctx context.Context, principal *models.Principal) context.Context {
+	return context.WithValue(audit.WithActor(ctx, principal), principalKey, principal)
+}
+
+// PrincipalFromContext returns the principal the middleware authenticated.
+func PrincipalFromContext(ctx context.Context) (*models.Principal, bool) {
+	principal, ok := ctx.Value(principalKey).(*models.Principal)
+	return principal, ok && principal != nil
+}
+
+// UserIDFromContext returns the ID of the user the middleware authenticated. It reports false for service accounts.
+func UserIDFromContext(ctx context.Context) (uint64, bool) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || !principal.IsUser() || principal.ID == 0 {
+		return 0, false
+	}
+	return principal.ID, true
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/models/enum"
+	"goflare.io/auth/pkg/client"
+	sdkmiddleware "goflare.io/auth/pkg/middleware"
+)
+
+// Verify validates a user token, service account token or API key as a pkg/middleware.Verifier, so that the gRPC
+// server of AuthService is protected by the interceptors of package interceptor, like downstream services.
+func (middleware *AuthenticationMiddleware) Verify(ctx context.Context, tokenStr string) (*client.Principal, error) {
+	principal, err := middleware.authentication.ValidatePrincipal(ctx, tokenStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", client.ErrInvalidToken, err)
+	}
+
+	return &client.Principal{
+		Type:      string(principal.Type),
+		ID:        principal.ID,
+		ClientID:  principal.ClientID,
+		Scopes:    principal.Scopes,
+		APIKeyID:  principal.APIKeyID,
+		Audience:  principal.Audience,
+		Actor:     toClientActor(principal.Actor),
+		ExpiresAt: principal.ExpiresAt,
+	}, nil
+}
+
+// CheckPrincipalPermission decides the permissions the interceptors require per method as a
+// pkg/middleware.Checker, with the scopes and roles of the principal.
+func (middleware *AuthenticationMiddleware) CheckPrincipalPermission(
+	ctx context.Context,
+	principal *client.Principal,
+	resource, action string,
+) (bool, error) {
+	return middleware.authentication.CheckPrincipalPermission(
+		ctx, fromClientPrincipal(principal), enum.ResourceType(resource), enum.ActionType(action),
+	)
+}
+
+// GRPCPrincipalFromContext returns the principal the interceptors of package interceptor authenticated a gRPC
+// call as.
+func GRPCPrincipalFromContext(ctx context.Context) (*models.Principal, bool) {
+	principal, ok := sdkmiddleware.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	return fromClientPrincipal(principal), true
+}
+
+// fromClientPrincipal converts a principal of the SDK back to the principal it was verified as.
+func fromClientPrincipal(principal *client.Principal) *models.Principal {
+	return &models.Principal{
+		Type:      enum.SubjectType(principal.Type),
+		ID:        principal.ID,
+		ClientID:  principal.ClientID,
+		Scopes:    principal.Scopes,
+		APIKeyID:  principal.APIKeyID,
+		Audience:  principal.Audience,
+		Actor:     fromClientActor(principal.Actor),
+		ExpiresAt: principal.ExpiresAt,
+	}
+}
+
+// toClientActor converts the actor chain of a delegated token to the one of the SDK.
+func toClientActor(actor *models.Actor) *client.Actor {
+	if actor == nil {
+		return nil
+	}
+	return &client.Actor{
+		Subject:          actor.Subject,
+		ServiceAccountID: actor.ServiceAccountID,
+		Actor:            toClientActor(actor.Actor),
+	}
+}
+
+// fromClientActor converts the actor chain of the SDK back to the one of a delegated token.
+func fromClientActor(actor *client.Actor) *models.Actor {
+	if actor == nil {
+		return nil
+	}
+	return &models.Actor{
+		Subject:          actor.Subject,
+		ServiceAccountID: actor.ServiceAccountID,
+		Actor:            fromClientActor(actor.Actor),
+	}
+}
@@ -1,11 +1,10 @@
 package middleware
 
 import (
-	"context"
 	"net/http"
+	"strings"
 
 	"goflare.io/auth/internal/authentication"
-	"goflare.io/auth/internal/models/enum"
 )
 
 // AuthenticationMiddleware represents middleware for authentication
@@ -22,22 +21,56 @@ func NewAuthenticationMiddleware(
 	}
 }
 
-// AuthorizeUser is a middleware function that authorizes the user
+// AuthorizeUser is a middleware function that authorizes the user, with a user token or an API key. The principal
+// is put in the context, so that the scopes of an API key restrict the permission checks of the handler.
 func (middleware *AuthenticationMiddleware) AuthorizeUser(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		tokenStr := r.Header.Get("Authorization")
+		tokenStr := bearerToken(r)
 		if tokenStr == "" {
 			http.Error(w, "missing token", http.StatusUnauthorized)
 			return
 		}
 
-		userID, err := middleware.authentication.ValidateToken(tokenStr)
+		principal, err := middleware.authentication.ValidatePrincipal(r.Context(), tokenStr)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusUnauthorized)
 			return
 		}
+		if !principal.IsUser() {
+			http.Error(w, "a user token is required", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), principal)))
+	}
+}
+
+// AuthorizePrincipal is a middleware function that authorizes a user or a service account. The principal is put
+// in the context.
+func (middleware *AuthenticationMiddleware) AuthorizePrincipal(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tokenStr := bearerToken(r)
+		if tokenStr == "" {
+			http.Error(w, "missing token", http.StatusUnauthorized)
+			return
+		}
+
+		principal, err := middleware.authentication.ValidatePrincipal(r.Context(), tokenStr)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), principal)))
+	}
+}
 
-		ctx := context.WithValue(r.Context(), enum.UserIDKey, userID)
-		next.ServeHTTP(w, r.WithContext(ctx))
+// bearerToken returns the token of the Authorization header. Clients of the OAuth 2.0 authorization server send
+// RFC 6750 bearer tokens; the prefix is optional.
+func bearerToken(r *http.Request) string {
+	tokenStr := r.Header.Get("Authorization")
+	if len(tokenStr) > 7 && strings.EqualFold(tokenStr[:7], "Bearer ") {
+		tokenStr = strings.TrimSpace(tokenStr[7:])
 	}
+	return tokenStr
 }
@@ -0,0 +1,3 @@
+ALTER TABLE permissions
+    DROP COLUMN IF EXISTS time_window,
+    DROP COLUMN IF EXISTS attr_condition;
@@ -0,0 +1,3 @@
+ALTER TABLE permissions
+    ADD COLUMN attr_condition VARCHAR(1024) NOT NULL DEFAULT '',
+    ADD COLUMN time_window    VARCHAR(255)  NOT NULL DEFAULT '';
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_resource_relations_user_id;
+
+DROP TABLE IF EXISTS resource_relations CASCADE;
+
+ALTER TABLE permissions
+    DROP COLUMN IF EXISTS resource_pattern;
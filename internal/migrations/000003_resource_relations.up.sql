@@ -0,0 +1,13 @@
+ALTER TABLE permissions
+    ADD COLUMN resource_pattern VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE TABLE resource_relations (
+                                    resource_type resource_type NOT NULL,
+                                    resource_id VARCHAR(255) NOT NULL CHECK (length(resource_id) > 0),
+                                    relation VARCHAR(64) NOT NULL,
+                                    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+                                    PRIMARY KEY (resource_type, resource_id, relation, user_id)
+);
+
+CREATE INDEX idx_resource_relations_user_id ON resource_relations (user_id);
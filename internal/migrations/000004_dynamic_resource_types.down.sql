@@ -0,0 +1,16 @@
+CREATE TYPE action_type AS ENUM ('CREATE', 'READ', 'UPDATE', 'DELETE', 'LIST');
+
+CREATE TYPE resource_type AS ENUM('USER', 'ROLE', 'PERMISSION', 'PRODUCT', 'ORDER');
+
+ALTER TABLE resource_relations
+    DROP CONSTRAINT IF EXISTS fk_resource_relations_resource_type,
+    ALTER COLUMN resource_type TYPE resource_type USING resource_type::resource_type;
+
+ALTER TABLE permissions
+    DROP CONSTRAINT IF EXISTS fk_permissions_action,
+    DROP CONSTRAINT IF EXISTS fk_permissions_resource,
+    ALTER COLUMN action TYPE action_type USING action::action_type,
+    ALTER COLUMN resource TYPE resource_type USING resource::resource_type;
+
+DROP TABLE IF EXISTS action_types CASCADE;
+DROP TABLE IF EXISTS resource_types CASCADE;
@@ -0,0 +1,39 @@
+CREATE TABLE resource_types (
+                                name VARCHAR(64) PRIMARY KEY CHECK (name ~ '^[A-Z][A-Z0-9_]*$'),
+                                description VARCHAR(255),
+                                created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE action_types (
+                              name VARCHAR(64) PRIMARY KEY CHECK (name ~ '^[A-Z][A-Z0-9_]*$'),
+                              description VARCHAR(255),
+                              created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- 內建的資源與操作類型
+INSERT INTO resource_types (name, description) VALUES
+                                                   ('USER', 'Users'),
+                                                   ('ROLE', 'Roles'),
+                                                   ('PERMISSION', 'Permissions'),
+                                                   ('PRODUCT', 'Products'),
+                                                   ('ORDER', 'Orders');
+
+INSERT INTO action_types (name, description) VALUES
+                                                 ('CREATE', 'Create a resource'),
+                                                 ('READ', 'Read a resource'),
+                                                 ('UPDATE', 'Update a resource'),
+                                                 ('DELETE', 'Delete a resource'),
+                                                 ('LIST', 'List resources');
+
+ALTER TABLE permissions
+    ALTER COLUMN resource TYPE VARCHAR(64) USING resource::text,
+    ALTER COLUMN action TYPE VARCHAR(64) USING action::text,
+    ADD CONSTRAINT fk_permissions_resource FOREIGN KEY (resource) REFERENCES resource_types (name) ON UPDATE CASCADE,
+    ADD CONSTRAINT fk_permissions_action FOREIGN KEY (action) REFERENCES action_types (name) ON UPDATE CASCADE;
+
+ALTER TABLE resource_relations
+    ALTER COLUMN resource_type TYPE VARCHAR(64) USING resource_type::text,
+    ADD CONSTRAINT fk_resource_relations_resource_type FOREIGN KEY (resource_type) REFERENCES resource_types (name) ON UPDATE CASCADE;
+
+DROP TYPE IF EXISTS action_type;
+DROP TYPE IF EXISTS resource_type;
@@ -0,0 +1,19 @@
+CREATE TABLE user_identities (
+                                 id SERIAL PRIMARY KEY,
+                                 user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                 provider VARCHAR(64) NOT NULL CHECK (length(provider) > 0),
+                                 subject VARCHAR(255) NOT NULL CHECK (length(subject) > 0),
+                                 email VARCHAR(100) NOT NULL DEFAULT '',
+                                 linked_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+                                 UNIQUE (provider, subject),
+                                 UNIQUE (user_id, provider)
+);
+
+CREATE INDEX idx_user_identities_user_id ON user_identities (user_id);
+
+-- Users created through Firebase keep signing in through their Firebase UID.
+INSERT INTO user_identities (user_id, provider, subject, email, linked_at)
+SELECT id, 'firebase', firebase_uid, email, created_at
+FROM users
+WHERE firebase_uid IS NOT NULL AND firebase_uid <> ''
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,8 @@
+ALTER TABLE user_identities
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS token_expires_at,
+    DROP COLUMN IF EXISTS refresh_token,
+    DROP COLUMN IF EXISTS access_token;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS last_sign_in_at;
@@ -0,0 +1,12 @@
+ALTER TABLE users
+    ADD COLUMN last_sign_in_at TIMESTAMP WITH TIME ZONE;
+
+-- Users without Firebase were written with an empty UID instead of NULL.
+UPDATE users SET firebase_uid = NULL WHERE firebase_uid = '';
+
+-- Provider tokens are sealed with AES-GCM by the service before they are stored.
+ALTER TABLE user_identities
+    ADD COLUMN access_token BYTEA,
+    ADD COLUMN refresh_token BYTEA,
+    ADD COLUMN token_expires_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
@@ -0,0 +1,16 @@
+DELETE FROM role_permissions
+WHERE permission_id IN (SELECT id FROM permissions WHERE resource = 'OAUTH_CLIENT');
+
+DELETE FROM permissions WHERE resource = 'OAUTH_CLIENT';
+
+DELETE FROM resource_types WHERE name = 'OAUTH_CLIENT';
+
+DROP INDEX IF EXISTS idx_oauth_refresh_tokens_user_client;
+DROP TABLE IF EXISTS oauth_refresh_tokens CASCADE;
+
+DROP TABLE IF EXISTS oauth_consents CASCADE;
+
+DROP INDEX IF EXISTS idx_oauth_authorization_codes_expires_at;
+DROP TABLE IF EXISTS oauth_authorization_codes CASCADE;
+
+DROP TABLE IF EXISTS oauth_clients CASCADE;
@@ -0,0 +1,56 @@
+CREATE TABLE oauth_clients (
+                               id SERIAL PRIMARY KEY,
+                               client_id VARCHAR(64) NOT NULL UNIQUE,
+                               secret_hash VARCHAR(255),
+                               name VARCHAR(255) NOT NULL CHECK (length(name) > 0),
+                               redirect_uris TEXT[] NOT NULL DEFAULT '{}',
+                               scopes TEXT[] NOT NULL DEFAULT '{}',
+                               created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+                               updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE oauth_authorization_codes (
+                                           code_hash VARCHAR(64) PRIMARY KEY,
+                                           client_id VARCHAR(64) NOT NULL REFERENCES oauth_clients(client_id) ON DELETE CASCADE,
+                                           user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                           redirect_uri TEXT NOT NULL,
+                                           scopes TEXT[] NOT NULL DEFAULT '{}',
+                                           code_challenge VARCHAR(128) NOT NULL,
+                                           expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                                           created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_oauth_authorization_codes_expires_at ON oauth_authorization_codes (expires_at);
+
+CREATE TABLE oauth_consents (
+                                user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                client_id VARCHAR(64) NOT NULL REFERENCES oauth_clients(client_id) ON DELETE CASCADE,
+                                scopes TEXT[] NOT NULL DEFAULT '{}',
+                                granted_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+                                PRIMARY KEY (user_id, client_id)
+);
+
+CREATE TABLE oauth_refresh_tokens (
+                                      token_hash VARCHAR(64) PRIMARY KEY,
+                                      client_id VARCHAR(64) NOT NULL REFERENCES oauth_clients(client_id) ON DELETE CASCADE,
+                                      user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                      scopes TEXT[] NOT NULL DEFAULT '{}',
+                                      expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                                      revoked_at TIMESTAMP WITH TIME ZONE,
+                                      created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_oauth_refresh_tokens_user_client ON oauth_refresh_tokens (user_id, client_id);
+
+-- OAuth clients are managed by administrators
+INSERT INTO resource_types (name, description) VALUES ('OAUTH_CLIENT', 'OAuth clients');
+
+INSERT INTO permissions (name, description, resource, action) VALUES
+                                                                  ('create_oauth_client', 'Register an OAuth client', 'OAUTH_CLIENT', 'CREATE'),
+                                                                  ('list_oauth_clients', 'List OAuth clients', 'OAUTH_CLIENT', 'READ'),
+                                                                  ('delete_oauth_client', 'Delete an OAuth client', 'OAUTH_CLIENT', 'DELETE');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'admin' AND p.resource = 'OAUTH_CLIENT';
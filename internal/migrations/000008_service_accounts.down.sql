@@ -0,0 +1,10 @@
+DELETE FROM role_permissions
+WHERE permission_id IN (SELECT id FROM permissions WHERE resource = 'SERVICE_ACCOUNT');
+
+DELETE FROM permissions WHERE resource = 'SERVICE_ACCOUNT';
+
+DELETE FROM resource_types WHERE name = 'SERVICE_ACCOUNT';
+
+DROP TABLE IF EXISTS service_account_roles CASCADE;
+
+DROP TABLE IF EXISTS service_accounts CASCADE;
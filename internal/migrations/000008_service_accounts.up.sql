@@ -0,0 +1,34 @@
+CREATE TABLE service_accounts (
+                                  id SERIAL PRIMARY KEY,
+                                  name VARCHAR(100) NOT NULL UNIQUE CHECK (length(name) >= 2),
+                                  description VARCHAR(255),
+                                  client_id VARCHAR(64) NOT NULL UNIQUE,
+                                  secret_hash VARCHAR(255) NOT NULL,
+                                  previous_secret_hash VARCHAR(255),
+                                  previous_secret_expires_at TIMESTAMP WITH TIME ZONE,
+                                  scopes TEXT[] NOT NULL DEFAULT '{}',
+                                  disabled BOOLEAN NOT NULL DEFAULT FALSE,
+                                  last_used_at TIMESTAMP WITH TIME ZONE,
+                                  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+                                  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE service_account_roles (
+                                       service_account_id INTEGER NOT NULL REFERENCES service_accounts(id) ON DELETE CASCADE,
+                                       role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+                                       PRIMARY KEY (service_account_id, role_id)
+);
+
+-- Service accounts are managed by administrators
+INSERT INTO resource_types (name, description) VALUES ('SERVICE_ACCOUNT', 'Service accounts');
+
+INSERT INTO permissions (name, description, resource, action) VALUES
+                                                                  ('create_service_account', 'Create a service account', 'SERVICE_ACCOUNT', 'CREATE'),
+                                                                  ('read_service_account', 'Read service accounts', 'SERVICE_ACCOUNT', 'READ'),
+                                                                  ('update_service_account', 'Update a service account, its secret and roles', 'SERVICE_ACCOUNT', 'UPDATE'),
+                                                                  ('delete_service_account', 'Delete a service account', 'SERVICE_ACCOUNT', 'DELETE');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'admin' AND p.resource = 'SERVICE_ACCOUNT';
@@ -0,0 +1,2 @@
+-- The nonce of an OpenID Connect authentication request is returned in the ID token issued for its code
+ALTER TABLE oauth_authorization_codes ADD COLUMN nonce VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,14 @@
+CREATE TABLE oauth_device_codes (
+                                    device_code_hash VARCHAR(64) PRIMARY KEY,
+                                    user_code VARCHAR(16) NOT NULL UNIQUE,
+                                    client_id VARCHAR(64) NOT NULL REFERENCES oauth_clients(client_id) ON DELETE CASCADE,
+                                    scopes TEXT[] NOT NULL DEFAULT '{}',
+                                    status VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'denied')),
+                                    approved_by INTEGER REFERENCES users(id) ON DELETE CASCADE,
+                                    poll_interval INTEGER NOT NULL,
+                                    last_polled_at TIMESTAMP WITH TIME ZONE,
+                                    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                                    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_oauth_device_codes_expires_at ON oauth_device_codes (expires_at);
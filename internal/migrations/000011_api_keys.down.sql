@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_api_keys_user_id_name;
+DROP INDEX IF EXISTS idx_api_keys_user_id;
+DROP TABLE IF EXISTS api_keys CASCADE;
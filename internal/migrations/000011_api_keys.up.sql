@@ -0,0 +1,18 @@
+CREATE TABLE api_keys (
+                          id SERIAL PRIMARY KEY,
+                          user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                          name VARCHAR(255) NOT NULL CHECK (length(name) > 0),
+                          token_prefix VARCHAR(32) NOT NULL,
+                          token_hash VARCHAR(64) NOT NULL UNIQUE,
+                          scopes TEXT[] NOT NULL DEFAULT '{}',
+                          expires_at TIMESTAMP WITH TIME ZONE,
+                          last_used_at TIMESTAMP WITH TIME ZONE,
+                          revoked_at TIMESTAMP WITH TIME ZONE,
+                          created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+                          updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_api_keys_user_id ON api_keys (user_id);
+
+-- Names only need to be unique among the keys of a user that were not revoked
+CREATE UNIQUE INDEX idx_api_keys_user_id_name ON api_keys (user_id, name) WHERE revoked_at IS NULL;
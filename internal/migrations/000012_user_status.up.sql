@@ -0,0 +1,3 @@
+-- Disabled users cannot sign in, and their roles are not loaded into the enforcer.
+ALTER TABLE users
+    ADD COLUMN disabled_at TIMESTAMP WITH TIME ZONE;
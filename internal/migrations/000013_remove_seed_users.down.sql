@@ -0,0 +1,2 @@
+-- The seed users are not restored: they had a publicly known password. Load configs/fixtures instead.
+SELECT 1;
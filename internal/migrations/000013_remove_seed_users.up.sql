@@ -0,0 +1,6 @@
+-- 000001 seeds admin, user1 and user2 with a shared, publicly known password. It stays as it was applied, so the
+-- accounts still using that password are removed here, in new and existing databases alike; the first administrator
+-- is now created by the bootstrap.
+DELETE FROM users
+WHERE username IN ('admin', 'user1', 'user2')
+  AND password_hash = '$2a$10$Gqpa6ukRxP.XxwCLjNRrweuWPgrw0XIIo5xi.a8XUpVcndvIgWPlW';
@@ -0,0 +1,10 @@
+DELETE FROM role_permissions
+WHERE permission_id IN (SELECT id FROM permissions WHERE resource = 'AUDIT');
+
+DELETE FROM permissions WHERE resource = 'AUDIT';
+
+DELETE FROM resource_types WHERE name = 'AUDIT';
+
+DROP TABLE IF EXISTS audit_events CASCADE;
+
+DROP FUNCTION IF EXISTS audit_events_append_only();
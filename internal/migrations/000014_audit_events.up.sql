@@ -0,0 +1,45 @@
+-- Security-relevant events. Each row carries the hash of the previous one, so that a removed or altered row
+-- breaks the chain; the trigger below keeps the application from changing rows in the first place.
+CREATE TABLE audit_events (
+                              id BIGSERIAL PRIMARY KEY,
+                              occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                              actor_type VARCHAR(32) NOT NULL DEFAULT '',
+                              actor_id VARCHAR(255) NOT NULL DEFAULT '',
+                              action VARCHAR(100) NOT NULL,
+                              target_type VARCHAR(100) NOT NULL DEFAULT '',
+                              target_id VARCHAR(255) NOT NULL DEFAULT '',
+                              outcome VARCHAR(16) NOT NULL CHECK (outcome IN ('success', 'failure')),
+                              reason TEXT NOT NULL DEFAULT '',
+                              ip_address VARCHAR(64) NOT NULL DEFAULT '',
+                              request_id VARCHAR(128) NOT NULL DEFAULT '',
+                              before JSONB,
+                              after JSONB,
+                              prev_hash VARCHAR(64) NOT NULL DEFAULT '',
+                              hash VARCHAR(64) NOT NULL
+);
+
+CREATE INDEX idx_audit_events_occurred_at ON audit_events (occurred_at);
+CREATE INDEX idx_audit_events_actor ON audit_events (actor_type, actor_id);
+CREATE INDEX idx_audit_events_target ON audit_events (target_type, target_id);
+CREATE INDEX idx_audit_events_action ON audit_events (action);
+
+CREATE FUNCTION audit_events_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_events
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
+
+-- The audit log is read by administrators
+INSERT INTO resource_types (name, description) VALUES ('AUDIT', 'Audit log of security-relevant events');
+
+INSERT INTO permissions (name, description, resource, action) VALUES
+                                                                  ('read_audit', 'Read and verify the audit log', 'AUDIT', 'READ');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'admin' AND p.resource = 'AUDIT';
@@ -0,0 +1,20 @@
+-- Domain events waiting to be published to NATS. Rows are written in the transaction of the change they describe,
+-- so an event is published exactly when its change was committed. The relay publishes pending rows and marks them
+-- published; published rows are deleted after a while.
+CREATE TABLE outbox_events (
+                               id BIGSERIAL PRIMARY KEY,
+                               event_id VARCHAR(36) NOT NULL UNIQUE,
+                               event_type VARCHAR(100) NOT NULL,
+                               version INTEGER NOT NULL,
+                               aggregate_type VARCHAR(100) NOT NULL,
+                               aggregate_id VARCHAR(255) NOT NULL,
+                               payload BYTEA NOT NULL,
+                               created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+                               attempts INTEGER NOT NULL DEFAULT 0,
+                               next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+                               last_error TEXT NOT NULL DEFAULT '',
+                               published_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_outbox_events_pending ON outbox_events (next_attempt_at, id) WHERE published_at IS NULL;
+CREATE INDEX idx_outbox_events_published_at ON outbox_events (published_at) WHERE published_at IS NOT NULL;
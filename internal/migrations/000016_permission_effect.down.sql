@@ -0,0 +1,3 @@
+ALTER TABLE permissions
+    DROP CONSTRAINT IF EXISTS chk_permissions_effect,
+    DROP COLUMN IF EXISTS effect;
@@ -0,0 +1,4 @@
+-- A permission either grants its action or denies it; a deny overrides every grant of the same action.
+ALTER TABLE permissions
+    ADD COLUMN effect VARCHAR(16) NOT NULL DEFAULT 'allow',
+    ADD CONSTRAINT chk_permissions_effect CHECK (effect IN ('allow', 'deny'));
@@ -0,0 +1,89 @@
+// Package migrations holds the database migrations of the auth service, embedded into the binary, and applies
+// them. Files are named NNNNNN_title.up.sql and NNNNNN_title.down.sql after the convention of golang-migrate.
+package migrations
+
+import (
+	"cmp"
+	"embed"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// Migration is a schema change. Up applies it and Down undoes it.
+type Migration struct {
+	Version uint64 `json:"version"`
+	Title   string `json:"title"`
+	Up      string `json:"-"`
+	Down    string `json:"-"`
+}
+
+// Load returns the embedded migrations, ordered by version.
+func Load() ([]*Migration, error) {
+	return load(files)
+}
+
+// load reads the migrations in the root of fsys, ordered by version.
+func load(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		base, up := strings.CutSuffix(name, ".up.sql")
+		if !up {
+			var down bool
+			if base, down = strings.CutSuffix(name, ".down.sql"); !down {
+				continue
+			}
+		}
+
+		prefix, title, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name %s", name)
+		}
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", name)
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Title: title}
+			byVersion[version] = migration
+		} else if migration.Title != title {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", migration.Title, title, version)
+		}
+		if up {
+			migration.Up = string(data)
+		} else {
+			migration.Down = string(data)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Title)
+		}
+		migrations = append(migrations, migration)
+	}
+	slices.SortFunc(migrations, func(a, b *Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+
+	return migrations, nil
+}
@@ -0,0 +1,155 @@
+package migrations
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad(t *testing.T) {
+	file := func(data string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(data)}
+	}
+
+	tests := []struct {
+		name         string
+		fsys         fstest.MapFS
+		wantVersions []uint64
+		wantErr      bool
+	}{
+		{
+			name: "ordered by version, not by name",
+			fsys: fstest.MapFS{
+				"10_ten.up.sql":     file("ten"),
+				"2_two.up.sql":      file("two"),
+				"000001_one.up.sql": file("one"),
+				"2_two.down.sql":    file("undo two"),
+			},
+			wantVersions: []uint64{1, 2, 10},
+		},
+		{
+			name: "gaps are allowed",
+			fsys: fstest.MapFS{
+				"000001_one.up.sql":   file("one"),
+				"000003_three.up.sql": file("three"),
+				"000007_seven.up.sql": file("seven"),
+			},
+			wantVersions: []uint64{1, 3, 7},
+		},
+		{
+			name: "other files are ignored",
+			fsys: fstest.MapFS{
+				"000001_one.up.sql": file("one"),
+				"README.md":         file("readme"),
+				"000002_two.sql":    file("two"),
+			},
+			wantVersions: []uint64{1},
+		},
+		{
+			name:    "missing up file",
+			fsys:    fstest.MapFS{"000001_one.down.sql": file("undo one")},
+			wantErr: true,
+		},
+		{
+			name: "duplicate version",
+			fsys: fstest.MapFS{
+				"000001_one.up.sql":   file("one"),
+				"000001_other.up.sql": file("other"),
+			},
+			wantErr: true,
+		},
+		{
+			name:    "version zero",
+			fsys:    fstest.MapFS{"000000_zero.up.sql": file("zero")},
+			wantErr: true,
+		},
+		{
+			name:    "no version",
+			fsys:    fstest.MapFS{"one.up.sql": file("one")},
+			wantErr: true,
+		},
+		{
+			name:    "invalid version",
+			fsys:    fstest.MapFS{"v1_one.up.sql": file("one")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := load(tt.fsys)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if len(migrations) != len(tt.wantVersions) {
+				t.Fatalf("load() returned %d migrations, want %d", len(migrations), len(tt.wantVersions))
+			}
+			for i, migration := range migrations {
+				if migration.Version != tt.wantVersions[i] {
+					t.Errorf("migration %d has version %d, want %d", i, migration.Version, tt.wantVersions[i])
+				}
+				if migration.Up == "" {
+					t.Errorf("migration %d has no up SQL", migration.Version)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadEmbedded(t *testing.T) {
+	migrations, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	for i, migration := range migrations {
+		if migration.Version != uint64(i+1) {
+			t.Errorf("embedded migration %d_%s, want version %d", migration.Version, migration.Title, i+1)
+		}
+		if migration.Down == "" {
+			t.Errorf("embedded migration %d_%s has no down file", migration.Version, migration.Title)
+		}
+	}
+}
+
+func TestStatus(t *testing.T) {
+	migrations := []*Migration{{Version: 1}, {Version: 3}, {Version: 7}}
+
+	tests := []struct {
+		name        string
+		status      Status
+		wantPending []uint64
+		wantErr     error
+	}{
+		{"empty database", Status{Version: 0}, []uint64{1, 3, 7}, nil},
+		{"across a gap", Status{Version: 3}, []uint64{7}, nil},
+		{"up to date", Status{Version: 7}, nil, nil},
+		{"newer than the binary", Status{Version: 8}, nil, ErrSchemaTooNew},
+		{"dirty", Status{Version: 3, Dirty: true}, []uint64{7}, ErrDirty},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := tt.status
+			status.Migrations = migrations
+
+			pending := status.Pending()
+			if len(pending) != len(tt.wantPending) {
+				t.Fatalf("Pending() returned %d migrations, want %d", len(pending), len(tt.wantPending))
+			}
+			for i, migration := range pending {
+				if migration.Version != tt.wantPending[i] {
+					t.Errorf("pending migration %d has version %d, want %d", i, migration.Version, tt.wantPending[i])
+				}
+			}
+
+			if err := (&migrator{}).check(&status); !errors.Is(err, tt.wantErr) {
+				t.Errorf("check() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,333 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+	"goflare.io/nexus/driver"
+
+	appconfig "goflare.io/auth/internal/config"
+)
+
+// defaultTable records the schema version unless postgres.x-migrations-table names another table.
+const defaultTable = "schema_migrations"
+
+var (
+	// ErrDirty is returned when a migration failed halfway outside a transaction, e.g. under golang-migrate, and the
+	// schema has to be repaired by hand before the version row is cleared of its dirty flag.
+	ErrDirty = errors.New("the schema is dirty")
+
+	// ErrSchemaTooNew is returned when the schema was migrated by a newer release, which this one cannot run against.
+	ErrSchemaTooNew = errors.New("the schema is newer than this release")
+)
+
+// Status is the state of the schema.
+type Status struct {
+
+	// Version is the version of the last applied migration, or zero for an empty database.
+	Version uint64 `json:"version"`
+
+	// Dirty reports that the last migration failed halfway.
+	Dirty bool `json:"dirty"`
+
+	// Migrations are the migrations embedded in the binary.
+	Migrations []*Migration `json:"migrations"`
+}
+
+// Latest returns the version of the newest embedded migration.
+func (s *Status) Latest() uint64 {
+	if len(s.Migrations) == 0 {
+		return 0
+	}
+	return s.Migrations[len(s.Migrations)-1].Version
+}
+
+// Pending returns the migrations that are not applied yet.
+func (s *Status) Pending() []*Migration {
+	var pending []*Migration
+	for _, migration := range s.Migrations {
+		if migration.Version > s.Version {
+			pending = append(pending, migration)
+		}
+	}
+	return pending
+}
+
+// Migrator applies the embedded migrations. Every method holds a Postgres advisory lock while it works, so that
+// replicas starting together migrate one after the other, and each migration runs in a transaction with the update
+// of the version table.
+type Migrator interface {
+	// Up applies the pending migrations and returns them.
+	Up(ctx context.Context) ([]*Migration, error)
+	// Down undoes the last steps migrations and returns them.
+	Down(ctx context.Context, steps int) ([]*Migration, error)
+	// Status returns the state of the schema.
+	Status(ctx context.Context) (*Status, error)
+	// Prepare readies the schema when the service starts. It applies the pending migrations when migration.auto is
+	// set, and otherwise fails if the schema is dirty or newer than the binary.
+	Prepare(ctx context.Context) error
+}
+
+type migrator struct {
+	pool       driver.PostgresPool
+	migrations []*Migration
+	table      pgx.Identifier
+	lockKey    int64
+	auto       bool
+	logger     *zap.Logger
+}
+
+var _ Migrator = (*migrator)(nil)
+
+// NewMigrator creates a new Migrator of the embedded migrations.
+func NewMigrator(pool driver.PostgresPool, cfg *appconfig.Config, logger *zap.Logger) (Migrator, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	name := cfg.Postgres.MigrationsTable
+	if name == "" {
+		name = defaultTable
+	}
+	table := pgx.Identifier(strings.Split(name, "."))
+	if len(table) > 2 {
+		return nil, fmt.Errorf("invalid migrations table %q", name)
+	}
+
+	// The lock is keyed by the table, so that services sharing a database but not a schema do not wait on each
+	// other.
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte("goflare.io/auth:" + name))
+
+	return &migrator{
+		pool:       pool,
+		migrations: migrations,
+		table:      table,
+		lockKey:    int64(hash.Sum64()),
+		auto:       cfg.Migration.Auto,
+		logger:     logger,
+	}, nil
+}
+
+// Up applies the pending migrations and returns them.
+func (m *migrator) Up(ctx context.Context) ([]*Migration, error) {
+	conn, unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	status, err := m.status(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	if err = m.check(status); err != nil {
+		return nil, err
+	}
+
+	var applied []*Migration
+	for _, migration := range status.Pending() {
+		if err = m.apply(ctx, conn, migration.Up, migration.Version); err != nil {
+			return applied, fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Title, err)
+		}
+		m.logger.Info("applied migration", zap.Uint64("version", migration.Version), zap.String("title", migration.Title))
+		applied = append(applied, migration)
+	}
+
+	return applied, nil
+}
+
+// Down undoes the last steps migrations and returns them.
+func (m *migrator) Down(ctx context.Context, steps int) ([]*Migration, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("steps must be at least 1")
+	}
+
+	conn, unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	status, err := m.status(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	if status.Dirty {
+		return nil, fmt.Errorf("%w at version %d", ErrDirty, status.Version)
+	}
+
+	var undone []*Migration
+	for version := status.Version; version > 0 && len(undone) < steps; {
+		index := m.index(version)
+		if index < 0 {
+			return undone, fmt.Errorf("%w: no migration to undo version %d", ErrSchemaTooNew, version)
+		}
+		migration := m.migrations[index]
+		if migration.Down == "" {
+			return undone, fmt.Errorf("migration %d_%s cannot be undone", migration.Version, migration.Title)
+		}
+
+		var previous uint64
+		if index > 0 {
+			previous = m.migrations[index-1].Version
+		}
+		if err = m.apply(ctx, conn, migration.Down, previous); err != nil {
+			return undone, fmt.Errorf("failed to undo migration %d_%s: %w", migration.Version, migration.Title, err)
+		}
+		m.logger.Info("undid migration", zap.Uint64("version", migration.Version), zap.String("title", migration.Title))
+		undone = append(undone, migration)
+		version = previous
+	}
+
+	return undone, nil
+}
+
+// Status returns the state of the schema.
+func (m *migrator) Status(ctx context.Context) (*Status, error) {
+	conn, unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return m.status(ctx, conn)
+}
+
+// Prepare readies the schema when the service starts.
+func (m *migrator) Prepare(ctx context.Context) error {
+	if m.auto {
+		_, err := m.Up(ctx)
+		return err
+	}
+
+	status, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if err = m.check(status); err != nil {
+		return err
+	}
+	if pending := status.Pending(); len(pending) > 0 {
+		m.logger.Warn("the schema has pending migrations, apply them with authctl migrate up",
+			zap.Uint64("version", status.Version),
+			zap.Uint64("latest", status.Latest()),
+		)
+	}
+
+	return nil
+}
+
+// check fails if the schema is dirty or newer than the embedded migrations.
+func (m *migrator) check(status *Status) error {
+	if status.Dirty {
+		return fmt.Errorf("%w at version %d", ErrDirty, status.Version)
+	}
+	if status.Version > status.Latest() {
+		return fmt.Errorf("%w: the schema is at version %d, this release knows up to %d",
+			ErrSchemaTooNew, status.Version, status.Latest())
+	}
+	return nil
+}
+
+// status reads the version table, creating it if needed.
+func (m *migrator) status(ctx context.Context, conn *pgxpool.Conn) (*Status, error) {
+	if len(m.table) == 2 {
+		if _, err := conn.Exec(ctx, "CREATE SCHEMA IF NOT EXISTS "+m.table[:1].Sanitize()); err != nil {
+			return nil, fmt.Errorf("failed to create schema of the migrations table: %w", err)
+		}
+	}
+	if _, err := conn.Exec(ctx, "CREATE TABLE IF NOT EXISTS "+m.table.Sanitize()+
+		" (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)"); err != nil {
+		return nil, fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	status := &Status{Migrations: m.migrations}
+	var version int64
+	err := conn.QueryRow(ctx, "SELECT version, dirty FROM "+m.table.Sanitize()+" LIMIT 1").Scan(&version, &status.Dirty)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	// golang-migrate records -1 once every migration has been undone.
+	if version > 0 {
+		status.Version = uint64(version)
+	}
+
+	return status, nil
+}
+
+// apply runs a migration and records the resulting version in one transaction; version zero empties the table.
+func (m *migrator) apply(ctx context.Context, conn *pgxpool.Conn, sql string, version uint64) (err error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				m.logger.Error("failed to rollback transaction", zap.Error(rbErr))
+			}
+		}
+	}()
+
+	// Without arguments the statements are sent with the simple protocol, which runs a whole file.
+	if _, err = tx.Exec(ctx, sql); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(ctx, "DELETE FROM "+m.table.Sanitize()); err != nil {
+		return fmt.Errorf("failed to clear schema version: %w", err)
+	}
+	if version > 0 {
+		if _, err = tx.Exec(ctx, "INSERT INTO "+m.table.Sanitize()+" (version, dirty) VALUES ($1, false)",
+			int64(version)); err != nil {
+			return fmt.Errorf("failed to record schema version: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// lock takes the advisory lock on a connection of its own, since the lock belongs to the session. The returned
+// function releases both.
+func (m *migrator) lock(ctx context.Context) (*pgxpool.Conn, func(), error) {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+
+	var locked bool
+	if err = conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", m.lockKey).Scan(&locked); err == nil && !locked {
+		m.logger.Info("waiting for another instance to finish migrating")
+		_, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1)", m.lockKey)
+	}
+	if err != nil {
+		conn.Release()
+		return nil, nil, fmt.Errorf("failed to lock migrations: %w", err)
+	}
+
+	return conn, func() {
+		if _, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", m.lockKey); err != nil {
+			// A connection still holding the lock must not go back to the pool.
+			m.logger.Error("failed to unlock migrations", zap.Error(err))
+			_ = conn.Conn().Close(context.Background())
+		}
+		conn.Release()
+	}, nil
+}
+
+// index returns the position of the migration with version, or -1.
+func (m *migrator) index(version uint64) int {
+	for i, migration := range m.migrations {
+		if migration.Version == version {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"goflare.io/auth/internal/sqlc"
+)
+
+// APIKey is a personal access token a user creates for scripts. It acts for its owner, limited to its scopes.
+// Only the hash of the key is stored.
+type APIKey struct {
+
+	// ID is the ID of the key.
+	ID uint64 `json:"id"`
+
+	// UserID is the ID of the user who owns the key.
+	UserID uint64 `json:"user_id"`
+
+	// Name is the name the user gave the key, unique among the user's active keys.
+	Name string `json:"name"`
+
+	// TokenPrefix is the beginning of the key, shown so that users can recognize it.
+	TokenPrefix string `json:"token_prefix"`
+
+	// TokenHash is the SHA-256 hash of the key.
+	TokenHash string `json:"-"`
+
+	// Scopes are the permissions the key is limited to, each a resource and action such as ORDER:READ.
+	Scopes []string `json:"scopes"`
+
+	// ExpiresAt is when the key stops working. It is zero for keys that do not expire.
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+
+	// LastUsedAt is when the key was last used.
+	LastUsedAt time.Time `json:"last_used_at,omitempty"`
+
+	// RevokedAt is when the key was revoked. It is zero for active keys.
+	RevokedAt time.Time `json:"revoked_at,omitempty"`
+
+	// CreatedAt is when the key was created.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ConvertFromSQLCAPIKey converts a SQLC API key to an APIKey.
+func (k *APIKey) ConvertFromSQLCAPIKey(sqlcKey *sqlc.ApiKey) *APIKey {
+
+	k.ID = sqlcKey.ID
+	k.UserID = sqlcKey.UserID
+	k.Name = sqlcKey.Name
+	k.TokenPrefix = sqlcKey.TokenPrefix
+	k.TokenHash = sqlcKey.TokenHash
+	k.Scopes = sqlcKey.Scopes
+	k.ExpiresAt = sqlcKey.ExpiresAt.Time
+	k.LastUsedAt = sqlcKey.LastUsedAt.Time
+	k.RevokedAt = sqlcKey.RevokedAt.Time
+	k.CreatedAt = sqlcKey.CreatedAt.Time
+
+	return k
+}
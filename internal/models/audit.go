@@ -0,0 +1,179 @@
+package models
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"goflare.io/auth/internal/sqlc"
+)
+
+const (
+	// AuditSuccess is the outcome of an operation that succeeded.
+	AuditSuccess = "success"
+
+	// AuditFailure is the outcome of an operation that failed or was denied.
+	AuditFailure = "failure"
+)
+
+// AuditEvent is a security-relevant event, such as a login or a change to the roles of a user. Events are only
+// ever appended, each carrying the hash of the one before it.
+type AuditEvent struct {
+
+	// ID is the ID of the event, increasing in the order events were recorded.
+	ID uint64 `json:"id"`
+
+	// OccurredAt is when the event was recorded.
+	OccurredAt time.Time `json:"occurred_at"`
+
+	// ActorType and ActorID identify who acted, e.g. a user or service account. They are empty for anonymous
+	// requests, such as failed logins.
+	ActorType string `json:"actor_type,omitempty"`
+	ActorID   string `json:"actor_id,omitempty"`
+
+	// Action is what was done, e.g. user.login or role.assign.
+	Action string `json:"action"`
+
+	// TargetType and TargetID identify what was acted on.
+	TargetType string `json:"target_type,omitempty"`
+	TargetID   string `json:"target_id,omitempty"`
+
+	// Outcome is AuditSuccess or AuditFailure.
+	Outcome string `json:"outcome"`
+
+	// Reason explains a failure.
+	Reason string `json:"reason,omitempty"`
+
+	// IPAddress and RequestID identify the request that caused the event.
+	IPAddress string `json:"ip_address,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+
+	// Before and After are the state of the target before and after the event, as JSON.
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+
+	// PrevHash is the hash of the previous event, empty for the first one.
+	PrevHash string `json:"prev_hash"`
+
+	// Hash is the hash of this event and PrevHash.
+	Hash string `json:"hash"`
+}
+
+// ConvertFromSQLCAuditEvent converts a SQLC audit event to an AuditEvent.
+func (e *AuditEvent) ConvertFromSQLCAuditEvent(sqlcEvent *sqlc.AuditEvent) *AuditEvent {
+
+	e.ID = sqlcEvent.ID
+	e.OccurredAt = sqlcEvent.OccurredAt.Time
+	e.ActorType = sqlcEvent.ActorType
+	e.ActorID = sqlcEvent.ActorID
+	e.Action = sqlcEvent.Action
+	e.TargetType = sqlcEvent.TargetType
+	e.TargetID = sqlcEvent.TargetID
+	e.Outcome = sqlcEvent.Outcome
+	e.Reason = sqlcEvent.Reason
+	e.IPAddress = sqlcEvent.IpAddress
+	e.RequestID = sqlcEvent.RequestID
+	e.Before = sqlcEvent.Before
+	e.After = sqlcEvent.After
+	e.PrevHash = sqlcEvent.PrevHash
+	e.Hash = sqlcEvent.Hash
+
+	return e
+}
+
+// ComputeHash returns the SHA-256 hash, hex-encoded, of the event chained to the hash of the previous event.
+// Before and After are hashed in canonical form, since the database does not keep the JSON as it was written.
+func (e *AuditEvent) ComputeHash(prevHash string) (string, error) {
+	before, err := canonicalJSON(e.Before)
+	if err != nil {
+		return "", err
+	}
+	after, err := canonicalJSON(e.After)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := json.Marshal([]any{
+		prevHash,
+		e.OccurredAt.UTC().Format(time.RFC3339Nano),
+		e.ActorType,
+		e.ActorID,
+		e.Action,
+		e.TargetType,
+		e.TargetID,
+		e.Outcome,
+		e.Reason,
+		e.IPAddress,
+		e.RequestID,
+		before,
+		after,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// canonicalJSON re-encodes JSON with sorted object keys and no insignificant whitespace.
+func canonicalJSON(raw json.RawMessage) (json.RawMessage, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// AuditFilter selects audit events. Empty fields match every event.
+type AuditFilter struct {
+	ActorType  string
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	Outcome    string
+
+	// Since and Until bound the time events occurred at, Until exclusively.
+	Since time.Time
+	Until time.Time
+
+	// BeforeID continues a listing from the events older than the event with this ID.
+	BeforeID uint64
+
+	// Limit is the maximum number of events returned.
+	Limit int
+}
+
+// AuditPage is a page of audit events, newest first.
+type AuditPage struct {
+	Events []*AuditEvent `json:"events"`
+
+	// NextBeforeID is the BeforeID of the next page, or zero on the last page.
+	NextBeforeID uint64 `json:"next_before_id,omitempty"`
+}
+
+// AuditVerification is the result of checking the hash chain of the audit log.
+type AuditVerification struct {
+
+	// Valid reports whether every event checked matches its hash and chains to the previous event.
+	Valid bool `json:"valid"`
+
+	// Checked is the number of events checked.
+	Checked int `json:"checked"`
+
+	// BrokenAt is the ID of the first event that does not match, when Valid is false.
+	BrokenAt uint64 `json:"broken_at,omitempty"`
+
+	// LastHash is the hash of the last event. Events removed from the end of the log leave the chain intact, so
+	// it is worth keeping elsewhere to compare with later.
+	LastHash string `json:"last_hash,omitempty"`
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestAuditEventComputeHash(t *testing.T) {
+	base := func() *AuditEvent {
+		return &AuditEvent{
+			ID:         7,
+			OccurredAt: time.Date(2024, time.March, 1, 12, 0, 0, 123456000, time.UTC),
+			ActorType:  "user",
+			ActorID:    "1",
+			Action:     "role.assign",
+			TargetType: "user",
+			TargetID:   "2",
+			Outcome:    AuditSuccess,
+			IPAddress:  "192.0.2.1",
+			RequestID:  "req-1",
+			Before:     json.RawMessage(`{"roles":["user"],"name":"bob"}`),
+			After:      json.RawMessage(`{"roles":["user","admin"],"name":"bob"}`),
+		}
+	}
+
+	want, err := base().ComputeHash("prev")
+	if err != nil {
+		t.Fatalf("ComputeHash failed: %v", err)
+	}
+	if len(want) != 64 {
+		t.Fatalf("ComputeHash = %q, want 64 hex characters", want)
+	}
+
+	tests := []struct {
+		name     string
+		prevHash string
+		change   func(e *AuditEvent)
+		same     bool
+	}{
+		{"identical event", "prev", func(e *AuditEvent) {}, true},
+		{"ID and stored hashes are not covered", "prev", func(e *AuditEvent) {
+			e.ID, e.PrevHash, e.Hash = 8, "other", "other"
+		}, true},
+		{"time in another location", "prev", func(e *AuditEvent) {
+			e.OccurredAt = e.OccurredAt.In(time.FixedZone("UTC+8", 8*60*60))
+		}, true},
+		{"JSON key order and whitespace", "prev", func(e *AuditEvent) {
+			e.Before = json.RawMessage(`{ "name": "bob", "roles": [ "user" ] }`)
+		}, true},
+		{"previous hash", "other", func(e *AuditEvent) {}, false},
+		{"first event", "", func(e *AuditEvent) {}, false},
+		{"time", "prev", func(e *AuditEvent) { e.OccurredAt = e.OccurredAt.Add(time.Microsecond) }, false},
+		{"actor", "prev", func(e *AuditEvent) { e.ActorID = "3" }, false},
+		{"action", "prev", func(e *AuditEvent) { e.Action = "role.remove" }, false},
+		{"target", "prev", func(e *AuditEvent) { e.TargetID = "3" }, false},
+		{"outcome", "prev", func(e *AuditEvent) { e.Outcome = AuditFailure }, false},
+		{"reason", "prev", func(e *AuditEvent) { e.Reason = "denied" }, false},
+		{"IP address", "prev", func(e *AuditEvent) { e.IPAddress = "192.0.2.2" }, false},
+		{"request ID", "prev", func(e *AuditEvent) { e.RequestID = "req-2" }, false},
+		{"before", "prev", func(e *AuditEvent) { e.Before = json.RawMessage(`{"roles":[],"name":"bob"}`) }, false},
+		{"after", "prev", func(e *AuditEvent) { e.After = nil }, false},
+		{"fields do not run into each other", "prev", func(e *AuditEvent) {
+			e.ActorType, e.ActorID = "user1", ""
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := base()
+			tt.change(event)
+
+			got, err := event.ComputeHash(tt.prevHash)
+			if err != nil {
+				t.Fatalf("ComputeHash failed: %v", err)
+			}
+			if (got == want) != tt.same {
+				t.Errorf("ComputeHash = %s, want same hash as the original: %v", got, tt.same)
+			}
+		})
+	}
+}
+
+func TestAuditEventComputeHashInvalidJSON(t *testing.T) {
+	event := &AuditEvent{Action: "role.assign", Before: json.RawMessage(`{"roles":`)}
+	if _, err := event.ComputeHash(""); err == nil {
+		t.Error("ComputeHash of invalid JSON succeeded, want an error")
+	}
+}
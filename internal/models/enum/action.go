@@ -1,6 +1,8 @@
 package enum
 
 // ActionType is the type of action.
+// Action types are registered at runtime in the action_types table; the constants below are
+// the well-known defaults seeded by the migrations.
 type ActionType string
 
 const (
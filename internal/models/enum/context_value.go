@@ -1,6 +0,0 @@
-package enum
-
-type contextKey string
-
-// UserIDKey is the key for the user ID in the context.
-const UserIDKey contextKey = "user_id"
@@ -1,6 +1,8 @@
 package enum
 
 // ResourceType is the type of resource.
+// Resource types are registered at runtime in the resource_types table; the constants below are
+// the well-known defaults seeded by the migrations.
 type ResourceType string
 
 const (
@@ -18,4 +20,13 @@ const (
 
 	// ResourceOrder is the resource for order.
 	ResourceOrder ResourceType = "ORDER"
+
+	// ResourceOAuthClient is the resource for the clients registered with the OAuth 2.0 authorization server.
+	ResourceOAuthClient ResourceType = "OAUTH_CLIENT"
+
+	// ResourceServiceAccount is the resource for service accounts.
+	ResourceServiceAccount ResourceType = "SERVICE_ACCOUNT"
+
+	// ResourceAudit is the resource for the audit log.
+	ResourceAudit ResourceType = "AUDIT"
 )
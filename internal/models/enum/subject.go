@@ -0,0 +1,12 @@
+package enum
+
+// SubjectType is the kind of principal a token is issued to.
+type SubjectType string
+
+const (
+	// SubjectUser is a human user. Tokens without a subject type are user tokens.
+	SubjectUser SubjectType = "user"
+
+	// SubjectServiceAccount is a service account used for machine-to-machine calls.
+	SubjectServiceAccount SubjectType = "service_account"
+)
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"goflare.io/auth/internal/sqlc"
+)
+
+// ExternalIdentity is the identity of a user as asserted by an external identity provider.
+type ExternalIdentity struct {
+
+	// Provider is the name of the identity provider, e.g. google.
+	Provider string `json:"provider"`
+
+	// Subject is the stable identifier of the user at the provider.
+	Subject string `json:"subject"`
+
+	// Username is the preferred username of the user, if the provider has one.
+	Username string `json:"username"`
+
+	// Email is the email of the user.
+	Email string `json:"email"`
+
+	// EmailVerified reports whether the provider verified the email.
+	EmailVerified bool `json:"email_verified"`
+
+	// Name is the display name of the user.
+	Name string `json:"name"`
+
+	// PhotoURL is the avatar URL of the user.
+	PhotoURL string `json:"photo_url"`
+
+	// Phone is the phone number of the user.
+	Phone string `json:"phone"`
+
+	// Token holds the tokens the provider issued during the sign-in, if any.
+	Token *oauth2.Token `json:"-"`
+}
+
+// UserIdentity is an external identity linked to a local user. A user signs in through any linked identity.
+type UserIdentity struct {
+
+	// ID is the ID of the link.
+	ID uint64 `json:"id"`
+
+	// UserID is the ID of the local user.
+	UserID uint64 `json:"user_id"`
+
+	// Provider is the name of the identity provider.
+	Provider string `json:"provider"`
+
+	// Subject is the identifier of the user at the provider.
+	Subject string `json:"subject"`
+
+	// Email is the email the provider reported when the identity was linked.
+	Email string `json:"email"`
+
+	// LinkedAt is when the identity was linked.
+	LinkedAt time.Time `json:"linked_at"`
+
+	// AccessToken is the sealed access token the provider issued at the last sign-in.
+	AccessToken []byte `json:"-"`
+
+	// RefreshToken is the sealed refresh token the provider issued, kept until the provider issues a new one.
+	RefreshToken []byte `json:"-"`
+
+	// TokenExpiresAt is when the access token expires.
+	TokenExpiresAt time.Time `json:"token_expires_at,omitempty"`
+}
+
+// ConvertFromSQLCUserIdentity converts a SQLC user identity to a UserIdentity.
+func (i *UserIdentity) ConvertFromSQLCUserIdentity(sqlcIdentity *sqlc.UserIdentity) *UserIdentity {
+
+	i.ID = sqlcIdentity.ID
+	i.UserID = sqlcIdentity.UserID
+	i.Provider = sqlcIdentity.Provider
+	i.Subject = sqlcIdentity.Subject
+	i.Email = sqlcIdentity.Email
+	i.LinkedAt = sqlcIdentity.LinkedAt.Time
+	i.AccessToken = sqlcIdentity.AccessToken
+	i.RefreshToken = sqlcIdentity.RefreshToken
+	i.TokenExpiresAt = sqlcIdentity.TokenExpiresAt.Time
+
+	return i
+}
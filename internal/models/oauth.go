@@ -0,0 +1,366 @@
+package models
+
+import (
+	"time"
+
+	"goflare.io/auth/internal/sqlc"
+)
+
+// OAuthClient is an application registered with the OAuth 2.0 authorization server.
+type OAuthClient struct {
+
+	// ID is the ID of the client record.
+	ID uint64 `json:"id"`
+
+	// ClientID is the public identifier of the client.
+	ClientID string `json:"client_id"`
+
+	// Name is the name shown to users when they are asked for consent.
+	Name string `json:"name"`
+
+	// RedirectURIs are the redirect URIs the client may use. They are matched exactly.
+	RedirectURIs []string `json:"redirect_uris"`
+
+	// Scopes are the scopes the client may request.
+	Scopes []string `json:"scopes"`
+
+	// Public reports whether the client has no secret, e.g. a single-page or native application.
+	Public bool `json:"public"`
+
+	// SecretHash is the bcrypt hash of the client secret. It is empty for public clients.
+	SecretHash string `json:"-"`
+
+	// CreatedAt is when the client was registered.
+	CreatedAt time.Time `json:"created_at"`
+
+	// UpdatedAt is when the client was last updated.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ConvertFromSQLCOAuthClient converts a SQLC OAuth client to an OAuthClient.
+func (c *OAuthClient) ConvertFromSQLCOAuthClient(sqlcClient *sqlc.OauthClient) *OAuthClient {
+
+	c.ID = sqlcClient.ID
+	c.ClientID = sqlcClient.ClientID
+	c.Name = sqlcClient.Name
+	c.RedirectURIs = sqlcClient.RedirectUris
+	c.Scopes = sqlcClient.Scopes
+	c.Public = sqlcClient.SecretHash == nil
+	if sqlcClient.SecretHash != nil {
+		c.SecretHash = *sqlcClient.SecretHash
+	}
+	c.CreatedAt = sqlcClient.CreatedAt.Time
+	c.UpdatedAt = sqlcClient.UpdatedAt.Time
+
+	return c
+}
+
+// OAuthConsent records the scopes a user allowed a client to access on their behalf.
+type OAuthConsent struct {
+
+	// UserID is the ID of the user who gave the consent.
+	UserID uint64 `json:"user_id"`
+
+	// ClientID is the client the consent was given to.
+	ClientID string `json:"client_id"`
+
+	// Scopes are the scopes the user allowed.
+	Scopes []string `json:"scopes"`
+
+	// GrantedAt is when the consent was last given.
+	GrantedAt time.Time `json:"granted_at"`
+}
+
+// ConvertFromSQLCOAuthConsent converts a SQLC OAuth consent to an OAuthConsent.
+func (c *OAuthConsent) ConvertFromSQLCOAuthConsent(sqlcConsent *sqlc.OauthConsent) *OAuthConsent {
+
+	c.UserID = sqlcConsent.UserID
+	c.ClientID = sqlcConsent.ClientID
+	c.Scopes = sqlcConsent.Scopes
+	c.GrantedAt = sqlcConsent.GrantedAt.Time
+
+	return c
+}
+
+// AuthorizationCode is an authorization code issued to a client. Only the hash of the code is stored.
+type AuthorizationCode struct {
+
+	// CodeHash is the SHA-256 hash of the code.
+	CodeHash string
+
+	// ClientID is the client the code was issued to.
+	ClientID string
+
+	// UserID is the user who authorized the client.
+	UserID uint64
+
+	// RedirectURI is the redirect URI of the authorization request, which the token request must repeat.
+	RedirectURI string
+
+	// Scopes are the scopes granted.
+	Scopes []string
+
+	// CodeChallenge is the S256 PKCE code challenge of the authorization request.
+	CodeChallenge string
+
+	// ExpiresAt is when the code can no longer be redeemed.
+	ExpiresAt time.Time
+
+	// Nonce is the nonce of an OpenID Connect authentication request, returned in the ID token.
+	Nonce string
+}
+
+// ConvertFromSQLCAuthorizationCode converts a SQLC authorization code to an AuthorizationCode.
+func (c *AuthorizationCode) ConvertFromSQLCAuthorizationCode(sqlcCode *sqlc.OauthAuthorizationCode) *AuthorizationCode {
+
+	c.CodeHash = sqlcCode.CodeHash
+	c.ClientID = sqlcCode.ClientID
+	c.UserID = sqlcCode.UserID
+	c.RedirectURI = sqlcCode.RedirectUri
+	c.Scopes = sqlcCode.Scopes
+	c.CodeChallenge = sqlcCode.CodeChallenge
+	c.ExpiresAt = sqlcCode.ExpiresAt.Time
+	c.Nonce = sqlcCode.Nonce
+
+	return c
+}
+
+// RefreshToken is a refresh token issued to a client. Only the hash of the token is stored.
+type RefreshToken struct {
+
+	// TokenHash is the SHA-256 hash of the token.
+	TokenHash string
+
+	// ClientID is the client the token was issued to.
+	ClientID string
+
+	// UserID is the user the token acts for.
+	UserID uint64
+
+	// Scopes are the scopes granted.
+	Scopes []string
+
+	// ExpiresAt is when the token can no longer be used.
+	ExpiresAt time.Time
+
+	// Revoked reports whether the token was revoked or already used.
+	Revoked bool
+}
+
+// ConvertFromSQLCRefreshToken converts a SQLC refresh token to a RefreshToken.
+func (t *RefreshToken) ConvertFromSQLCRefreshToken(sqlcToken *sqlc.OauthRefreshToken) *RefreshToken {
+
+	t.TokenHash = sqlcToken.TokenHash
+	t.ClientID = sqlcToken.ClientID
+	t.UserID = sqlcToken.UserID
+	t.Scopes = sqlcToken.Scopes
+	t.ExpiresAt = sqlcToken.ExpiresAt.Time
+	t.Revoked = sqlcToken.RevokedAt.Valid
+
+	return t
+}
+
+// DeviceCode is a pending device authorization (RFC 8628). Only the hash of the device code is stored; the user
+// code is what the user types on the verification page.
+type DeviceCode struct {
+
+	// DeviceCodeHash is the SHA-256 hash of the device code.
+	DeviceCodeHash string
+
+	// UserCode is the code shown to the user, without separators.
+	UserCode string
+
+	// ClientID is the client the code was issued to.
+	ClientID string
+
+	// Scopes are the scopes requested.
+	Scopes []string
+
+	// Status is pending until the user approves or denies the request.
+	Status string
+
+	// UserID is the user who approved the request.
+	UserID uint64
+
+	// PollInterval is the minimum time between two token requests of the client.
+	PollInterval time.Duration
+
+	// LastPolledAt is when the client last asked for a token.
+	LastPolledAt time.Time
+
+	// ExpiresAt is when the codes can no longer be used.
+	ExpiresAt time.Time
+}
+
+// ConvertFromSQLCDeviceCode converts a SQLC device code to a DeviceCode.
+func (c *DeviceCode) ConvertFromSQLCDeviceCode(sqlcCode *sqlc.OauthDeviceCode) *DeviceCode {
+
+	c.DeviceCodeHash = sqlcCode.DeviceCodeHash
+	c.UserCode = sqlcCode.UserCode
+	c.ClientID = sqlcCode.ClientID
+	c.Scopes = sqlcCode.Scopes
+	c.Status = sqlcCode.Status
+	if sqlcCode.ApprovedBy != nil {
+		c.UserID = uint64(*sqlcCode.ApprovedBy)
+	}
+	c.PollInterval = time.Duration(sqlcCode.PollInterval) * time.Second
+	c.LastPolledAt = sqlcCode.LastPolledAt.Time
+	c.ExpiresAt = sqlcCode.ExpiresAt.Time
+
+	return c
+}
+
+// DeviceAuthorizationResponse is a successful device authorization response (RFC 8628 section 3.2).
+type DeviceAuthorizationResponse struct {
+
+	// DeviceCode is the code the client polls the token endpoint with.
+	DeviceCode string `json:"device_code"`
+
+	// UserCode is the code the user enters on the verification page.
+	UserCode string `json:"user_code"`
+
+	// VerificationURI is the page where the user enters the user code.
+	VerificationURI string `json:"verification_uri"`
+
+	// VerificationURIComplete is the verification page with the user code filled in, e.g. for a QR code.
+	VerificationURIComplete string `json:"verification_uri_complete"`
+
+	// ExpiresIn is the lifetime of the codes in seconds.
+	ExpiresIn int64 `json:"expires_in"`
+
+	// Interval is the minimum number of seconds between two token requests.
+	Interval int64 `json:"interval"`
+}
+
+// DeviceVerification is what the verification page shows the user before they approve a device.
+type DeviceVerification struct {
+
+	// UserCode is the user code that was entered.
+	UserCode string `json:"user_code"`
+
+	// Client is the client asking for authorization.
+	Client *OAuthClient `json:"client"`
+
+	// Scopes are the scopes the user is asked to approve.
+	Scopes []string `json:"scopes"`
+}
+
+// AuthorizationRequest is an OAuth 2.0 authorization request (RFC 6749 section 4.1.1 with RFC 7636 PKCE).
+type AuthorizationRequest struct {
+
+	// ResponseType must be code.
+	ResponseType string `json:"response_type"`
+
+	// ClientID is the client asking for authorization.
+	ClientID string `json:"client_id"`
+
+	// RedirectURI is where the user is sent back to. It must be one of the client's redirect URIs.
+	RedirectURI string `json:"redirect_uri"`
+
+	// Scope is the space-separated list of requested scopes. It defaults to every scope of the client.
+	Scope string `json:"scope"`
+
+	// State is returned to the client unchanged.
+	State string `json:"state"`
+
+	// CodeChallenge is the PKCE code challenge.
+	CodeChallenge string `json:"code_challenge"`
+
+	// CodeChallengeMethod must be S256.
+	CodeChallengeMethod string `json:"code_challenge_method"`
+
+	// Nonce is returned in the ID token of an OpenID Connect authentication request.
+	Nonce string `json:"nonce,omitempty"`
+
+	// Consent is the user's answer to the consent prompt: approve, deny, or empty when not asked yet.
+	Consent string `json:"consent,omitempty"`
+}
+
+// AuthorizationResult is the outcome of an authorization request for a signed-in user.
+type AuthorizationResult struct {
+
+	// RedirectTo is the client's redirect URI with the authorization code or error.
+	RedirectTo string `json:"redirect_to,omitempty"`
+
+	// ConsentRequired reports whether the user has to approve the requested scopes first.
+	ConsentRequired bool `json:"consent_required,omitempty"`
+
+	// Client is the client asking for consent.
+	Client *OAuthClient `json:"client,omitempty"`
+
+	// Scopes are the scopes the user is asked to approve.
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// TokenRequest is an OAuth 2.0 token request.
+type TokenRequest struct {
+
+	// GrantType is authorization_code, refresh_token, client_credentials, or the device code or token exchange
+	// grant type.
+	GrantType string
+
+	// ClientID is the client making the request.
+	ClientID string
+
+	// ClientSecret is the client secret. It is empty for public clients.
+	ClientSecret string
+
+	// Code is the authorization code of an authorization_code grant.
+	Code string
+
+	// RedirectURI is the redirect URI of the authorization request of an authorization_code grant.
+	RedirectURI string
+
+	// CodeVerifier is the PKCE code verifier of an authorization_code grant.
+	CodeVerifier string
+
+	// RefreshToken is the refresh token of a refresh_token grant.
+	RefreshToken string
+
+	// DeviceCode is the device code of a device code grant.
+	DeviceCode string
+
+	// SubjectToken is the user token a service exchanges in a token exchange grant.
+	SubjectToken string
+
+	// SubjectTokenType is the type of SubjectToken, which must be an access token.
+	SubjectTokenType string
+
+	// RequestedTokenType is the type of token requested by a token exchange grant, if any.
+	RequestedTokenType string
+
+	// ActorToken is the token of the acting party in a token exchange grant. It is not supported, since the acting
+	// service authenticates with its client credentials instead.
+	ActorToken string
+
+	// Audience is the service the token of a token exchange grant will be used at.
+	Audience string
+
+	// Scope optionally narrows the scopes of a refresh_token, client_credentials or token exchange grant.
+	Scope string
+}
+
+// TokenResponse is a successful OAuth 2.0 token response (RFC 6749 section 5.1).
+type TokenResponse struct {
+
+	// AccessToken is the PASETO access token.
+	AccessToken string `json:"access_token"`
+
+	// TokenType is always Bearer.
+	TokenType string `json:"token_type"`
+
+	// ExpiresIn is the lifetime of the access token in seconds.
+	ExpiresIn int64 `json:"expires_in"`
+
+	// RefreshToken is a new refresh token, if one was issued.
+	RefreshToken string `json:"refresh_token,omitempty"`
+
+	// Scope is the space-separated list of granted scopes.
+	Scope string `json:"scope"`
+
+	// IDToken is the OpenID Connect ID token, issued when the openid scope was granted.
+	IDToken string `json:"id_token,omitempty"`
+
+	// IssuedTokenType is the type of the access token issued by a token exchange grant.
+	IssuedTokenType string `json:"issued_token_type,omitempty"`
+}
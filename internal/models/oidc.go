@@ -0,0 +1,106 @@
+package models
+
+// DiscoveryDocument is the OpenID Provider metadata served at /.well-known/openid-configuration
+// (OpenID Connect Discovery 1.0 section 3).
+type DiscoveryDocument struct {
+
+	// Issuer is the issuer identifier, the iss claim of every ID token.
+	Issuer string `json:"issuer"`
+
+	// AuthorizationEndpoint is the URL of the authorization endpoint.
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+
+	// TokenEndpoint is the URL of the token endpoint.
+	TokenEndpoint string `json:"token_endpoint"`
+
+	// DeviceAuthorizationEndpoint is the URL of the device authorization endpoint (RFC 8628 section 4).
+	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+
+	// UserinfoEndpoint is the URL of the userinfo endpoint.
+	UserinfoEndpoint string `json:"userinfo_endpoint"`
+
+	// JWKSURI is the URL of the key set that verifies ID tokens.
+	JWKSURI string `json:"jwks_uri"`
+
+	// ScopesSupported are the scopes clients may request.
+	ScopesSupported []string `json:"scopes_supported"`
+
+	// ResponseTypesSupported are the supported response types.
+	ResponseTypesSupported []string `json:"response_types_supported"`
+
+	// GrantTypesSupported are the supported grant types.
+	GrantTypesSupported []string `json:"grant_types_supported"`
+
+	// SubjectTypesSupported are the supported subject identifier types.
+	SubjectTypesSupported []string `json:"subject_types_supported"`
+
+	// IDTokenSigningAlgValuesSupported are the algorithms ID tokens are signed with.
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+
+	// TokenEndpointAuthMethodsSupported are the ways clients authenticate at the token endpoint.
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+
+	// CodeChallengeMethodsSupported are the supported PKCE code challenge methods.
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported"`
+
+	// ClaimsSupported are the claims ID tokens and the userinfo endpoint may return.
+	ClaimsSupported []string `json:"claims_supported"`
+}
+
+// JSONWebKey is a public key in JSON Web Key format (RFC 7517), an RSA key or an Ed25519 key (RFC 8037).
+type JSONWebKey struct {
+
+	// KeyType is the key type, RSA or OKP.
+	KeyType string `json:"kty"`
+
+	// Use is the intended use of the key, sig.
+	Use string `json:"use"`
+
+	// Algorithm is the algorithm the key is used with.
+	Algorithm string `json:"alg"`
+
+	// KeyID is the ID in the kid header of the tokens the key signed.
+	KeyID string `json:"kid"`
+
+	// N is the base64url encoded modulus of an RSA key.
+	N string `json:"n,omitempty"`
+
+	// E is the base64url encoded public exponent of an RSA key.
+	E string `json:"e,omitempty"`
+
+	// Curve is the curve of an OKP key, Ed25519.
+	Curve string `json:"crv,omitempty"`
+
+	// X is the base64url encoded public key of an OKP key.
+	X string `json:"x,omitempty"`
+}
+
+// JSONWebKeySet is the set of keys published at the JWKS endpoint.
+type JSONWebKeySet struct {
+
+	// Keys are the public keys.
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// UserInfo holds the standard claims about a user released for the granted scopes
+// (OpenID Connect Core 1.0 section 5.1).
+type UserInfo struct {
+
+	// Subject is the ID of the user.
+	Subject string `json:"sub"`
+
+	// Name is the display name of the user, released for the profile scope.
+	Name string `json:"name,omitempty"`
+
+	// PreferredUsername is the username, released for the profile scope.
+	PreferredUsername string `json:"preferred_username,omitempty"`
+
+	// Picture is the photo URL of the user, released for the profile scope.
+	Picture string `json:"picture,omitempty"`
+
+	// UpdatedAt is when the user was last updated in seconds since the epoch, released for the profile scope.
+	UpdatedAt int64 `json:"updated_at,omitempty"`
+
+	// Email is the email of the user, released for the email scope.
+	Email string `json:"email,omitempty"`
+}
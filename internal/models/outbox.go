@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"goflare.io/auth/internal/sqlc"
+)
+
+// OutboxEvent is a domain event stored in the outbox, in the transaction of the change it describes, until the
+// relay has published it.
+type OutboxEvent struct {
+
+	// ID is the ID of the row, increasing in the order events were written.
+	ID uint64 `json:"id"`
+
+	// EventID identifies the event to consumers, which can use it to drop events delivered twice.
+	EventID string `json:"event_id"`
+
+	// Type is the type of the event, e.g. user.registered.
+	Type string `json:"type"`
+
+	// Version is the version of the schema of the payload.
+	Version int `json:"version"`
+
+	// AggregateType and AggregateID identify what the event is about, e.g. a user.
+	AggregateType string `json:"aggregate_type"`
+	AggregateID   string `json:"aggregate_id"`
+
+	// Payload is the event message encoded in protobuf.
+	Payload []byte `json:"payload"`
+
+	// CreatedAt is when the event was written.
+	CreatedAt time.Time `json:"created_at"`
+
+	// Attempts is the number of failed attempts to publish the event.
+	Attempts int `json:"attempts"`
+
+	// LastError is the error of the last failed attempt.
+	LastError string `json:"last_error,omitempty"`
+
+	// PublishedAt is when the event was published. It is zero for pending events.
+	PublishedAt time.Time `json:"published_at,omitempty"`
+}
+
+// ConvertFromSQLCOutboxEvent converts a SQLC outbox event to an OutboxEvent.
+func (e *OutboxEvent) ConvertFromSQLCOutboxEvent(sqlcEvent *sqlc.OutboxEvent) *OutboxEvent {
+
+	e.ID = sqlcEvent.ID
+	e.EventID = sqlcEvent.EventID
+	e.Type = sqlcEvent.EventType
+	e.Version = int(sqlcEvent.Version)
+	e.AggregateType = sqlcEvent.AggregateType
+	e.AggregateID = sqlcEvent.AggregateID
+	e.Payload = sqlcEvent.Payload
+	e.CreatedAt = sqlcEvent.CreatedAt.Time
+	e.Attempts = int(sqlcEvent.Attempts)
+	e.LastError = sqlcEvent.LastError
+	e.PublishedAt = sqlcEvent.PublishedAt.Time
+
+	return e
+}
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"goflare.io/auth/internal/models/enum"
+)
 
 // PasetoSecret is the secret for the PASETO token.
 type PasetoSecret struct {
@@ -14,6 +18,32 @@ type PASETOToken struct {
 	Token      string
 	Expiration int64
 	ExpiresAt  time.Time
+	ClientID   string   `json:",omitempty"`
+	Scopes     []string `json:",omitempty"`
+
+	// SubjectType is service_account for tokens issued to service accounts, whose ID is in ServiceAccountID.
+	// It is empty for user tokens.
+	SubjectType      enum.SubjectType `json:",omitempty"`
+	ServiceAccountID uint64           `json:",omitempty"`
+
+	// Audience is the service a token obtained by token exchange is restricted to, and Actor the service that
+	// acts for the user with it. Both are empty for other tokens.
+	Audience string `json:"aud,omitempty"`
+	Actor    *Actor `json:"act,omitempty"`
+}
+
+// Actor is the act claim of a token obtained by token exchange (RFC 8693 section 4.1): the service that acts for
+// the subject of the token. When the subject token was itself exchanged, its actor is nested as the prior actor.
+type Actor struct {
+
+	// Subject is the client ID of the service account acting for the user.
+	Subject string `json:"sub"`
+
+	// ServiceAccountID is the ID of the service account acting for the user.
+	ServiceAccountID uint64 `json:"service_account_id"`
+
+	// Actor is the actor of the exchanged subject token, if it had one.
+	Actor *Actor `json:"act,omitempty"`
 }
 
 // Claims are the claims for the PASETO token.
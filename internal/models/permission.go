@@ -9,13 +9,17 @@ import (
 
 // Permission is the permission for the resource and action.
 type Permission struct {
-	ID          uint64            `json:"id"`
-	Name        string            `json:"name"`
-	Description string            `json:"description"`
-	Resource    enum.ResourceType `json:"resource"`
-	Action      enum.ActionType   `json:"action"`
-	CreatedAt   time.Time         `json:"created_at"`
-	UpdatedAt   time.Time         `json:"updated_at"`
+	ID              uint64            `json:"id"`
+	Name            string            `json:"name"`
+	Description     string            `json:"description"`
+	Resource        enum.ResourceType `json:"resource"`
+	Action          enum.ActionType   `json:"action"`
+	Condition       string            `json:"condition"`
+	TimeWindow      string            `json:"time_window"`
+	ResourcePattern string            `json:"resource_pattern"`
+	Effect          string            `json:"effect"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
 }
 
 // NewPermission creates a new Permission.
@@ -27,7 +31,7 @@ func NewPermission() *Permission {
 // ConvertFromSQLCPermission converts a SQLC permission to a Permission.
 func (p *Permission) ConvertFromSQLCPermission(sqlcPermission any) *Permission {
 
-	var name, description string
+	var name, description, condition, timeWindow, resourcePattern, effect string
 	var resource enum.ResourceType
 	var action enum.ActionType
 
@@ -39,13 +43,22 @@ func (p *Permission) ConvertFromSQLCPermission(sqlcPermission any) *Permission {
 		}
 		resource = enum.ResourceType(sp.Resource)
 		action = enum.ActionType(sp.Action)
+		condition = sp.AttrCondition
+		timeWindow = sp.TimeWindow
+		resourcePattern = sp.ResourcePattern
+		effect = sp.Effect
 	case *sqlc.Permission:
+		p.ID = sp.ID
 		name = sp.Name
 		if sp.Description != nil {
 			description = *sp.Description
 		}
 		resource = enum.ResourceType(sp.Resource)
 		action = enum.ActionType(sp.Action)
+		condition = sp.AttrCondition
+		timeWindow = sp.TimeWindow
+		resourcePattern = sp.ResourcePattern
+		effect = sp.Effect
 	default:
 		return nil
 	}
@@ -54,6 +67,41 @@ func (p *Permission) ConvertFromSQLCPermission(sqlcPermission any) *Permission {
 	p.Description = description
 	p.Resource = resource
 	p.Action = action
+	p.Condition = condition
+	p.TimeWindow = timeWindow
+	p.ResourcePattern = resourcePattern
+	p.Effect = effect
 
 	return p
 }
+
+// Object returns the Casbin object the permission applies to. Permissions without a resource pattern
+// apply to the resource type itself; otherwise to the instances matching `type/pattern`, e.g. `order/*`.
+func (p *Permission) Object() string {
+	if p.ResourcePattern == "" {
+		return string(p.Resource)
+	}
+	return ResourceID{Type: p.Resource, ID: p.ResourcePattern}.String()
+}
+
+// PermissionCheck is a single resource and action pair of a batch permission check.
+type PermissionCheck struct {
+	Resource enum.ResourceType `json:"resource"`
+	Action   enum.ActionType   `json:"action"`
+}
+
+// PermissionDecision is the outcome of a single PermissionCheck.
+type PermissionDecision struct {
+	Resource enum.ResourceType `json:"resource"`
+	Action   enum.ActionType   `json:"action"`
+	Allowed  bool              `json:"allowed"`
+}
+
+// GrantedPermission is an action a user may perform on an object through one of their roles.
+// Grants carrying a condition only apply to requests whose attributes satisfy it.
+type GrantedPermission struct {
+	Object     string `json:"object"`
+	Action     string `json:"action"`
+	Condition  string `json:"condition,omitempty"`
+	TimeWindow string `json:"time_window,omitempty"`
+}
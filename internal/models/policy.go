@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"goflare.io/auth/internal/policy"
+)
+
+// PolicyRule is a single policy rule as stored in the enforcer. Its subject is a role name or user:<id>.
+type PolicyRule struct {
+	Subject    string `json:"subject"`
+	Object     string `json:"object"`
+	Action     string `json:"action"`
+	Condition  string `json:"condition"`
+	Effect     string `json:"effect"`
+	TimeWindow string `json:"time_window"`
+}
+
+// Fields returns the rule in the field order of casbin.conf. An empty effect means allow.
+func (r PolicyRule) Fields() []string {
+	effect := r.Effect
+	if effect == "" {
+		effect = policy.EffectAllow
+	}
+	return []string{r.Subject, r.Object, r.Action, r.Condition, effect, r.TimeWindow}
+}
+
+// NewPolicyRule returns the rule of a policy as stored in the enforcer, in the field order of casbin.conf.
+// Missing fields are left empty.
+func NewPolicyRule(fields []string) PolicyRule {
+	padded := make([]string, policy.RuleLength)
+	copy(padded, fields)
+	return PolicyRule{
+		Subject:    padded[0],
+		Object:     padded[1],
+		Action:     padded[2],
+		Condition:  padded[3],
+		Effect:     padded[4],
+		TimeWindow: padded[5],
+	}
+}
+
+// GroupingRule assigns a subject to a role. Users are written as user:<id>, roles by name.
+type GroupingRule struct {
+	Subject string `json:"subject"`
+	Role    string `json:"role"`
+}
+
+// PolicySet is a complete set of policies proposed to replace the current ones.
+type PolicySet struct {
+
+	// Rules are the proposed policy rules.
+	Rules []PolicyRule `json:"rules"`
+
+	// GroupingRules are the proposed role assignments. When nil, the current assignments are kept.
+	GroupingRules []GroupingRule `json:"grouping_rules"`
+}
+
+// TestRequest is a request a proposed policy set is evaluated against.
+type TestRequest struct {
+
+	// UserID is the ID of the requesting user.
+	UserID uint64 `json:"user_id"`
+
+	// Object is a resource type such as ORDER or a resource instance such as order/42.
+	Object string `json:"object"`
+
+	// Action is the requested action.
+	Action string `json:"action"`
+
+	// Attributes are matched against the attribute conditions of the policies.
+	Attributes map[string]any `json:"attributes"`
+
+	// At is matched against the time windows of the policies; defaults to now.
+	At time.Time `json:"at"`
+}
+
+// DryRunResult compares the decision of the current and the proposed policies for a TestRequest.
+type DryRunResult struct {
+	Request  TestRequest         `json:"request"`
+	Current  *policy.Explanation `json:"current"`
+	Proposed *policy.Explanation `json:"proposed"`
+	Changed  bool                `json:"changed"`
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"goflare.io/auth/internal/models/enum"
+	"goflare.io/auth/internal/sqlc"
+)
+
+// resourceIDPattern limits instance identifiers and patterns to characters that are safe for keyMatch.
+var resourceIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.:@*-]+$`)
+
+// ResourceID identifies a single resource instance, written as `order/42`.
+// The ID may also be a keyMatch pattern such as `*` when it is used in a policy.
+type ResourceID struct {
+
+	// Type is the resource type of the instance.
+	Type enum.ResourceType `json:"type"`
+
+	// ID is the identifier of the instance within its type.
+	ID string `json:"id"`
+}
+
+// NewResourceID creates a ResourceID after validating the instance identifier.
+func NewResourceID(resourceType enum.ResourceType, id string) (ResourceID, error) {
+	if resourceType == "" {
+		return ResourceID{}, errors.New("resource type is required")
+	}
+	if !resourceIDPattern.MatchString(id) {
+		return ResourceID{}, fmt.Errorf("invalid resource id %q", id)
+	}
+
+	return ResourceID{Type: enum.ResourceType(strings.ToUpper(string(resourceType))), ID: id}, nil
+}
+
+// ParseResourceID parses the `type/id` form of a resource instance.
+func ParseResourceID(value string) (ResourceID, error) {
+	resourceType, id, found := strings.Cut(value, "/")
+	if !found {
+		return ResourceID{}, fmt.Errorf("resource %q must be of the form type/id", value)
+	}
+
+	return NewResourceID(enum.ResourceType(resourceType), id)
+}
+
+// String returns the `type/id` form used as the Casbin object of instance-level policies.
+func (r ResourceID) String() string {
+	return strings.ToLower(string(r.Type)) + "/" + r.ID
+}
+
+// Relation links a user to a resource instance, for example as its owner.
+type Relation struct {
+
+	// Resource is the resource instance.
+	Resource ResourceID `json:"resource"`
+
+	// Relation is the name of the relation, e.g. owner.
+	Relation string `json:"relation"`
+
+	// UserID is the ID of the related user.
+	UserID uint64 `json:"user_id"`
+
+	// CreatedAt is the created at time.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ConvertFromSQLCResourceRelation converts a SQLC resource relation to a Relation.
+func (r *Relation) ConvertFromSQLCResourceRelation(sqlcRelation *sqlc.ResourceRelation) *Relation {
+
+	r.Resource = ResourceID{Type: enum.ResourceType(sqlcRelation.ResourceType), ID: sqlcRelation.ResourceID}
+	r.Relation = sqlcRelation.Relation
+	r.UserID = sqlcRelation.UserID
+	r.CreatedAt = sqlcRelation.CreatedAt.Time
+
+	return r
+}
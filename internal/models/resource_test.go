@@ -0,0 +1,34 @@
+package models
+
+import "testing"
+
+func TestParseResourceID(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"order/42", "order/42", false},
+		{"ORDER/42", "order/42", false},
+		{"order/*", "order/*", false},
+		{"order/archive-*", "order/archive-*", false},
+		{"user/alice@example.com", "user/alice@example.com", false},
+		{"order/a/b", "", true},
+		{"order/", "", true},
+		{"/42", "", true},
+		{"order", "", true},
+		{"order/4 2", "", true},
+		{"order/../42", "", true},
+	}
+
+	for _, tt := range tests {
+		resourceID, err := ParseResourceID(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseResourceID(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && resourceID.String() != tt.want {
+			t.Errorf("ParseResourceID(%q) = %s, want %s", tt.value, resourceID, tt.want)
+		}
+	}
+}
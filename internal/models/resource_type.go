@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"goflare.io/auth/internal/models/enum"
+	"goflare.io/auth/internal/sqlc"
+)
+
+// ResourceType is a registered type of resource that permissions and relations can refer to.
+type ResourceType struct {
+
+	// Name is the identifier of the resource type, e.g. ORDER.
+	Name enum.ResourceType `json:"name"`
+
+	// Description is the description of the resource type.
+	Description string `json:"description"`
+
+	// CreatedAt is the created at time.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ConvertFromSQLCResourceType converts a SQLC resource type to a ResourceType.
+func (r *ResourceType) ConvertFromSQLCResourceType(sqlcResourceType *sqlc.ResourceType) *ResourceType {
+
+	r.Name = enum.ResourceType(sqlcResourceType.Name)
+	if sqlcResourceType.Description != nil {
+		r.Description = *sqlcResourceType.Description
+	}
+	r.CreatedAt = sqlcResourceType.CreatedAt.Time
+
+	return r
+}
+
+// ActionType is a registered type of action that permissions can refer to.
+type ActionType struct {
+
+	// Name is the identifier of the action type, e.g. APPROVE.
+	Name enum.ActionType `json:"name"`
+
+	// Description is the description of the action type.
+	Description string `json:"description"`
+
+	// CreatedAt is the created at time.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ConvertFromSQLCActionType converts a SQLC action type to an ActionType.
+func (a *ActionType) ConvertFromSQLCActionType(sqlcActionType *sqlc.ActionType) *ActionType {
+
+	a.Name = enum.ActionType(sqlcActionType.Name)
+	if sqlcActionType.Description != nil {
+		a.Description = *sqlcActionType.Description
+	}
+	a.CreatedAt = sqlcActionType.CreatedAt.Time
+
+	return a
+}
@@ -32,6 +32,7 @@ func (r *Role) ConvertFromSQLCRole(sqlcRole any) *Role {
 
 	switch sp := sqlcRole.(type) {
 	case *sqlc.Role:
+		id = sp.ID
 		name = sp.Name
 		if sp.Description != nil {
 			description = *sp.Description
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"time"
+
+	"goflare.io/auth/internal/models/enum"
+	"goflare.io/auth/internal/sqlc"
+)
+
+// ServiceAccount is a non-human principal that obtains tokens with the client credentials grant.
+// Roles are assigned to service accounts just like users.
+type ServiceAccount struct {
+
+	// ID is the ID of the service account.
+	ID uint64 `json:"id"`
+
+	// Name is the unique name of the service account, e.g. billing-worker.
+	Name string `json:"name"`
+
+	// Description is the description of the service account.
+	Description string `json:"description"`
+
+	// ClientID is the client ID the service account authenticates with.
+	ClientID string `json:"client_id"`
+
+	// Scopes are the scopes the service account may request. Its tokens are allowed only the RESOURCE:ACTION
+	// pairs among their scopes that its roles grant.
+	Scopes []string `json:"scopes"`
+
+	// Disabled reports whether the service account is disabled. Disabled accounts cannot obtain tokens.
+	Disabled bool `json:"disabled"`
+
+	// LastUsedAt is when the service account last obtained a token.
+	LastUsedAt time.Time `json:"last_used_at,omitempty"`
+
+	// CreatedAt is when the service account was created.
+	CreatedAt time.Time `json:"created_at"`
+
+	// UpdatedAt is when the service account was last updated.
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// SecretHash is the bcrypt hash of the current client secret.
+	SecretHash string `json:"-"`
+
+	// PreviousSecretHash is the bcrypt hash of the secret replaced by the last rotation.
+	PreviousSecretHash string `json:"-"`
+
+	// PreviousSecretExpiresAt is when the previous secret stops being accepted.
+	PreviousSecretExpiresAt time.Time `json:"previous_secret_expires_at,omitempty"`
+}
+
+// ConvertFromSQLCServiceAccount converts a SQLC service account to a ServiceAccount.
+func (a *ServiceAccount) ConvertFromSQLCServiceAccount(sqlcAccount *sqlc.ServiceAccount) *ServiceAccount {
+
+	a.ID = sqlcAccount.ID
+	a.Name = sqlcAccount.Name
+	if sqlcAccount.Description != nil {
+		a.Description = *sqlcAccount.Description
+	}
+	a.ClientID = sqlcAccount.ClientID
+	a.Scopes = sqlcAccount.Scopes
+	a.Disabled = sqlcAccount.Disabled
+	a.LastUsedAt = sqlcAccount.LastUsedAt.Time
+	a.CreatedAt = sqlcAccount.CreatedAt.Time
+	a.UpdatedAt = sqlcAccount.UpdatedAt.Time
+	a.SecretHash = sqlcAccount.SecretHash
+	if sqlcAccount.PreviousSecretHash != nil {
+		a.PreviousSecretHash = *sqlcAccount.PreviousSecretHash
+	}
+	a.PreviousSecretExpiresAt = sqlcAccount.PreviousSecretExpiresAt.Time
+
+	return a
+}
+
+// Principal is the user or service account a request was authenticated as.
+type Principal struct {
+
+	// Type is the subject type of the principal.
+	Type enum.SubjectType `json:"type"`
+
+	// ID is the ID of the user or service account.
+	ID uint64 `json:"id"`
+
+	// ClientID is the client the token was issued to, if any.
+	ClientID string `json:"client_id,omitempty"`
+
+	// Scopes are the scopes of the token, if it was issued to a client, or of the API key.
+	Scopes []string `json:"scopes,omitempty"`
+
+	// APIKeyID is the ID of the API key the user authenticated with, if any.
+	APIKeyID uint64 `json:"api_key_id,omitempty"`
+
+	// Audience is the service a delegated token is restricted to.
+	Audience string `json:"aud,omitempty"`
+
+	// Actor is the service acting for the user with a delegated token obtained by token exchange.
+	Actor *Actor `json:"act,omitempty"`
+
+	// ExpiresAt is when the token or API key expires. It is zero for API keys that do not expire.
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// IsUser reports whether the principal is a user.
+func (p *Principal) IsUser() bool {
+	return p.Type == enum.SubjectUser
+}
+
+// IsServiceAccount reports whether the principal is a service account.
+func (p *Principal) IsServiceAccount() bool {
+	return p.Type == enum.SubjectServiceAccount
+}
+
+// IsAPIKey reports whether the principal is a user who authenticated with an API key.
+func (p *Principal) IsAPIKey() bool {
+	return p.APIKeyID != 0
+}
+
+// IsDelegated reports whether the principal is a user on whose behalf a service acts with a token obtained by
+// token exchange.
+func (p *Principal) IsDelegated() bool {
+	return p.Actor != nil
+}
+
+// IsClient reports whether the principal is a user on whose behalf an OAuth client acts with a token issued by
+// the authorization server.
+func (p *Principal) IsClient() bool {
+	return p.IsUser() && p.ClientID != ""
+}
+
+// Restricted reports whether the permissions of the principal are limited to the RESOURCE:ACTION pairs of its
+// scopes, as for service accounts, API keys, delegated tokens and tokens issued to clients.
+func (p *Principal) Restricted() bool {
+	return p.IsServiceAccount() || p.IsAPIKey() || p.IsDelegated() || p.IsClient()
+}
@@ -44,65 +44,43 @@ type User struct {
 
 	// LastSignInAt is the last sign-in at time.
 	LastSignInAt time.Time `json:"last_sign_in_at"`
+
+	// DisabledAt is when the user was disabled, or nil for an active user.
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
 }
 
 // ConvertFromSQLCUser converts an SQLC user to a User.
-func (u *User) ConvertFromSQLCUser(sqlcUser any) *User {
-	type userFields struct {
-		ID           uint64
-		Username     string
-		PasswordHash string
-		Email        string
-		FirebaseUID  string
-		Provider     string
+func (u *User) ConvertFromSQLCUser(sqlcUser *sqlc.User) *User {
+
+	u.ID = sqlcUser.ID
+	u.Username = sqlcUser.Username
+	u.PasswordHash = sqlcUser.PasswordHash
+	u.Email = sqlcUser.Email
+	u.Phone = sqlcUser.Phone
+	u.Provider = string(sqlcUser.Provider)
+	u.CreatedAt = sqlcUser.CreatedAt.Time
+	u.UpdatedAt = sqlcUser.UpdatedAt.Time
+	u.LastSignInAt = sqlcUser.LastSignInAt.Time
+
+	if sqlcUser.DisabledAt.Valid {
+		disabledAt := sqlcUser.DisabledAt.Time
+		u.DisabledAt = &disabledAt
 	}
 
-	var fields userFields
-
-	switch sp := sqlcUser.(type) {
-	case *sqlc.FindUserByIDRow:
-		fields = userFields{
-			Username:     sp.Username,
-			PasswordHash: sp.PasswordHash,
-			Email:        sp.Email,
-		}
-	case *sqlc.FindUserByUsernameRow:
-		fields = userFields{
-			ID:           sp.ID,
-			PasswordHash: sp.PasswordHash,
-			Email:        sp.Email,
-		}
-	case *sqlc.FindUserByEmailRow:
-		fields = userFields{
-			ID:           sp.ID,
-			Username:     sp.Username,
-			PasswordHash: sp.PasswordHash,
-			FirebaseUID:  *sp.FirebaseUid,
-			Provider:     string(sp.Provider),
-		}
-	case *sqlc.ListUsersRow:
-		fields = userFields{
-			ID:       sp.ID,
-			Username: sp.Username,
-			Email:    sp.Email,
-		}
-	case *sqlc.FindUserByFirebaseUIDRow:
-		fields = userFields{
-			ID:           sp.ID,
-			Username:     sp.Username,
-			PasswordHash: sp.PasswordHash,
-			Email:        sp.Email,
-		}
-	default:
-		return nil
+	if sqlcUser.FirebaseUid != nil {
+		u.FirebaseUID = *sqlcUser.FirebaseUid
+	}
+	if sqlcUser.DisplayName != nil {
+		u.DisplayName = *sqlcUser.DisplayName
+	}
+	if sqlcUser.PhotoUrl != nil {
+		u.PhotoURL = *sqlcUser.PhotoUrl
 	}
-
-	u.ID = fields.ID
-	u.Username = fields.Username
-	u.Email = fields.Email
-	u.PasswordHash = fields.PasswordHash
-	u.FirebaseUID = fields.FirebaseUID
-	u.Provider = fields.Provider
 
 	return u
 }
+
+// IsDisabled reports whether the user was disabled and can no longer sign in.
+func (u *User) IsDisabled() bool {
+	return u.DisabledAt != nil
+}
@@ -0,0 +1,335 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/models"
+)
+
+const (
+	// DefaultDeviceCodeTTL is how long a device authorization can be approved and redeemed unless configured otherwise.
+	DefaultDeviceCodeTTL = 10 * time.Minute
+
+	// DefaultDevicePollInterval is the minimum time between two token requests of a device unless configured otherwise.
+	DefaultDevicePollInterval = 5 * time.Second
+
+	// slowDownIncrement is added to the poll interval of a device that polls too often (RFC 8628 section 3.5).
+	slowDownIncrement = 5 * time.Second
+
+	// userCodeAlphabet has no vowels, so user codes spell no words, and no characters that are easily confused.
+	userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+
+	// userCodeLength is the number of characters of a user code, about 34 bits of entropy.
+	userCodeLength = 8
+
+	// MaxUserCodeFailures is the number of invalid user codes a user may enter within UserCodeFailureWindow.
+	MaxUserCodeFailures = 5
+
+	// UserCodeFailureWindow is how long the invalid user codes a user entered count against them.
+	UserCodeFailureWindow = 15 * time.Minute
+
+	// maxTrackedUsers is the number of users with failures above which expired failures are dropped.
+	maxTrackedUsers = 10000
+)
+
+// Statuses of a device authorization.
+const (
+	deviceStatusPending  = "pending"
+	deviceStatusApproved = "approved"
+	deviceStatusDenied   = "denied"
+)
+
+var (
+	// ErrInvalidUserCode is returned for a user code that does not belong to a pending device authorization.
+	ErrInvalidUserCode = errors.New("invalid or expired user code")
+
+	// ErrTooManyUserCodeAttempts is returned to a user who entered too many invalid user codes.
+	ErrTooManyUserCodeAttempts = errors.New("too many invalid user codes, try again later")
+)
+
+// userCodeAttempts counts the invalid user codes each user entered, so that user codes, which are short enough to
+// type, cannot be guessed by trying many (RFC 8628 section 5.1). The counts are kept by each instance.
+type userCodeAttempts struct {
+	mu       sync.Mutex
+	failures map[uint64]*userCodeFailures
+}
+
+// userCodeFailures is the number of invalid user codes a user entered since the first of them.
+type userCodeFailures struct {
+	count int
+	since time.Time
+}
+
+// newUserCodeAttempts creates an empty userCodeAttempts.
+func newUserCodeAttempts() *userCodeAttempts {
+	return &userCodeAttempts{failures: make(map[uint64]*userCodeFailures)}
+}
+
+// allow reports whether a user may look up a user code at now.
+func (a *userCodeAttempts) allow(userID uint64, now time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	failures, ok := a.failures[userID]
+	return !ok || now.Sub(failures.since) >= UserCodeFailureWindow || failures.count < MaxUserCodeFailures
+}
+
+// fail records an invalid user code a user entered at now and returns the number of failures in the window.
+func (a *userCodeAttempts) fail(userID uint64, now time.Time) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.failures) >= maxTrackedUsers {
+		for id, failures := range a.failures {
+			if now.Sub(failures.since) >= UserCodeFailureWindow {
+				delete(a.failures, id)
+			}
+		}
+	}
+
+	failures, ok := a.failures[userID]
+	if !ok || now.Sub(failures.since) >= UserCodeFailureWindow {
+		failures = &userCodeFailures{since: now}
+		a.failures[userID] = failures
+	}
+	failures.count++
+	return failures.count
+}
+
+// AuthorizeDevice starts a device authorization (RFC 8628 section 3.1). The client authenticates like at the token
+// endpoint, so public clients such as CLIs only send their client ID.
+func (s *service) AuthorizeDevice(ctx context.Context, clientID, clientSecret, scope string) (*models.DeviceAuthorizationResponse, error) {
+	if s.verificationURL == "" {
+		return nil, newError(ErrorUnauthorizedClient, "the device authorization grant is not enabled")
+	}
+
+	client, err := s.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes, err := s.requestedScopes(client, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceCode, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	userCode, err := newUserCode()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.repository.CreateDeviceCode(ctx, &models.DeviceCode{
+		DeviceCodeHash: hashToken(deviceCode),
+		UserCode:       userCode,
+		ClientID:       client.ClientID,
+		Scopes:         scopes,
+		PollInterval:   s.pollInterval,
+		ExpiresAt:      time.Now().Add(s.deviceCodeTTL),
+	}); err != nil {
+		return nil, err
+	}
+
+	displayed := formatUserCode(userCode)
+	return &models.DeviceAuthorizationResponse{
+		DeviceCode:              deviceCode,
+		UserCode:                displayed,
+		VerificationURI:         s.verificationURL,
+		VerificationURIComplete: redirectURL(s.verificationURL, url.Values{"user_code": {displayed}}),
+		ExpiresIn:               int64(s.deviceCodeTTL / time.Second),
+		Interval:                int64(s.pollInterval / time.Second),
+	}, nil
+}
+
+// VerifyDevice looks up the pending device authorization of a user code, so that the verification page can show
+// the user which client asks for which scopes.
+func (s *service) VerifyDevice(ctx context.Context, userID uint64, userCode string) (*models.DeviceVerification, error) {
+	code, err := s.lookupUserCode(ctx, userID, userCode)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := s.repository.FindClient(ctx, code.ClientID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.DeviceVerification{
+		UserCode: formatUserCode(code.UserCode),
+		Client:   client,
+		Scopes:   code.Scopes,
+	}, nil
+}
+
+// DecideDevice records a signed-in user's answer to the device authorization of a user code. An approval is also
+// recorded as consent for the requested scopes.
+func (s *service) DecideDevice(ctx context.Context, userID uint64, userCode, consent string) error {
+	status := deviceStatusApproved
+	switch consent {
+	case ConsentApprove:
+	case ConsentDeny:
+		status = deviceStatusDenied
+	default:
+		return newError(ErrorInvalidRequest, "consent must be approve or deny")
+	}
+
+	code, err := s.lookupUserCode(ctx, userID, userCode)
+	if err != nil {
+		return err
+	}
+
+	if err = s.repository.DecideDeviceCode(ctx, code.UserCode, status, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidUserCode
+		}
+		return err
+	}
+
+	s.logger.Info("device authorization decided",
+		zap.String("client_id", code.ClientID), zap.Uint64("user_id", userID), zap.String("status", status))
+
+	if status == deviceStatusApproved {
+		return s.grantConsent(ctx, userID, code.ClientID, code.Scopes)
+	}
+	return nil
+}
+
+// pollDevice answers the token request of a device (RFC 8628 section 3.4). Until the user decides, the device is
+// told to keep polling; a device that polls faster than its interval is told to slow down, and its interval grows.
+func (s *service) pollDevice(ctx context.Context, client *models.OAuthClient, req *models.TokenRequest) (*models.TokenResponse, error) {
+	if req.DeviceCode == "" {
+		return nil, newError(ErrorInvalidRequest, "device_code is required")
+	}
+
+	deviceCodeHash := hashToken(req.DeviceCode)
+	code, err := s.repository.FindDeviceCode(ctx, deviceCodeHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, newError(ErrorInvalidGrant, "invalid device code")
+		}
+		return nil, err
+	}
+
+	switch {
+	case code.ClientID != client.ClientID:
+		return nil, newError(ErrorInvalidGrant, "the device code was issued to another client")
+	case time.Now().After(code.ExpiresAt):
+		return nil, newError(ErrorExpiredToken, "the device code has expired")
+	}
+
+	switch code.Status {
+	case deviceStatusApproved:
+		if err = s.repository.ConsumeDeviceCode(ctx, deviceCodeHash); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, newError(ErrorInvalidGrant, "the device code was already redeemed")
+			}
+			return nil, err
+		}
+		return s.issueTokens(ctx, client.ClientID, code.UserID, code.Scopes, "", "")
+	case deviceStatusDenied:
+		if err = s.repository.ConsumeDeviceCode(ctx, deviceCodeHash); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			s.logger.Warn("failed to remove denied device code", zap.Error(err))
+		}
+		return nil, newError(ErrorAccessDenied, "the user denied the request")
+	}
+
+	interval := code.PollInterval
+	tooSoon := !code.LastPolledAt.IsZero() && time.Since(code.LastPolledAt) < interval
+	if tooSoon {
+		interval += slowDownIncrement
+	}
+	if err = s.repository.RecordDeviceCodePoll(ctx, deviceCodeHash, interval); err != nil {
+		return nil, err
+	}
+
+	if tooSoon {
+		return nil, newError(ErrorSlowDown, fmt.Sprintf("poll at most every %d seconds", int64(interval/time.Second)))
+	}
+	return nil, newError(ErrorAuthorizationPending, "")
+}
+
+// pendingDeviceCode finds the device authorization of a user code as typed by the user, which must still be pending.
+func (s *service) pendingDeviceCode(ctx context.Context, userCode string) (*models.DeviceCode, error) {
+	normalized := normalizeUserCode(userCode)
+	if len(normalized) != userCodeLength {
+		return nil, ErrInvalidUserCode
+	}
+
+	code, err := s.repository.FindDeviceCodeByUserCode(ctx, normalized)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidUserCode
+		}
+		return nil, err
+	}
+	if code.Status != deviceStatusPending || time.Now().After(code.ExpiresAt) {
+		return nil, ErrInvalidUserCode
+	}
+
+	return code, nil
+}
+
+// lookupUserCode returns the pending device authorization of a user code a user entered. Invalid user codes are
+// logged and count against the user, who is refused further lookups after MaxUserCodeFailures of them.
+func (s *service) lookupUserCode(ctx context.Context, userID uint64, userCode string) (*models.DeviceCode, error) {
+	now := time.Now()
+	if !s.userCodes.allow(userID, now) {
+		s.logger.Warn("user code lookup refused after too many invalid user codes", zap.Uint64("user_id", userID))
+		return nil, ErrTooManyUserCodeAttempts
+	}
+
+	code, err := s.pendingDeviceCode(ctx, userCode)
+	if errors.Is(err, ErrInvalidUserCode) {
+		failures := s.userCodes.fail(userID, now)
+		s.logger.Warn("invalid user code entered", zap.Uint64("user_id", userID), zap.Int("failures", failures))
+	}
+	return code, err
+}
+
+// newUserCode returns a random user code without separators.
+func newUserCode() (string, error) {
+	base := big.NewInt(int64(len(userCodeAlphabet)))
+	code := make([]byte, userCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, base)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate user code: %w", err)
+		}
+		code[i] = userCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// formatUserCode splits a user code in two halves for display, e.g. WDJB-MJHT.
+func formatUserCode(code string) string {
+	if len(code) != userCodeLength {
+		return code
+	}
+	return code[:userCodeLength/2] + "-" + code[userCodeLength/2:]
+}
+
+// normalizeUserCode upper-cases a user code as typed by the user and drops separators and other characters that
+// cannot be part of it (RFC 8628 section 6.1).
+func normalizeUserCode(code string) string {
+	var b strings.Builder
+	for _, c := range strings.ToUpper(code) {
+		if strings.ContainsRune(userCodeAlphabet, c) {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
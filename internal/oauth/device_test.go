@@ -0,0 +1,33 @@
+package oauth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUserCodeAttempts(t *testing.T) {
+	attempts := newUserCodeAttempts()
+	now := time.Now()
+
+	for i := 1; i <= MaxUserCodeFailures; i++ {
+		if !attempts.allow(1, now) {
+			t.Fatalf("allow after %d failures = false, want true", i-1)
+		}
+		if got := attempts.fail(1, now); got != i {
+			t.Fatalf("fail = %d, want %d", got, i)
+		}
+	}
+
+	if attempts.allow(1, now) {
+		t.Error("allow after MaxUserCodeFailures failures = true, want false")
+	}
+	if !attempts.allow(2, now) {
+		t.Error("allow for another user = false, want true")
+	}
+	if !attempts.allow(1, now.Add(UserCodeFailureWindow)) {
+		t.Error("allow after the failure window = false, want true")
+	}
+	if got := attempts.fail(1, now.Add(UserCodeFailureWindow)); got != 1 {
+		t.Errorf("fail after the failure window = %d, want 1", got)
+	}
+}
@@ -0,0 +1,85 @@
+package oauth
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Error codes of RFC 6749 sections 4.1.2.1 and 5.2, of RFC 8628 section 3.5 and of RFC 8693 section 2.2.2.
+const (
+	ErrorInvalidRequest       = "invalid_request"
+	ErrorInvalidClient        = "invalid_client"
+	ErrorInvalidGrant         = "invalid_grant"
+	ErrorUnauthorizedClient   = "unauthorized_client"
+	ErrorUnsupportedGrantType = "unsupported_grant_type"
+	ErrorUnsupportedResponse  = "unsupported_response_type"
+	ErrorInvalidScope         = "invalid_scope"
+	ErrorAccessDenied         = "access_denied"
+	ErrorServerError          = "server_error"
+	ErrorAuthorizationPending = "authorization_pending"
+	ErrorSlowDown             = "slow_down"
+	ErrorExpiredToken         = "expired_token"
+	ErrorInvalidTarget        = "invalid_target"
+)
+
+// Error is an OAuth 2.0 error response.
+type Error struct {
+
+	// Code is the error code, e.g. invalid_grant.
+	Code string `json:"error"`
+
+	// Description is a human-readable explanation.
+	Description string `json:"error_description,omitempty"`
+
+	// Status is the HTTP status of the error at the token endpoint.
+	Status int `json:"-"`
+
+	// RedirectURI is set once the client and redirect URI of an authorization request were validated,
+	// meaning the error can be returned to the client by redirecting the user to it.
+	RedirectURI string `json:"-"`
+
+	// State is the state of the authorization request, returned along with the error.
+	State string `json:"-"`
+}
+
+// Error returns the error code and description.
+func (e *Error) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+// newError creates an error with the status the token endpoint answers it with.
+func newError(code, description string) *Error {
+	status := http.StatusBadRequest
+	switch code {
+	case ErrorInvalidClient:
+		status = http.StatusUnauthorized
+	case ErrorServerError:
+		status = http.StatusInternalServerError
+	}
+	return &Error{Code: code, Description: description, Status: status}
+}
+
+// redirectable marks an authorization error as one to send back to the client's redirect URI.
+func (e *Error) redirectable(req redirectTarget) *Error {
+	e.RedirectURI = req.redirectURI
+	e.State = req.state
+	return e
+}
+
+// redirectTarget is where the error of a validated authorization request is sent.
+type redirectTarget struct {
+	redirectURI string
+	state       string
+}
+
+// AsError returns err as an OAuth 2.0 error, reporting unexpected errors as server_error.
+func AsError(err error) *Error {
+	var oauthErr *Error
+	if errors.As(err, &oauthErr) {
+		return oauthErr
+	}
+	return newError(ErrorServerError, "")
+}
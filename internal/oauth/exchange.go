@@ -0,0 +1,130 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/models/enum"
+	"goflare.io/auth/internal/serviceaccount"
+)
+
+const (
+	// DefaultExchangeTokenTTL is the lifetime of the tokens issued by token exchange unless configured otherwise.
+	DefaultExchangeTokenTTL = 5 * time.Minute
+
+	// TokenTypeAccessToken is the token type identifier of access tokens (RFC 8693 section 3).
+	TokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+// exchangeToken answers a token exchange request (RFC 8693 section 2). A service account that received a user's
+// token trades it for a token that acts for the user at a single audience, with scopes narrowed to those the
+// subject token had and the service account may request. The new token records the service account as its actor,
+// and never outlives the subject token.
+//
+// A subject token that was itself exchanged can only be exchanged again by its audience, which then becomes the
+// actor, with the previous actor nested in the act claim.
+func (s *service) exchangeToken(ctx context.Context, req *models.TokenRequest) (*models.TokenResponse, error) {
+	account, err := s.accounts.Authenticate(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		if errors.Is(err, serviceaccount.ErrInvalidCredentials) {
+			return nil, newError(ErrorInvalidClient, "client authentication failed")
+		}
+		return nil, err
+	}
+
+	switch {
+	case req.SubjectToken == "" || req.SubjectTokenType == "":
+		return nil, newError(ErrorInvalidRequest, "subject_token and subject_token_type are required")
+	case req.SubjectTokenType != TokenTypeAccessToken:
+		return nil, newError(ErrorInvalidRequest, "subject_token_type must be "+TokenTypeAccessToken)
+	case req.RequestedTokenType != "" && req.RequestedTokenType != TokenTypeAccessToken:
+		return nil, newError(ErrorInvalidRequest, "requested_token_type must be "+TokenTypeAccessToken)
+	case req.ActorToken != "":
+		return nil, newError(ErrorInvalidRequest, "actor_token is not supported; the acting service authenticates as the client")
+	case req.Audience == "" || strings.ContainsAny(req.Audience, " \"\\"):
+		return nil, newError(ErrorInvalidTarget, "a single audience is required")
+	}
+
+	subject, err := s.tokens.ParseToken(req.SubjectToken)
+	if err != nil {
+		return nil, newError(ErrorInvalidGrant, "invalid subject token")
+	}
+	if (subject.SubjectType != "" && subject.SubjectType != enum.SubjectUser) || subject.UserID == 0 {
+		return nil, newError(ErrorInvalidGrant, "the subject token must be a user token")
+	}
+	if subject.Audience != "" && subject.Audience != account.ClientID {
+		return nil, newError(ErrorInvalidGrant, "the subject token was issued for another audience")
+	}
+
+	scopes, err := exchangedScopes(account, subject, req.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	expiration := s.exchangeTTL
+	if remaining := time.Until(subject.ExpiresAt); remaining < expiration {
+		expiration = remaining
+	}
+
+	actor := &models.Actor{Subject: account.ClientID, ServiceAccountID: account.ID, Actor: subject.Actor}
+	accessToken, err := s.tokens.GenerateDelegatedToken(subject.UserID, req.Audience, actor, scopes, expiration)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("token exchanged",
+		zap.String("client_id", account.ClientID),
+		zap.Uint64("user_id", subject.UserID),
+		zap.String("audience", req.Audience),
+		zap.Strings("scopes", scopes),
+	)
+
+	return &models.TokenResponse{
+		AccessToken:     accessToken.Token,
+		TokenType:       "Bearer",
+		ExpiresIn:       int64(expiration / time.Second),
+		Scope:           strings.Join(scopes, " "),
+		IssuedTokenType: TokenTypeAccessToken,
+	}, nil
+}
+
+// exchangedScopes returns the scopes of an exchanged token: the requested scopes, or by default every scope the
+// service account may request. They must be among the scopes of the service account and, when the subject token
+// was issued to a client, among the scopes of the subject token too. A token without scopes would carry no
+// permission, so it is refused.
+func exchangedScopes(account *models.ServiceAccount, subject *models.PASETOToken, scope string) ([]string, error) {
+	granted := func(scope string) bool {
+		return subject.ClientID == "" || slices.Contains(subject.Scopes, scope)
+	}
+
+	var scopes []string
+	if scope == "" {
+		for _, scope := range account.Scopes {
+			if granted(scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	} else {
+		scopes = ParseScope(scope)
+		for _, scope := range scopes {
+			if !slices.Contains(account.Scopes, scope) {
+				return nil, newError(ErrorInvalidScope, fmt.Sprintf("scope %s is not allowed for the service account", scope))
+			}
+			if !granted(scope) {
+				return nil, newError(ErrorInvalidScope, fmt.Sprintf("scope %s was not granted to the subject token", scope))
+			}
+		}
+	}
+
+	if len(scopes) == 0 {
+		return nil, newError(ErrorInvalidScope, "no scope can be delegated")
+	}
+	return scopes, nil
+}
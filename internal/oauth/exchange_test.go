@@ -0,0 +1,252 @@
+package oauth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/models/enum"
+	"goflare.io/auth/internal/serviceaccount"
+	"goflare.io/auth/internal/token"
+)
+
+func TestExchangedScopes(t *testing.T) {
+	account := &models.ServiceAccount{ClientID: "orders", Scopes: []string{"USER:READ", "ORDER:READ"}}
+
+	tests := []struct {
+		name     string
+		subject  *models.PASETOToken
+		scope    string
+		want     []string
+		wantCode string
+	}{
+		{
+			name:    "session token, default scopes",
+			subject: &models.PASETOToken{UserID: 1},
+			want:    []string{"USER:READ", "ORDER:READ"},
+		},
+		{
+			name:    "session token, requested scope",
+			subject: &models.PASETOToken{UserID: 1},
+			scope:   "ORDER:READ",
+			want:    []string{"ORDER:READ"},
+		},
+		{
+			name:     "scope the service account may not request",
+			subject:  &models.PASETOToken{UserID: 1},
+			scope:    "USER:DELETE",
+			wantCode: ErrorInvalidScope,
+		},
+		{
+			name:    "client token, default scopes narrowed to its scopes",
+			subject: &models.PASETOToken{UserID: 1, ClientID: "web", Scopes: []string{"USER:READ", "USER:UPDATE"}},
+			want:    []string{"USER:READ"},
+		},
+		{
+			name:     "client token, scope it was not granted",
+			subject:  &models.PASETOToken{UserID: 1, ClientID: "web", Scopes: []string{"USER:READ"}},
+			scope:    "ORDER:READ",
+			wantCode: ErrorInvalidScope,
+		},
+		{
+			name:     "client token sharing no scope",
+			subject:  &models.PASETOToken{UserID: 1, ClientID: "web", Scopes: []string{"USER:UPDATE"}},
+			wantCode: ErrorInvalidScope,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scopes, err := exchangedScopes(account, tt.subject, tt.scope)
+			if tt.wantCode != "" {
+				var oauthErr *Error
+				if !errors.As(err, &oauthErr) || oauthErr.Code != tt.wantCode {
+					t.Fatalf("exchangedScopes() error = %v, want %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("exchangedScopes failed: %v", err)
+			}
+			if !reflect.DeepEqual(scopes, tt.want) {
+				t.Errorf("exchangedScopes() = %v, want %v", scopes, tt.want)
+			}
+		})
+	}
+}
+
+// memoryAccounts is a service account service authenticating the accounts it holds by client ID and secret.
+type memoryAccounts struct {
+	serviceaccount.Service
+	accounts map[string]*models.ServiceAccount
+}
+
+func (a *memoryAccounts) Authenticate(_ context.Context, clientID, secret string) (*models.ServiceAccount, error) {
+	account, ok := a.accounts[clientID]
+	if !ok || secret != "secret" {
+		return nil, serviceaccount.ErrInvalidCredentials
+	}
+	return account, nil
+}
+
+func TestExchangeToken(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	tokens := token.NewPasetoManager(
+		base64.StdEncoding.EncodeToString(publicKey),
+		base64.StdEncoding.EncodeToString(privateKey),
+		time.Hour,
+	)
+
+	s := &service{
+		accounts: &memoryAccounts{accounts: map[string]*models.ServiceAccount{
+			"orders":   {ID: 2, ClientID: "orders", Scopes: []string{"USER:READ"}},
+			"billing":  {ID: 3, ClientID: "billing", Scopes: []string{"USER:READ"}},
+			"shipping": {ID: 4, ClientID: "shipping", Scopes: []string{"USER:READ"}},
+		}},
+		tokens:      tokens,
+		exchangeTTL: DefaultExchangeTokenTTL,
+		logger:      zap.NewNop(),
+	}
+
+	newToken := func(t *testing.T, generate func() (*models.PASETOToken, error)) string {
+		t.Helper()
+		generated, err := generate()
+		if err != nil {
+			t.Fatalf("failed to generate token: %v", err)
+		}
+		return generated.Token
+	}
+	userToken := newToken(t, func() (*models.PASETOToken, error) { return tokens.GenerateToken(1) })
+	shortToken := newToken(t, func() (*models.PASETOToken, error) { return tokens.GenerateScopedToken(1, "", nil, time.Minute) })
+	serviceToken := newToken(t, func() (*models.PASETOToken, error) {
+		return tokens.GenerateServiceAccountToken(3, "billing", []string{"USER:READ"}, time.Hour)
+	})
+	delegatedToken := newToken(t, func() (*models.PASETOToken, error) {
+		return tokens.GenerateDelegatedToken(1, "billing", &models.Actor{Subject: "orders", ServiceAccountID: 2}, []string{"USER:READ"}, time.Hour)
+	})
+
+	request := func(clientID, subjectToken, audience string) *models.TokenRequest {
+		return &models.TokenRequest{
+			ClientID:         clientID,
+			ClientSecret:     "secret",
+			SubjectToken:     subjectToken,
+			SubjectTokenType: TokenTypeAccessToken,
+			Audience:         audience,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		req       *models.TokenRequest
+		wantCode  string
+		wantActor *models.Actor
+		wantTTL   time.Duration
+	}{
+		{
+			name:      "user token",
+			req:       request("orders", userToken, "billing"),
+			wantActor: &models.Actor{Subject: "orders", ServiceAccountID: 2},
+			wantTTL:   DefaultExchangeTokenTTL,
+		},
+		{
+			name:      "not outliving the subject token",
+			req:       request("orders", shortToken, "billing"),
+			wantActor: &models.Actor{Subject: "orders", ServiceAccountID: 2},
+			wantTTL:   time.Minute,
+		},
+		{
+			name: "exchanged again by its audience",
+			req:  request("billing", delegatedToken, "shipping"),
+			wantActor: &models.Actor{Subject: "billing", ServiceAccountID: 3,
+				Actor: &models.Actor{Subject: "orders", ServiceAccountID: 2}},
+			wantTTL: DefaultExchangeTokenTTL,
+		},
+		{
+			name:     "exchanged again by another service",
+			req:      request("shipping", delegatedToken, "billing"),
+			wantCode: ErrorInvalidGrant,
+		},
+		{
+			name:     "service account token",
+			req:      request("orders", serviceToken, "billing"),
+			wantCode: ErrorInvalidGrant,
+		},
+		{
+			name:     "invalid subject token",
+			req:      request("orders", "v2.public.invalid", "billing"),
+			wantCode: ErrorInvalidGrant,
+		},
+		{
+			name:     "no audience",
+			req:      request("orders", userToken, ""),
+			wantCode: ErrorInvalidTarget,
+		},
+		{
+			name:     "several audiences",
+			req:      request("orders", userToken, "billing shipping"),
+			wantCode: ErrorInvalidTarget,
+		},
+		{
+			name:     "wrong client secret",
+			req:      &models.TokenRequest{ClientID: "orders", ClientSecret: "wrong", SubjectToken: userToken, SubjectTokenType: TokenTypeAccessToken, Audience: "billing"},
+			wantCode: ErrorInvalidClient,
+		},
+		{
+			name:     "other subject token type",
+			req:      &models.TokenRequest{ClientID: "orders", ClientSecret: "secret", SubjectToken: userToken, SubjectTokenType: "urn:ietf:params:oauth:token-type:id_token", Audience: "billing"},
+			wantCode: ErrorInvalidRequest,
+		},
+		{
+			name:     "actor token",
+			req:      &models.TokenRequest{ClientID: "orders", ClientSecret: "secret", SubjectToken: userToken, SubjectTokenType: TokenTypeAccessToken, ActorToken: userToken, Audience: "billing"},
+			wantCode: ErrorInvalidRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := s.exchangeToken(context.Background(), tt.req)
+			if tt.wantCode != "" {
+				var oauthErr *Error
+				if !errors.As(err, &oauthErr) || oauthErr.Code != tt.wantCode {
+					t.Fatalf("exchangeToken() error = %v, want %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("exchangeToken failed: %v", err)
+			}
+
+			claims, err := tokens.ParseToken(response.AccessToken)
+			if err != nil {
+				t.Fatalf("failed to parse the exchanged token: %v", err)
+			}
+			if claims.UserID != 1 || claims.SubjectType == enum.SubjectServiceAccount {
+				t.Errorf("exchanged token is for %s %d, want user 1", claims.SubjectType, claims.UserID)
+			}
+			if claims.Audience != tt.req.Audience {
+				t.Errorf("aud = %s, want %s", claims.Audience, tt.req.Audience)
+			}
+			if !reflect.DeepEqual(claims.Actor, tt.wantActor) {
+				t.Errorf("act = %+v, want %+v", claims.Actor, tt.wantActor)
+			}
+			if ttl := time.Until(claims.ExpiresAt); ttl > tt.wantTTL || ttl < tt.wantTTL-10*time.Second {
+				t.Errorf("exchanged token expires in %v, want %v", ttl, tt.wantTTL)
+			}
+			if response.IssuedTokenType != TokenTypeAccessToken {
+				t.Errorf("issued_token_type = %s, want %s", response.IssuedTokenType, TokenTypeAccessToken)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/config"
+	"goflare.io/auth/internal/models"
+)
+
+const (
+	// ScopeOpenID marks an OpenID Connect authentication request, for which an ID token is issued.
+	ScopeOpenID = "openid"
+
+	// ScopeProfile releases the name, preferred_username, picture and updated_at claims.
+	ScopeProfile = "profile"
+
+	// ScopeEmail releases the email claim.
+	ScopeEmail = "email"
+
+	// DefaultIDTokenTTL is the lifetime of ID tokens unless configured otherwise.
+	DefaultIDTokenTTL = time.Hour
+
+	// minSigningKeyBits is the smallest RSA key accepted for signing ID tokens.
+	minSigningKeyBits = 2048
+)
+
+// OIDCScopes are the OpenID Connect scopes every client may request once OpenID Connect is enabled.
+var OIDCScopes = []string{ScopeOpenID, ScopeProfile, ScopeEmail}
+
+// ErrInsufficientScope is returned by the userinfo endpoint for an access token without the openid scope.
+var ErrInsufficientScope = errors.New("the access token was not granted the openid scope")
+
+// IDTokenClaims are the claims of an ID token (OpenID Connect Core 1.0 section 2).
+type IDTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce             string `json:"nonce,omitempty"`
+	AuthorizedParty   string `json:"azp,omitempty"`
+	AccessTokenHash   string `json:"at_hash,omitempty"`
+	Name              string `json:"name,omitempty"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	Picture           string `json:"picture,omitempty"`
+	UpdatedAt         int64  `json:"updated_at,omitempty"`
+	Email             string `json:"email,omitempty"`
+}
+
+// Signer signs ID tokens with an RSA key and publishes its public half. A signer without an issuer is disabled.
+type Signer struct {
+	issuer string
+	key    *rsa.PrivateKey
+	keyID  string
+	ttl    time.Duration
+}
+
+// ProvideSigner creates the ID token signer from the configuration. OpenID Connect is disabled when no issuer
+// is configured; without a signing key file a key is generated for the lifetime of the process.
+func ProvideSigner(cfg *config.Config, logger *zap.Logger) (*Signer, error) {
+	if cfg.OAuthServer.Issuer == "" {
+		logger.Info("OpenID Connect is disabled: no issuer is configured")
+		return &Signer{}, nil
+	}
+
+	var key *rsa.PrivateKey
+	var err error
+	if cfg.OAuthServer.SigningKeyFile != "" {
+		if key, err = loadSigningKey(cfg.OAuthServer.SigningKeyFile); err != nil {
+			return nil, err
+		}
+	} else {
+		logger.Warn("no ID token signing key is configured, generating one; ID tokens will not verify after a restart")
+		if key, err = rsa.GenerateKey(rand.Reader, minSigningKeyBits); err != nil {
+			return nil, fmt.Errorf("failed to generate ID token signing key: %w", err)
+		}
+	}
+
+	return NewSigner(cfg.OAuthServer.Issuer, key, cfg.OAuthServer.IDTokenTTL)
+}
+
+// NewSigner creates an ID token signer for an issuer. The key ID is the JWK thumbprint of the key (RFC 7638).
+func NewSigner(issuer string, key *rsa.PrivateKey, ttl time.Duration) (*Signer, error) {
+	issuer = strings.TrimSuffix(issuer, "/")
+	u, err := url.Parse(issuer)
+	if err != nil || !u.IsAbs() || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("issuer %q must be an absolute URL without query or fragment", issuer)
+	}
+	if key == nil || key.N.BitLen() < minSigningKeyBits {
+		return nil, fmt.Errorf("the ID token signing key must be an RSA key of at least %d bits", minSigningKeyBits)
+	}
+	if ttl <= 0 {
+		ttl = DefaultIDTokenTTL
+	}
+
+	thumbprint, err := json.Marshal(struct {
+		E   string `json:"e"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+	}{E: encodeExponent(key.E), Kty: "RSA", N: base64.RawURLEncoding.EncodeToString(key.N.Bytes())})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute key ID: %w", err)
+	}
+	sum := sha256.Sum256(thumbprint)
+
+	return &Signer{
+		issuer: issuer,
+		key:    key,
+		keyID:  base64.RawURLEncoding.EncodeToString(sum[:]),
+		ttl:    ttl,
+	}, nil
+}
+
+// Enabled reports whether OpenID Connect is enabled.
+func (s *Signer) Enabled() bool {
+	return s.key != nil
+}
+
+// Issuer returns the issuer identifier.
+func (s *Signer) Issuer() string {
+	return s.issuer
+}
+
+// KeySet returns the public signing key as a JSON Web Key Set.
+func (s *Signer) KeySet() *models.JSONWebKeySet {
+	if s.key == nil {
+		return &models.JSONWebKeySet{Keys: []models.JSONWebKey{}}
+	}
+	return &models.JSONWebKeySet{Keys: []models.JSONWebKey{{
+		KeyType:   "RSA",
+		Use:       "sig",
+		Algorithm: jwt.SigningMethodRS256.Alg(),
+		KeyID:     s.keyID,
+		N:         base64.RawURLEncoding.EncodeToString(s.key.N.Bytes()),
+		E:         encodeExponent(s.key.E),
+	}}}
+}
+
+// Discovery returns the OpenID Provider metadata of the issuer.
+func (s *Signer) Discovery() *models.DiscoveryDocument {
+	return &models.DiscoveryDocument{
+		Issuer:                            s.issuer,
+		AuthorizationEndpoint:             s.issuer + "/oauth2/authorize",
+		TokenEndpoint:                     s.issuer + "/oauth2/token",
+		DeviceAuthorizationEndpoint:       s.issuer + "/oauth2/device_authorization",
+		UserinfoEndpoint:                  s.issuer + "/oauth2/userinfo",
+		JWKSURI:                           s.issuer + "/.well-known/jwks.json",
+		ScopesSupported:                   OIDCScopes,
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               GrantTypes,
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{jwt.SigningMethodRS256.Alg()},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ClaimsSupported: []string{
+			"iss", "sub", "aud", "exp", "iat", "nonce", "azp", "at_hash",
+			"name", "preferred_username", "picture", "updated_at", "email",
+		},
+	}
+}
+
+// SignIDToken signs an ID token for a client. The claims about the user come from info, which must already be
+// limited to the granted scopes; at_hash binds the token to the access token issued with it.
+func (s *Signer) SignIDToken(clientID string, info *models.UserInfo, nonce, accessToken string) (string, error) {
+	if s.key == nil {
+		return "", errors.New("OpenID Connect is not enabled")
+	}
+
+	now := time.Now()
+	accessTokenSum := sha256.Sum256([]byte(accessToken))
+	claims := &IDTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.issuer,
+			Subject:   info.Subject,
+			Audience:  jwt.ClaimStrings{clientID},
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+		Nonce:             nonce,
+		AuthorizedParty:   clientID,
+		AccessTokenHash:   base64.RawURLEncoding.EncodeToString(accessTokenSum[:len(accessTokenSum)/2]),
+		Name:              info.Name,
+		PreferredUsername: info.PreferredUsername,
+		Picture:           info.Picture,
+		UpdatedAt:         info.UpdatedAt,
+		Email:             info.Email,
+	}
+
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	idToken.Header["kid"] = s.keyID
+	signed, err := idToken.SignedString(s.key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign ID token: %w", err)
+	}
+	return signed, nil
+}
+
+// userInfo returns the claims about a user released for the granted scopes.
+func userInfo(user *models.User, scopes []string) *models.UserInfo {
+	info := &models.UserInfo{Subject: strconv.FormatUint(user.ID, 10)}
+	if slices.Contains(scopes, ScopeProfile) {
+		info.Name = user.DisplayName
+		info.PreferredUsername = user.Username
+		info.Picture = user.PhotoURL
+		if !user.UpdatedAt.IsZero() {
+			info.UpdatedAt = user.UpdatedAt.Unix()
+		}
+	}
+	if slices.Contains(scopes, ScopeEmail) {
+		info.Email = user.Email
+	}
+	return info
+}
+
+// loadSigningKey reads a PEM encoded RSA private key in PKCS #1 or PKCS #8 form.
+func loadSigningKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ID token signing key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("ID token signing key %s is not PEM encoded", path)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ID token signing key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("ID token signing key %s is not an RSA key", path)
+	}
+	return key, nil
+}
+
+// encodeExponent encodes an RSA public exponent as a base64url big-endian integer.
+func encodeExponent(e int) string {
+	return base64.RawURLEncoding.EncodeToString(big.NewInt(int64(e)).Bytes())
+}
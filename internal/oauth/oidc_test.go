@@ -0,0 +1,160 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"goflare.io/auth/internal/models"
+)
+
+func newTestSigner(t *testing.T) *Signer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, minSigningKeyBits)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	signer, err := NewSigner("https://auth.example.com/", key, time.Minute)
+	if err != nil {
+		t.Fatalf("NewSigner failed: %v", err)
+	}
+	return signer
+}
+
+func TestNewSigner(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, minSigningKeyBits)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	weakKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		issuer  string
+		key     *rsa.PrivateKey
+		wantErr bool
+	}{
+		{"valid", "https://auth.example.com", key, false},
+		{"path", "https://example.com/auth/", key, false},
+		{"relative issuer", "/auth", key, true},
+		{"issuer with query", "https://auth.example.com?tenant=1", key, true},
+		{"issuer with fragment", "https://auth.example.com#top", key, true},
+		{"no key", "https://auth.example.com", nil, true},
+		{"weak key", "https://auth.example.com", weakKey, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSigner(tt.issuer, tt.key, 0)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewSigner() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSignIDToken(t *testing.T) {
+	signer := newTestSigner(t)
+	if issuer := signer.Issuer(); issuer != "https://auth.example.com" {
+		t.Errorf("Issuer() = %s, want it without trailing slash", issuer)
+	}
+
+	info := &models.UserInfo{Subject: "1", Name: "Alice", Email: "alice@example.com"}
+	signed, err := signer.SignIDToken("client", info, "nonce", "access")
+	if err != nil {
+		t.Fatalf("SignIDToken failed: %v", err)
+	}
+
+	// Verify the token with the published key set, as a relying party would.
+	keys := signer.KeySet().Keys
+	if len(keys) != 1 {
+		t.Fatalf("KeySet() has %d keys, want 1", len(keys))
+	}
+	jwk := keys[0]
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		t.Fatalf("failed to decode modulus: %v", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil {
+		t.Fatalf("failed to decode exponent: %v", err)
+	}
+	publicKey := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+
+	claims := &IDTokenClaims{}
+	token, err := jwt.ParseWithClaims(signed, claims, func(token *jwt.Token) (any, error) {
+		if token.Header["kid"] != jwk.KeyID {
+			t.Errorf("kid = %v, want %s", token.Header["kid"], jwk.KeyID)
+		}
+		return publicKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}))
+	if err != nil || !token.Valid {
+		t.Fatalf("ID token does not verify with the key set: %v", err)
+	}
+
+	accessTokenSum := sha256.Sum256([]byte("access"))
+	if claims.Issuer != signer.Issuer() {
+		t.Errorf("iss = %s, want %s", claims.Issuer, signer.Issuer())
+	}
+	if !reflect.DeepEqual(claims.Audience, jwt.ClaimStrings{"client"}) || claims.AuthorizedParty != "client" {
+		t.Errorf("aud = %v, azp = %s, want the client", claims.Audience, claims.AuthorizedParty)
+	}
+	if claims.Subject != "1" || claims.Nonce != "nonce" {
+		t.Errorf("sub = %s, nonce = %s, want 1 and nonce", claims.Subject, claims.Nonce)
+	}
+	if claims.AccessTokenHash != base64.RawURLEncoding.EncodeToString(accessTokenSum[:16]) {
+		t.Errorf("at_hash = %s, want the left half of the SHA-256 of the access token", claims.AccessTokenHash)
+	}
+	if claims.Name != "Alice" || claims.Email != "alice@example.com" {
+		t.Errorf("name = %s, email = %s, want the claims of info", claims.Name, claims.Email)
+	}
+	if lifetime := claims.ExpiresAt.Sub(claims.IssuedAt.Time); lifetime != time.Minute {
+		t.Errorf("lifetime = %v, want %v", lifetime, time.Minute)
+	}
+
+	if _, err = (&Signer{}).SignIDToken("client", info, "", "access"); err == nil {
+		t.Error("SignIDToken signed without a key")
+	}
+}
+
+func TestUserInfo(t *testing.T) {
+	user := &models.User{
+		ID:          1,
+		Username:    "alice",
+		DisplayName: "Alice",
+		PhotoURL:    "https://example.com/alice.png",
+		Email:       "alice@example.com",
+		UpdatedAt:   time.Unix(1700000000, 0),
+	}
+
+	tests := []struct {
+		name   string
+		scopes []string
+		want   *models.UserInfo
+	}{
+		{"openid", []string{ScopeOpenID}, &models.UserInfo{Subject: "1"}},
+		{"profile", []string{ScopeOpenID, ScopeProfile}, &models.UserInfo{
+			Subject: "1", Name: "Alice", PreferredUsername: "alice", Picture: user.PhotoURL, UpdatedAt: 1700000000,
+		}},
+		{"email", []string{ScopeOpenID, ScopeEmail}, &models.UserInfo{Subject: "1", Email: "alice@example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := userInfo(user, tt.scopes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("userInfo() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
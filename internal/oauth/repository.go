@@ -0,0 +1,439 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/sqlc"
+	"goflare.io/nexus/driver"
+)
+
+// ErrTokenReused is returned when a refresh token that was already used or revoked is presented again.
+var ErrTokenReused = errors.New("refresh token was already used")
+
+// _ is a type assertion to ensure that the repository implements the Repository interface.
+var _ Repository = (*repository)(nil)
+
+// Repository is the interface for the OAuth 2.0 authorization server repository.
+type Repository interface {
+
+	// CreateClient registers a client.
+	CreateClient(ctx context.Context, client *models.OAuthClient) (uint64, error)
+
+	// FindClient finds a client by its client ID.
+	FindClient(ctx context.Context, clientID string) (*models.OAuthClient, error)
+
+	// ListClients lists every registered client.
+	ListClients(ctx context.Context) ([]*models.OAuthClient, error)
+
+	// DeleteClient removes a client with its codes, consents and refresh tokens.
+	DeleteClient(ctx context.Context, clientID string) error
+
+	// CreateAuthorizationCode stores an authorization code.
+	CreateAuthorizationCode(ctx context.Context, code *models.AuthorizationCode) error
+
+	// ConsumeAuthorizationCode removes an authorization code and returns it, so that it can be redeemed only once.
+	ConsumeAuthorizationCode(ctx context.Context, codeHash string) (*models.AuthorizationCode, error)
+
+	// FindConsent finds the consent a user gave a client.
+	FindConsent(ctx context.Context, userID uint64, clientID string) (*models.OAuthConsent, error)
+
+	// ListConsents lists the consents a user gave.
+	ListConsents(ctx context.Context, userID uint64) ([]*models.OAuthConsent, error)
+
+	// SaveConsent records the scopes a user allowed a client.
+	SaveConsent(ctx context.Context, consent *models.OAuthConsent) error
+
+	// DeleteConsent removes the consent a user gave a client and revokes the client's refresh tokens for the user.
+	DeleteConsent(ctx context.Context, userID uint64, clientID string) error
+
+	// CreateRefreshToken stores a refresh token.
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+
+	// FindRefreshToken finds a refresh token by its hash.
+	FindRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+
+	// RotateRefreshToken revokes a refresh token and stores its replacement in one transaction.
+	// It returns ErrTokenReused if the token was already revoked.
+	RotateRefreshToken(ctx context.Context, tokenHash string, replacement *models.RefreshToken) error
+
+	// RevokeRefreshTokens revokes every refresh token of a client for a user.
+	RevokeRefreshTokens(ctx context.Context, userID uint64, clientID string) error
+
+	// CreateDeviceCode stores a pending device authorization.
+	CreateDeviceCode(ctx context.Context, code *models.DeviceCode) error
+
+	// FindDeviceCode finds a device authorization by the hash of its device code.
+	FindDeviceCode(ctx context.Context, deviceCodeHash string) (*models.DeviceCode, error)
+
+	// FindDeviceCodeByUserCode finds a device authorization by its user code.
+	FindDeviceCodeByUserCode(ctx context.Context, userCode string) (*models.DeviceCode, error)
+
+	// DecideDeviceCode records the user's answer to a pending, unexpired device authorization.
+	DecideDeviceCode(ctx context.Context, userCode, status string, userID uint64) error
+
+	// RecordDeviceCodePoll records a token request for a device authorization and its new poll interval.
+	RecordDeviceCodePoll(ctx context.Context, deviceCodeHash string, interval time.Duration) error
+
+	// ConsumeDeviceCode removes a device authorization, so that it can be redeemed only once.
+	ConsumeDeviceCode(ctx context.Context, deviceCodeHash string) error
+}
+
+// repository is the implementation of the Repository interface.
+type repository struct {
+	conn   driver.PostgresPool
+	logger *zap.Logger
+}
+
+// NewRepository creates a new repository.
+func NewRepository(conn driver.PostgresPool, logger *zap.Logger) Repository {
+	return &repository{
+		conn:   conn,
+		logger: logger,
+	}
+}
+
+// CreateClient registers a client. Public clients are stored without a secret hash.
+func (r *repository) CreateClient(ctx context.Context, client *models.OAuthClient) (uint64, error) {
+	var secretHash *string
+	if !client.Public {
+		secretHash = &client.SecretHash
+	}
+
+	id, err := sqlc.New(r.conn).CreateOAuthClient(ctx, sqlc.CreateOAuthClientParams{
+		ClientID:     client.ClientID,
+		SecretHash:   secretHash,
+		Name:         client.Name,
+		RedirectUris: client.RedirectURIs,
+		Scopes:       client.Scopes,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create OAuth client: %w", err)
+	}
+
+	return id, nil
+}
+
+// FindClient finds a client by its client ID.
+func (r *repository) FindClient(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	sqlcClient, err := sqlc.New(r.conn).GetOAuthClient(ctx, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OAuth client %s: %w", clientID, err)
+	}
+
+	return new(models.OAuthClient).ConvertFromSQLCOAuthClient(sqlcClient), nil
+}
+
+// ListClients lists every registered client.
+func (r *repository) ListClients(ctx context.Context) ([]*models.OAuthClient, error) {
+	sqlcClients, err := sqlc.New(r.conn).ListOAuthClients(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list OAuth clients: %w", err)
+	}
+
+	clients := make([]*models.OAuthClient, 0, len(sqlcClients))
+	for _, sqlcClient := range sqlcClients {
+		clients = append(clients, new(models.OAuthClient).ConvertFromSQLCOAuthClient(sqlcClient))
+	}
+
+	return clients, nil
+}
+
+// DeleteClient removes a client. Its codes, consents and refresh tokens are removed by the foreign keys.
+func (r *repository) DeleteClient(ctx context.Context, clientID string) error {
+	removed, err := sqlc.New(r.conn).DeleteOAuthClient(ctx, clientID)
+	if err != nil {
+		return fmt.Errorf("failed to delete OAuth client %s: %w", clientID, err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("no OAuth client %s: %w", clientID, pgx.ErrNoRows)
+	}
+
+	return nil
+}
+
+// CreateAuthorizationCode stores an authorization code.
+func (r *repository) CreateAuthorizationCode(ctx context.Context, code *models.AuthorizationCode) error {
+	if err := sqlc.New(r.conn).CreateAuthorizationCode(ctx, sqlc.CreateAuthorizationCodeParams{
+		CodeHash:      code.CodeHash,
+		ClientID:      code.ClientID,
+		UserID:        code.UserID,
+		RedirectUri:   code.RedirectURI,
+		Scopes:        code.Scopes,
+		CodeChallenge: code.CodeChallenge,
+		ExpiresAt:     pgtype.Timestamptz{Time: code.ExpiresAt, Valid: true},
+		Nonce:         code.Nonce,
+	}); err != nil {
+		return fmt.Errorf("failed to create authorization code: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumeAuthorizationCode removes an authorization code and returns it. Expired codes are removed on the way.
+func (r *repository) ConsumeAuthorizationCode(ctx context.Context, codeHash string) (*models.AuthorizationCode, error) {
+	queries := sqlc.New(r.conn)
+
+	if err := queries.DeleteExpiredAuthorizationCodes(ctx); err != nil {
+		r.logger.Warn("failed to delete expired authorization codes", zap.Error(err))
+	}
+
+	sqlcCode, err := queries.ConsumeAuthorizationCode(ctx, codeHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume authorization code: %w", err)
+	}
+
+	return new(models.AuthorizationCode).ConvertFromSQLCAuthorizationCode(sqlcCode), nil
+}
+
+// FindConsent finds the consent a user gave a client.
+func (r *repository) FindConsent(ctx context.Context, userID uint64, clientID string) (*models.OAuthConsent, error) {
+	sqlcConsent, err := sqlc.New(r.conn).GetOAuthConsent(ctx, sqlc.GetOAuthConsentParams{
+		UserID:   userID,
+		ClientID: clientID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OAuth consent: %w", err)
+	}
+
+	return new(models.OAuthConsent).ConvertFromSQLCOAuthConsent(sqlcConsent), nil
+}
+
+// ListConsents lists the consents a user gave.
+func (r *repository) ListConsents(ctx context.Context, userID uint64) ([]*models.OAuthConsent, error) {
+	sqlcConsents, err := sqlc.New(r.conn).ListOAuthConsents(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list OAuth consents: %w", err)
+	}
+
+	consents := make([]*models.OAuthConsent, 0, len(sqlcConsents))
+	for _, sqlcConsent := range sqlcConsents {
+		consents = append(consents, new(models.OAuthConsent).ConvertFromSQLCOAuthConsent(sqlcConsent))
+	}
+
+	return consents, nil
+}
+
+// SaveConsent records the scopes a user allowed a client, replacing an earlier consent.
+func (r *repository) SaveConsent(ctx context.Context, consent *models.OAuthConsent) error {
+	if err := sqlc.New(r.conn).UpsertOAuthConsent(ctx, sqlc.UpsertOAuthConsentParams{
+		UserID:   consent.UserID,
+		ClientID: consent.ClientID,
+		Scopes:   consent.Scopes,
+	}); err != nil {
+		return fmt.Errorf("failed to save OAuth consent: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteConsent removes the consent a user gave a client and revokes the client's refresh tokens for the user
+// in one transaction.
+func (r *repository) DeleteConsent(ctx context.Context, userID uint64, clientID string) (err error) {
+	tx, err := r.conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				r.logger.Error("failed to rollback transaction", zap.Error(rbErr))
+			}
+		}
+	}()
+
+	queries := sqlc.New(r.conn).WithTx(tx)
+	removed, err := queries.DeleteOAuthConsent(ctx, sqlc.DeleteOAuthConsentParams{
+		UserID:   userID,
+		ClientID: clientID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete OAuth consent: %w", err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("no consent given to %s: %w", clientID, pgx.ErrNoRows)
+	}
+
+	if err = queries.RevokeRefreshTokens(ctx, sqlc.RevokeRefreshTokensParams{
+		UserID:   userID,
+		ClientID: clientID,
+	}); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// CreateRefreshToken stores a refresh token.
+func (r *repository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	if err := sqlc.New(r.conn).CreateRefreshToken(ctx, refreshTokenParams(token)); err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// FindRefreshToken finds a refresh token by its hash.
+func (r *repository) FindRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	sqlcToken, err := sqlc.New(r.conn).GetRefreshToken(ctx, tokenHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	return new(models.RefreshToken).ConvertFromSQLCRefreshToken(sqlcToken), nil
+}
+
+// RotateRefreshToken revokes a refresh token and stores its replacement in one transaction. The revocation only
+// succeeds for a token that is still active, so of two concurrent requests with the same token only one succeeds.
+func (r *repository) RotateRefreshToken(ctx context.Context, tokenHash string, replacement *models.RefreshToken) (err error) {
+	tx, err := r.conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				r.logger.Error("failed to rollback transaction", zap.Error(rbErr))
+			}
+		}
+	}()
+
+	queries := sqlc.New(r.conn).WithTx(tx)
+	revoked, err := queries.RevokeRefreshToken(ctx, tokenHash)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	if revoked == 0 {
+		return ErrTokenReused
+	}
+
+	if err = queries.CreateRefreshToken(ctx, refreshTokenParams(replacement)); err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeRefreshTokens revokes every refresh token of a client for a user.
+func (r *repository) RevokeRefreshTokens(ctx context.Context, userID uint64, clientID string) error {
+	if err := sqlc.New(r.conn).RevokeRefreshTokens(ctx, sqlc.RevokeRefreshTokensParams{
+		UserID:   userID,
+		ClientID: clientID,
+	}); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	return nil
+}
+
+// CreateDeviceCode stores a pending device authorization. Expired ones are removed on the way.
+func (r *repository) CreateDeviceCode(ctx context.Context, code *models.DeviceCode) error {
+	queries := sqlc.New(r.conn)
+
+	if err := queries.DeleteExpiredDeviceCodes(ctx); err != nil {
+		r.logger.Warn("failed to delete expired device codes", zap.Error(err))
+	}
+
+	if err := queries.CreateDeviceCode(ctx, sqlc.CreateDeviceCodeParams{
+		DeviceCodeHash: code.DeviceCodeHash,
+		UserCode:       code.UserCode,
+		ClientID:       code.ClientID,
+		Scopes:         code.Scopes,
+		PollInterval:   int32(code.PollInterval / time.Second),
+		ExpiresAt:      pgtype.Timestamptz{Time: code.ExpiresAt, Valid: true},
+	}); err != nil {
+		return fmt.Errorf("failed to create device code: %w", err)
+	}
+
+	return nil
+}
+
+// FindDeviceCode finds a device authorization by the hash of its device code.
+func (r *repository) FindDeviceCode(ctx context.Context, deviceCodeHash string) (*models.DeviceCode, error) {
+	sqlcCode, err := sqlc.New(r.conn).GetDeviceCode(ctx, deviceCodeHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device code: %w", err)
+	}
+
+	return new(models.DeviceCode).ConvertFromSQLCDeviceCode(sqlcCode), nil
+}
+
+// FindDeviceCodeByUserCode finds a device authorization by its user code.
+func (r *repository) FindDeviceCodeByUserCode(ctx context.Context, userCode string) (*models.DeviceCode, error) {
+	sqlcCode, err := sqlc.New(r.conn).GetDeviceCodeByUserCode(ctx, userCode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device code: %w", err)
+	}
+
+	return new(models.DeviceCode).ConvertFromSQLCDeviceCode(sqlcCode), nil
+}
+
+// DecideDeviceCode records the user's answer to a device authorization. Only a pending, unexpired authorization
+// can be decided, so a user code cannot be approved twice.
+func (r *repository) DecideDeviceCode(ctx context.Context, userCode, status string, userID uint64) error {
+	approvedBy := int32(userID)
+	decided, err := sqlc.New(r.conn).DecideDeviceCode(ctx, sqlc.DecideDeviceCodeParams{
+		UserCode:   userCode,
+		Status:     status,
+		ApprovedBy: &approvedBy,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to decide device code: %w", err)
+	}
+	if decided == 0 {
+		return fmt.Errorf("no pending device code %s: %w", userCode, pgx.ErrNoRows)
+	}
+
+	return nil
+}
+
+// RecordDeviceCodePoll records a token request for a device authorization and its new poll interval.
+func (r *repository) RecordDeviceCodePoll(ctx context.Context, deviceCodeHash string, interval time.Duration) error {
+	if err := sqlc.New(r.conn).RecordDeviceCodePoll(ctx, sqlc.RecordDeviceCodePollParams{
+		DeviceCodeHash: deviceCodeHash,
+		PollInterval:   int32(interval / time.Second),
+	}); err != nil {
+		return fmt.Errorf("failed to record device code poll: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumeDeviceCode removes a device authorization. Of two concurrent token requests only one removes it.
+func (r *repository) ConsumeDeviceCode(ctx context.Context, deviceCodeHash string) error {
+	removed, err := sqlc.New(r.conn).DeleteDeviceCode(ctx, deviceCodeHash)
+	if err != nil {
+		return fmt.Errorf("failed to consume device code: %w", err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("device code already redeemed: %w", pgx.ErrNoRows)
+	}
+
+	return nil
+}
+
+// refreshTokenParams converts a refresh token to the parameters of its insert.
+func refreshTokenParams(token *models.RefreshToken) sqlc.CreateRefreshTokenParams {
+	return sqlc.CreateRefreshTokenParams{
+		TokenHash: token.TokenHash,
+		ClientID:  token.ClientID,
+		UserID:    token.UserID,
+		Scopes:    token.Scopes,
+		ExpiresAt: pgtype.Timestamptz{Time: token.ExpiresAt, Valid: true},
+	}
+}
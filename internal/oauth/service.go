@@ -0,0 +1,764 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+
+	"goflare.io/auth/internal/audit"
+	"goflare.io/auth/internal/config"
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/serviceaccount"
+	"goflare.io/auth/internal/token"
+	"goflare.io/auth/internal/user"
+)
+
+const (
+	// DefaultCodeTTL is how long an authorization code can be redeemed unless configured otherwise.
+	DefaultCodeTTL = 5 * time.Minute
+
+	// DefaultAccessTokenTTL is the lifetime of the access tokens issued to clients unless configured otherwise.
+	DefaultAccessTokenTTL = time.Hour
+
+	// DefaultRefreshTokenTTL is the lifetime of the refresh tokens issued to clients unless configured otherwise.
+	DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+const (
+	// GrantTypeAuthorizationCode is the authorization code grant of RFC 6749 section 4.1.
+	GrantTypeAuthorizationCode = "authorization_code"
+
+	// GrantTypeRefreshToken is the refresh token grant of RFC 6749 section 6.
+	GrantTypeRefreshToken = "refresh_token"
+
+	// GrantTypeClientCredentials is the client credentials grant of RFC 6749 section 4.4, used by service accounts.
+	GrantTypeClientCredentials = "client_credentials"
+
+	// GrantTypeDeviceCode is the device authorization grant of RFC 8628, used by CLIs and devices without a browser.
+	GrantTypeDeviceCode = "urn:ietf:params:oauth:grant-type:device_code"
+
+	// GrantTypeTokenExchange is the token exchange grant of RFC 8693, used by services acting for users.
+	GrantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+
+	// ConsentApprove is the answer of a user who approves the requested scopes.
+	ConsentApprove = "approve"
+
+	// ConsentDeny is the answer of a user who denies the request.
+	ConsentDeny = "deny"
+)
+
+// GrantTypes are the grant types the token endpoint supports.
+var GrantTypes = []string{
+	GrantTypeAuthorizationCode,
+	GrantTypeRefreshToken,
+	GrantTypeClientCredentials,
+	GrantTypeDeviceCode,
+	GrantTypeTokenExchange,
+}
+
+// _ is used to ensure that *service implements the Service interface at compile time.
+var _ Service = (*service)(nil)
+
+// Service is the OAuth 2.0 authorization server: registered clients obtain tokens for users with the
+// authorization code grant and mandatory PKCE, and renew them with rotating refresh tokens. Service accounts
+// obtain tokens for themselves with the client credentials grant, and down-scoped tokens for the users they act for
+// with the token exchange grant. Clients without a browser obtain tokens for users with the device authorization
+// grant. When OpenID Connect is enabled, requests with the openid scope also get an ID token.
+type Service interface {
+
+	// RegisterClient registers a client and returns its secret, which is not stored and cannot be retrieved again.
+	// Public clients get no secret.
+	RegisterClient(ctx context.Context, client *models.OAuthClient) (string, error)
+
+	// ListClients lists every registered client.
+	ListClients(ctx context.Context) ([]*models.OAuthClient, error)
+
+	// DeleteClient removes a client with its consents and tokens.
+	DeleteClient(ctx context.Context, clientID string) error
+
+	// ValidateAuthorizationRequest checks an authorization request before the user is asked to sign in.
+	ValidateAuthorizationRequest(ctx context.Context, req *models.AuthorizationRequest) (*models.OAuthClient, []string, error)
+
+	// Authorize answers an authorization request for a signed-in user, asking for consent when the user
+	// has not yet allowed the requested scopes.
+	Authorize(ctx context.Context, userID uint64, req *models.AuthorizationRequest) (*models.AuthorizationResult, error)
+
+	// Token answers a token request.
+	Token(ctx context.Context, req *models.TokenRequest) (*models.TokenResponse, error)
+
+	// AuthorizeDevice starts a device authorization for a client, returning the codes the device shows and polls with.
+	AuthorizeDevice(ctx context.Context, clientID, clientSecret, scope string) (*models.DeviceAuthorizationResponse, error)
+
+	// VerifyDevice looks up the pending device authorization of a user code for the verification page.
+	VerifyDevice(ctx context.Context, userID uint64, userCode string) (*models.DeviceVerification, error)
+
+	// DecideDevice records a signed-in user's approval or denial of the device authorization of a user code.
+	DecideDevice(ctx context.Context, userID uint64, userCode, consent string) error
+
+	// ListConsents lists the consents a user gave.
+	ListConsents(ctx context.Context, userID uint64) ([]*models.OAuthConsent, error)
+
+	// RevokeConsent removes the consent a user gave a client and revokes the client's refresh tokens for the user.
+	RevokeConsent(ctx context.Context, userID uint64, clientID string) error
+
+	// UserInfo returns the claims about the user of an access token granted the openid scope.
+	UserInfo(ctx context.Context, principal *models.Principal) (*models.UserInfo, error)
+}
+
+// service is the implementation of the Service interface.
+type service struct {
+	repository      Repository
+	accounts        serviceaccount.Service
+	users           user.Repository
+	tokens          token.Manager
+	signer          *Signer
+	codeTTL         time.Duration
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+	serviceTokenTTL time.Duration
+	exchangeTTL     time.Duration
+	verificationURL string
+	deviceCodeTTL   time.Duration
+	pollInterval    time.Duration
+	userCodes       *userCodeAttempts
+	audit           audit.Service
+	logger          *zap.Logger
+}
+
+// NewService creates a new OAuth 2.0 authorization server with the lifetimes from the configuration.
+func NewService(
+	repository Repository,
+	accounts serviceaccount.Service,
+	users user.Repository,
+	tokens token.Manager,
+	signer *Signer,
+	cfg *config.Config,
+	audit audit.Service,
+	logger *zap.Logger,
+) Service {
+	s := &service{
+		repository:      repository,
+		accounts:        accounts,
+		users:           users,
+		tokens:          tokens,
+		signer:          signer,
+		codeTTL:         cfg.OAuthServer.CodeTTL,
+		accessTokenTTL:  cfg.OAuthServer.AccessTokenTTL,
+		refreshTokenTTL: cfg.OAuthServer.RefreshTokenTTL,
+		serviceTokenTTL: cfg.ServiceAccounts.TokenTTL,
+		exchangeTTL:     cfg.OAuthServer.ExchangeTokenTTL,
+		verificationURL: cfg.OAuthServer.VerificationURL,
+		deviceCodeTTL:   cfg.OAuthServer.DeviceCodeTTL,
+		pollInterval:    cfg.OAuthServer.DevicePollInterval,
+		userCodes:       newUserCodeAttempts(),
+		audit:           audit,
+		logger:          logger,
+	}
+	if s.codeTTL <= 0 {
+		s.codeTTL = DefaultCodeTTL
+	}
+	if s.accessTokenTTL <= 0 {
+		s.accessTokenTTL = DefaultAccessTokenTTL
+	}
+	if s.refreshTokenTTL <= 0 {
+		s.refreshTokenTTL = DefaultRefreshTokenTTL
+	}
+	if s.serviceTokenTTL <= 0 {
+		s.serviceTokenTTL = DefaultAccessTokenTTL
+	}
+	if s.exchangeTTL <= 0 {
+		s.exchangeTTL = DefaultExchangeTokenTTL
+	}
+	if s.deviceCodeTTL <= 0 {
+		s.deviceCodeTTL = DefaultDeviceCodeTTL
+	}
+	if s.pollInterval < time.Second {
+		s.pollInterval = DefaultDevicePollInterval
+	}
+	return s
+}
+
+// RegisterClient registers a client. Redirect URIs must be absolute without a fragment, and use https
+// unless they point at the loopback interface.
+func (s *service) RegisterClient(ctx context.Context, client *models.OAuthClient) (secret string, err error) {
+	defer func() {
+		s.recordClientChange(ctx, audit.ActionOAuthClientRegister, client.ClientID, nil, client, err)
+	}()
+
+	client.Name = strings.TrimSpace(client.Name)
+	if client.Name == "" {
+		return "", errors.New("name is required")
+	}
+	if len(client.RedirectURIs) == 0 {
+		return "", errors.New("at least one redirect URI is required")
+	}
+	for _, redirectURI := range client.RedirectURIs {
+		if err = ValidateRedirectURI(redirectURI); err != nil {
+			return "", err
+		}
+	}
+	for _, scope := range client.Scopes {
+		if scope == "" || strings.ContainsAny(scope, " \"\\") {
+			return "", fmt.Errorf("invalid scope %q", scope)
+		}
+	}
+
+	clientID, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+	client.ClientID = clientID
+
+	if !client.Public {
+		if secret, err = randomToken(32); err != nil {
+			return "", err
+		}
+		secretHash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+		if err != nil {
+			return "", fmt.Errorf("failed to hash client secret: %w", err)
+		}
+		client.SecretHash = string(secretHash)
+	}
+
+	if client.ID, err = s.repository.CreateClient(ctx, client); err != nil {
+		return "", err
+	}
+
+	s.logger.Info("registered OAuth client", zap.String("client_id", client.ClientID), zap.String("name", client.Name))
+	return secret, nil
+}
+
+// ListClients lists every registered client.
+func (s *service) ListClients(ctx context.Context) ([]*models.OAuthClient, error) {
+	return s.repository.ListClients(ctx)
+}
+
+// DeleteClient removes a client with its consents and tokens.
+func (s *service) DeleteClient(ctx context.Context, clientID string) error {
+	err := s.repository.DeleteClient(ctx, clientID)
+	s.recordClientChange(ctx, audit.ActionOAuthClientDelete, clientID, nil, nil, err)
+	return err
+}
+
+// ValidateAuthorizationRequest checks the client and redirect URI of an authorization request first. Errors about
+// them carry no redirect URI, since the user must not be sent to an unverified URI; later errors do.
+func (s *service) ValidateAuthorizationRequest(ctx context.Context, req *models.AuthorizationRequest) (*models.OAuthClient, []string, error) {
+	if req.ClientID == "" {
+		return nil, nil, newError(ErrorInvalidRequest, "client_id is required")
+	}
+
+	client, err := s.repository.FindClient(ctx, req.ClientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, newError(ErrorInvalidClient, "unknown client")
+		}
+		return nil, nil, err
+	}
+
+	if req.RedirectURI == "" || !slices.Contains(client.RedirectURIs, req.RedirectURI) {
+		return nil, nil, newError(ErrorInvalidRequest, "redirect_uri is not registered for the client")
+	}
+
+	target := redirectTarget{redirectURI: req.RedirectURI, state: req.State}
+	if req.ResponseType != "code" {
+		return nil, nil, newError(ErrorUnsupportedResponse, "response_type must be code").redirectable(target)
+	}
+	if req.CodeChallengeMethod != "S256" || !validPKCEValue(req.CodeChallenge) {
+		return nil, nil, newError(ErrorInvalidRequest, "a code_challenge with code_challenge_method S256 is required").redirectable(target)
+	}
+
+	scopes, err := s.requestedScopes(client, req.Scope)
+	if err != nil {
+		return nil, nil, AsError(err).redirectable(target)
+	}
+
+	return client, scopes, nil
+}
+
+// Authorize answers an authorization request for a signed-in user. Without an earlier consent covering the
+// requested scopes the user is asked for one; an approval is recorded and a denial is sent back to the client.
+func (s *service) Authorize(ctx context.Context, userID uint64, req *models.AuthorizationRequest) (*models.AuthorizationResult, error) {
+	client, scopes, err := s.ValidateAuthorizationRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	target := redirectTarget{redirectURI: req.RedirectURI, state: req.State}
+
+	switch req.Consent {
+	case ConsentDeny:
+		return nil, newError(ErrorAccessDenied, "the user denied the request").redirectable(target)
+	case ConsentApprove:
+		if err = s.grantConsent(ctx, userID, client.ClientID, scopes); err != nil {
+			return nil, err
+		}
+	case "":
+		consented, err := s.hasConsent(ctx, userID, client.ClientID, scopes)
+		if err != nil {
+			return nil, err
+		}
+		if !consented {
+			return &models.AuthorizationResult{ConsentRequired: true, Client: client, Scopes: scopes}, nil
+		}
+	default:
+		return nil, newError(ErrorInvalidRequest, "consent must be approve or deny").redirectable(target)
+	}
+
+	code, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.repository.CreateAuthorizationCode(ctx, &models.AuthorizationCode{
+		CodeHash:      hashToken(code),
+		ClientID:      client.ClientID,
+		UserID:        userID,
+		RedirectURI:   req.RedirectURI,
+		Scopes:        scopes,
+		CodeChallenge: req.CodeChallenge,
+		ExpiresAt:     time.Now().Add(s.codeTTL),
+		Nonce:         req.Nonce,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &models.AuthorizationResult{RedirectTo: redirectURL(req.RedirectURI, url.Values{
+		"code":  {code},
+		"state": {req.State},
+	})}, nil
+}
+
+// Token answers a token request after authenticating the client, or the service account of a client
+// credentials or token exchange grant.
+func (s *service) Token(ctx context.Context, req *models.TokenRequest) (*models.TokenResponse, error) {
+	switch req.GrantType {
+	case GrantTypeClientCredentials:
+		return s.clientCredentials(ctx, req)
+	case GrantTypeTokenExchange:
+		return s.exchangeToken(ctx, req)
+	}
+
+	client, err := s.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.GrantType {
+	case GrantTypeAuthorizationCode:
+		return s.exchangeCode(ctx, client, req)
+	case GrantTypeRefreshToken:
+		return s.refresh(ctx, client, req)
+	case GrantTypeDeviceCode:
+		return s.pollDevice(ctx, client, req)
+	case "":
+		return nil, newError(ErrorInvalidRequest, "grant_type is required")
+	default:
+		return nil, newError(ErrorUnsupportedGrantType, "")
+	}
+}
+
+// ListConsents lists the consents a user gave.
+func (s *service) ListConsents(ctx context.Context, userID uint64) ([]*models.OAuthConsent, error) {
+	return s.repository.ListConsents(ctx, userID)
+}
+
+// RevokeConsent removes the consent a user gave a client and revokes the client's refresh tokens for the user.
+// Access tokens already issued remain valid until they expire.
+func (s *service) RevokeConsent(ctx context.Context, userID uint64, clientID string) error {
+	err := s.repository.DeleteConsent(ctx, userID, clientID)
+	s.recordClientChange(ctx, audit.ActionConsentRevoke, clientID, map[string]uint64{"user_id": userID}, nil, err)
+	return err
+}
+
+// recordClientChange records a change of a client by the actor in ctx. The secret is never part of its state.
+func (s *service) recordClientChange(ctx context.Context, action, clientID string, before, after any, err error) {
+	event := &models.AuditEvent{
+		Action:     action,
+		TargetType: audit.TargetOAuthClient,
+		TargetID:   clientID,
+		Before:     audit.State(before),
+		After:      audit.State(after),
+	}
+	event.Outcome, event.Reason = audit.Outcome(err)
+	s.audit.Record(ctx, event)
+}
+
+// UserInfo returns the claims released for the scopes of the access token. Only tokens issued to a client for a
+// user with the openid scope are accepted.
+func (s *service) UserInfo(ctx context.Context, principal *models.Principal) (*models.UserInfo, error) {
+	if !s.signer.Enabled() || !principal.IsUser() || !slices.Contains(principal.Scopes, ScopeOpenID) {
+		return nil, ErrInsufficientScope
+	}
+
+	u, err := s.users.FindUserByID(ctx, principal.ID)
+	if err != nil {
+		return nil, err
+	}
+	return userInfo(u, principal.Scopes), nil
+}
+
+// exchangeCode redeems an authorization code. The code is removed before it is checked, so a code presented
+// twice fails the second time even if the first attempt failed.
+func (s *service) exchangeCode(ctx context.Context, client *models.OAuthClient, req *models.TokenRequest) (*models.TokenResponse, error) {
+	if req.Code == "" || req.CodeVerifier == "" {
+		return nil, newError(ErrorInvalidRequest, "code and code_verifier are required")
+	}
+
+	code, err := s.repository.ConsumeAuthorizationCode(ctx, hashToken(req.Code))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, newError(ErrorInvalidGrant, "invalid or expired authorization code")
+		}
+		return nil, err
+	}
+
+	switch {
+	case time.Now().After(code.ExpiresAt):
+		return nil, newError(ErrorInvalidGrant, "invalid or expired authorization code")
+	case code.ClientID != client.ClientID:
+		return nil, newError(ErrorInvalidGrant, "the authorization code was issued to another client")
+	case code.RedirectURI != req.RedirectURI:
+		return nil, newError(ErrorInvalidGrant, "redirect_uri does not match the authorization request")
+	case !verifyPKCE(req.CodeVerifier, code.CodeChallenge):
+		return nil, newError(ErrorInvalidGrant, "code_verifier does not match the code_challenge")
+	}
+
+	return s.issueTokens(ctx, client.ClientID, code.UserID, code.Scopes, code.Nonce, "")
+}
+
+// refresh redeems a refresh token for new tokens, rotating the refresh token. A token that was already used
+// indicates that it leaked, so every refresh token of the client for the user is revoked.
+func (s *service) refresh(ctx context.Context, client *models.OAuthClient, req *models.TokenRequest) (*models.TokenResponse, error) {
+	if req.RefreshToken == "" {
+		return nil, newError(ErrorInvalidRequest, "refresh_token is required")
+	}
+
+	tokenHash := hashToken(req.RefreshToken)
+	refreshToken, err := s.repository.FindRefreshToken(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, newError(ErrorInvalidGrant, "invalid refresh token")
+		}
+		return nil, err
+	}
+	if refreshToken.ClientID != client.ClientID {
+		return nil, newError(ErrorInvalidGrant, "the refresh token was issued to another client")
+	}
+	if refreshToken.Revoked {
+		s.revokeReused(ctx, refreshToken)
+		return nil, newError(ErrorInvalidGrant, "the refresh token was already used")
+	}
+	if time.Now().After(refreshToken.ExpiresAt) {
+		return nil, newError(ErrorInvalidGrant, "the refresh token has expired")
+	}
+
+	scopes := refreshToken.Scopes
+	if req.Scope != "" {
+		scopes = ParseScope(req.Scope)
+		for _, scope := range scopes {
+			if !slices.Contains(refreshToken.Scopes, scope) {
+				return nil, newError(ErrorInvalidScope, fmt.Sprintf("scope %s was not granted", scope))
+			}
+		}
+	}
+
+	response, err := s.issueTokens(ctx, client.ClientID, refreshToken.UserID, scopes, "", tokenHash)
+	if errors.Is(err, ErrTokenReused) {
+		s.revokeReused(ctx, refreshToken)
+		return nil, newError(ErrorInvalidGrant, "the refresh token was already used")
+	}
+	return response, err
+}
+
+// clientCredentials issues a service account token. No refresh token is issued, since the service account
+// can always authenticate again (RFC 6749 section 4.4.3).
+func (s *service) clientCredentials(ctx context.Context, req *models.TokenRequest) (*models.TokenResponse, error) {
+	account, err := s.accounts.Authenticate(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		if errors.Is(err, serviceaccount.ErrInvalidCredentials) {
+			return nil, newError(ErrorInvalidClient, "client authentication failed")
+		}
+		return nil, err
+	}
+
+	scopes := account.Scopes
+	if req.Scope != "" {
+		scopes = ParseScope(req.Scope)
+		for _, scope := range scopes {
+			if !slices.Contains(account.Scopes, scope) {
+				return nil, newError(ErrorInvalidScope, fmt.Sprintf("scope %s is not allowed for the service account", scope))
+			}
+		}
+	}
+
+	accessToken, err := s.tokens.GenerateServiceAccountToken(account.ID, account.ClientID, scopes, s.serviceTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenResponse{
+		AccessToken: accessToken.Token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.serviceTokenTTL / time.Second),
+		Scope:       strings.Join(scopes, " "),
+	}, nil
+}
+
+// revokeReused revokes every refresh token of the client for the user after a refresh token was reused.
+func (s *service) revokeReused(ctx context.Context, refreshToken *models.RefreshToken) {
+	s.logger.Warn("refresh token reused, revoking the client's tokens",
+		zap.String("client_id", refreshToken.ClientID), zap.Uint64("user_id", refreshToken.UserID))
+
+	if err := s.repository.RevokeRefreshTokens(ctx, refreshToken.UserID, refreshToken.ClientID); err != nil {
+		s.logger.Error("failed to revoke refresh tokens", zap.Error(err))
+	}
+}
+
+// issueTokens issues an access token and a refresh token, and an ID token with the nonce when the openid scope
+// was granted. When replacing is set, the refresh token with that hash is revoked in the same transaction as the
+// new one is stored.
+func (s *service) issueTokens(ctx context.Context, clientID string, userID uint64, scopes []string, nonce, replacing string) (*models.TokenResponse, error) {
+	accessToken, err := s.tokens.GenerateScopedToken(userID, clientID, scopes, s.accessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	var idToken string
+	if s.signer.Enabled() && slices.Contains(scopes, ScopeOpenID) {
+		u, err := s.users.FindUserByID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if idToken, err = s.signer.SignIDToken(clientID, userInfo(u, scopes), nonce, accessToken.Token); err != nil {
+			return nil, err
+		}
+	}
+
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	stored := &models.RefreshToken{
+		TokenHash: hashToken(refreshToken),
+		ClientID:  clientID,
+		UserID:    userID,
+		Scopes:    scopes,
+		ExpiresAt: time.Now().Add(s.refreshTokenTTL),
+	}
+
+	if replacing == "" {
+		err = s.repository.CreateRefreshToken(ctx, stored)
+	} else {
+		err = s.repository.RotateRefreshToken(ctx, replacing, stored)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenResponse{
+		AccessToken:  accessToken.Token,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.accessTokenTTL / time.Second),
+		RefreshToken: refreshToken,
+		Scope:        strings.Join(scopes, " "),
+		IDToken:      idToken,
+	}, nil
+}
+
+// authenticateClient finds the client of a token request and checks its secret. Public clients have no secret
+// and are bound to their codes by PKCE instead.
+func (s *service) authenticateClient(ctx context.Context, clientID, secret string) (*models.OAuthClient, error) {
+	if clientID == "" {
+		return nil, newError(ErrorInvalidClient, "client authentication is required")
+	}
+
+	client, err := s.repository.FindClient(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, newError(ErrorInvalidClient, "client authentication failed")
+		}
+		return nil, err
+	}
+
+	if client.Public {
+		if secret != "" {
+			return nil, newError(ErrorInvalidClient, "public clients have no secret")
+		}
+		return client, nil
+	}
+	if secret == "" || bcrypt.CompareHashAndPassword([]byte(client.SecretHash), []byte(secret)) != nil {
+		return nil, newError(ErrorInvalidClient, "client authentication failed")
+	}
+
+	return client, nil
+}
+
+// requestedScopes returns the scopes of a scope parameter, or every scope of the client when it is empty.
+// The OpenID Connect scopes are allowed for every client once OpenID Connect is enabled.
+func (s *service) requestedScopes(client *models.OAuthClient, scope string) ([]string, error) {
+	if scope == "" {
+		return client.Scopes, nil
+	}
+
+	scopes := ParseScope(scope)
+	for _, scope := range scopes {
+		if !slices.Contains(client.Scopes, scope) && !(s.signer.Enabled() && slices.Contains(OIDCScopes, scope)) {
+			return nil, newError(ErrorInvalidScope, fmt.Sprintf("scope %s is not allowed for the client", scope))
+		}
+	}
+	return scopes, nil
+}
+
+// hasConsent reports whether the user already allowed the client every requested scope.
+func (s *service) hasConsent(ctx context.Context, userID uint64, clientID string, scopes []string) (bool, error) {
+	consent, err := s.repository.FindConsent(ctx, userID, clientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(consent.Scopes, scope) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// grantConsent records the approved scopes together with the ones the user allowed before.
+func (s *service) grantConsent(ctx context.Context, userID uint64, clientID string, scopes []string) error {
+	granted := slices.Clone(scopes)
+
+	consent, err := s.repository.FindConsent(ctx, userID, clientID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if consent != nil {
+		for _, scope := range consent.Scopes {
+			if !slices.Contains(granted, scope) {
+				granted = append(granted, scope)
+			}
+		}
+	}
+
+	return s.repository.SaveConsent(ctx, &models.OAuthConsent{UserID: userID, ClientID: clientID, Scopes: granted})
+}
+
+// ValidateRedirectURI checks that a redirect URI is absolute, has no fragment, and uses https unless it points
+// at the loopback interface (RFC 8252 section 7.3).
+func ValidateRedirectURI(redirectURI string) error {
+	u, err := url.Parse(redirectURI)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		return fmt.Errorf("redirect URI %q must be an absolute URL", redirectURI)
+	}
+	if u.Fragment != "" || strings.Contains(redirectURI, "#") {
+		return fmt.Errorf("redirect URI %q must not have a fragment", redirectURI)
+	}
+
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		host := u.Hostname()
+		if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+			return nil
+		}
+		return fmt.Errorf("redirect URI %q must use https", redirectURI)
+	default:
+		return fmt.Errorf("redirect URI %q must use https", redirectURI)
+	}
+}
+
+// ParseScope splits a space-separated scope parameter, dropping duplicates.
+func ParseScope(scope string) []string {
+	var scopes []string
+	for _, s := range strings.Fields(scope) {
+		if !slices.Contains(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
+
+// RedirectError returns the URL that sends an authorization error back to the client.
+func RedirectError(e *Error) string {
+	query := url.Values{"error": {e.Code}}
+	if e.Description != "" {
+		query.Set("error_description", e.Description)
+	}
+	if e.State != "" {
+		query.Set("state", e.State)
+	}
+	return redirectURL(e.RedirectURI, query)
+}
+
+// redirectURL adds the non-empty parameters to the query of a redirect URI.
+func redirectURL(redirectURI string, params url.Values) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+
+	query := u.Query()
+	for key, values := range params {
+		if len(values) > 0 && values[0] != "" {
+			query.Set(key, values[0])
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// verifyPKCE checks an S256 code verifier against the code challenge (RFC 7636 section 4.6).
+func verifyPKCE(verifier, challenge string) bool {
+	if !validPKCEValue(verifier) {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
+
+// validPKCEValue reports whether a code verifier or S256 challenge has 43 to 128 unreserved characters.
+func validPKCEValue(value string) bool {
+	if len(value) < 43 || len(value) > 128 {
+		return false
+	}
+	for _, c := range value {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '.', c == '_', c == '~':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// randomToken returns n random bytes encoded as unpadded base64url.
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hex SHA-256 hash under which a code or refresh token is stored.
+func hashToken(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
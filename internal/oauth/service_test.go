@@ -0,0 +1,193 @@
+package oauth
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/audit"
+	"goflare.io/auth/internal/models"
+)
+
+// The code verifier and challenge of RFC 7636, Appendix B.
+const (
+	rfcVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	rfcChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+func TestVerifyPKCE(t *testing.T) {
+	tests := []struct {
+		name      string
+		verifier  string
+		challenge string
+		want      bool
+	}{
+		{"RFC 7636 example", rfcVerifier, rfcChallenge, true},
+		{"wrong verifier", strings.Replace(rfcVerifier, "d", "e", 1), rfcChallenge, false},
+		{"plain challenge", rfcVerifier, rfcVerifier, false},
+		{"padded challenge", rfcVerifier, rfcChallenge + "=", false},
+		{"short verifier", "abc", "ungWv48Bz-pBQUDeXa4iI7ADYaOWF3qctBD_YfIAFa0", false},
+		{"empty", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyPKCE(tt.verifier, tt.challenge); got != tt.want {
+				t.Errorf("verifyPKCE(%q, %q) = %v, want %v", tt.verifier, tt.challenge, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidPKCEValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  bool
+	}{
+		{"43 characters", strings.Repeat("a", 43), true},
+		{"128 characters", strings.Repeat("a", 128), true},
+		{"unreserved characters", strings.Repeat("Az09-._~", 6), true},
+		{"42 characters", strings.Repeat("a", 42), false},
+		{"129 characters", strings.Repeat("a", 129), false},
+		{"plus sign", strings.Repeat("a", 42) + "+", false},
+		{"slash", strings.Repeat("a", 42) + "/", false},
+		{"padding", strings.Repeat("a", 42) + "=", false},
+		{"non-ASCII", strings.Repeat("a", 42) + "é", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validPKCEValue(tt.value); got != tt.want {
+				t.Errorf("validPKCEValue(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRedirectURI(t *testing.T) {
+	tests := []struct {
+		redirectURI string
+		wantErr     bool
+	}{
+		{"https://app.example.com/callback", false},
+		{"https://app.example.com:8443/callback?tenant=1", false},
+		{"http://localhost:3000/callback", false},
+		{"http://127.0.0.1:3000/callback", false},
+		{"http://[::1]:3000/callback", false},
+		{"http://app.example.com/callback", true},
+		{"https://app.example.com/callback#fragment", true},
+		{"https://app.example.com/callback#", true},
+		{"/callback", true},
+		{"https:///callback", true},
+		{"javascript:alert(1)", true},
+		{"com.example.app:/callback", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateRedirectURI(tt.redirectURI)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateRedirectURI(%q) error = %v, wantErr %v", tt.redirectURI, err, tt.wantErr)
+		}
+	}
+}
+
+// memoryClients is a repository of clients held in memory.
+type memoryClients struct {
+	Repository
+	clients map[string]*models.OAuthClient
+}
+
+func (r *memoryClients) CreateClient(_ context.Context, client *models.OAuthClient) (uint64, error) {
+	r.clients[client.ClientID] = client
+	return uint64(len(r.clients)), nil
+}
+
+func (r *memoryClients) DeleteClient(_ context.Context, clientID string) error {
+	if _, ok := r.clients[clientID]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(r.clients, clientID)
+	return nil
+}
+
+// recordingAudit is an audit service keeping the recorded events.
+type recordingAudit struct {
+	audit.Service
+	events []*models.AuditEvent
+}
+
+func (a *recordingAudit) Record(_ context.Context, event *models.AuditEvent) {
+	a.events = append(a.events, event)
+}
+
+func TestClientAudit(t *testing.T) {
+	tests := []struct {
+		name       string
+		change     func(ctx context.Context, s *service) error
+		wantAction string
+		wantFailed bool
+	}{
+		{
+			name: "register",
+			change: func(ctx context.Context, s *service) error {
+				_, err := s.RegisterClient(ctx, &models.OAuthClient{Name: "dashboard", RedirectURIs: []string{"https://app.example.com/callback"}})
+				return err
+			},
+			wantAction: audit.ActionOAuthClientRegister,
+		},
+		{
+			name: "register with an invalid redirect URI",
+			change: func(ctx context.Context, s *service) error {
+				_, err := s.RegisterClient(ctx, &models.OAuthClient{Name: "dashboard", RedirectURIs: []string{"http://app.example.com/callback"}})
+				return err
+			},
+			wantAction: audit.ActionOAuthClientRegister,
+			wantFailed: true,
+		},
+		{
+			name:       "delete",
+			change:     func(ctx context.Context, s *service) error { return s.DeleteClient(ctx, "existing") },
+			wantAction: audit.ActionOAuthClientDelete,
+		},
+		{
+			name:       "delete of an unknown client",
+			change:     func(ctx context.Context, s *service) error { return s.DeleteClient(ctx, "unknown") },
+			wantAction: audit.ActionOAuthClientDelete,
+			wantFailed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &recordingAudit{}
+			s := &service{
+				repository: &memoryClients{clients: map[string]*models.OAuthClient{"existing": {ClientID: "existing"}}},
+				audit:      recorder,
+				logger:     zap.NewNop(),
+			}
+
+			if err := tt.change(context.Background(), s); (err != nil) != tt.wantFailed {
+				t.Fatalf("change error = %v, wantFailed %v", err, tt.wantFailed)
+			}
+			if len(recorder.events) != 1 {
+				t.Fatalf("recorded %d events, want 1", len(recorder.events))
+			}
+
+			event := recorder.events[0]
+			if event.Action != tt.wantAction || event.TargetType != audit.TargetOAuthClient {
+				t.Errorf("event = %s on %s, want %s on %s", event.Action, event.TargetType, tt.wantAction, audit.TargetOAuthClient)
+			}
+			if failed := event.Outcome == models.AuditFailure; failed != tt.wantFailed {
+				t.Errorf("event outcome = %s, wantFailed %v", event.Outcome, tt.wantFailed)
+			}
+			if strings.Contains(string(event.After), "$2a$") {
+				t.Errorf("event state %s contains the secret hash", event.After)
+			}
+		})
+	}
+}
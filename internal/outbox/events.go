@@ -0,0 +1,127 @@
+// Package outbox publishes domain events, such as user.registered, to NATS through a transactional outbox: events
+// are written to the outbox_events table in the transaction of the change they describe, and a relay publishes
+// them afterwards, retrying until NATS accepts them. An event is thus published at least once if and only if its
+// change was committed.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/sqlc"
+	eventsv1 "goflare.io/auth/proto/pb/proto/events/v1"
+)
+
+// Version is the version of the event schemas in proto/events/v1.
+const Version = 1
+
+// Types of the events.
+const (
+	TypeUserRegistered  = "user.registered"
+	TypeUserDeleted     = "user.deleted"
+	TypeRoleAssigned    = "role.assigned"
+	TypePasswordChanged = "password.changed"
+)
+
+// Aggregate types of the events.
+const (
+	AggregateUser           = "user"
+	AggregateServiceAccount = "service_account"
+)
+
+// Event is a domain event to write to the outbox.
+type Event struct {
+	Type          string
+	AggregateType string
+	AggregateID   string
+	Message       proto.Message
+}
+
+// UserRegistered is the event of a created user.
+func UserRegistered(user *models.User, at time.Time) *Event {
+	return userEvent(TypeUserRegistered, user.ID, &eventsv1.UserRegistered{
+		UserId:       user.ID,
+		Username:     user.Username,
+		Email:        user.Email,
+		Provider:     user.Provider,
+		RegisteredAt: timestamppb.New(at),
+	})
+}
+
+// UserDeleted is the event of a deleted user.
+func UserDeleted(userID uint64, at time.Time) *Event {
+	return userEvent(TypeUserDeleted, userID, &eventsv1.UserDeleted{
+		UserId:    userID,
+		DeletedAt: timestamppb.New(at),
+	})
+}
+
+// RoleAssigned is the event of a role assigned to a user who did not have it.
+func RoleAssigned(userID uint64, roleName string, at time.Time) *Event {
+	return userEvent(TypeRoleAssigned, userID, &eventsv1.RoleAssigned{
+		UserId:     userID,
+		Role:       roleName,
+		AssignedAt: timestamppb.New(at),
+	})
+}
+
+// ServiceAccountRoleAssigned is the event of a role assigned to a service account that did not have it.
+func ServiceAccountRoleAssigned(serviceAccountID uint64, roleName string, at time.Time) *Event {
+	return &Event{
+		Type:          TypeRoleAssigned,
+		AggregateType: AggregateServiceAccount,
+		AggregateID:   strconv.FormatUint(serviceAccountID, 10),
+		Message: &eventsv1.RoleAssigned{
+			ServiceAccountId: serviceAccountID,
+			Role:             roleName,
+			AssignedAt:       timestamppb.New(at),
+		},
+	}
+}
+
+// PasswordChanged is the event of a user who changed their password.
+func PasswordChanged(userID uint64, at time.Time) *Event {
+	return userEvent(TypePasswordChanged, userID, &eventsv1.PasswordChanged{
+		UserId:    userID,
+		ChangedAt: timestamppb.New(at),
+	})
+}
+
+// userEvent returns an event about a user.
+func userEvent(eventType string, userID uint64, message proto.Message) *Event {
+	return &Event{
+		Type:          eventType,
+		AggregateType: AggregateUser,
+		AggregateID:   strconv.FormatUint(userID, 10),
+		Message:       message,
+	}
+}
+
+// Write writes an event to the outbox with queries, which must belong to the transaction of the change the event
+// describes.
+func Write(ctx context.Context, queries *sqlc.Queries, event *Event) error {
+	payload, err := proto.Marshal(event.Message)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", event.Type, err)
+	}
+
+	if err = queries.CreateOutboxEvent(ctx, sqlc.CreateOutboxEventParams{
+		EventID:       uuid.NewString(),
+		EventType:     event.Type,
+		Version:       Version,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		Payload:       payload,
+	}); err != nil {
+		return fmt.Errorf("failed to write %s event to the outbox: %w", event.Type, err)
+	}
+
+	return nil
+}
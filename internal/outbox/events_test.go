@@ -0,0 +1,128 @@
+package outbox
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"goflare.io/auth/internal/models"
+	eventsv1 "goflare.io/auth/proto/pb/proto/events/v1"
+)
+
+func TestEvents(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name              string
+		event             *Event
+		wantType          string
+		wantAggregateType string
+		wantAggregateID   string
+		wantMessage       proto.Message
+	}{
+		{
+			name:              "user registered",
+			event:             UserRegistered(&models.User{ID: 1, Username: "alice", Email: "alice@example.com", Provider: "email"}, at),
+			wantType:          TypeUserRegistered,
+			wantAggregateType: AggregateUser,
+			wantAggregateID:   "1",
+			wantMessage:       &eventsv1.UserRegistered{UserId: 1, Username: "alice", Email: "alice@example.com", Provider: "email"},
+		},
+		{
+			name:              "user deleted",
+			event:             UserDeleted(1, at),
+			wantType:          TypeUserDeleted,
+			wantAggregateType: AggregateUser,
+			wantAggregateID:   "1",
+			wantMessage:       &eventsv1.UserDeleted{UserId: 1},
+		},
+		{
+			name:              "role assigned to a user",
+			event:             RoleAssigned(1, "editor", at),
+			wantType:          TypeRoleAssigned,
+			wantAggregateType: AggregateUser,
+			wantAggregateID:   "1",
+			wantMessage:       &eventsv1.RoleAssigned{UserId: 1, Role: "editor"},
+		},
+		{
+			name:              "role assigned to a service account",
+			event:             ServiceAccountRoleAssigned(2, "editor", at),
+			wantType:          TypeRoleAssigned,
+			wantAggregateType: AggregateServiceAccount,
+			wantAggregateID:   "2",
+			wantMessage:       &eventsv1.RoleAssigned{ServiceAccountId: 2, Role: "editor"},
+		},
+		{
+			name:              "password changed",
+			event:             PasswordChanged(1, at),
+			wantType:          TypePasswordChanged,
+			wantAggregateType: AggregateUser,
+			wantAggregateID:   "1",
+			wantMessage:       &eventsv1.PasswordChanged{UserId: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.event.Type != tt.wantType || tt.event.AggregateType != tt.wantAggregateType || tt.event.AggregateID != tt.wantAggregateID {
+				t.Errorf("event = %s on %s %s, want %s on %s %s", tt.event.Type, tt.event.AggregateType, tt.event.AggregateID,
+					tt.wantType, tt.wantAggregateType, tt.wantAggregateID)
+			}
+
+			// The payload round-trips through its encoding; timestamps are compared apart.
+			payload, err := proto.Marshal(tt.event.Message)
+			if err != nil {
+				t.Fatalf("failed to encode message: %v", err)
+			}
+			decoded := tt.wantMessage.ProtoReflect().New().Interface()
+			if err = proto.Unmarshal(payload, decoded); err != nil {
+				t.Fatalf("failed to decode message: %v", err)
+			}
+			if got := takeTimestamp(decoded); got == nil || !got.AsTime().Equal(at) {
+				t.Errorf("timestamp = %v, want %v", got, at)
+			}
+			if !proto.Equal(decoded, tt.wantMessage) {
+				t.Errorf("message = %v, want %v", decoded, tt.wantMessage)
+			}
+		})
+	}
+}
+
+// takeTimestamp clears the timestamp field of an event message and returns it.
+func takeTimestamp(message proto.Message) *timestamppb.Timestamp {
+	m := message.ProtoReflect()
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if field.Message() == nil || field.Message().FullName() != "google.protobuf.Timestamp" {
+			continue
+		}
+		timestamp, _ := m.Get(field).Message().Interface().(*timestamppb.Timestamp)
+		m.Clear(field)
+		return timestamp
+	}
+	return nil
+}
+
+func TestSubject(t *testing.T) {
+	tests := []struct {
+		prefix    string
+		eventType string
+		version   int
+		want      string
+	}{
+		{DefaultSubjectPrefix, TypeUserRegistered, 1, "auth.events.v1.user.registered"},
+		{DefaultSubjectPrefix, TypeRoleAssigned, 2, "auth.events.v2.role.assigned"},
+		{"tenant.auth", TypePasswordChanged, 1, "tenant.auth.v1.password.changed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := Subject(tt.prefix, tt.eventType, tt.version); got != tt.want {
+				t.Errorf("Subject() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
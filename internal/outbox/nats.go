@@ -0,0 +1,87 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.uber.org/zap"
+)
+
+// publishTimeout bounds a publication whose context has no deadline.
+const publishTimeout = 5 * time.Second
+
+// _ ensures that *natsPublisher implements the Publisher interface at compile time.
+var _ Publisher = (*natsPublisher)(nil)
+
+// natsPublisher publishes messages to NATS.
+type natsPublisher struct {
+	conn      *nats.Conn
+	jetStream jetstream.JetStream
+}
+
+// NewNATSPublisher connects to the NATS server at url. With useJetStream, messages are published to JetStream and
+// Publish returns once a stream stored them; otherwise once the server received them, which loses the messages
+// of subjects no one subscribes to at the time. The connection is retried in the background, so that the service
+// starts while NATS is down.
+func NewNATSPublisher(url string, useJetStream bool, logger *zap.Logger) (Publisher, error) {
+	conn, err := nats.Connect(url,
+		nats.Name("auth"),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				logger.Warn("disconnected from NATS", zap.Error(err))
+			}
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			logger.Info("reconnected to NATS", zap.String("url", conn.ConnectedUrlRedacted()))
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	publisher := &natsPublisher{conn: conn}
+	if useJetStream {
+		if publisher.jetStream, err = jetstream.New(conn); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+		}
+	}
+	return publisher, nil
+}
+
+// Publish publishes a message, to JetStream if enabled.
+func (p *natsPublisher) Publish(ctx context.Context, msg *Message) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, publishTimeout)
+		defer cancel()
+	}
+
+	natsMsg := nats.NewMsg(msg.Subject)
+	natsMsg.Data = msg.Data
+	for key, value := range msg.Header {
+		natsMsg.Header.Set(key, value)
+	}
+
+	if p.jetStream != nil {
+		if _, err := p.jetStream.PublishMsg(ctx, natsMsg); err != nil {
+			return fmt.Errorf("failed to publish to JetStream: %w", err)
+		}
+		return nil
+	}
+
+	if err := p.conn.PublishMsg(natsMsg); err != nil {
+		return fmt.Errorf("failed to publish to NATS: %w", err)
+	}
+	return p.conn.FlushWithContext(ctx)
+}
+
+// Close flushes pending messages and closes the connection.
+func (p *natsPublisher) Close() error {
+	return p.conn.Drain()
+}
@@ -0,0 +1,121 @@
+package outbox
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"goflare.io/auth/internal/models"
+)
+
+// DefaultSubjectPrefix starts the subjects events are published on, followed by the schema version and the type,
+// e.g. auth.events.v1.user.registered.
+const DefaultSubjectPrefix = "auth.events"
+
+// Headers of the published messages.
+const (
+	// HeaderMessageID carries the event ID. JetStream drops a message whose ID it has seen within its duplicate
+	// window, so that an event published twice after a failure is stored once.
+	HeaderMessageID = "Nats-Msg-Id"
+
+	HeaderEventType    = "Event-Type"
+	HeaderEventVersion = "Event-Version"
+	HeaderEventTime    = "Event-Time"
+	HeaderContentType  = "Content-Type"
+)
+
+// ContentType is the content type of the published messages.
+const ContentType = "application/protobuf"
+
+// Message is an event as it is published.
+type Message struct {
+	Subject string
+	Header  map[string]string
+	Data    []byte
+}
+
+// Publisher publishes messages, e.g. to NATS.
+type Publisher interface {
+	// Publish publishes a message. It returns once the message was accepted, or with an error.
+	Publish(ctx context.Context, msg *Message) error
+	// Close releases the connection of the publisher.
+	Close() error
+}
+
+// Subject returns the subject of an event of a type and version.
+func Subject(prefix, eventType string, version int) string {
+	return prefix + ".v" + strconv.Itoa(version) + "." + eventType
+}
+
+// newMessage returns the message of an event from the outbox.
+func newMessage(prefix string, event *models.OutboxEvent) *Message {
+	return &Message{
+		Subject: Subject(prefix, event.Type, event.Version),
+		Header: map[string]string{
+			HeaderMessageID:    event.EventID,
+			HeaderEventType:    event.Type,
+			HeaderEventVersion: strconv.Itoa(event.Version),
+			HeaderEventTime:    event.CreatedAt.UTC().Format(time.RFC3339Nano),
+			HeaderContentType:  ContentType,
+		},
+		Data: event.Payload,
+	}
+}
+
+// _ ensures that *MemoryPublisher implements the Publisher interface at compile time.
+var _ Publisher = (*MemoryPublisher)(nil)
+
+// MemoryPublisher keeps published messages in memory, for tests and for development without NATS.
+type MemoryPublisher struct {
+	mu       sync.Mutex
+	messages []*Message
+	err      error
+}
+
+// NewMemoryPublisher creates a new MemoryPublisher.
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+// Publish keeps a message, or fails with the error set by Fail.
+func (p *MemoryPublisher) Publish(_ context.Context, msg *Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return p.err
+	}
+	p.messages = append(p.messages, msg)
+	return nil
+}
+
+// Messages returns the messages published so far, oldest first.
+func (p *MemoryPublisher) Messages() []*Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return slices.Clone(p.messages)
+}
+
+// Fail makes Publish fail with err until it is called again with nil, to test how failures are retried.
+func (p *MemoryPublisher) Fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.err = err
+}
+
+// Reset forgets the messages published so far.
+func (p *MemoryPublisher) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.messages = nil
+}
+
+// Close does nothing.
+func (p *MemoryPublisher) Close() error {
+	return nil
+}
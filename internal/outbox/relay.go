@@ -0,0 +1,163 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	appconfig "goflare.io/auth/internal/config"
+	"goflare.io/auth/internal/models"
+)
+
+// Defaults of the relay configuration.
+const (
+	DefaultPollInterval = time.Second
+	DefaultBatchSize    = 100
+	DefaultRetention    = 7 * 24 * time.Hour
+)
+
+// cleanupInterval is how often published events older than the retention are deleted.
+const cleanupInterval = time.Hour
+
+// _ ensures that *relay implements the Relay interface at compile time.
+var _ Relay = (*relay)(nil)
+
+// Relay publishes the events of the outbox.
+type Relay interface {
+	// Run publishes pending events until ctx is done, then closes the publisher. Without a publisher, it returns
+	// at once and events stay in the outbox.
+	Run(ctx context.Context) error
+	// Flush publishes one batch of pending events and returns the number of events it claimed.
+	Flush(ctx context.Context) (int, error)
+}
+
+type relay struct {
+	repository    Repository
+	publisher     Publisher
+	subjectPrefix string
+	pollInterval  time.Duration
+	batchSize     int
+	retention     time.Duration
+	logger        *zap.Logger
+}
+
+// NewRelay creates a new Relay. publisher may be nil, to keep events in the outbox.
+func NewRelay(repository Repository, publisher Publisher, cfg *appconfig.Config, logger *zap.Logger) Relay {
+	r := &relay{
+		repository:    repository,
+		publisher:     publisher,
+		subjectPrefix: cfg.Events.SubjectPrefix,
+		pollInterval:  cfg.Events.PollInterval,
+		batchSize:     cfg.Events.BatchSize,
+		retention:     cfg.Events.Retention,
+		logger:        logger,
+	}
+	if r.subjectPrefix == "" {
+		r.subjectPrefix = DefaultSubjectPrefix
+	}
+	if r.pollInterval <= 0 {
+		r.pollInterval = DefaultPollInterval
+	}
+	if r.batchSize <= 0 {
+		r.batchSize = DefaultBatchSize
+	}
+	if r.retention <= 0 {
+		r.retention = DefaultRetention
+	}
+	return r
+}
+
+// ProvidePublisher creates the publisher selected in the configuration, or nil when none is.
+func ProvidePublisher(cfg *appconfig.Config, logger *zap.Logger) (Publisher, error) {
+	publisher := cfg.Events.Publisher
+	if publisher == "" && cfg.NATS.URL != "" {
+		publisher = "nats"
+	}
+
+	switch publisher {
+	case "":
+		return nil, nil
+	case "memory":
+		return NewMemoryPublisher(), nil
+	case "nats":
+		if cfg.NATS.URL == "" {
+			return nil, fmt.Errorf("nats.url is required to publish events to NATS")
+		}
+		return NewNATSPublisher(cfg.NATS.URL, cfg.Events.JetStream, logger)
+	default:
+		return nil, fmt.Errorf("unknown event publisher %q", cfg.Events.Publisher)
+	}
+}
+
+// Run publishes pending events every poll interval, and deletes published events older than the retention.
+func (r *relay) Run(ctx context.Context) error {
+	if r.publisher == nil {
+		r.logger.Warn("no event publisher configured, domain events stay in the outbox")
+		return nil
+	}
+	defer func() {
+		if err := r.publisher.Close(); err != nil {
+			r.logger.Warn("failed to close event publisher", zap.Error(err))
+		}
+	}()
+
+	poll := time.NewTicker(r.pollInterval)
+	defer poll.Stop()
+	cleanup := time.NewTicker(cleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		r.drain(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-cleanup.C:
+			r.cleanup(ctx)
+		case <-poll.C:
+		}
+	}
+}
+
+// Flush publishes one batch of pending events.
+func (r *relay) Flush(ctx context.Context) (int, error) {
+	if r.publisher == nil {
+		return 0, errors.New("no event publisher configured")
+	}
+
+	return r.repository.PublishPending(ctx, r.batchSize, func(ctx context.Context, event *models.OutboxEvent) error {
+		return r.publisher.Publish(ctx, newMessage(r.subjectPrefix, event))
+	})
+}
+
+// drain publishes batches of pending events until a batch is not full, so that a backlog is published without
+// waiting for the poll interval between batches.
+func (r *relay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		claimed, err := r.Flush(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				r.logger.Error("failed to publish pending events", zap.Error(err))
+			}
+			return
+		}
+		if claimed < r.batchSize {
+			return
+		}
+	}
+}
+
+// cleanup deletes published events older than the retention.
+func (r *relay) cleanup(ctx context.Context) {
+	deleted, err := r.repository.DeletePublished(ctx, time.Now().Add(-r.retention))
+	if err != nil {
+		r.logger.Error("failed to delete published events", zap.Error(err))
+		return
+	}
+	if deleted > 0 {
+		r.logger.Info("deleted published events", zap.Int64("count", deleted))
+	}
+}
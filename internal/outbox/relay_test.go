@@ -0,0 +1,243 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	appconfig "goflare.io/auth/internal/config"
+	"goflare.io/auth/internal/models"
+)
+
+// memoryRepository is an outbox held in memory. Like the table, it hands out pending events oldest first and keeps
+// those that failed to publish for a later attempt.
+type memoryRepository struct {
+	mu     sync.Mutex
+	events []*models.OutboxEvent
+	claims int
+}
+
+func (r *memoryRepository) add(events ...*Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, event := range events {
+		id := uint64(len(r.events) + 1)
+		r.events = append(r.events, &models.OutboxEvent{
+			ID:            id,
+			EventID:       "event-" + strconv.FormatUint(id, 10),
+			Type:          event.Type,
+			Version:       Version,
+			AggregateType: event.AggregateType,
+			AggregateID:   event.AggregateID,
+			CreatedAt:     time.Now(),
+		})
+	}
+}
+
+func (r *memoryRepository) PublishPending(
+	ctx context.Context,
+	limit int,
+	publish func(ctx context.Context, event *models.OutboxEvent) error,
+) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.claims++
+	claimed := 0
+	for _, event := range r.events {
+		if claimed == limit {
+			break
+		}
+		if !event.PublishedAt.IsZero() {
+			continue
+		}
+		claimed++
+		if err := publish(ctx, event); err != nil {
+			event.Attempts++
+			event.LastError = err.Error()
+			continue
+		}
+		event.PublishedAt = time.Now()
+	}
+	return claimed, nil
+}
+
+func (r *memoryRepository) DeletePublished(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (r *memoryRepository) claimCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.claims
+}
+
+func (r *memoryRepository) pending() []*models.OutboxEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var pending []*models.OutboxEvent
+	for _, event := range r.events {
+		if event.PublishedAt.IsZero() {
+			pending = append(pending, event)
+		}
+	}
+	return pending
+}
+
+func newTestRelay(repository Repository, publisher Publisher, batchSize int) *relay {
+	cfg := &appconfig.Config{}
+	cfg.Events.BatchSize = batchSize
+	// Relays only poll once in a test, so that every batch published comes from draining the outbox.
+	cfg.Events.PollInterval = time.Hour
+	return NewRelay(repository, publisher, cfg, zap.NewNop()).(*relay)
+}
+
+func TestRelayFlush(t *testing.T) {
+	repository := &memoryRepository{}
+	repository.add(RoleAssigned(1, "editor", time.Now()), ServiceAccountRoleAssigned(2, "editor", time.Now()))
+	publisher := NewMemoryPublisher()
+	r := newTestRelay(repository, publisher, 0)
+
+	// Events that fail to publish stay in the outbox.
+	publisher.Fail(errors.New("nats: no servers available"))
+	if claimed, err := r.Flush(context.Background()); err != nil || claimed != 2 {
+		t.Fatalf("Flush() = %d, %v, want 2 claimed", claimed, err)
+	}
+	if pending := repository.pending(); len(pending) != 2 || pending[0].Attempts != 1 || pending[0].LastError == "" {
+		t.Fatalf("pending after a failure = %d events, want 2 with an attempt and an error", len(pending))
+	}
+
+	publisher.Fail(nil)
+	if claimed, err := r.Flush(context.Background()); err != nil || claimed != 2 {
+		t.Fatalf("Flush() = %d, %v, want 2 claimed", claimed, err)
+	}
+	if pending := repository.pending(); len(pending) != 0 {
+		t.Errorf("%d events pending, want none", len(pending))
+	}
+
+	messages := publisher.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("published %d messages, want 2", len(messages))
+	}
+	for i, msg := range messages {
+		event := repository.events[i]
+		if msg.Subject != "auth.events.v1.role.assigned" {
+			t.Errorf("subject = %q, want %q", msg.Subject, "auth.events.v1.role.assigned")
+		}
+		wantHeader := map[string]string{
+			HeaderMessageID:    event.EventID,
+			HeaderEventType:    TypeRoleAssigned,
+			HeaderEventVersion: "1",
+			HeaderContentType:  ContentType,
+		}
+		for key, want := range wantHeader {
+			if got := msg.Header[key]; got != want {
+				t.Errorf("header %s = %q, want %q", key, got, want)
+			}
+		}
+		if _, err := time.Parse(time.RFC3339Nano, msg.Header[HeaderEventTime]); err != nil {
+			t.Errorf("header %s = %q is not a time: %v", HeaderEventTime, msg.Header[HeaderEventTime], err)
+		}
+	}
+}
+
+func TestRelayRun(t *testing.T) {
+	tests := []struct {
+		name        string
+		events      int
+		batchSize   int
+		wantClaims  int
+		wantPublish int
+	}{
+		{"empty outbox", 0, 2, 1, 0},
+		{"partial batch", 1, 2, 1, 1},
+		{"backlog drained without waiting", 5, 2, 3, 5},
+		{"backlog of full batches", 4, 2, 3, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository := &memoryRepository{}
+			for i := 0; i < tt.events; i++ {
+				repository.add(UserDeleted(uint64(i+1), time.Now()))
+			}
+			publisher := NewMemoryPublisher()
+			r := newTestRelay(repository, publisher, tt.batchSize)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() { done <- r.Run(ctx) }()
+
+			deadline := time.Now().Add(5 * time.Second)
+			for repository.claimCount() < tt.wantClaims && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			cancel()
+			if err := <-done; err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			if got := repository.claimCount(); got != tt.wantClaims {
+				t.Errorf("claimed %d batches, want %d", got, tt.wantClaims)
+			}
+			if got := len(publisher.Messages()); got != tt.wantPublish {
+				t.Errorf("published %d messages, want %d", got, tt.wantPublish)
+			}
+		})
+	}
+}
+
+func TestRelayWithoutPublisher(t *testing.T) {
+	repository := &memoryRepository{}
+	repository.add(UserDeleted(1, time.Now()))
+	r := newTestRelay(repository, nil, 0)
+
+	if err := r.Run(context.Background()); err != nil {
+		t.Errorf("Run() error = %v, want nil", err)
+	}
+	if _, err := r.Flush(context.Background()); err == nil {
+		t.Error("Flush() succeeded without a publisher")
+	}
+	if pending := repository.pending(); len(pending) != 1 {
+		t.Errorf("%d events pending, want 1", len(pending))
+	}
+}
+
+func TestProvidePublisher(t *testing.T) {
+	tests := []struct {
+		name      string
+		publisher string
+		natsURL   string
+		wantNil   bool
+		wantErr   bool
+	}{
+		{name: "none", wantNil: true},
+		{name: "memory", publisher: "memory"},
+		{name: "nats without a URL", publisher: "nats", wantErr: true},
+		{name: "unknown", publisher: "kafka", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &appconfig.Config{}
+			cfg.Events.Publisher = tt.publisher
+			cfg.NATS.URL = tt.natsURL
+
+			publisher, err := ProvidePublisher(cfg, zap.NewNop())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProvidePublisher() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (publisher == nil) != (tt.wantNil || tt.wantErr) {
+				t.Errorf("ProvidePublisher() = %v, wantNil %v", publisher, tt.wantNil)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/sqlc"
+	"goflare.io/nexus/driver"
+)
+
+// _ is a type assertion to ensure that the repository implements the Repository interface.
+var _ Repository = (*repository)(nil)
+
+// Repository is the interface for the outbox repository. Events are written with Write, in the transaction of
+// their change.
+type Repository interface {
+
+	// PublishPending claims up to limit pending events that are due and calls publish for each, oldest first. An
+	// event publish succeeds for is marked published; one it fails for is retried later, with a growing delay.
+	// It returns the number of events claimed.
+	PublishPending(ctx context.Context, limit int, publish func(ctx context.Context, event *models.OutboxEvent) error) (int, error)
+
+	// DeletePublished deletes the events published before a time, and returns their number.
+	DeletePublished(ctx context.Context, before time.Time) (int64, error)
+}
+
+// repository is the implementation of the Repository interface.
+type repository struct {
+	conn   driver.PostgresPool
+	logger *zap.Logger
+}
+
+// NewRepository creates a new repository.
+func NewRepository(conn driver.PostgresPool, logger *zap.Logger) Repository {
+	return &repository{
+		conn:   conn,
+		logger: logger,
+	}
+}
+
+// PublishPending publishes pending events in one transaction, which keeps the claimed rows locked, so that
+// relays of other instances skip them rather than publish them twice.
+func (r *repository) PublishPending(
+	ctx context.Context,
+	limit int,
+	publish func(ctx context.Context, event *models.OutboxEvent) error,
+) (claimed int, err error) {
+	tx, err := r.conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				r.logger.Error("failed to rollback transaction", zap.Error(rbErr))
+			}
+		}
+	}()
+
+	queries := sqlc.New(r.conn).WithTx(tx)
+	sqlcEvents, err := queries.ClaimOutboxEvents(ctx, int32(limit))
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+
+	for _, sqlcEvent := range sqlcEvents {
+		event := new(models.OutboxEvent).ConvertFromSQLCOutboxEvent(sqlcEvent)
+		if publishErr := publish(ctx, event); publishErr != nil {
+			r.logger.Warn("failed to publish event",
+				zap.String("event_id", event.EventID),
+				zap.String("type", event.Type),
+				zap.Int("attempts", event.Attempts+1),
+				zap.Error(publishErr),
+			)
+			if err = queries.MarkOutboxEventFailed(ctx, sqlc.MarkOutboxEventFailedParams{
+				ID:        event.ID,
+				LastError: publishErr.Error(),
+			}); err != nil {
+				return 0, fmt.Errorf("failed to mark outbox event failed: %w", err)
+			}
+			continue
+		}
+
+		if err = queries.MarkOutboxEventPublished(ctx, event.ID); err != nil {
+			return 0, fmt.Errorf("failed to mark outbox event published: %w", err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return len(sqlcEvents), nil
+}
+
+// DeletePublished deletes the events published before a time.
+func (r *repository) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	deleted, err := sqlc.New(r.conn).DeletePublishedOutboxEvents(ctx, pgtype.Timestamptz{Time: before, Valid: true})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete published outbox events: %w", err)
+	}
+
+	return deleted, nil
+}
@@ -22,6 +22,7 @@ type Repository interface {
 	Create(ctx context.Context, permission *models.Permission) error
 	GetByID(ctx context.Context, id uint64) (*models.Permission, error)
 	Delete(ctx context.Context, id uint64) error
+	List(ctx context.Context) ([]*models.Permission, error)
 }
 
 // repository is the implementation of the Repository interface.
@@ -43,10 +44,14 @@ func NewRepository(conn driver.PostgresPool, logger *zap.Logger) Repository {
 func (r *repository) Create(ctx context.Context, permission *models.Permission) error {
 
 	return r.queries.CreatePermission(ctx, sqlc.CreatePermissionParams{
-		Name:        permission.Name,
-		Description: &permission.Description,
-		Resource:    sqlc.ResourceType(permission.Resource),
-		Action:      sqlc.ActionType(permission.Action),
+		Name:            permission.Name,
+		Description:     &permission.Description,
+		Resource:        string(permission.Resource),
+		Action:          string(permission.Action),
+		AttrCondition:   permission.Condition,
+		TimeWindow:      permission.TimeWindow,
+		ResourcePattern: permission.ResourcePattern,
+		Effect:          permission.Effect,
 	})
 }
 
@@ -61,12 +66,14 @@ func (r *repository) GetByID(ctx context.Context, id uint64) (*models.Permission
 	sqlcPermission, err := r.queries.GetPermissionByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("permission not found")
+			return nil, fmt.Errorf("permission not found: %w", err)
 		}
 		return nil, fmt.Errorf("failed to get permission from database: %w", err)
 	}
 
-	return models.NewPermission().ConvertFromSQLCPermission(sqlcPermission), nil
+	permission := models.NewPermission().ConvertFromSQLCPermission(sqlcPermission)
+	permission.ID = id
+	return permission, nil
 }
 
 // Delete deletes a permission by ID.
@@ -79,3 +86,19 @@ func (r *repository) Delete(ctx context.Context, id uint64) error {
 
 	return r.queries.DeletePermission(ctx, id)
 }
+
+// List lists every permission, ordered by ID.
+func (r *repository) List(ctx context.Context) ([]*models.Permission, error) {
+
+	sqlcPermissions, err := r.queries.ListPermissions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list permissions: %w", err)
+	}
+
+	permissions := make([]*models.Permission, 0, len(sqlcPermissions))
+	for _, sqlcPermission := range sqlcPermissions {
+		permissions = append(permissions, models.NewPermission().ConvertFromSQLCPermission(sqlcPermission))
+	}
+
+	return permissions, nil
+}
@@ -2,8 +2,11 @@ package permission
 
 import (
 	"context"
+	"fmt"
 
 	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/policy"
+	"goflare.io/auth/internal/resource"
 )
 
 // Service is the interface for the permission service.
@@ -11,25 +14,56 @@ type Service interface {
 	Create(ctx context.Context, permission *models.Permission) error
 	GetByID(ctx context.Context, id uint64) (*models.Permission, error)
 	Delete(ctx context.Context, id uint64) error
+	List(ctx context.Context) ([]*models.Permission, error)
 }
 
 // service is the implementation of the Service interface.
 type service struct {
-	repo Repository
+	repo      Repository
+	resources resource.Service
 }
 
 // NewService creates a new service.
 func NewService(
 	repo Repository,
+	resources resource.Service,
 ) Service {
 	return &service{
-		repo: repo,
+		repo:      repo,
+		resources: resources,
 	}
 }
 
-// Create creates a new permission.
+// Create creates a new permission after validating its resource and action types, attribute condition,
+// time window, resource pattern, and effect. Permissions without an effect allow.
 func (s *service) Create(ctx context.Context, permission *models.Permission) error {
 
+	var err error
+	if permission.Resource, err = resource.NormalizeResourceType(permission.Resource); err != nil {
+		return err
+	}
+	if permission.Action, err = resource.NormalizeActionType(permission.Action); err != nil {
+		return err
+	}
+	if err = s.resources.Validate(ctx, permission.Resource, permission.Action); err != nil {
+		return err
+	}
+	if err := policy.Validate(permission.Condition, permission.TimeWindow); err != nil {
+		return err
+	}
+	switch permission.Effect {
+	case "":
+		permission.Effect = policy.EffectAllow
+	case policy.EffectAllow, policy.EffectDeny:
+	default:
+		return fmt.Errorf("invalid effect %q: must be %s or %s", permission.Effect, policy.EffectAllow, policy.EffectDeny)
+	}
+	if permission.ResourcePattern != "" {
+		if _, err := models.NewResourceID(permission.Resource, permission.ResourcePattern); err != nil {
+			return err
+		}
+	}
+
 	return s.repo.Create(ctx, permission)
 }
 
@@ -44,3 +78,9 @@ func (s *service) Delete(ctx context.Context, id uint64) error {
 
 	return s.repo.Delete(ctx, id)
 }
+
+// List lists every permission.
+func (s *service) List(ctx context.Context) ([]*models.Permission, error) {
+
+	return s.repo.List(ctx)
+}
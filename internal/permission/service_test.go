@@ -0,0 +1,110 @@
+package permission
+
+import (
+	"context"
+	"testing"
+
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/models/enum"
+	"goflare.io/auth/internal/policy"
+	"goflare.io/auth/internal/resource"
+)
+
+// registeredTypes accepts every resource and action type.
+type registeredTypes struct {
+	resource.Service
+}
+
+func (registeredTypes) Validate(context.Context, enum.ResourceType, enum.ActionType) error {
+	return nil
+}
+
+// memoryRepository keeps the created permissions.
+type memoryRepository struct {
+	Repository
+	created []*models.Permission
+}
+
+func (r *memoryRepository) Create(_ context.Context, permission *models.Permission) error {
+	r.created = append(r.created, permission)
+	return nil
+}
+
+func TestCreate(t *testing.T) {
+	tests := []struct {
+		name       string
+		permission models.Permission
+		wantEffect string
+		wantErr    bool
+	}{
+		{
+			name:       "effect defaults to allow",
+			permission: models.Permission{Name: "read orders", Resource: "order", Action: "read"},
+			wantEffect: policy.EffectAllow,
+		},
+		{
+			name:       "deny",
+			permission: models.Permission{Name: "no refunds", Resource: "ORDER", Action: "REFUND", Effect: policy.EffectDeny},
+			wantEffect: policy.EffectDeny,
+		},
+		{
+			name:       "deny on a resource pattern",
+			permission: models.Permission{Name: "no archive", Resource: "ORDER", Action: "UPDATE", ResourcePattern: "archive-*", Effect: policy.EffectDeny},
+			wantEffect: policy.EffectDeny,
+		},
+		{
+			name:       "unknown effect",
+			permission: models.Permission{Name: "maybe", Resource: "ORDER", Action: "READ", Effect: "audit"},
+			wantErr:    true,
+		},
+		{
+			name:       "invalid condition",
+			permission: models.Permission{Name: "broken", Resource: "ORDER", Action: "READ", Condition: "owner ==", Effect: policy.EffectDeny},
+			wantErr:    true,
+		},
+		{
+			name:       "invalid time window",
+			permission: models.Permission{Name: "broken", Resource: "ORDER", Action: "READ", TimeWindow: "Mon-Fri"},
+			wantErr:    true,
+		},
+		{
+			name:       "invalid resource pattern",
+			permission: models.Permission{Name: "broken", Resource: "ORDER", Action: "READ", ResourcePattern: "a b"},
+			wantErr:    true,
+		},
+		{
+			name:       "invalid resource type",
+			permission: models.Permission{Name: "broken", Resource: "9ORDER", Action: "READ"},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memoryRepository{}
+			s := NewService(repo, registeredTypes{})
+
+			permission := tt.permission
+			err := s.Create(context.Background(), &permission)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if len(repo.created) != 0 {
+					t.Errorf("Create() stored an invalid permission")
+				}
+				return
+			}
+
+			if len(repo.created) != 1 {
+				t.Fatalf("Create() stored %d permissions, want 1", len(repo.created))
+			}
+			if permission.Effect != tt.wantEffect {
+				t.Errorf("effect = %q, want %q", permission.Effect, tt.wantEffect)
+			}
+			if permission.Resource != "ORDER" {
+				t.Errorf("resource = %q, want the normalized ORDER", permission.Resource)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+)
+
+const (
+	// DefaultCacheTTL bounds how long a decision is reused. Within one process the cache is invalidated
+	// whenever policies are reloaded; the TTL bounds staleness for changes made by other replicas.
+	DefaultCacheTTL = time.Minute
+
+	// DefaultCacheSize is the number of decisions kept before the cache is reset.
+	DefaultCacheSize = 100_000
+)
+
+// decision is a cached enforcement result.
+type decision struct {
+	allowed   bool
+	expiresAt time.Time
+}
+
+// DecisionCache caches enforcement decisions until the policies change.
+// Decisions are keyed by the whole request, including attributes and the minute of evaluation,
+// which is the resolution of time windows.
+type DecisionCache struct {
+	mu        sync.RWMutex
+	decisions map[string]decision
+	ttl       time.Duration
+	size      int
+
+	// generation counts invalidations, so that a decision evaluated against policies
+	// that changed meanwhile is not cached.
+	generation uint64
+}
+
+// NewDecisionCache creates a DecisionCache that keeps up to size decisions for at most ttl.
+func NewDecisionCache(ttl time.Duration, size int) *DecisionCache {
+	return &DecisionCache{
+		decisions: make(map[string]decision),
+		ttl:       ttl,
+		size:      size,
+	}
+}
+
+// ProvideDecisionCache provides a DecisionCache with the default TTL and size.
+func ProvideDecisionCache() *DecisionCache {
+	return NewDecisionCache(DefaultCacheTTL, DefaultCacheSize)
+}
+
+// Enforce returns the cached decision for request, enforcing and caching it on a miss.
+// Requests whose attributes cannot be encoded as a key bypass the cache.
+func (c *DecisionCache) Enforce(enforcer *casbin.Enforcer, request []any) (bool, error) {
+	key, ok := cacheKey(request)
+	if !ok {
+		return enforcer.Enforce(request...)
+	}
+
+	now := time.Now()
+	c.mu.RLock()
+	cached, found := c.decisions[key]
+	generation := c.generation
+	c.mu.RUnlock()
+	if found && now.Before(cached.expiresAt) {
+		return cached.allowed, nil
+	}
+
+	allowed, err := enforcer.Enforce(request...)
+	if err != nil {
+		return false, err
+	}
+
+	c.store(key, decision{allowed: allowed, expiresAt: now.Add(c.ttl)}, generation)
+
+	return allowed, nil
+}
+
+// store caches a decision evaluated at generation, unless the cache was invalidated since.
+func (c *DecisionCache) store(key string, d decision, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation != generation {
+		return
+	}
+	if len(c.decisions) >= c.size {
+		c.decisions = make(map[string]decision)
+	}
+	c.decisions[key] = d
+}
+
+// Invalidate drops every cached decision. It must be called whenever policies or role assignments change.
+func (c *DecisionCache) Invalidate() {
+	c.mu.Lock()
+	c.decisions = make(map[string]decision)
+	c.generation++
+	c.mu.Unlock()
+}
+
+// cacheKey encodes a request built by Request as a string.
+func cacheKey(request []any) (string, bool) {
+	if len(request) != 5 {
+		return "", false
+	}
+
+	at, ok := request[4].(time.Time)
+	if !ok {
+		return "", false
+	}
+
+	// encoding/json sorts map keys, so equal attribute maps encode equally.
+	attributes, err := json.Marshal(request[3])
+	if err != nil {
+		return "", false
+	}
+
+	return fmt.Sprintf("%v\x00%v\x00%v\x00%s\x00%d", request[0], request[1], request[2], attributes, at.Unix()/60), true
+}
@@ -0,0 +1,65 @@
+package policy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDecisionCache(t *testing.T) {
+	enforcer := newEnforcer(t, [][]string{{"editor", "ARTICLE", "READ", "", EffectAllow, ""}}, [][]string{{"user:1", "editor"}})
+	request := Request("user:1", "ARTICLE", "READ", nil, time.Now())
+
+	t.Run("decisions are cached until invalidated", func(t *testing.T) {
+		cache := NewDecisionCache(time.Minute, 100)
+		if allowed, err := cache.Enforce(enforcer, request); err != nil || !allowed {
+			t.Fatalf("Enforce = %v, %v; want allowed", allowed, err)
+		}
+
+		if _, err := enforcer.RemoveGroupingPolicy("user:1", "editor"); err != nil {
+			t.Fatalf("failed to remove grouping policy: %v", err)
+		}
+		defer enforcer.AddGroupingPolicy("user:1", "editor")
+
+		if allowed, _ := cache.Enforce(enforcer, request); !allowed {
+			t.Error("Enforce evaluated again, want the cached decision")
+		}
+
+		cache.Invalidate()
+		if allowed, _ := cache.Enforce(enforcer, request); allowed {
+			t.Error("Enforce returned a decision cached before Invalidate")
+		}
+	})
+
+	t.Run("decisions evaluated before an invalidation are not stored", func(t *testing.T) {
+		cache := NewDecisionCache(time.Minute, 100)
+		key, ok := cacheKey(request)
+		if !ok {
+			t.Fatal("cacheKey failed")
+		}
+
+		cache.mu.RLock()
+		generation := cache.generation
+		cache.mu.RUnlock()
+
+		cache.Invalidate()
+		cache.store(key, decision{allowed: true, expiresAt: time.Now().Add(time.Minute)}, generation)
+		if _, found := cache.decisions[key]; found {
+			t.Error("store cached a decision evaluated before Invalidate")
+		}
+
+		cache.store(key, decision{allowed: true, expiresAt: time.Now().Add(time.Minute)}, generation+1)
+		if _, found := cache.decisions[key]; !found {
+			t.Error("store dropped a decision of the current generation")
+		}
+	})
+
+	t.Run("expired decisions are evaluated again", func(t *testing.T) {
+		cache := NewDecisionCache(time.Minute, 100)
+		key, _ := cacheKey(request)
+		cache.store(key, decision{allowed: false, expiresAt: time.Now().Add(-time.Second)}, 0)
+
+		if allowed, err := cache.Enforce(enforcer, request); err != nil || !allowed {
+			t.Errorf("Enforce = %v, %v; want the expired decision evaluated again", allowed, err)
+		}
+	})
+}
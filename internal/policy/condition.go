@@ -0,0 +1,79 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/casbin/govaluate"
+)
+
+// maxConditionLength mirrors the size of the permissions.attr_condition column.
+const maxConditionLength = 1024
+
+// compiledConditions caches parsed condition expressions by their source text,
+// so a policy is only parsed once no matter how many requests it is evaluated against.
+var compiledConditions sync.Map
+
+// ValidateCondition checks that an attribute condition is a well-formed expression.
+// An empty condition is valid and matches every request.
+func ValidateCondition(condition string) error {
+	if condition == "" {
+		return nil
+	}
+	if len(condition) > maxConditionLength {
+		return fmt.Errorf("condition exceeds %d characters", maxConditionLength)
+	}
+
+	if _, err := compileCondition(condition); err != nil {
+		return fmt.Errorf("invalid condition %q: %w", condition, err)
+	}
+	return nil
+}
+
+// EvaluateCondition evaluates an attribute condition against the request attributes.
+// Conditions are govaluate expressions over the attribute names, e.g. `department == 'sales' && level >= 3`.
+// A condition referencing an attribute that the request does not carry never matches.
+func EvaluateCondition(condition string, attributes map[string]any) (bool, error) {
+	if condition == "" {
+		return true, nil
+	}
+
+	expression, err := compileCondition(condition)
+	if err != nil {
+		return false, err
+	}
+
+	for _, name := range expression.Vars() {
+		if _, ok := attributes[name]; !ok {
+			return false, nil
+		}
+	}
+
+	result, err := expression.Evaluate(attributes)
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate condition %q: %w", condition, err)
+	}
+
+	matched, ok := result.(bool)
+	if !ok {
+		return false, errors.New("condition must evaluate to a boolean")
+	}
+
+	return matched, nil
+}
+
+// compileCondition parses a condition, reusing a previously parsed expression if there is one.
+func compileCondition(condition string) (*govaluate.EvaluableExpression, error) {
+	if cached, ok := compiledConditions.Load(condition); ok {
+		return cached.(*govaluate.EvaluableExpression), nil
+	}
+
+	expression, err := govaluate.NewEvaluableExpression(condition)
+	if err != nil {
+		return nil, err
+	}
+
+	compiledConditions.Store(condition, expression)
+	return expression, nil
+}
@@ -0,0 +1,86 @@
+// Package policy holds the pieces of the Casbin model that are evaluated in Go:
+// attribute conditions and time windows carried by permission rows, decision
+// explanations, and sandboxes for evaluating proposed policies.
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+)
+
+const (
+	// EffectAllow is the eft value of a policy that grants access.
+	EffectAllow = "allow"
+
+	// EffectDeny is the eft value of a policy that denies access.
+	EffectDeny = "deny"
+
+	// RuleLength is the number of fields in a policy rule.
+	RuleLength = 6
+)
+
+// Validate checks the attribute condition and time window of a policy before it is written.
+func Validate(condition, timeWindow string) error {
+	return errors.Join(ValidateCondition(condition), ValidateTimeWindow(timeWindow))
+}
+
+// Rule builds a policy rule in the field order of casbin.conf: sub, obj, act, attr, eft, time.
+func Rule(subject, object, action, condition, effect, timeWindow string) []any {
+	return []any{subject, object, action, condition, effect, timeWindow}
+}
+
+// Request builds an enforcement request in the field order of casbin.conf: sub, obj, act, attr, time.
+func Request(subject, object, action string, attributes map[string]any, at time.Time) []any {
+	if attributes == nil {
+		attributes = map[string]any{}
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+	return []any{subject, object, action, attributes, at}
+}
+
+// RegisterFunctions adds the attrMatch and timeMatch functions used by the casbin.conf matcher.
+func RegisterFunctions(enforcer *casbin.Enforcer) {
+	enforcer.AddFunction("attrMatch", attrMatch)
+	enforcer.AddFunction("timeMatch", timeMatch)
+}
+
+// attrMatch is the matcher function for attrMatch(r.attr, p.attr).
+func attrMatch(args ...any) (any, error) {
+	if len(args) != 2 {
+		return false, errors.New("attrMatch expects 2 arguments")
+	}
+
+	attributes, ok := args[0].(map[string]any)
+	if !ok {
+		return false, fmt.Errorf("attrMatch: request attributes must be a map, got %T", args[0])
+	}
+	condition, ok := args[1].(string)
+	if !ok {
+		return false, fmt.Errorf("attrMatch: policy condition must be a string, got %T", args[1])
+	}
+
+	return EvaluateCondition(condition, attributes)
+}
+
+// timeMatch is the matcher function for timeMatch(r.time, p.time).
+func timeMatch(args ...any) (any, error) {
+	if len(args) != 2 {
+		return false, errors.New("timeMatch expects 2 arguments")
+	}
+
+	at, ok := args[0].(time.Time)
+	if !ok {
+		return false, fmt.Errorf("timeMatch: request time must be a time.Time, got %T", args[0])
+	}
+	window, ok := args[1].(string)
+	if !ok {
+		return false, fmt.Errorf("timeMatch: policy time window must be a string, got %T", args[1])
+	}
+
+	return MatchTimeWindow(window, at)
+}
@@ -0,0 +1,62 @@
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+)
+
+func TestDenyOverridesAllow(t *testing.T) {
+	models := []string{
+		"../../configs/casbin/casbin.conf",
+		"../../configs/casbin/casbin-ecommerce.conf",
+	}
+
+	for _, modelPath := range models {
+		t.Run(modelPath, func(t *testing.T) {
+			enforcer, err := casbin.NewEnforcer(modelPath)
+			if err != nil {
+				t.Fatalf("failed to load model: %v", err)
+			}
+			RegisterFunctions(enforcer)
+
+			rules := [][]string{
+				{"editor", "ARTICLE", "READ", "", EffectAllow, ""},
+				{"editor", "ARTICLE", "UPDATE", "", EffectAllow, ""},
+				{"suspended", "ARTICLE", "UPDATE", "", EffectDeny, ""},
+			}
+			if _, err = enforcer.AddPolicies(rules); err != nil {
+				t.Fatalf("failed to add policies: %v", err)
+			}
+			groupingRules := [][]string{
+				{"user:1", "editor"},
+				{"user:2", "editor"},
+				{"user:2", "suspended"},
+			}
+			if _, err = enforcer.AddGroupingPolicies(groupingRules); err != nil {
+				t.Fatalf("failed to add grouping policies: %v", err)
+			}
+
+			tests := []struct {
+				subject string
+				action  string
+				want    bool
+			}{
+				{"user:1", "UPDATE", true},
+				{"user:2", "READ", true},
+				{"user:2", "UPDATE", false},
+				{"user:3", "READ", false},
+			}
+			for _, tt := range tests {
+				allowed, err := enforcer.Enforce(Request(tt.subject, "ARTICLE", tt.action, nil, time.Now())...)
+				if err != nil {
+					t.Fatalf("Enforce(%s, %s) failed: %v", tt.subject, tt.action, err)
+				}
+				if allowed != tt.want {
+					t.Errorf("Enforce(%s, %s) = %v, want %v", tt.subject, tt.action, allowed, tt.want)
+				}
+			}
+		})
+	}
+}
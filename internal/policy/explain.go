@@ -0,0 +1,84 @@
+package policy
+
+import (
+	"fmt"
+
+	"github.com/casbin/casbin/v2"
+)
+
+// Explanation describes why an enforcement request was allowed or denied.
+type Explanation struct {
+
+	// Allowed is the decision.
+	Allowed bool `json:"allowed"`
+
+	// Rule is the policy rule that decided the request, in the field order of casbin.conf.
+	// It is empty when no rule matched and the request was denied by default.
+	Rule []string `json:"rule,omitempty"`
+
+	// RoleChain is the path of grouping policies from the subject to the subject of Rule,
+	// e.g. [alice, editor, admin]. It only holds the subject when the rule names it directly.
+	RoleChain []string `json:"role_chain,omitempty"`
+}
+
+// Explain enforces request and reports the rule that decided it along with the role chain that led to that rule.
+// The subject is the first field of the request.
+func Explain(enforcer *casbin.Enforcer, request []any) (*Explanation, error) {
+	subject, ok := request[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("request subject must be a string, got %T", request[0])
+	}
+
+	allowed, rule, err := enforcer.EnforceEx(request...)
+	if err != nil {
+		return nil, err
+	}
+
+	explanation := &Explanation{Allowed: allowed, Rule: rule}
+	if len(rule) == 0 {
+		return explanation, nil
+	}
+
+	if explanation.RoleChain, err = roleChain(enforcer, subject, rule[0]); err != nil {
+		return nil, err
+	}
+
+	return explanation, nil
+}
+
+// roleChain finds the shortest path of grouping policies from subject to role.
+func roleChain(enforcer *casbin.Enforcer, subject, role string) ([]string, error) {
+	if subject == role {
+		return []string{subject}, nil
+	}
+
+	parents := map[string]string{subject: ""}
+	queue := []string{subject}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		roles, err := enforcer.GetRolesForUser(current)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, next := range roles {
+			if _, visited := parents[next]; visited {
+				continue
+			}
+			parents[next] = current
+
+			if next == role {
+				chain := []string{next}
+				for node := current; node != ""; node = parents[node] {
+					chain = append([]string{node}, chain...)
+				}
+				return chain, nil
+			}
+			queue = append(queue, next)
+		}
+	}
+
+	return nil, nil
+}
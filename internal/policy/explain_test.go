@@ -0,0 +1,169 @@
+package policy
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+)
+
+// newEnforcer returns an enforcer of casbin.conf with the given policies and role assignments.
+func newEnforcer(t *testing.T, rules, groupingRules [][]string) *casbin.Enforcer {
+	t.Helper()
+
+	enforcer, err := casbin.NewEnforcer("../../configs/casbin/casbin.conf")
+	if err != nil {
+		t.Fatalf("failed to load model: %v", err)
+	}
+	RegisterFunctions(enforcer)
+
+	if len(rules) > 0 {
+		if _, err = enforcer.AddPolicies(rules); err != nil {
+			t.Fatalf("failed to add policies: %v", err)
+		}
+	}
+	if len(groupingRules) > 0 {
+		if _, err = enforcer.AddGroupingPolicies(groupingRules); err != nil {
+			t.Fatalf("failed to add grouping policies: %v", err)
+		}
+	}
+	return enforcer
+}
+
+func TestExplain(t *testing.T) {
+	enforcer := newEnforcer(t,
+		[][]string{
+			{"admin", "USER", "DELETE", "", EffectAllow, ""},
+			{"editor", "ARTICLE", "UPDATE", "", EffectAllow, ""},
+			{"user:1", "ARTICLE", "READ", "", EffectAllow, ""},
+			{"suspended", "ARTICLE", "UPDATE", "", EffectDeny, ""},
+		},
+		[][]string{
+			{"user:1", "editor"},
+			{"editor", "admin"},
+			{"user:2", "editor"},
+			{"user:2", "suspended"},
+		},
+	)
+
+	tests := []struct {
+		name          string
+		subject       string
+		object        string
+		action        string
+		wantAllowed   bool
+		wantRule      []string
+		wantRoleChain []string
+	}{
+		{
+			name:          "direct rule",
+			subject:       "user:1",
+			object:        "ARTICLE",
+			action:        "READ",
+			wantAllowed:   true,
+			wantRule:      []string{"user:1", "ARTICLE", "READ", "", EffectAllow, ""},
+			wantRoleChain: []string{"user:1"},
+		},
+		{
+			name:          "role",
+			subject:       "user:1",
+			object:        "ARTICLE",
+			action:        "UPDATE",
+			wantAllowed:   true,
+			wantRule:      []string{"editor", "ARTICLE", "UPDATE", "", EffectAllow, ""},
+			wantRoleChain: []string{"user:1", "editor"},
+		},
+		{
+			name:          "inherited role",
+			subject:       "user:1",
+			object:        "USER",
+			action:        "DELETE",
+			wantAllowed:   true,
+			wantRule:      []string{"admin", "USER", "DELETE", "", EffectAllow, ""},
+			wantRoleChain: []string{"user:1", "editor", "admin"},
+		},
+		{
+			name:          "deny rule",
+			subject:       "user:2",
+			object:        "ARTICLE",
+			action:        "UPDATE",
+			wantRule:      []string{"suspended", "ARTICLE", "UPDATE", "", EffectDeny, ""},
+			wantRoleChain: []string{"user:2", "suspended"},
+		},
+		{
+			name:    "denied by default",
+			subject: "user:3",
+			object:  "ARTICLE",
+			action:  "READ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			explanation, err := Explain(enforcer, Request(tt.subject, tt.object, tt.action, nil, time.Now()))
+			if err != nil {
+				t.Fatalf("Explain failed: %v", err)
+			}
+			if explanation.Allowed != tt.wantAllowed {
+				t.Errorf("Allowed = %v, want %v", explanation.Allowed, tt.wantAllowed)
+			}
+			if len(explanation.Rule) != 0 || len(tt.wantRule) != 0 {
+				if !reflect.DeepEqual(explanation.Rule, tt.wantRule) {
+					t.Errorf("Rule = %v, want %v", explanation.Rule, tt.wantRule)
+				}
+			}
+			if !reflect.DeepEqual(explanation.RoleChain, tt.wantRoleChain) {
+				t.Errorf("RoleChain = %v, want %v", explanation.RoleChain, tt.wantRoleChain)
+			}
+		})
+	}
+}
+
+func TestNewSandbox(t *testing.T) {
+	enforcer := newEnforcer(t,
+		[][]string{{"editor", "ARTICLE", "READ", "", EffectAllow, ""}},
+		[][]string{{"user:1", "editor"}},
+	)
+
+	tests := []struct {
+		name    string
+		rules   [][]string
+		wantErr bool
+	}{
+		{"valid rules", [][]string{{"editor", "ARTICLE", "UPDATE", "draft == true", EffectDeny, "Mon-Fri 09:00-18:00"}}, false},
+		{"no rules", nil, false},
+		{"missing fields", [][]string{{"editor", "ARTICLE", "UPDATE"}}, true},
+		{"unknown effect", [][]string{{"editor", "ARTICLE", "UPDATE", "", "maybe", ""}}, true},
+		{"empty effect", [][]string{{"editor", "ARTICLE", "UPDATE", "", "", ""}}, true},
+		{"invalid condition", [][]string{{"editor", "ARTICLE", "UPDATE", "draft ==", EffectAllow, ""}}, true},
+		{"invalid time window", [][]string{{"editor", "ARTICLE", "UPDATE", "", EffectAllow, "Mon-Fri"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSandbox(enforcer, tt.rules, [][]string{{"user:1", "editor"}})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewSandbox() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("proposed rules stay in the sandbox", func(t *testing.T) {
+		sandbox, err := NewSandbox(enforcer, [][]string{{"editor", "ARTICLE", "UPDATE", "", EffectAllow, ""}}, [][]string{{"user:1", "editor"}})
+		if err != nil {
+			t.Fatalf("NewSandbox failed: %v", err)
+		}
+
+		request := Request("user:1", "ARTICLE", "UPDATE", nil, time.Now())
+		if allowed, err := sandbox.Enforce(request...); err != nil || !allowed {
+			t.Errorf("sandbox Enforce = %v, %v; want the proposed rule to allow", allowed, err)
+		}
+		if allowed, err := enforcer.Enforce(request...); err != nil || allowed {
+			t.Errorf("enforcer Enforce = %v, %v; want the current rules to be unchanged", allowed, err)
+		}
+		if allowed, err := sandbox.Enforce(Request("user:1", "ARTICLE", "READ", nil, time.Now())...); err != nil || allowed {
+			t.Errorf("sandbox Enforce = %v, %v; want only the proposed rules", allowed, err)
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package policy
+
+import (
+	"fmt"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+)
+
+// NewSandbox creates an in-memory enforcer with the model of enforcer and only the given rules and grouping
+// policies, so a proposed policy set can be evaluated without touching the stored policies.
+func NewSandbox(enforcer *casbin.Enforcer, rules, groupingRules [][]string) (*casbin.Enforcer, error) {
+	m, err := model.NewModelFromString(enforcer.GetModel().ToText())
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy model: %w", err)
+	}
+
+	sandbox, err := casbin.NewEnforcer(m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sandbox enforcer: %w", err)
+	}
+	RegisterFunctions(sandbox)
+
+	for _, rule := range rules {
+		if len(rule) != RuleLength {
+			return nil, fmt.Errorf("policy rule %v must have %d fields", rule, RuleLength)
+		}
+		if rule[4] != EffectAllow && rule[4] != EffectDeny {
+			return nil, fmt.Errorf("policy rule %v has unknown effect %q", rule, rule[4])
+		}
+		if err = Validate(rule[3], rule[5]); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rules) > 0 {
+		if _, err = sandbox.AddPolicies(rules); err != nil {
+			return nil, fmt.Errorf("failed to add proposed policies: %w", err)
+		}
+	}
+	if len(groupingRules) > 0 {
+		if _, err = sandbox.AddGroupingPolicies(groupingRules); err != nil {
+			return nil, fmt.Errorf("failed to add proposed grouping policies: %w", err)
+		}
+	}
+
+	return sandbox, nil
+}
@@ -0,0 +1,46 @@
+package policy
+
+import (
+	"strconv"
+	"strings"
+
+	"goflare.io/auth/internal/models/enum"
+)
+
+const (
+	// userSubjectPrefix distinguishes user subjects from role names, which are subjects too.
+	userSubjectPrefix = "user:"
+
+	// serviceAccountSubjectPrefix distinguishes service account subjects from users and roles.
+	serviceAccountSubjectPrefix = "service:"
+)
+
+// UserSubject returns the Casbin subject of a user. Subjects are keyed by the stable user ID,
+// so renaming a user keeps their grants.
+func UserSubject(userID uint64) string {
+	return userSubjectPrefix + strconv.FormatUint(userID, 10)
+}
+
+// ServiceAccountSubject returns the Casbin subject of a service account. Service accounts are assigned roles
+// through grouping policies just like users.
+func ServiceAccountSubject(serviceAccountID uint64) string {
+	return serviceAccountSubjectPrefix + strconv.FormatUint(serviceAccountID, 10)
+}
+
+// PrincipalSubject returns the Casbin subject of a user or service account.
+func PrincipalSubject(subjectType enum.SubjectType, id uint64) string {
+	if subjectType == enum.SubjectServiceAccount {
+		return ServiceAccountSubject(id)
+	}
+	return UserSubject(id)
+}
+
+// IsServiceAccountSubject reports whether a subject names a service account.
+func IsServiceAccountSubject(subject string) bool {
+	return strings.HasPrefix(subject, serviceAccountSubjectPrefix)
+}
+
+// IsUserSubject reports whether a subject names a user rather than a role.
+func IsUserSubject(subject string) bool {
+	return strings.HasPrefix(subject, userSubjectPrefix)
+}
@@ -0,0 +1,180 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// parsedWindows caches parsed time windows by their textual form.
+var parsedWindows sync.Map
+
+// TimeWindow is a recurring window of time during which a policy applies.
+// Its textual form is `[DAYS ]HH:MM-HH:MM[ LOCATION]`, for example
+// "Mon-Fri 09:00-18:00 Asia/Taipei" or "22:00-06:00".
+type TimeWindow struct {
+	days     [7]bool
+	start    time.Duration
+	end      time.Duration
+	location *time.Location
+}
+
+// weekdays maps the accepted day abbreviations to time.Weekday.
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ValidateTimeWindow checks that a time window is well-formed.
+// An empty time window is valid and matches at any time.
+func ValidateTimeWindow(window string) error {
+	if window == "" {
+		return nil
+	}
+
+	if _, err := ParseTimeWindow(window); err != nil {
+		return fmt.Errorf("invalid time window %q: %w", window, err)
+	}
+	return nil
+}
+
+// ParseTimeWindow parses the textual form of a time window.
+func ParseTimeWindow(window string) (*TimeWindow, error) {
+	fields := strings.Fields(window)
+	if len(fields) == 0 || len(fields) > 3 {
+		return nil, errors.New("expected [DAYS ]HH:MM-HH:MM[ LOCATION]")
+	}
+
+	tw := &TimeWindow{location: time.UTC}
+	for i := range tw.days {
+		tw.days[i] = true
+	}
+
+	// The clock range is the only field that always contains a colon.
+	clockIndex := -1
+	for i, field := range fields {
+		if strings.Contains(field, ":") {
+			clockIndex = i
+			break
+		}
+	}
+	if clockIndex == -1 || clockIndex > 1 {
+		return nil, errors.New("missing HH:MM-HH:MM clock range")
+	}
+
+	if clockIndex == 1 {
+		days, err := parseDays(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		tw.days = days
+	}
+
+	start, end, found := strings.Cut(fields[clockIndex], "-")
+	if !found {
+		return nil, errors.New("clock range must be HH:MM-HH:MM")
+	}
+
+	var err error
+	if tw.start, err = parseClock(start); err != nil {
+		return nil, err
+	}
+	if tw.end, err = parseClock(end); err != nil {
+		return nil, err
+	}
+	if tw.start == tw.end {
+		return nil, errors.New("clock range must not be empty")
+	}
+
+	if clockIndex+1 < len(fields) {
+		if tw.location, err = time.LoadLocation(fields[clockIndex+1]); err != nil {
+			return nil, fmt.Errorf("unknown location: %w", err)
+		}
+	}
+
+	return tw, nil
+}
+
+// Contains reports whether t falls inside the window.
+// Windows whose end is before their start wrap past midnight and belong to the day they start on.
+func (tw *TimeWindow) Contains(t time.Time) bool {
+	t = t.In(tw.location)
+	clock := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+
+	if tw.start < tw.end {
+		return tw.days[t.Weekday()] && clock >= tw.start && clock < tw.end
+	}
+
+	if clock >= tw.start {
+		return tw.days[t.Weekday()]
+	}
+	if clock < tw.end {
+		return tw.days[(t.Weekday()+6)%7]
+	}
+	return false
+}
+
+// MatchTimeWindow reports whether t falls inside the textual time window.
+// An empty window always matches.
+func MatchTimeWindow(window string, t time.Time) (bool, error) {
+	if window == "" {
+		return true, nil
+	}
+
+	if cached, ok := parsedWindows.Load(window); ok {
+		return cached.(*TimeWindow).Contains(t), nil
+	}
+
+	tw, err := ParseTimeWindow(window)
+	if err != nil {
+		return false, err
+	}
+
+	parsedWindows.Store(window, tw)
+	return tw.Contains(t), nil
+}
+
+// parseDays parses a day list such as "Mon-Fri" or "Sat,Sun".
+func parseDays(field string) ([7]bool, error) {
+	var days [7]bool
+	for _, part := range strings.Split(strings.ToLower(field), ",") {
+		from, to, isRange := strings.Cut(part, "-")
+
+		first, ok := weekdays[from]
+		if !ok {
+			return days, fmt.Errorf("unknown day %q", from)
+		}
+		if !isRange {
+			days[first] = true
+			continue
+		}
+
+		last, ok := weekdays[to]
+		if !ok {
+			return days, fmt.Errorf("unknown day %q", to)
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// parseClock parses an HH:MM time of day into an offset from midnight.
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
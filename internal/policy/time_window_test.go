@@ -0,0 +1,80 @@
+package policy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeWindow(t *testing.T) {
+	tests := []struct {
+		window  string
+		wantErr bool
+	}{
+		{"09:00-18:00", false},
+		{"Mon-Fri 09:00-18:00", false},
+		{"Sat,Sun 10:00-14:00 Asia/Taipei", false},
+		{"Fri-Mon 22:00-06:00 UTC", false},
+		{"09:00-18:00 Asia/Taipei", false},
+		{"", true},
+		{"Mon-Fri", true},
+		{"Mon-Fri 09:00", true},
+		{"Mon-Fri 09:00-09:00", true},
+		{"Mon-Fri 25:00-26:00", true},
+		{"Funday 09:00-18:00", true},
+		{"Mon-Fri 09:00-18:00 Nowhere/Land", true},
+		{"Mon Tue 09:00-18:00", true},
+		{"Mon-Fri 09:00-18:00 UTC extra", true},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseTimeWindow(tt.window)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTimeWindow(%q) error = %v, wantErr %v", tt.window, err, tt.wantErr)
+		}
+	}
+}
+
+func TestMatchTimeWindow(t *testing.T) {
+	// 2024-01-01 is a Monday.
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, time.January, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name   string
+		window string
+		at     time.Time
+		want   bool
+	}{
+		{"empty window", "", at(6, 3, 0), true},
+		{"inside every day", "09:00-18:00", at(6, 12, 0), true},
+		{"start is inclusive", "09:00-18:00", at(1, 9, 0), true},
+		{"end is exclusive", "09:00-18:00", at(1, 18, 0), false},
+		{"weekday inside", "Mon-Fri 09:00-18:00", at(5, 10, 0), true},
+		{"weekend outside", "Mon-Fri 09:00-18:00", at(6, 10, 0), false},
+		{"day list", "Sat,Sun 09:00-18:00", at(7, 10, 0), true},
+		{"day range wraps the week", "Fri-Mon 09:00-18:00", at(7, 10, 0), true},
+		{"day range wraps the week outside", "Fri-Mon 09:00-18:00", at(3, 10, 0), false},
+		{"midnight wrap before midnight", "Fri 22:00-06:00", at(5, 23, 0), true},
+		{"midnight wrap after midnight belongs to start day", "Fri 22:00-06:00", at(6, 5, 59), true},
+		{"midnight wrap after midnight of another day", "Fri 22:00-06:00", at(5, 5, 0), false},
+		{"midnight wrap end is exclusive", "Fri 22:00-06:00", at(6, 6, 0), false},
+		{"midnight wrap in the gap", "22:00-06:00", at(3, 12, 0), false},
+		{"location shifts the clock", "09:00-18:00 Asia/Taipei", at(1, 2, 0), true},
+		{"location shifts the clock outside", "09:00-18:00 Asia/Taipei", at(1, 12, 0), false},
+		{"location shifts the day", "Mon 09:00-18:00 Asia/Taipei", at(7, 23, 0), false},
+		{"location shifts the day inside", "Mon 00:00-09:00 Asia/Taipei", at(7, 17, 0), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MatchTimeWindow(tt.window, tt.at)
+			if err != nil {
+				t.Fatalf("MatchTimeWindow(%q) failed: %v", tt.window, err)
+			}
+			if got != tt.want {
+				t.Errorf("MatchTimeWindow(%q, %s) = %v, want %v", tt.window, tt.at, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package relation
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/sqlc"
+	"goflare.io/nexus/driver"
+)
+
+// _ is a type assertion to ensure that the repository implements the Repository interface.
+var _ Repository = (*repository)(nil)
+
+// Repository is the interface for the resource relation repository.
+type Repository interface {
+	// Add relates a user to a resource instance.
+	Add(ctx context.Context, relation *models.Relation) error
+	// Remove removes the relation between a user and a resource instance.
+	Remove(ctx context.Context, relation *models.Relation) error
+	// FindUserRelations finds the names of the relations a user has to a resource instance.
+	FindUserRelations(ctx context.Context, userID uint64, resource models.ResourceID) ([]string, error)
+	// ListByResource lists every relation of a resource instance.
+	ListByResource(ctx context.Context, resource models.ResourceID) ([]*models.Relation, error)
+}
+
+// repository is the implementation of the Repository interface.
+type repository struct {
+	queries sqlc.Querier
+	logger  *zap.Logger
+}
+
+// NewRepository creates a new repository.
+func NewRepository(conn driver.PostgresPool, logger *zap.Logger) Repository {
+
+	return &repository{
+		queries: sqlc.New(conn),
+		logger:  logger,
+	}
+}
+
+// Add relates a user to a resource instance. Adding an existing relation is a no-op.
+func (r *repository) Add(ctx context.Context, relation *models.Relation) error {
+
+	return r.queries.AddResourceRelation(ctx, sqlc.AddResourceRelationParams{
+		ResourceType: string(relation.Resource.Type),
+		ResourceID:   relation.Resource.ID,
+		Relation:     relation.Relation,
+		UserID:       relation.UserID,
+	})
+}
+
+// Remove removes the relation between a user and a resource instance.
+func (r *repository) Remove(ctx context.Context, relation *models.Relation) error {
+
+	return r.queries.RemoveResourceRelation(ctx, sqlc.RemoveResourceRelationParams{
+		ResourceType: string(relation.Resource.Type),
+		ResourceID:   relation.Resource.ID,
+		Relation:     relation.Relation,
+		UserID:       relation.UserID,
+	})
+}
+
+// FindUserRelations finds the names of the relations a user has to a resource instance.
+func (r *repository) FindUserRelations(ctx context.Context, userID uint64, resource models.ResourceID) ([]string, error) {
+
+	relations, err := r.queries.GetUserResourceRelations(ctx, sqlc.GetUserResourceRelationsParams{
+		ResourceType: string(resource.Type),
+		ResourceID:   resource.ID,
+		UserID:       userID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user relations for %s: %w", resource, err)
+	}
+
+	return relations, nil
+}
+
+// ListByResource lists every relation of a resource instance.
+func (r *repository) ListByResource(ctx context.Context, resource models.ResourceID) ([]*models.Relation, error) {
+
+	sqlcRelations, err := r.queries.ListResourceRelations(ctx, sqlc.ListResourceRelationsParams{
+		ResourceType: string(resource.Type),
+		ResourceID:   resource.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list relations for %s: %w", resource, err)
+	}
+
+	relations := make([]*models.Relation, 0, len(sqlcRelations))
+	for _, sqlcRelation := range sqlcRelations {
+		relations = append(relations, new(models.Relation).ConvertFromSQLCResourceRelation(sqlcRelation))
+	}
+
+	return relations, nil
+}
@@ -0,0 +1,127 @@
+package relation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"goflare.io/auth/internal/audit"
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/resource"
+)
+
+// RelationOwner is the relation of a user who owns a resource instance.
+const RelationOwner = "owner"
+
+// relationNamePattern limits relation names to lowercase identifiers, so they can be referenced in conditions.
+var relationNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// Service is the interface for the resource relation service.
+type Service interface {
+	// Grant relates a user to a resource instance.
+	Grant(ctx context.Context, relation *models.Relation) error
+	// Revoke removes the relation between a user and a resource instance.
+	Revoke(ctx context.Context, relation *models.Relation) error
+	// Relations returns the names of the relations a user has to a resource instance.
+	Relations(ctx context.Context, userID uint64, resource models.ResourceID) ([]string, error)
+	// List lists every relation of a resource instance.
+	List(ctx context.Context, resource models.ResourceID) ([]*models.Relation, error)
+}
+
+// service is the implementation of the Service interface.
+type service struct {
+	repo      Repository
+	resources resource.Service
+	audit     audit.Service
+}
+
+// NewService creates a new service.
+func NewService(
+	repo Repository,
+	resources resource.Service,
+	audit audit.Service,
+) Service {
+	return &service{
+		repo:      repo,
+		resources: resources,
+		audit:     audit,
+	}
+}
+
+// Grant relates a user to a resource instance.
+func (s *service) Grant(ctx context.Context, relation *models.Relation) (err error) {
+	defer func() {
+		s.record(ctx, audit.ActionRelationGrant, relation, nil, relationState(relation), err)
+	}()
+
+	if err = validate(relation); err != nil {
+		return err
+	}
+	if err = s.resources.ValidateResourceType(ctx, relation.Resource.Type); err != nil {
+		return err
+	}
+
+	return s.repo.Add(ctx, relation)
+}
+
+// Revoke removes the relation between a user and a resource instance.
+func (s *service) Revoke(ctx context.Context, relation *models.Relation) (err error) {
+	defer func() {
+		s.record(ctx, audit.ActionRelationRevoke, relation, relationState(relation), nil, err)
+	}()
+
+	if err = validate(relation); err != nil {
+		return err
+	}
+
+	return s.repo.Remove(ctx, relation)
+}
+
+// record records a change to the relations of a resource instance, made by the actor in ctx.
+func (s *service) record(ctx context.Context, action string, relation *models.Relation, before, after any, err error) {
+	event := &models.AuditEvent{
+		Action:     action,
+		TargetType: audit.TargetResource,
+		TargetID:   relation.Resource.String(),
+		Before:     audit.State(before),
+		After:      audit.State(after),
+	}
+	event.Outcome, event.Reason = audit.Outcome(err)
+	s.audit.Record(ctx, event)
+}
+
+// relationState is what the audit log records of a relation.
+func relationState(relation *models.Relation) map[string]any {
+	return map[string]any{"relation": relation.Relation, "user_id": relation.UserID}
+}
+
+// Relations returns the names of the relations a user has to a resource instance.
+func (s *service) Relations(ctx context.Context, userID uint64, resource models.ResourceID) ([]string, error) {
+
+	return s.repo.FindUserRelations(ctx, userID, resource)
+}
+
+// List lists every relation of a resource instance.
+func (s *service) List(ctx context.Context, resource models.ResourceID) ([]*models.Relation, error) {
+
+	return s.repo.ListByResource(ctx, resource)
+}
+
+// validate checks that a relation names a concrete resource instance, a relation, and a user.
+func validate(relation *models.Relation) error {
+	if relation.UserID == 0 {
+		return errors.New("user id is required")
+	}
+	if !relationNamePattern.MatchString(relation.Relation) {
+		return fmt.Errorf("invalid relation name %q", relation.Relation)
+	}
+	if _, err := models.NewResourceID(relation.Resource.Type, relation.Resource.ID); err != nil {
+		return err
+	}
+	if strings.Contains(relation.Resource.ID, "*") {
+		return errors.New("relations must name a concrete resource instance")
+	}
+	return nil
+}
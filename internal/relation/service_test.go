@@ -0,0 +1,36 @@
+package relation
+
+import (
+	"testing"
+
+	"goflare.io/auth/internal/models"
+)
+
+func TestValidate(t *testing.T) {
+	order := models.ResourceID{Type: "ORDER", ID: "42"}
+
+	tests := []struct {
+		name     string
+		relation models.Relation
+		wantErr  bool
+	}{
+		{"owner", models.Relation{Resource: order, Relation: RelationOwner, UserID: 1}, false},
+		{"custom relation", models.Relation{Resource: order, Relation: "approver_2", UserID: 1}, false},
+		{"no user", models.Relation{Resource: order, Relation: RelationOwner}, true},
+		{"upper-case relation", models.Relation{Resource: order, Relation: "Owner", UserID: 1}, true},
+		{"relation with a quote", models.Relation{Resource: order, Relation: "owner'", UserID: 1}, true},
+		{"no relation", models.Relation{Resource: order, UserID: 1}, true},
+		{"no resource type", models.Relation{Resource: models.ResourceID{ID: "42"}, Relation: RelationOwner, UserID: 1}, true},
+		{"invalid resource ID", models.Relation{Resource: models.ResourceID{Type: "ORDER", ID: "4 2"}, Relation: RelationOwner, UserID: 1}, true},
+		{"pattern", models.Relation{Resource: models.ResourceID{Type: "ORDER", ID: "*"}, Relation: RelationOwner, UserID: 1}, true},
+		{"partial pattern", models.Relation{Resource: models.ResourceID{Type: "ORDER", ID: "4*"}, Relation: RelationOwner, UserID: 1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validate(&tt.relation); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
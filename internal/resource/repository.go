@@ -0,0 +1,142 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/models/enum"
+	"goflare.io/auth/internal/sqlc"
+	"goflare.io/nexus/driver"
+)
+
+// _ is a type assertion to ensure that the repository implements the Repository interface.
+var _ Repository = (*repository)(nil)
+
+// Repository is the interface for the resource and action type repository.
+type Repository interface {
+	// CreateResourceType registers a resource type.
+	CreateResourceType(ctx context.Context, resourceType *models.ResourceType) error
+	// ResourceTypeExists reports whether a resource type is registered.
+	ResourceTypeExists(ctx context.Context, name enum.ResourceType) (bool, error)
+	// ListResourceTypes lists every registered resource type.
+	ListResourceTypes(ctx context.Context) ([]*models.ResourceType, error)
+	// DeleteResourceType removes a resource type that is no longer referenced.
+	DeleteResourceType(ctx context.Context, name enum.ResourceType) error
+
+	// CreateActionType registers an action type.
+	CreateActionType(ctx context.Context, actionType *models.ActionType) error
+	// ActionTypeExists reports whether an action type is registered.
+	ActionTypeExists(ctx context.Context, name enum.ActionType) (bool, error)
+	// ListActionTypes lists every registered action type.
+	ListActionTypes(ctx context.Context) ([]*models.ActionType, error)
+	// DeleteActionType removes an action type that is no longer referenced.
+	DeleteActionType(ctx context.Context, name enum.ActionType) error
+}
+
+// repository is the implementation of the Repository interface.
+type repository struct {
+	queries sqlc.Querier
+	logger  *zap.Logger
+}
+
+// NewRepository creates a new repository.
+func NewRepository(conn driver.PostgresPool, logger *zap.Logger) Repository {
+
+	return &repository{
+		queries: sqlc.New(conn),
+		logger:  logger,
+	}
+}
+
+// CreateResourceType registers a resource type.
+func (r *repository) CreateResourceType(ctx context.Context, resourceType *models.ResourceType) error {
+
+	return r.queries.CreateResourceType(ctx, sqlc.CreateResourceTypeParams{
+		Name:        string(resourceType.Name),
+		Description: &resourceType.Description,
+	})
+}
+
+// ResourceTypeExists reports whether a resource type is registered.
+func (r *repository) ResourceTypeExists(ctx context.Context, name enum.ResourceType) (bool, error) {
+
+	if _, err := r.queries.GetResourceType(ctx, string(name)); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get resource type %s: %w", name, err)
+	}
+
+	return true, nil
+}
+
+// ListResourceTypes lists every registered resource type.
+func (r *repository) ListResourceTypes(ctx context.Context) ([]*models.ResourceType, error) {
+
+	sqlcResourceTypes, err := r.queries.ListResourceTypes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resource types: %w", err)
+	}
+
+	resourceTypes := make([]*models.ResourceType, 0, len(sqlcResourceTypes))
+	for _, sqlcResourceType := range sqlcResourceTypes {
+		resourceTypes = append(resourceTypes, new(models.ResourceType).ConvertFromSQLCResourceType(sqlcResourceType))
+	}
+
+	return resourceTypes, nil
+}
+
+// DeleteResourceType removes a resource type that is no longer referenced.
+func (r *repository) DeleteResourceType(ctx context.Context, name enum.ResourceType) error {
+
+	return r.queries.DeleteResourceType(ctx, string(name))
+}
+
+// CreateActionType registers an action type.
+func (r *repository) CreateActionType(ctx context.Context, actionType *models.ActionType) error {
+
+	return r.queries.CreateActionType(ctx, sqlc.CreateActionTypeParams{
+		Name:        string(actionType.Name),
+		Description: &actionType.Description,
+	})
+}
+
+// ActionTypeExists reports whether an action type is registered.
+func (r *repository) ActionTypeExists(ctx context.Context, name enum.ActionType) (bool, error) {
+
+	if _, err := r.queries.GetActionType(ctx, string(name)); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get action type %s: %w", name, err)
+	}
+
+	return true, nil
+}
+
+// ListActionTypes lists every registered action type.
+func (r *repository) ListActionTypes(ctx context.Context) ([]*models.ActionType, error) {
+
+	sqlcActionTypes, err := r.queries.ListActionTypes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list action types: %w", err)
+	}
+
+	actionTypes := make([]*models.ActionType, 0, len(sqlcActionTypes))
+	for _, sqlcActionType := range sqlcActionTypes {
+		actionTypes = append(actionTypes, new(models.ActionType).ConvertFromSQLCActionType(sqlcActionType))
+	}
+
+	return actionTypes, nil
+}
+
+// DeleteActionType removes an action type that is no longer referenced.
+func (r *repository) DeleteActionType(ctx context.Context, name enum.ActionType) error {
+
+	return r.queries.DeleteActionType(ctx, string(name))
+}
@@ -0,0 +1,176 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/models/enum"
+)
+
+// typeNamePattern mirrors the CHECK constraint on resource_types.name and action_types.name.
+var typeNamePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{0,63}$`)
+
+// Service is the interface for the resource and action type registry.
+type Service interface {
+	// RegisterResourceType registers a new resource type.
+	RegisterResourceType(ctx context.Context, resourceType *models.ResourceType) error
+	// ListResourceTypes lists every registered resource type.
+	ListResourceTypes(ctx context.Context) ([]*models.ResourceType, error)
+	// DeleteResourceType removes a resource type that no permission or relation refers to.
+	DeleteResourceType(ctx context.Context, name enum.ResourceType) error
+
+	// RegisterActionType registers a new action type.
+	RegisterActionType(ctx context.Context, actionType *models.ActionType) error
+	// ListActionTypes lists every registered action type.
+	ListActionTypes(ctx context.Context) ([]*models.ActionType, error)
+	// DeleteActionType removes an action type that no permission refers to.
+	DeleteActionType(ctx context.Context, name enum.ActionType) error
+
+	// ValidateResourceType checks that a resource type is registered.
+	ValidateResourceType(ctx context.Context, name enum.ResourceType) error
+	// Validate checks that a resource type and an action type are registered.
+	Validate(ctx context.Context, resource enum.ResourceType, action enum.ActionType) error
+}
+
+// service is the implementation of the Service interface.
+type service struct {
+	repo Repository
+}
+
+// NewService creates a new service.
+func NewService(
+	repo Repository,
+) Service {
+	return &service{
+		repo: repo,
+	}
+}
+
+// NormalizeResourceType returns the canonical, upper-case name of a resource type.
+func NormalizeResourceType(name enum.ResourceType) (enum.ResourceType, error) {
+	normalized, err := normalizeName(string(name))
+	return enum.ResourceType(normalized), err
+}
+
+// NormalizeActionType returns the canonical, upper-case name of an action type.
+func NormalizeActionType(name enum.ActionType) (enum.ActionType, error) {
+	normalized, err := normalizeName(string(name))
+	return enum.ActionType(normalized), err
+}
+
+// RegisterResourceType registers a new resource type.
+func (s *service) RegisterResourceType(ctx context.Context, resourceType *models.ResourceType) error {
+
+	name, err := NormalizeResourceType(resourceType.Name)
+	if err != nil {
+		return err
+	}
+	resourceType.Name = name
+
+	return s.repo.CreateResourceType(ctx, resourceType)
+}
+
+// ListResourceTypes lists every registered resource type.
+func (s *service) ListResourceTypes(ctx context.Context) ([]*models.ResourceType, error) {
+
+	return s.repo.ListResourceTypes(ctx)
+}
+
+// DeleteResourceType removes a resource type that no permission or relation refers to.
+func (s *service) DeleteResourceType(ctx context.Context, name enum.ResourceType) error {
+
+	name, err := NormalizeResourceType(name)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.DeleteResourceType(ctx, name)
+}
+
+// RegisterActionType registers a new action type.
+func (s *service) RegisterActionType(ctx context.Context, actionType *models.ActionType) error {
+
+	name, err := NormalizeActionType(actionType.Name)
+	if err != nil {
+		return err
+	}
+	actionType.Name = name
+
+	return s.repo.CreateActionType(ctx, actionType)
+}
+
+// ListActionTypes lists every registered action type.
+func (s *service) ListActionTypes(ctx context.Context) ([]*models.ActionType, error) {
+
+	return s.repo.ListActionTypes(ctx)
+}
+
+// DeleteActionType removes an action type that no permission refers to.
+func (s *service) DeleteActionType(ctx context.Context, name enum.ActionType) error {
+
+	name, err := NormalizeActionType(name)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.DeleteActionType(ctx, name)
+}
+
+// ValidateResourceType checks that a resource type is registered.
+func (s *service) ValidateResourceType(ctx context.Context, name enum.ResourceType) error {
+
+	name, err := NormalizeResourceType(name)
+	if err != nil {
+		return err
+	}
+
+	exists, err := s.repo.ResourceTypeExists(ctx, name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("unknown resource type %q", name)
+	}
+
+	return nil
+}
+
+// Validate checks that a resource type and an action type are registered.
+func (s *service) Validate(ctx context.Context, resource enum.ResourceType, action enum.ActionType) error {
+
+	if err := s.ValidateResourceType(ctx, resource); err != nil {
+		return err
+	}
+
+	action, err := NormalizeActionType(action)
+	if err != nil {
+		return err
+	}
+
+	exists, err := s.repo.ActionTypeExists(ctx, action)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("unknown action type %q", action)
+	}
+
+	return nil
+}
+
+// normalizeName upper-cases a type name and checks it against typeNamePattern.
+func normalizeName(name string) (string, error) {
+	if name == "" {
+		return "", errors.New("type name is required")
+	}
+
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if !typeNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid type name %q: must start with a letter and contain only letters, digits and underscores", name)
+	}
+	return name, nil
+}
@@ -0,0 +1,83 @@
+package resource
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"goflare.io/auth/internal/models/enum"
+)
+
+func TestNormalizeResourceType(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    enum.ResourceType
+		wantErr bool
+	}{
+		{"ORDER", "ORDER", false},
+		{"order", "ORDER", false},
+		{" invoice_line ", "INVOICE_LINE", false},
+		{"V2_ORDER", "V2_ORDER", false},
+		{"A" + strings.Repeat("B", 63), enum.ResourceType("A" + strings.Repeat("B", 63)), false},
+		{"A" + strings.Repeat("B", 64), "", true},
+		{"", "", true},
+		{"2FA", "", true},
+		{"_ORDER", "", true},
+		{"ORDER-ITEM", "", true},
+		{"order/42", "", true},
+		{"ORDER ITEM", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeResourceType(enum.ResourceType(tt.name))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NormalizeResourceType(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NormalizeResourceType(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// memoryRepository knows a fixed set of resource and action types.
+type memoryRepository struct {
+	Repository
+	resourceTypes map[enum.ResourceType]bool
+	actionTypes   map[enum.ActionType]bool
+}
+
+func (r *memoryRepository) ResourceTypeExists(_ context.Context, name enum.ResourceType) (bool, error) {
+	return r.resourceTypes[name], nil
+}
+
+func (r *memoryRepository) ActionTypeExists(_ context.Context, name enum.ActionType) (bool, error) {
+	return r.actionTypes[name], nil
+}
+
+func TestValidate(t *testing.T) {
+	s := NewService(&memoryRepository{
+		resourceTypes: map[enum.ResourceType]bool{"ORDER": true, "INVOICE": true},
+		actionTypes:   map[enum.ActionType]bool{"READ": true, "APPROVE": true},
+	})
+
+	tests := []struct {
+		resource enum.ResourceType
+		action   enum.ActionType
+		wantErr  bool
+	}{
+		{"ORDER", "READ", false},
+		{"invoice", "approve", false},
+		{"PRODUCT", "READ", true},
+		{"ORDER", "REFUND", true},
+		{"ORDER", "", true},
+		{"", "READ", true},
+		{"ORDER", "*", true},
+	}
+
+	for _, tt := range tests {
+		if err := s.Validate(context.Background(), tt.resource, tt.action); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%q, %q) error = %v, wantErr %v", tt.resource, tt.action, err, tt.wantErr)
+		}
+	}
+}
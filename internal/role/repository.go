@@ -3,6 +3,9 @@ package role
 import (
 	"context"
 	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
 
 	"go.uber.org/zap"
 
@@ -23,6 +26,9 @@ type Repository interface {
 	RemovePermissionFromRole(ctx context.Context, roleID, permissionID uint64) error
 	FindRolePermissions(ctx context.Context, roleID uint64) ([]*models.Permission, error)
 	ListAllRoles(ctx context.Context) ([]*models.Role, error)
+	GrantPermission(ctx context.Context, roleName string, permissionID uint64) error
+	RevokePermission(ctx context.Context, roleName string, permissionID uint64) error
+	FindPermissionRoles(ctx context.Context, permissionID uint64) ([]string, error)
 }
 
 // repository is the implementation of the Repository interface.s
@@ -128,3 +134,50 @@ func (r *repository) ListAllRoles(ctx context.Context) ([]*models.Role, error) {
 
 	return roles, nil
 }
+
+// GrantPermission grants a permission to the role with a name. Granting a permission twice is not an error.
+// Returns pgx.ErrNoRows if there is no such role.
+func (r *repository) GrantPermission(ctx context.Context, roleName string, permissionID uint64) error {
+
+	granted, err := sqlc.New(r.conn).AssignPermissionToRoleByName(ctx, sqlc.AssignPermissionToRoleByNameParams{
+		PermissionID: permissionID,
+		RoleName:     roleName,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to grant permission %d to role %s: %w", permissionID, roleName, err)
+	}
+	if granted == 0 {
+		return fmt.Errorf("role %s not found: %w", roleName, pgx.ErrNoRows)
+	}
+
+	return nil
+}
+
+// RevokePermission revokes a permission from the role with a name.
+// Returns pgx.ErrNoRows if the role does not have the permission.
+func (r *repository) RevokePermission(ctx context.Context, roleName string, permissionID uint64) error {
+
+	revoked, err := sqlc.New(r.conn).RemovePermissionFromRoleByName(ctx, sqlc.RemovePermissionFromRoleByNameParams{
+		RoleName:     roleName,
+		PermissionID: permissionID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revoke permission %d from role %s: %w", permissionID, roleName, err)
+	}
+	if revoked == 0 {
+		return fmt.Errorf("role %s does not have permission %d: %w", roleName, permissionID, pgx.ErrNoRows)
+	}
+
+	return nil
+}
+
+// FindPermissionRoles finds the names of the roles a permission is granted to.
+func (r *repository) FindPermissionRoles(ctx context.Context, permissionID uint64) ([]string, error) {
+
+	names, err := sqlc.New(r.conn).GetPermissionRoleNames(ctx, permissionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get roles of permission %d: %w", permissionID, err)
+	}
+
+	return names, nil
+}
@@ -3,6 +3,8 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,11 +12,19 @@ import (
 	"time"
 
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 
+	"goflare.io/auth/internal/admin"
+	"goflare.io/auth/internal/audit"
 	"goflare.io/auth/internal/authentication"
 	"goflare.io/auth/internal/authorization"
+	"goflare.io/auth/internal/config"
 	"goflare.io/auth/internal/handler"
 	"goflare.io/auth/internal/middleware"
+	"goflare.io/auth/internal/migrations"
+	"goflare.io/auth/internal/outbox"
+	"goflare.io/auth/pkg/interceptor"
+	authpb "goflare.io/auth/proto/pb/proto/auth"
 )
 
 // Server represents the server
@@ -23,7 +33,22 @@ type Server struct {
 	server         *http.Server
 	authentication authentication.Service
 	authorization  authorization.Service
+	migrator       migrations.Migrator
+	bootstrap      admin.Bootstrap
+	auditLog       audit.Service
+	relay          outbox.Relay
 	user           *handler.UserHandler
+	authz          *handler.AuthorizationHandler
+	resource       *handler.ResourceHandler
+	identity       *handler.IdentityHandler
+	oauth          *handler.OAuthHandler
+	accounts       *handler.ServiceAccountHandler
+	apiKeys        *handler.APIKeyHandler
+	admin          *handler.AdminHandler
+	audit          *handler.AuditHandler
+	grpcAuth       *handler.GRPCHandler
+	grpcServer     *grpc.Server
+	grpcAddress    string
 	middleware     *middleware.AuthenticationMiddleware
 	logger         *zap.Logger
 }
@@ -32,8 +57,22 @@ type Server struct {
 func NewServer(
 	middleware *middleware.AuthenticationMiddleware,
 	user *handler.UserHandler,
+	authz *handler.AuthorizationHandler,
+	resource *handler.ResourceHandler,
+	identity *handler.IdentityHandler,
+	oauth *handler.OAuthHandler,
+	accounts *handler.ServiceAccountHandler,
+	apiKeys *handler.APIKeyHandler,
+	admin *handler.AdminHandler,
+	audit *handler.AuditHandler,
+	grpcAuth *handler.GRPCHandler,
 	authentication authentication.Service,
 	authorization authorization.Service,
+	migrator migrations.Migrator,
+	bootstrap admin.Bootstrap,
+	auditLog audit.Service,
+	relay outbox.Relay,
+	cfg *config.Config,
 	logger *zap.Logger,
 ) *Server {
 	mux := http.NewServeMux()
@@ -41,8 +80,22 @@ func NewServer(
 		mux:            mux,
 		authentication: authentication,
 		authorization:  authorization,
+		migrator:       migrator,
+		bootstrap:      bootstrap,
+		auditLog:       auditLog,
+		relay:          relay,
 		middleware:     middleware,
 		user:           user,
+		authz:          authz,
+		resource:       resource,
+		identity:       identity,
+		oauth:          oauth,
+		accounts:       accounts,
+		apiKeys:        apiKeys,
+		admin:          admin,
+		audit:          audit,
+		grpcAuth:       grpcAuth,
+		grpcAddress:    cfg.GRPC.Address,
 		logger:         logger,
 	}
 }
@@ -52,19 +105,79 @@ func (s *Server) Start(address string) error {
 	s.registerRoutes()
 	s.server = &http.Server{
 		Addr:              address,
-		Handler:           s.mux,
+		Handler:           s.auditLog.Middleware(s.mux),
 		ReadHeaderTimeout: 10 * time.Second, // 添加這一行
 	}
 	return s.server.ListenAndServe()
 }
 
-// Run runs the server.
+// listenGRPC creates the gRPC server of AuthService and the listener it serves on.
+func (s *Server) listenGRPC(address string) (net.Listener, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", address, err)
+	}
+
+	guard := interceptor.New(interceptor.Config{
+		Verifier:    s.middleware,
+		Checker:     s.middleware,
+		Permissions: handler.GRPCPermissions,
+		Public:      handler.PublicGRPCMethods,
+	})
+	s.grpcServer = grpc.NewServer(grpc.UnaryInterceptor(guard.Unary()), grpc.StreamInterceptor(guard.Stream()))
+	authpb.RegisterAuthServiceServer(s.grpcServer, s.grpcAuth)
+	return listener, nil
+}
+
+// Run runs the server, once the schema is ready and the first administrator exists or can be created, with the
+// relay of domain events and the writer of the audit log. AuthService is served over gRPC too when an address is configured.
 func (s *Server) Run(address string) error {
+	if err := s.migrator.Prepare(context.Background()); err != nil {
+		return fmt.Errorf("failed to prepare database schema: %w", err)
+	}
+	if err := s.bootstrap.Prepare(context.Background()); err != nil {
+		return fmt.Errorf("failed to bootstrap the first administrator: %w", err)
+	}
+
+	var grpcListener net.Listener
+	if s.grpcAddress != "" {
+		var err error
+		if grpcListener, err = s.listenGRPC(s.grpcAddress); err != nil {
+			return err
+		}
+	}
+
+	auditCtx, stopAudit := context.WithCancel(context.Background())
+	auditDone := make(chan struct{})
+	go func() {
+		defer close(auditDone)
+		if err := s.auditLog.Run(auditCtx); err != nil {
+			s.logger.Error("audit writer stopped", zap.Error(err))
+		}
+	}()
+
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		if err := s.relay.Run(relayCtx); err != nil {
+			s.logger.Error("event relay stopped", zap.Error(err))
+		}
+	}()
+
 	go func() {
 		// Load policies
 		if err := s.authorization.LoadPolicies(context.Background()); err != nil {
 			s.logger.Fatal("Failed to load policies", zap.Error(err))
 		}
+		if grpcListener != nil {
+			go func() {
+				s.logger.Info("Starting gRPC server on " + s.grpcAddress)
+				if err := s.grpcServer.Serve(grpcListener); err != nil {
+					panic(err)
+				}
+			}()
+		}
 		s.logger.Info("Starting server on " + address)
 		if err := s.Start(address); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			panic(err)
@@ -78,7 +191,24 @@ func (s *Server) Run(address string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	return s.server.Shutdown(ctx)
+	err := s.server.Shutdown(ctx)
+	if s.grpcServer != nil {
+		stopped := make(chan struct{})
+		go func() {
+			s.grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			s.grpcServer.Stop()
+		}
+	}
+	stopRelay()
+	<-relayDone
+	stopAudit()
+	<-auditDone
+	return err
 }
 
 // registerRoutes registers the routes for the server.
@@ -87,7 +217,72 @@ func (s *Server) registerRoutes() {
 	s.mux.HandleFunc("/register", s.user.Register)
 	s.mux.HandleFunc("/firebase/login", s.user.FirebaseLogin)
 	s.mux.HandleFunc("/firebase/register", s.user.RegisterWithFirebase)
-	s.mux.HandleFunc("/oauth/login", s.user.OAuthLogin)
-	s.mux.HandleFunc("/oauth/callback", s.user.OAuthCallback)
+	s.mux.HandleFunc("GET /login/{provider}", s.identity.Login)
+	s.mux.HandleFunc("GET /login/{provider}/callback", s.identity.Callback)
+	s.mux.HandleFunc("POST /login/{provider}/token", s.identity.TokenLogin)
+	s.mux.HandleFunc("GET /me/identities", s.middleware.AuthorizeUser(s.identity.ListIdentities))
+	s.mux.HandleFunc("POST /me/identities/{provider}", s.middleware.AuthorizeUser(s.identity.LinkIdentity))
+	s.mux.HandleFunc("DELETE /me/identities/{provider}", s.middleware.AuthorizeUser(s.identity.UnlinkIdentity))
+	s.mux.HandleFunc("POST /me/password", s.middleware.AuthorizeUser(s.identity.ChangePassword))
+	s.mux.HandleFunc("GET /oauth2/authorize", s.oauth.Authorize)
+	s.mux.HandleFunc("POST /oauth2/authorize", s.middleware.AuthorizeUser(s.oauth.AuthorizeUser))
+	s.mux.HandleFunc("POST /oauth2/token", s.oauth.Token)
+	s.mux.HandleFunc("POST /oauth2/device_authorization", s.oauth.DeviceAuthorization)
+	s.mux.HandleFunc("GET /oauth2/device", s.middleware.AuthorizeUser(s.oauth.VerifyDevice))
+	s.mux.HandleFunc("POST /oauth2/device", s.middleware.AuthorizeUser(s.oauth.DecideDevice))
+	s.mux.HandleFunc("GET /oauth2/userinfo", s.middleware.AuthorizePrincipal(s.oauth.UserInfo))
+	s.mux.HandleFunc("POST /oauth2/userinfo", s.middleware.AuthorizePrincipal(s.oauth.UserInfo))
+	s.mux.HandleFunc("GET /.well-known/openid-configuration", s.oauth.Discovery)
+	s.mux.HandleFunc("GET /.well-known/jwks.json", s.oauth.KeySet)
+	s.mux.HandleFunc("GET /.well-known/paseto-keys.json", s.oauth.TokenKeySet)
+	s.mux.HandleFunc("GET /oauth2/principal", s.middleware.AuthorizePrincipal(s.oauth.Principal))
+	s.mux.HandleFunc("GET /oauth2/clients", s.middleware.AuthorizePrincipal(s.oauth.ListClients))
+	s.mux.HandleFunc("POST /oauth2/clients", s.middleware.AuthorizePrincipal(s.oauth.RegisterClient))
+	s.mux.HandleFunc("DELETE /oauth2/clients/{client_id}", s.middleware.AuthorizePrincipal(s.oauth.DeleteClient))
+	s.mux.HandleFunc("GET /service-accounts", s.middleware.AuthorizePrincipal(s.accounts.ListServiceAccounts))
+	s.mux.HandleFunc("POST /service-accounts", s.middleware.AuthorizePrincipal(s.accounts.CreateServiceAccount))
+	s.mux.HandleFunc("GET /service-accounts/{id}", s.middleware.AuthorizePrincipal(s.accounts.GetServiceAccount))
+	s.mux.HandleFunc("PUT /service-accounts/{id}", s.middleware.AuthorizePrincipal(s.accounts.UpdateServiceAccount))
+	s.mux.HandleFunc("DELETE /service-accounts/{id}", s.middleware.AuthorizePrincipal(s.accounts.DeleteServiceAccount))
+	s.mux.HandleFunc("POST /service-accounts/{id}/secret", s.middleware.AuthorizePrincipal(s.accounts.RotateSecret))
+	s.mux.HandleFunc("GET /service-accounts/{id}/roles", s.middleware.AuthorizePrincipal(s.accounts.ListRoles))
+	s.mux.HandleFunc("PUT /service-accounts/{id}/roles/{role}", s.middleware.AuthorizePrincipal(s.accounts.AssignRole))
+	s.mux.HandleFunc("DELETE /service-accounts/{id}/roles/{role}", s.middleware.AuthorizePrincipal(s.accounts.RemoveRole))
+	s.mux.HandleFunc("GET /me/consents", s.middleware.AuthorizeUser(s.oauth.ListConsents))
+	s.mux.HandleFunc("DELETE /me/consents/{client_id}", s.middleware.AuthorizeUser(s.oauth.RevokeConsent))
+	s.mux.HandleFunc("GET /me/api-keys", s.middleware.AuthorizeUser(s.apiKeys.ListAPIKeys))
+	s.mux.HandleFunc("POST /me/api-keys", s.middleware.AuthorizeUser(s.apiKeys.CreateAPIKey))
+	s.mux.HandleFunc("PATCH /me/api-keys/{id}", s.middleware.AuthorizeUser(s.apiKeys.RenameAPIKey))
+	s.mux.HandleFunc("DELETE /me/api-keys/{id}", s.middleware.AuthorizeUser(s.apiKeys.RevokeAPIKey))
+	s.mux.HandleFunc("POST /bootstrap", s.admin.Bootstrap)
+	s.mux.HandleFunc("GET /admin/users", s.middleware.AuthorizePrincipal(s.admin.ListUsers))
+	s.mux.HandleFunc("POST /admin/users", s.middleware.AuthorizePrincipal(s.admin.CreateUser))
+	s.mux.HandleFunc("POST /admin/users/{id}/disable", s.middleware.AuthorizePrincipal(s.admin.DisableUser))
+	s.mux.HandleFunc("POST /admin/users/{id}/enable", s.middleware.AuthorizePrincipal(s.admin.EnableUser))
+	s.mux.HandleFunc("DELETE /admin/users/{id}", s.middleware.AuthorizePrincipal(s.admin.DeleteUser))
+	s.mux.HandleFunc("PUT /admin/users/{id}/roles/{role}", s.middleware.AuthorizePrincipal(s.admin.AssignRole))
+	s.mux.HandleFunc("DELETE /admin/users/{id}/roles/{role}", s.middleware.AuthorizePrincipal(s.admin.RemoveRole))
+	s.mux.HandleFunc("GET /admin/roles", s.middleware.AuthorizePrincipal(s.admin.ListRoles))
+	s.mux.HandleFunc("PUT /admin/roles/{role}/permissions/{permission}", s.middleware.AuthorizePrincipal(s.admin.GrantPermission))
+	s.mux.HandleFunc("DELETE /admin/roles/{role}/permissions/{permission}", s.middleware.AuthorizePrincipal(s.admin.RevokePermission))
+	s.mux.HandleFunc("GET /admin/permissions", s.middleware.AuthorizePrincipal(s.admin.ListPermissions))
+	s.mux.HandleFunc("POST /admin/permissions", s.middleware.AuthorizePrincipal(s.admin.CreatePermission))
+	s.mux.HandleFunc("DELETE /admin/permissions/{permission}", s.middleware.AuthorizePrincipal(s.admin.DeletePermission))
+	s.mux.HandleFunc("GET /admin/policies", s.middleware.AuthorizePrincipal(s.admin.Policies))
+	s.mux.HandleFunc("GET /audit/events", s.middleware.AuthorizePrincipal(s.audit.ListEvents))
+	s.mux.HandleFunc("GET /audit/verify", s.middleware.AuthorizePrincipal(s.audit.Verify))
 	s.mux.HandleFunc("/check", s.middleware.AuthorizeUser(s.user.CheckPermission))
+	s.mux.HandleFunc("POST /check/resource", s.middleware.AuthorizeUser(s.authz.CheckResourcePermission))
+	s.mux.HandleFunc("POST /check/batch", s.middleware.AuthorizeUser(s.authz.BatchCheckPermission))
+	s.mux.HandleFunc("POST /check/explain", s.middleware.AuthorizeUser(s.authz.ExplainPermission))
+	s.mux.HandleFunc("POST /policies/dry-run", s.middleware.AuthorizeUser(s.authz.DryRunPolicies))
+	s.mux.HandleFunc("GET /permissions", s.middleware.AuthorizeUser(s.authz.ListPermissions))
+	s.mux.HandleFunc("POST /relations", s.middleware.AuthorizeUser(s.authz.GrantRelation))
+	s.mux.HandleFunc("DELETE /relations", s.middleware.AuthorizeUser(s.authz.RevokeRelation))
+	s.mux.HandleFunc("GET /resource-types", s.middleware.AuthorizePrincipal(s.resource.ListResourceTypes))
+	s.mux.HandleFunc("POST /resource-types", s.middleware.AuthorizePrincipal(s.resource.RegisterResourceType))
+	s.mux.HandleFunc("DELETE /resource-types/{name}", s.middleware.AuthorizePrincipal(s.resource.DeleteResourceType))
+	s.mux.HandleFunc("GET /action-types", s.middleware.AuthorizePrincipal(s.resource.ListActionTypes))
+	s.mux.HandleFunc("POST /action-types", s.middleware.AuthorizePrincipal(s.resource.RegisterActionType))
+	s.mux.HandleFunc("DELETE /action-types/{name}", s.middleware.AuthorizePrincipal(s.resource.DeleteActionType))
 }
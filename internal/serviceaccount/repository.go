@@ -0,0 +1,267 @@
+package serviceaccount
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/outbox"
+	"goflare.io/auth/internal/sqlc"
+	"goflare.io/nexus/driver"
+)
+
+// _ is a type assertion to ensure that the repository implements the Repository interface.
+var _ Repository = (*repository)(nil)
+
+// Repository is the interface for the service account repository.
+type Repository interface {
+
+	// CreateServiceAccount creates a service account.
+	CreateServiceAccount(ctx context.Context, account *models.ServiceAccount) (uint64, error)
+
+	// FindServiceAccountByID finds a service account by its ID.
+	FindServiceAccountByID(ctx context.Context, id uint64) (*models.ServiceAccount, error)
+
+	// FindServiceAccountByClientID finds a service account by its client ID.
+	FindServiceAccountByClientID(ctx context.Context, clientID string) (*models.ServiceAccount, error)
+
+	// ListServiceAccounts lists every service account.
+	ListServiceAccounts(ctx context.Context) ([]*models.ServiceAccount, error)
+
+	// UpdateServiceAccount updates the description, scopes and disabled flag of a service account.
+	UpdateServiceAccount(ctx context.Context, account *models.ServiceAccount) error
+
+	// RotateSecret replaces the secret hash of a service account, keeping the previous one until previousExpiresAt.
+	RotateSecret(ctx context.Context, id uint64, secretHash string, previousExpiresAt time.Time) error
+
+	// TouchServiceAccount records that a service account obtained a token.
+	TouchServiceAccount(ctx context.Context, id uint64) error
+
+	// DeleteServiceAccount deletes a service account.
+	DeleteServiceAccount(ctx context.Context, id uint64) error
+
+	// AssignRole assigns a role, by name, to a service account, and writes the role.assigned event when the account
+	// did not have it.
+	AssignRole(ctx context.Context, id uint64, roleName string) error
+
+	// RemoveRole removes a role, by name, from a service account.
+	RemoveRole(ctx context.Context, id uint64, roleName string) error
+
+	// FindServiceAccountRoles retrieves the roles of a service account.
+	FindServiceAccountRoles(ctx context.Context, id uint64) ([]*models.Role, error)
+
+	// ListRoleAssignments lists the role names of every enabled service account by its ID.
+	ListRoleAssignments(ctx context.Context) (map[uint64][]string, error)
+}
+
+// repository is the implementation of the Repository interface.
+type repository struct {
+	conn   driver.PostgresPool
+	logger *zap.Logger
+}
+
+// NewRepository creates a new repository.
+func NewRepository(conn driver.PostgresPool, logger *zap.Logger) Repository {
+	return &repository{
+		conn:   conn,
+		logger: logger,
+	}
+}
+
+// CreateServiceAccount creates a service account.
+func (r *repository) CreateServiceAccount(ctx context.Context, account *models.ServiceAccount) (uint64, error) {
+	id, err := sqlc.New(r.conn).CreateServiceAccount(ctx, sqlc.CreateServiceAccountParams{
+		Name:        account.Name,
+		Description: &account.Description,
+		ClientID:    account.ClientID,
+		SecretHash:  account.SecretHash,
+		Scopes:      account.Scopes,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create service account: %w", err)
+	}
+
+	return id, nil
+}
+
+// FindServiceAccountByID finds a service account by its ID.
+func (r *repository) FindServiceAccountByID(ctx context.Context, id uint64) (*models.ServiceAccount, error) {
+	sqlcAccount, err := sqlc.New(r.conn).GetServiceAccount(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service account %d: %w", id, err)
+	}
+
+	return new(models.ServiceAccount).ConvertFromSQLCServiceAccount(sqlcAccount), nil
+}
+
+// FindServiceAccountByClientID finds a service account by its client ID.
+func (r *repository) FindServiceAccountByClientID(ctx context.Context, clientID string) (*models.ServiceAccount, error) {
+	sqlcAccount, err := sqlc.New(r.conn).GetServiceAccountByClientID(ctx, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service account by client id: %w", err)
+	}
+
+	return new(models.ServiceAccount).ConvertFromSQLCServiceAccount(sqlcAccount), nil
+}
+
+// ListServiceAccounts lists every service account.
+func (r *repository) ListServiceAccounts(ctx context.Context) ([]*models.ServiceAccount, error) {
+	sqlcAccounts, err := sqlc.New(r.conn).ListServiceAccounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service accounts: %w", err)
+	}
+
+	accounts := make([]*models.ServiceAccount, 0, len(sqlcAccounts))
+	for _, sqlcAccount := range sqlcAccounts {
+		accounts = append(accounts, new(models.ServiceAccount).ConvertFromSQLCServiceAccount(sqlcAccount))
+	}
+
+	return accounts, nil
+}
+
+// UpdateServiceAccount updates the description, scopes and disabled flag of a service account.
+func (r *repository) UpdateServiceAccount(ctx context.Context, account *models.ServiceAccount) error {
+	updated, err := sqlc.New(r.conn).UpdateServiceAccount(ctx, sqlc.UpdateServiceAccountParams{
+		ID:          account.ID,
+		Description: &account.Description,
+		Scopes:      account.Scopes,
+		Disabled:    account.Disabled,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update service account %d: %w", account.ID, err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("no service account %d: %w", account.ID, pgx.ErrNoRows)
+	}
+
+	return nil
+}
+
+// RotateSecret replaces the secret hash of a service account. The current hash becomes the previous one,
+// accepted until previousExpiresAt.
+func (r *repository) RotateSecret(ctx context.Context, id uint64, secretHash string, previousExpiresAt time.Time) error {
+	updated, err := sqlc.New(r.conn).RotateServiceAccountSecret(ctx, sqlc.RotateServiceAccountSecretParams{
+		PreviousSecretExpiresAt: pgtype.Timestamptz{Time: previousExpiresAt, Valid: true},
+		SecretHash:              secretHash,
+		ID:                      id,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rotate secret of service account %d: %w", id, err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("no service account %d: %w", id, pgx.ErrNoRows)
+	}
+
+	return nil
+}
+
+// TouchServiceAccount records that a service account obtained a token.
+func (r *repository) TouchServiceAccount(ctx context.Context, id uint64) error {
+	return sqlc.New(r.conn).TouchServiceAccount(ctx, id)
+}
+
+// DeleteServiceAccount deletes a service account with its role assignments.
+func (r *repository) DeleteServiceAccount(ctx context.Context, id uint64) error {
+	deleted, err := sqlc.New(r.conn).DeleteServiceAccount(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete service account %d: %w", id, err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("no service account %d: %w", id, pgx.ErrNoRows)
+	}
+
+	return nil
+}
+
+// AssignRole assigns a role, by name, to a service account. Assigning a role twice is not an error, and writes the
+// role.assigned event only the first time.
+// Returns pgx.ErrNoRows if there is no such role.
+func (r *repository) AssignRole(ctx context.Context, id uint64, roleName string) (err error) {
+	tx, err := r.conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				r.logger.Error("failed to rollback transaction", zap.Error(rbErr))
+			}
+		}
+	}()
+
+	queries := sqlc.New(r.conn).WithTx(tx)
+	inserted, err := queries.AssignRoleToServiceAccount(ctx, sqlc.AssignRoleToServiceAccountParams{
+		ServiceAccountID: id,
+		RoleName:         roleName,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("no role %s: %w", roleName, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to assign role %s to service account %d: %w", roleName, id, err)
+	}
+
+	if inserted {
+		if err = outbox.Write(ctx, queries, outbox.ServiceAccountRoleAssigned(id, roleName, time.Now())); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveRole removes a role, by name, from a service account.
+func (r *repository) RemoveRole(ctx context.Context, id uint64, roleName string) error {
+	removed, err := sqlc.New(r.conn).RemoveRoleFromServiceAccount(ctx, sqlc.RemoveRoleFromServiceAccountParams{
+		ServiceAccountID: id,
+		RoleName:         roleName,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove role %s from service account %d: %w", roleName, id, err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("role %s is not assigned to service account %d: %w", roleName, id, pgx.ErrNoRows)
+	}
+
+	return nil
+}
+
+// FindServiceAccountRoles retrieves the roles of a service account.
+func (r *repository) FindServiceAccountRoles(ctx context.Context, id uint64) ([]*models.Role, error) {
+	sqlcRoles, err := sqlc.New(r.conn).GetServiceAccountRoles(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get roles of service account %d: %w", id, err)
+	}
+
+	roles := make([]*models.Role, 0, len(sqlcRoles))
+	for _, sqlcRole := range sqlcRoles {
+		roles = append(roles, new(models.Role).ConvertFromSQLCRole(sqlcRole))
+	}
+
+	return roles, nil
+}
+
+// ListRoleAssignments lists the role names of every enabled service account by its ID.
+func (r *repository) ListRoleAssignments(ctx context.Context) (map[uint64][]string, error) {
+	rows, err := sqlc.New(r.conn).ListServiceAccountRoleNames(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service account roles: %w", err)
+	}
+
+	assignments := make(map[uint64][]string)
+	for _, row := range rows {
+		assignments[row.ServiceAccountID] = append(assignments[row.ServiceAccountID], row.RoleName)
+	}
+
+	return assignments, nil
+}
@@ -0,0 +1,378 @@
+package serviceaccount
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+
+	"goflare.io/auth/internal/audit"
+	"goflare.io/auth/internal/config"
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/policy"
+)
+
+// DefaultSecretGracePeriod is how long the previous secret keeps working after a rotation unless configured otherwise.
+const DefaultSecretGracePeriod = 24 * time.Hour
+
+var (
+	// ErrInvalidCredentials is returned when a service account cannot be authenticated with the given client credentials.
+	ErrInvalidCredentials = errors.New("invalid client credentials")
+
+	// ErrInvalidScope is returned for a scope that cannot be part of a space-separated scope parameter.
+	ErrInvalidScope = errors.New("invalid scope")
+)
+
+// _ is used to ensure that *service implements the Service interface at compile time.
+var _ Service = (*service)(nil)
+
+// Service manages service accounts, the principals backend jobs authenticate as. Their roles are loaded into
+// the enforcer as grouping policies of their own subject, see policy.ServiceAccountSubject.
+type Service interface {
+
+	// CreateServiceAccount creates a service account and returns its secret, which cannot be retrieved again.
+	CreateServiceAccount(ctx context.Context, account *models.ServiceAccount) (string, error)
+
+	// GetServiceAccount gets a service account by its ID.
+	GetServiceAccount(ctx context.Context, id uint64) (*models.ServiceAccount, error)
+
+	// ListServiceAccounts lists every service account.
+	ListServiceAccounts(ctx context.Context) ([]*models.ServiceAccount, error)
+
+	// UpdateServiceAccount updates the description, scopes and disabled flag of a service account.
+	UpdateServiceAccount(ctx context.Context, account *models.ServiceAccount) error
+
+	// DeleteServiceAccount deletes a service account and its grouping policies.
+	DeleteServiceAccount(ctx context.Context, id uint64) error
+
+	// RotateSecret issues a new secret. The previous secret keeps working for the configured grace period
+	// unless revokePrevious is set.
+	RotateSecret(ctx context.Context, id uint64, revokePrevious bool) (string, error)
+
+	// AssignRole assigns a role to a service account.
+	AssignRole(ctx context.Context, id uint64, roleName string) error
+
+	// RemoveRole removes a role from a service account.
+	RemoveRole(ctx context.Context, id uint64, roleName string) error
+
+	// ListRoles lists the roles of a service account.
+	ListRoles(ctx context.Context, id uint64) ([]*models.Role, error)
+
+	// Authenticate authenticates a service account with its client credentials.
+	Authenticate(ctx context.Context, clientID, secret string) (*models.ServiceAccount, error)
+}
+
+// service is the implementation of the Service interface.
+type service struct {
+	repository  Repository
+	enforcer    *casbin.Enforcer
+	decisions   *policy.DecisionCache
+	gracePeriod time.Duration
+	audit       audit.Service
+	logger      *zap.Logger
+}
+
+// NewService creates a new service account service with the secret grace period from the configuration.
+func NewService(
+	repository Repository,
+	enforcer *casbin.Enforcer,
+	decisions *policy.DecisionCache,
+	cfg *config.Config,
+	audit audit.Service,
+	logger *zap.Logger,
+) Service {
+	gracePeriod := cfg.ServiceAccounts.SecretGracePeriod
+	if gracePeriod <= 0 {
+		gracePeriod = DefaultSecretGracePeriod
+	}
+	return &service{
+		repository:  repository,
+		enforcer:    enforcer,
+		decisions:   decisions,
+		gracePeriod: gracePeriod,
+		audit:       audit,
+		logger:      logger,
+	}
+}
+
+// CreateServiceAccount creates a service account with a random client ID and secret.
+func (s *service) CreateServiceAccount(ctx context.Context, account *models.ServiceAccount) (secret string, err error) {
+	defer func() {
+		s.record(ctx, audit.ActionServiceAccountCreate, account.ID, nil, account, err)
+	}()
+
+	account.Name = strings.TrimSpace(account.Name)
+	if len(account.Name) < 2 {
+		return "", errors.New("name must be at least 2 characters")
+	}
+	if err = validateScopes(account.Scopes); err != nil {
+		return "", err
+	}
+	if account.Scopes == nil {
+		account.Scopes = []string{}
+	}
+
+	clientID, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+	secret, secretHash, err := newSecret()
+	if err != nil {
+		return "", err
+	}
+	account.ClientID = "sa-" + clientID
+	account.SecretHash = secretHash
+
+	if account.ID, err = s.repository.CreateServiceAccount(ctx, account); err != nil {
+		return "", err
+	}
+
+	s.logger.Info("created service account", zap.Uint64("id", account.ID), zap.String("name", account.Name))
+	return secret, nil
+}
+
+// GetServiceAccount gets a service account by its ID.
+func (s *service) GetServiceAccount(ctx context.Context, id uint64) (*models.ServiceAccount, error) {
+	return s.repository.FindServiceAccountByID(ctx, id)
+}
+
+// ListServiceAccounts lists every service account.
+func (s *service) ListServiceAccounts(ctx context.Context) ([]*models.ServiceAccount, error) {
+	return s.repository.ListServiceAccounts(ctx)
+}
+
+// UpdateServiceAccount updates the description, scopes and disabled flag of a service account. Disabling an account
+// removes its grouping policies, so tokens it already holds lose their permissions; enabling it restores them.
+func (s *service) UpdateServiceAccount(ctx context.Context, account *models.ServiceAccount) (err error) {
+	var previous *models.ServiceAccount
+	defer func() {
+		action := audit.ActionServiceAccountUpdate
+		if previous != nil && previous.Disabled != account.Disabled {
+			action = audit.ActionServiceAccountEnable
+			if account.Disabled {
+				action = audit.ActionServiceAccountDisable
+			}
+		}
+		var before any
+		if previous != nil {
+			before = previous
+		}
+		s.record(ctx, action, account.ID, before, account, err)
+	}()
+
+	if err = validateScopes(account.Scopes); err != nil {
+		return err
+	}
+	if account.Scopes == nil {
+		account.Scopes = []string{}
+	}
+
+	if previous, err = s.repository.FindServiceAccountByID(ctx, account.ID); err != nil {
+		return err
+	}
+	if err = s.repository.UpdateServiceAccount(ctx, account); err != nil {
+		return err
+	}
+
+	if account.Disabled {
+		return s.removeGroupingPolicies(account.ID)
+	}
+
+	roles, err := s.repository.FindServiceAccountRoles(ctx, account.ID)
+	if err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if err = s.addGroupingPolicy(account.ID, role.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteServiceAccount deletes a service account and its grouping policies.
+func (s *service) DeleteServiceAccount(ctx context.Context, id uint64) (err error) {
+	defer func() {
+		s.record(ctx, audit.ActionServiceAccountDelete, id, nil, nil, err)
+	}()
+
+	if err = s.repository.DeleteServiceAccount(ctx, id); err != nil {
+		return err
+	}
+
+	s.logger.Info("deleted service account", zap.Uint64("id", id))
+	return s.removeGroupingPolicies(id)
+}
+
+// RotateSecret issues a new secret. When revokePrevious is set the previous secret stops working at once,
+// e.g. after it leaked.
+func (s *service) RotateSecret(ctx context.Context, id uint64, revokePrevious bool) (secret string, err error) {
+	defer func() {
+		s.record(ctx, audit.ActionServiceAccountRotateSecret, id, nil, map[string]bool{"revoke_previous": revokePrevious}, err)
+	}()
+
+	secret, secretHash, err := newSecret()
+	if err != nil {
+		return "", err
+	}
+
+	previousExpiresAt := time.Now().Add(s.gracePeriod)
+	if revokePrevious {
+		previousExpiresAt = time.Now()
+	}
+
+	if err = s.repository.RotateSecret(ctx, id, secretHash, previousExpiresAt); err != nil {
+		return "", err
+	}
+
+	s.logger.Info("rotated service account secret", zap.Uint64("id", id), zap.Bool("revoke_previous", revokePrevious))
+	return secret, nil
+}
+
+// AssignRole assigns a role to a service account and adds the grouping policy of an enabled account.
+func (s *service) AssignRole(ctx context.Context, id uint64, roleName string) (err error) {
+	defer func() {
+		s.record(ctx, audit.ActionRoleAssign, id, nil, map[string]string{"role": roleName}, err)
+	}()
+
+	account, err := s.repository.FindServiceAccountByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err = s.repository.AssignRole(ctx, id, roleName); err != nil {
+		return err
+	}
+
+	if account.Disabled {
+		return nil
+	}
+	return s.addGroupingPolicy(id, roleName)
+}
+
+// RemoveRole removes a role from a service account with its grouping policy.
+func (s *service) RemoveRole(ctx context.Context, id uint64, roleName string) (err error) {
+	defer func() {
+		s.record(ctx, audit.ActionRoleRemove, id, map[string]string{"role": roleName}, nil, err)
+	}()
+
+	if err = s.repository.RemoveRole(ctx, id, roleName); err != nil {
+		return err
+	}
+
+	defer s.decisions.Invalidate()
+	if _, err = s.enforcer.RemoveGroupingPolicy(policy.ServiceAccountSubject(id), roleName); err != nil {
+		return fmt.Errorf("failed to remove grouping policy: %w", err)
+	}
+	return nil
+}
+
+// ListRoles lists the roles of a service account.
+func (s *service) ListRoles(ctx context.Context, id uint64) ([]*models.Role, error) {
+	return s.repository.FindServiceAccountRoles(ctx, id)
+}
+
+// Authenticate authenticates a service account with its client credentials. The previous secret is accepted
+// until its grace period ends. Every failure is reported as ErrInvalidCredentials.
+func (s *service) Authenticate(ctx context.Context, clientID, secret string) (*models.ServiceAccount, error) {
+	if clientID == "" || secret == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	account, err := s.repository.FindServiceAccountByClientID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if account.Disabled {
+		return nil, ErrInvalidCredentials
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(account.SecretHash), []byte(secret)) != nil {
+		if account.PreviousSecretHash == "" || time.Now().After(account.PreviousSecretExpiresAt) ||
+			bcrypt.CompareHashAndPassword([]byte(account.PreviousSecretHash), []byte(secret)) != nil {
+			return nil, ErrInvalidCredentials
+		}
+		s.logger.Info("service account authenticated with its previous secret", zap.Uint64("id", account.ID))
+	}
+
+	if err = s.repository.TouchServiceAccount(ctx, account.ID); err != nil {
+		s.logger.Warn("failed to record service account use", zap.Uint64("id", account.ID), zap.Error(err))
+	}
+
+	return account, nil
+}
+
+// addGroupingPolicy assigns a role to the subject of a service account in the enforcer.
+func (s *service) addGroupingPolicy(id uint64, roleName string) error {
+	defer s.decisions.Invalidate()
+	if _, err := s.enforcer.AddGroupingPolicy(policy.ServiceAccountSubject(id), roleName); err != nil {
+		return fmt.Errorf("failed to add grouping policy: %w", err)
+	}
+	return nil
+}
+
+// removeGroupingPolicies removes every role of the subject of a service account from the enforcer.
+func (s *service) removeGroupingPolicies(id uint64) error {
+	defer s.decisions.Invalidate()
+	if _, err := s.enforcer.RemoveFilteredGroupingPolicy(0, policy.ServiceAccountSubject(id)); err != nil {
+		return fmt.Errorf("failed to remove grouping policies: %w", err)
+	}
+	return nil
+}
+
+// record records a change of a service account by the actor in ctx. Secrets are never part of its state.
+func (s *service) record(ctx context.Context, action string, id uint64, before, after any, err error) {
+	event := &models.AuditEvent{
+		Action:     action,
+		TargetType: audit.TargetServiceAccount,
+		TargetID:   strconv.FormatUint(id, 10),
+		Before:     audit.State(before),
+		After:      audit.State(after),
+	}
+	event.Outcome, event.Reason = audit.Outcome(err)
+	s.audit.Record(ctx, event)
+}
+
+// validateScopes checks that scopes can be joined into a space-separated scope parameter.
+func validateScopes(scopes []string) error {
+	for _, scope := range scopes {
+		if scope == "" || strings.ContainsAny(scope, " \"\\") {
+			return fmt.Errorf("%w %q", ErrInvalidScope, scope)
+		}
+	}
+	return nil
+}
+
+// newSecret generates a client secret and its bcrypt hash.
+func newSecret() (string, string, error) {
+	secret, err := randomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to hash client secret: %w", err)
+	}
+	return secret, string(hash), nil
+}
+
+// randomToken returns n random bytes encoded as unpadded base64url.
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
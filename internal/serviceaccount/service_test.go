@@ -0,0 +1,313 @@
+package serviceaccount
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"go.uber.org/zap"
+
+	"goflare.io/auth/internal/audit"
+	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/policy"
+)
+
+// memoryRepository is a repository of service accounts and their roles held in memory.
+type memoryRepository struct {
+	Repository
+	accounts map[uint64]*models.ServiceAccount
+	roles    map[uint64][]string
+	nextID   uint64
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{
+		accounts: map[uint64]*models.ServiceAccount{},
+		roles:    map[uint64][]string{},
+	}
+}
+
+func (r *memoryRepository) CreateServiceAccount(_ context.Context, account *models.ServiceAccount) (uint64, error) {
+	r.nextID++
+	stored := *account
+	stored.ID = r.nextID
+	r.accounts[stored.ID] = &stored
+	return stored.ID, nil
+}
+
+func (r *memoryRepository) FindServiceAccountByID(_ context.Context, id uint64) (*models.ServiceAccount, error) {
+	account, ok := r.accounts[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	found := *account
+	return &found, nil
+}
+
+func (r *memoryRepository) FindServiceAccountByClientID(_ context.Context, clientID string) (*models.ServiceAccount, error) {
+	for _, account := range r.accounts {
+		if account.ClientID == clientID {
+			found := *account
+			return &found, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *memoryRepository) UpdateServiceAccount(_ context.Context, account *models.ServiceAccount) error {
+	stored, ok := r.accounts[account.ID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	stored.Description, stored.Scopes, stored.Disabled = account.Description, account.Scopes, account.Disabled
+	return nil
+}
+
+func (r *memoryRepository) RotateSecret(_ context.Context, id uint64, secretHash string, previousExpiresAt time.Time) error {
+	stored, ok := r.accounts[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	stored.PreviousSecretHash, stored.SecretHash = stored.SecretHash, secretHash
+	stored.PreviousSecretExpiresAt = previousExpiresAt
+	return nil
+}
+
+func (r *memoryRepository) DeleteServiceAccount(_ context.Context, id uint64) error {
+	if _, ok := r.accounts[id]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(r.accounts, id)
+	delete(r.roles, id)
+	return nil
+}
+
+func (r *memoryRepository) AssignRole(_ context.Context, id uint64, roleName string) error {
+	r.roles[id] = append(r.roles[id], roleName)
+	return nil
+}
+
+func (r *memoryRepository) FindServiceAccountRoles(_ context.Context, id uint64) ([]*models.Role, error) {
+	roles := make([]*models.Role, 0, len(r.roles[id]))
+	for _, name := range r.roles[id] {
+		roles = append(roles, &models.Role{Name: name})
+	}
+	return roles, nil
+}
+
+func (r *memoryRepository) TouchServiceAccount(context.Context, uint64) error {
+	return nil
+}
+
+// recordingAudit is an audit service keeping the recorded events.
+type recordingAudit struct {
+	audit.Service
+	events []*models.AuditEvent
+}
+
+func (a *recordingAudit) Record(_ context.Context, event *models.AuditEvent) {
+	a.events = append(a.events, event)
+}
+
+func newTestService(t *testing.T) (*service, *memoryRepository, *recordingAudit) {
+	t.Helper()
+
+	enforcer, err := casbin.NewEnforcer("../../configs/casbin/casbin.conf")
+	if err != nil {
+		t.Fatalf("failed to load model: %v", err)
+	}
+	policy.RegisterFunctions(enforcer)
+
+	repository := newMemoryRepository()
+	recorder := &recordingAudit{}
+	return &service{
+		repository:  repository,
+		enforcer:    enforcer,
+		decisions:   policy.NewDecisionCache(time.Minute, 100),
+		gracePeriod: DefaultSecretGracePeriod,
+		audit:       recorder,
+		logger:      zap.NewNop(),
+	}, repository, recorder
+}
+
+func TestServiceAccountAudit(t *testing.T) {
+	tests := []struct {
+		name       string
+		change     func(ctx context.Context, s *service, id uint64) error
+		wantAction string
+		wantFailed bool
+	}{
+		{
+			name: "update",
+			change: func(ctx context.Context, s *service, id uint64) error {
+				return s.UpdateServiceAccount(ctx, &models.ServiceAccount{ID: id, Description: "nightly", Scopes: []string{"ORDER:READ"}})
+			},
+			wantAction: audit.ActionServiceAccountUpdate,
+		},
+		{
+			name: "disable",
+			change: func(ctx context.Context, s *service, id uint64) error {
+				return s.UpdateServiceAccount(ctx, &models.ServiceAccount{ID: id, Disabled: true})
+			},
+			wantAction: audit.ActionServiceAccountDisable,
+		},
+		{
+			name: "update of an unknown account",
+			change: func(ctx context.Context, s *service, _ uint64) error {
+				return s.UpdateServiceAccount(ctx, &models.ServiceAccount{ID: 99, Disabled: true})
+			},
+			wantAction: audit.ActionServiceAccountUpdate,
+			wantFailed: true,
+		},
+		{
+			name: "rotate secret",
+			change: func(ctx context.Context, s *service, id uint64) error {
+				_, err := s.RotateSecret(ctx, id, true)
+				return err
+			},
+			wantAction: audit.ActionServiceAccountRotateSecret,
+		},
+		{
+			name:       "delete",
+			change:     func(ctx context.Context, s *service, id uint64) error { return s.DeleteServiceAccount(ctx, id) },
+			wantAction: audit.ActionServiceAccountDelete,
+		},
+		{
+			name:       "delete of an unknown account",
+			change:     func(ctx context.Context, s *service, _ uint64) error { return s.DeleteServiceAccount(ctx, 99) },
+			wantAction: audit.ActionServiceAccountDelete,
+			wantFailed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _, recorder := newTestService(t)
+			ctx := context.Background()
+
+			account := &models.ServiceAccount{Name: "orders", Scopes: []string{"ORDER:READ"}}
+			if _, err := s.CreateServiceAccount(ctx, account); err != nil {
+				t.Fatalf("CreateServiceAccount() error = %v", err)
+			}
+			if err := tt.change(ctx, s, account.ID); (err != nil) != tt.wantFailed {
+				t.Fatalf("change error = %v, wantFailed %v", err, tt.wantFailed)
+			}
+
+			if len(recorder.events) != 2 {
+				t.Fatalf("recorded %d events, want 2", len(recorder.events))
+			}
+			if created := recorder.events[0]; created.Action != audit.ActionServiceAccountCreate || created.Outcome != models.AuditSuccess {
+				t.Errorf("first event = %s %s, want a successful %s", created.Action, created.Outcome, audit.ActionServiceAccountCreate)
+			}
+
+			event := recorder.events[1]
+			if event.Action != tt.wantAction || event.TargetType != audit.TargetServiceAccount {
+				t.Errorf("event = %s on %s, want %s on %s", event.Action, event.TargetType, tt.wantAction, audit.TargetServiceAccount)
+			}
+			if failed := event.Outcome == models.AuditFailure; failed != tt.wantFailed {
+				t.Errorf("event outcome = %s, wantFailed %v", event.Outcome, tt.wantFailed)
+			}
+			for _, state := range []string{string(event.Before), string(event.After), string(recorder.events[0].After)} {
+				if strings.Contains(state, "$2a$") {
+					t.Errorf("event state %s contains a secret hash", state)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateServiceAccountGroupingPolicies(t *testing.T) {
+	s, _, recorder := newTestService(t)
+	ctx := context.Background()
+
+	account := &models.ServiceAccount{Name: "orders"}
+	if _, err := s.CreateServiceAccount(ctx, account); err != nil {
+		t.Fatalf("CreateServiceAccount() error = %v", err)
+	}
+	if err := s.AssignRole(ctx, account.ID, "editor"); err != nil {
+		t.Fatalf("AssignRole() error = %v", err)
+	}
+
+	subject := policy.ServiceAccountSubject(account.ID)
+	hasRole := func() bool {
+		ok, err := s.enforcer.HasGroupingPolicy(subject, "editor")
+		if err != nil {
+			t.Fatalf("HasGroupingPolicy() error = %v", err)
+		}
+		return ok
+	}
+
+	steps := []struct {
+		disabled   bool
+		wantAction string
+		wantRole   bool
+	}{
+		{true, audit.ActionServiceAccountDisable, false},
+		{true, audit.ActionServiceAccountUpdate, false},
+		{false, audit.ActionServiceAccountEnable, true},
+	}
+	for _, step := range steps {
+		if err := s.UpdateServiceAccount(ctx, &models.ServiceAccount{ID: account.ID, Disabled: step.disabled}); err != nil {
+			t.Fatalf("UpdateServiceAccount() error = %v", err)
+		}
+		if got := recorder.events[len(recorder.events)-1].Action; got != step.wantAction {
+			t.Errorf("disabled = %v: action = %s, want %s", step.disabled, got, step.wantAction)
+		}
+		if got := hasRole(); got != step.wantRole {
+			t.Errorf("disabled = %v: has role = %v, want %v", step.disabled, got, step.wantRole)
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	s, repository, _ := newTestService(t)
+	ctx := context.Background()
+
+	account := &models.ServiceAccount{Name: "orders"}
+	previous, err := s.CreateServiceAccount(ctx, account)
+	if err != nil {
+		t.Fatalf("CreateServiceAccount() error = %v", err)
+	}
+	current, err := s.RotateSecret(ctx, account.ID, false)
+	if err != nil {
+		t.Fatalf("RotateSecret() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		prepare func()
+		secret  string
+		wantErr bool
+	}{
+		{name: "current secret", secret: current},
+		{name: "previous secret within the grace period", secret: previous},
+		{name: "wrong secret", secret: "wrong", wantErr: true},
+		{
+			name:    "previous secret after the grace period",
+			prepare: func() { repository.accounts[account.ID].PreviousSecretExpiresAt = time.Now().Add(-time.Second) },
+			secret:  previous,
+			wantErr: true,
+		},
+		{
+			name:    "disabled account",
+			prepare: func() { repository.accounts[account.ID].Disabled = true },
+			secret:  current,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			_, err := s.Authenticate(ctx, account.ClientID, tt.secret)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Authenticate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
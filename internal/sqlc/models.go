@@ -11,63 +11,6 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-type ActionType string
-
-const (
-	ActionTypeCREATE ActionType = "CREATE"
-	ActionTypeREAD   ActionType = "READ"
-	ActionTypeUPDATE ActionType = "UPDATE"
-	ActionTypeDELETE ActionType = "DELETE"
-	ActionTypeLIST   ActionType = "LIST"
-)
-
-func (e *ActionType) Scan(src interface{}) error {
-	switch s := src.(type) {
-	case []byte:
-		*e = ActionType(s)
-	case string:
-		*e = ActionType(s)
-	default:
-		return fmt.Errorf("unsupported scan type for ActionType: %T", src)
-	}
-	return nil
-}
-
-type NullActionType struct {
-	ActionType ActionType `json:"actionType"`
-	Valid      bool       `json:"valid"` // Valid is true if ActionType is not NULL
-}
-
-// Scan implements the Scanner interface.
-func (ns *NullActionType) Scan(value interface{}) error {
-	if value == nil {
-		ns.ActionType, ns.Valid = "", false
-		return nil
-	}
-	ns.Valid = true
-	return ns.ActionType.Scan(value)
-}
-
-// Value implements the driver Valuer interface.
-func (ns NullActionType) Value() (driver.Value, error) {
-	if !ns.Valid {
-		return nil, nil
-	}
-	return string(ns.ActionType), nil
-}
-
-func (e ActionType) Valid() bool {
-	switch e {
-	case ActionTypeCREATE,
-		ActionTypeREAD,
-		ActionTypeUPDATE,
-		ActionTypeDELETE,
-		ActionTypeLIST:
-		return true
-	}
-	return false
-}
-
 type ProviderType string
 
 const (
@@ -143,71 +86,138 @@ func (e ProviderType) Valid() bool {
 	return false
 }
 
-type ResourceType string
+type ActionType struct {
+	Name        string             `json:"name"`
+	Description *string            `json:"description"`
+	CreatedAt   pgtype.Timestamptz `json:"createdAt"`
+}
 
-const (
-	ResourceTypeUSER       ResourceType = "USER"
-	ResourceTypeROLE       ResourceType = "ROLE"
-	ResourceTypePERMISSION ResourceType = "PERMISSION"
-	ResourceTypePRODUCT    ResourceType = "PRODUCT"
-	ResourceTypeORDER      ResourceType = "ORDER"
-)
+type ApiKey struct {
+	ID          uint64             `json:"id"`
+	UserID      uint64             `json:"userId"`
+	Name        string             `json:"name"`
+	TokenPrefix string             `json:"tokenPrefix"`
+	TokenHash   string             `json:"tokenHash"`
+	Scopes      []string           `json:"scopes"`
+	ExpiresAt   pgtype.Timestamptz `json:"expiresAt"`
+	LastUsedAt  pgtype.Timestamptz `json:"lastUsedAt"`
+	RevokedAt   pgtype.Timestamptz `json:"revokedAt"`
+	CreatedAt   pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt   pgtype.Timestamptz `json:"updatedAt"`
+}
 
-func (e *ResourceType) Scan(src interface{}) error {
-	switch s := src.(type) {
-	case []byte:
-		*e = ResourceType(s)
-	case string:
-		*e = ResourceType(s)
-	default:
-		return fmt.Errorf("unsupported scan type for ResourceType: %T", src)
-	}
-	return nil
+type AuditEvent struct {
+	ID         uint64             `json:"id"`
+	OccurredAt pgtype.Timestamptz `json:"occurredAt"`
+	ActorType  string             `json:"actorType"`
+	ActorID    string             `json:"actorId"`
+	Action     string             `json:"action"`
+	TargetType string             `json:"targetType"`
+	TargetID   string             `json:"targetId"`
+	Outcome    string             `json:"outcome"`
+	Reason     string             `json:"reason"`
+	IpAddress  string             `json:"ipAddress"`
+	RequestID  string             `json:"requestId"`
+	Before     []byte             `json:"before"`
+	After      []byte             `json:"after"`
+	PrevHash   string             `json:"prevHash"`
+	Hash       string             `json:"hash"`
 }
 
-type NullResourceType struct {
-	ResourceType ResourceType `json:"resourceType"`
-	Valid        bool         `json:"valid"` // Valid is true if ResourceType is not NULL
+type OauthAuthorizationCode struct {
+	CodeHash      string             `json:"codeHash"`
+	ClientID      string             `json:"clientId"`
+	UserID        uint64             `json:"userId"`
+	RedirectUri   string             `json:"redirectUri"`
+	Scopes        []string           `json:"scopes"`
+	CodeChallenge string             `json:"codeChallenge"`
+	ExpiresAt     pgtype.Timestamptz `json:"expiresAt"`
+	CreatedAt     pgtype.Timestamptz `json:"createdAt"`
+	Nonce         string             `json:"nonce"`
 }
 
-// Scan implements the Scanner interface.
-func (ns *NullResourceType) Scan(value interface{}) error {
-	if value == nil {
-		ns.ResourceType, ns.Valid = "", false
-		return nil
-	}
-	ns.Valid = true
-	return ns.ResourceType.Scan(value)
+type OauthClient struct {
+	ID           uint64             `json:"id"`
+	ClientID     string             `json:"clientId"`
+	SecretHash   *string            `json:"secretHash"`
+	Name         string             `json:"name"`
+	RedirectUris []string           `json:"redirectUris"`
+	Scopes       []string           `json:"scopes"`
+	CreatedAt    pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt    pgtype.Timestamptz `json:"updatedAt"`
 }
 
-// Value implements the driver Valuer interface.
-func (ns NullResourceType) Value() (driver.Value, error) {
-	if !ns.Valid {
-		return nil, nil
-	}
-	return string(ns.ResourceType), nil
+type OauthConsent struct {
+	UserID    uint64             `json:"userId"`
+	ClientID  string             `json:"clientId"`
+	Scopes    []string           `json:"scopes"`
+	GrantedAt pgtype.Timestamptz `json:"grantedAt"`
 }
 
-func (e ResourceType) Valid() bool {
-	switch e {
-	case ResourceTypeUSER,
-		ResourceTypeROLE,
-		ResourceTypePERMISSION,
-		ResourceTypePRODUCT,
-		ResourceTypeORDER:
-		return true
-	}
-	return false
+type OauthDeviceCode struct {
+	DeviceCodeHash string             `json:"deviceCodeHash"`
+	UserCode       string             `json:"userCode"`
+	ClientID       string             `json:"clientId"`
+	Scopes         []string           `json:"scopes"`
+	Status         string             `json:"status"`
+	ApprovedBy     *int32             `json:"approvedBy"`
+	PollInterval   int32              `json:"pollInterval"`
+	LastPolledAt   pgtype.Timestamptz `json:"lastPolledAt"`
+	ExpiresAt      pgtype.Timestamptz `json:"expiresAt"`
+	CreatedAt      pgtype.Timestamptz `json:"createdAt"`
+}
+
+type OauthRefreshToken struct {
+	TokenHash string             `json:"tokenHash"`
+	ClientID  string             `json:"clientId"`
+	UserID    uint64             `json:"userId"`
+	Scopes    []string           `json:"scopes"`
+	ExpiresAt pgtype.Timestamptz `json:"expiresAt"`
+	RevokedAt pgtype.Timestamptz `json:"revokedAt"`
+	CreatedAt pgtype.Timestamptz `json:"createdAt"`
+}
+
+type OutboxEvent struct {
+	ID            uint64             `json:"id"`
+	EventID       string             `json:"eventId"`
+	EventType     string             `json:"eventType"`
+	Version       int32              `json:"version"`
+	AggregateType string             `json:"aggregateType"`
+	AggregateID   string             `json:"aggregateId"`
+	Payload       []byte             `json:"payload"`
+	CreatedAt     pgtype.Timestamptz `json:"createdAt"`
+	Attempts      int32              `json:"attempts"`
+	NextAttemptAt pgtype.Timestamptz `json:"nextAttemptAt"`
+	LastError     string             `json:"lastError"`
+	PublishedAt   pgtype.Timestamptz `json:"publishedAt"`
 }
 
 type Permission struct {
-	ID          uint64             `json:"id"`
+	ID              uint64             `json:"id"`
+	Name            string             `json:"name"`
+	Description     *string            `json:"description"`
+	Resource        string             `json:"resource"`
+	Action          string             `json:"action"`
+	CreatedAt       pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt       pgtype.Timestamptz `json:"updatedAt"`
+	AttrCondition   string             `json:"attrCondition"`
+	TimeWindow      string             `json:"timeWindow"`
+	ResourcePattern string             `json:"resourcePattern"`
+	Effect          string             `json:"effect"`
+}
+
+type ResourceRelation struct {
+	ResourceType string             `json:"resourceType"`
+	ResourceID   string             `json:"resourceId"`
+	Relation     string             `json:"relation"`
+	UserID       uint64             `json:"userId"`
+	CreatedAt    pgtype.Timestamptz `json:"createdAt"`
+}
+
+type ResourceType struct {
 	Name        string             `json:"name"`
 	Description *string            `json:"description"`
-	Resource    ResourceType       `json:"resource"`
-	Action      ActionType         `json:"action"`
 	CreatedAt   pgtype.Timestamptz `json:"createdAt"`
-	UpdatedAt   pgtype.Timestamptz `json:"updatedAt"`
 }
 
 type Role struct {
@@ -223,6 +233,26 @@ type RolePermission struct {
 	PermissionID uint64 `json:"permissionId"`
 }
 
+type ServiceAccount struct {
+	ID                      uint64             `json:"id"`
+	Name                    string             `json:"name"`
+	Description             *string            `json:"description"`
+	ClientID                string             `json:"clientId"`
+	SecretHash              string             `json:"secretHash"`
+	PreviousSecretHash      *string            `json:"previousSecretHash"`
+	PreviousSecretExpiresAt pgtype.Timestamptz `json:"previousSecretExpiresAt"`
+	Scopes                  []string           `json:"scopes"`
+	Disabled                bool               `json:"disabled"`
+	LastUsedAt              pgtype.Timestamptz `json:"lastUsedAt"`
+	CreatedAt               pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt               pgtype.Timestamptz `json:"updatedAt"`
+}
+
+type ServiceAccountRole struct {
+	ServiceAccountID uint64 `json:"serviceAccountId"`
+	RoleID           uint64 `json:"roleId"`
+}
+
 type User struct {
 	ID           uint64             `json:"id"`
 	Username     string             `json:"username"`
@@ -235,6 +265,21 @@ type User struct {
 	PhotoUrl     *string            `json:"photoUrl"`
 	CreatedAt    pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt    pgtype.Timestamptz `json:"updatedAt"`
+	LastSignInAt pgtype.Timestamptz `json:"lastSignInAt"`
+	DisabledAt   pgtype.Timestamptz `json:"disabledAt"`
+}
+
+type UserIdentity struct {
+	ID             uint64             `json:"id"`
+	UserID         uint64             `json:"userId"`
+	Provider       string             `json:"provider"`
+	Subject        string             `json:"subject"`
+	Email          string             `json:"email"`
+	LinkedAt       pgtype.Timestamptz `json:"linkedAt"`
+	AccessToken    []byte             `json:"accessToken"`
+	RefreshToken   []byte             `json:"refreshToken"`
+	TokenExpiresAt pgtype.Timestamptz `json:"tokenExpiresAt"`
+	UpdatedAt      pgtype.Timestamptz `json:"updatedAt"`
 }
 
 type UserRole struct {
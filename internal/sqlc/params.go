@@ -0,0 +1,10 @@
+package sqlc
+
+// Optional returns nil for an empty string, which the queries take as NULL: the column is written as NULL or left
+// unchanged, or a filter matches everything.
+func Optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -10,15 +10,19 @@ import (
 )
 
 const createPermission = `-- name: CreatePermission :exec
-INSERT INTO permissions (name, description, resource, action)
-VALUES ($1, $2, $3, $4)
+INSERT INTO permissions (name, description, resource, action, attr_condition, time_window, resource_pattern, effect)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 `
 
 type CreatePermissionParams struct {
-	Name        string       `json:"name"`
-	Description *string      `json:"description"`
-	Resource    ResourceType `json:"resource"`
-	Action      ActionType   `json:"action"`
+	Name            string  `json:"name"`
+	Description     *string `json:"description"`
+	Resource        string  `json:"resource"`
+	Action          string  `json:"action"`
+	AttrCondition   string  `json:"attrCondition"`
+	TimeWindow      string  `json:"timeWindow"`
+	ResourcePattern string  `json:"resourcePattern"`
+	Effect          string  `json:"effect"`
 }
 
 func (q *Queries) CreatePermission(ctx context.Context, arg CreatePermissionParams) error {
@@ -27,6 +31,10 @@ func (q *Queries) CreatePermission(ctx context.Context, arg CreatePermissionPara
 		arg.Description,
 		arg.Resource,
 		arg.Action,
+		arg.AttrCondition,
+		arg.TimeWindow,
+		arg.ResourcePattern,
+		arg.Effect,
 	)
 	return err
 }
@@ -41,14 +49,18 @@ func (q *Queries) DeletePermission(ctx context.Context, id uint64) error {
 }
 
 const getPermissionByID = `-- name: GetPermissionByID :one
-SELECT name, description, resource, action FROM permissions WHERE id = $1
+SELECT name, description, resource, action, attr_condition, time_window, resource_pattern, effect FROM permissions WHERE id = $1
 `
 
 type GetPermissionByIDRow struct {
-	Name        string       `json:"name"`
-	Description *string      `json:"description"`
-	Resource    ResourceType `json:"resource"`
-	Action      ActionType   `json:"action"`
+	Name            string  `json:"name"`
+	Description     *string `json:"description"`
+	Resource        string  `json:"resource"`
+	Action          string  `json:"action"`
+	AttrCondition   string  `json:"attrCondition"`
+	TimeWindow      string  `json:"timeWindow"`
+	ResourcePattern string  `json:"resourcePattern"`
+	Effect          string  `json:"effect"`
 }
 
 func (q *Queries) GetPermissionByID(ctx context.Context, id uint64) (*GetPermissionByIDRow, error) {
@@ -59,6 +71,46 @@ func (q *Queries) GetPermissionByID(ctx context.Context, id uint64) (*GetPermiss
 		&i.Description,
 		&i.Resource,
 		&i.Action,
+		&i.AttrCondition,
+		&i.TimeWindow,
+		&i.ResourcePattern,
+		&i.Effect,
 	)
 	return &i, err
 }
+
+const listPermissions = `-- name: ListPermissions :many
+SELECT id, name, description, resource, action, created_at, updated_at, attr_condition, time_window, resource_pattern, effect FROM permissions ORDER BY id
+`
+
+func (q *Queries) ListPermissions(ctx context.Context) ([]*Permission, error) {
+	rows, err := q.db.Query(ctx, listPermissions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*Permission{}
+	for rows.Next() {
+		var i Permission
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Resource,
+			&i.Action,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.AttrCondition,
+			&i.TimeWindow,
+			&i.ResourcePattern,
+			&i.Effect,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -6,32 +6,111 @@ package sqlc
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
+	AddResourceRelation(ctx context.Context, arg AddResourceRelationParams) error
 	AssignPermissionToRole(ctx context.Context, arg AssignPermissionToRoleParams) error
+	AssignPermissionToRoleByName(ctx context.Context, arg AssignPermissionToRoleByNameParams) (int64, error)
+	AssignRoleToServiceAccount(ctx context.Context, arg AssignRoleToServiceAccountParams) (bool, error)
 	AssignRoleToUser(ctx context.Context, arg AssignRoleToUserParams) error
+	AssignRoleToUserByName(ctx context.Context, arg AssignRoleToUserByNameParams) (bool, error)
+	ClaimOutboxEvents(ctx context.Context, limit int32) ([]*OutboxEvent, error)
+	ConsumeAuthorizationCode(ctx context.Context, codeHash string) (*OauthAuthorizationCode, error)
+	CountUsersWithRole(ctx context.Context, name string) (int64, error)
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (uint64, error)
+	CreateActionType(ctx context.Context, arg CreateActionTypeParams) error
+	CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) (uint64, error)
+	CreateAuthorizationCode(ctx context.Context, arg CreateAuthorizationCodeParams) error
+	CreateDeviceCode(ctx context.Context, arg CreateDeviceCodeParams) error
+	CreateOAuthClient(ctx context.Context, arg CreateOAuthClientParams) (uint64, error)
+	CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) error
 	CreatePermission(ctx context.Context, arg CreatePermissionParams) error
+	CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) error
+	CreateResourceType(ctx context.Context, arg CreateResourceTypeParams) error
 	CreateRole(ctx context.Context, arg CreateRoleParams) error
+	CreateServiceAccount(ctx context.Context, arg CreateServiceAccountParams) (uint64, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (uint64, error)
+	CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) (uint64, error)
+	DecideDeviceCode(ctx context.Context, arg DecideDeviceCodeParams) (int64, error)
+	DeleteActionType(ctx context.Context, name string) error
+	DeleteDeviceCode(ctx context.Context, deviceCodeHash string) (int64, error)
+	DeleteExpiredAuthorizationCodes(ctx context.Context) error
+	DeleteExpiredDeviceCodes(ctx context.Context) error
+	DeleteOAuthClient(ctx context.Context, clientID string) (int64, error)
+	DeleteOAuthConsent(ctx context.Context, arg DeleteOAuthConsentParams) (int64, error)
 	DeletePermission(ctx context.Context, id uint64) error
+	DeletePublishedOutboxEvents(ctx context.Context, publishedAt pgtype.Timestamptz) (int64, error)
+	DeleteResourceType(ctx context.Context, name string) error
 	DeleteRole(ctx context.Context, id uint64) error
-	DeleteUser(ctx context.Context, id uint64) error
-	FindUserByEmail(ctx context.Context, email string) (*FindUserByEmailRow, error)
-	FindUserByFirebaseUID(ctx context.Context, firebaseUid *string) (*FindUserByFirebaseUIDRow, error)
-	FindUserByID(ctx context.Context, id uint64) (*FindUserByIDRow, error)
-	FindUserByUsername(ctx context.Context, username string) (*FindUserByUsernameRow, error)
+	DeleteServiceAccount(ctx context.Context, id uint64) (int64, error)
+	DeleteUser(ctx context.Context, id uint64) (int64, error)
+	DeleteUserIdentity(ctx context.Context, arg DeleteUserIdentityParams) (int64, error)
+	DisableUser(ctx context.Context, id uint64) (int64, error)
+	EnableUser(ctx context.Context, id uint64) (int64, error)
+	FindUserByEmail(ctx context.Context, email string) (*User, error)
+	FindUserByFirebaseUID(ctx context.Context, firebaseUid *string) (*User, error)
+	FindUserByID(ctx context.Context, id uint64) (*User, error)
+	FindUserByUsername(ctx context.Context, username string) (*User, error)
+	FindUserIdentity(ctx context.Context, arg FindUserIdentityParams) (*UserIdentity, error)
+	GetAPIKeyByTokenHash(ctx context.Context, tokenHash string) (*ApiKey, error)
+	GetActionType(ctx context.Context, name string) (*ActionType, error)
+	GetDeviceCode(ctx context.Context, deviceCodeHash string) (*OauthDeviceCode, error)
+	GetDeviceCodeByUserCode(ctx context.Context, userCode string) (*OauthDeviceCode, error)
+	GetLastAuditEventHash(ctx context.Context) (string, error)
+	GetOAuthClient(ctx context.Context, clientID string) (*OauthClient, error)
+	GetOAuthConsent(ctx context.Context, arg GetOAuthConsentParams) (*OauthConsent, error)
 	GetPermissionByID(ctx context.Context, id uint64) (*GetPermissionByIDRow, error)
+	GetPermissionRoleNames(ctx context.Context, permissionID uint64) ([]string, error)
+	GetRefreshToken(ctx context.Context, tokenHash string) (*OauthRefreshToken, error)
+	GetResourceType(ctx context.Context, name string) (*ResourceType, error)
 	GetRoleByID(ctx context.Context, id uint64) (*GetRoleByIDRow, error)
 	GetRolePermissions(ctx context.Context, roleID uint64) ([]*Permission, error)
+	GetServiceAccount(ctx context.Context, id uint64) (*ServiceAccount, error)
+	GetServiceAccountByClientID(ctx context.Context, clientID string) (*ServiceAccount, error)
+	GetServiceAccountRoles(ctx context.Context, serviceAccountID uint64) ([]*Role, error)
+	GetUserResourceRelations(ctx context.Context, arg GetUserResourceRelationsParams) ([]string, error)
 	GetUserRoles(ctx context.Context, userID uint64) ([]*Role, error)
+	ListAPIKeys(ctx context.Context, userID uint64) ([]*ApiKey, error)
+	ListActionTypes(ctx context.Context) ([]*ActionType, error)
+	ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]*AuditEvent, error)
+	ListAuditEventsAfter(ctx context.Context, arg ListAuditEventsAfterParams) ([]*AuditEvent, error)
+	ListOAuthClients(ctx context.Context) ([]*OauthClient, error)
+	ListOAuthConsents(ctx context.Context, userID uint64) ([]*OauthConsent, error)
+	ListPermissions(ctx context.Context) ([]*Permission, error)
+	ListResourceRelations(ctx context.Context, arg ListResourceRelationsParams) ([]*ResourceRelation, error)
+	ListResourceTypes(ctx context.Context) ([]*ResourceType, error)
 	ListRoles(ctx context.Context) ([]*ListRolesRow, error)
-	ListUsers(ctx context.Context) ([]*ListUsersRow, error)
+	ListServiceAccountRoleNames(ctx context.Context) ([]*ListServiceAccountRoleNamesRow, error)
+	ListServiceAccounts(ctx context.Context) ([]*ServiceAccount, error)
+	ListUserIdentities(ctx context.Context, userID uint64) ([]*UserIdentity, error)
+	ListUsers(ctx context.Context) ([]*User, error)
+	MarkOutboxEventFailed(ctx context.Context, arg MarkOutboxEventFailedParams) error
+	MarkOutboxEventPublished(ctx context.Context, id uint64) error
+	RecordDeviceCodePoll(ctx context.Context, arg RecordDeviceCodePollParams) error
 	RemovePermissionFromRole(ctx context.Context, arg RemovePermissionFromRoleParams) error
+	RemovePermissionFromRoleByName(ctx context.Context, arg RemovePermissionFromRoleByNameParams) (int64, error)
+	RemoveResourceRelation(ctx context.Context, arg RemoveResourceRelationParams) error
+	RemoveRoleFromServiceAccount(ctx context.Context, arg RemoveRoleFromServiceAccountParams) (int64, error)
 	RemoveRoleFromUser(ctx context.Context, arg RemoveRoleFromUserParams) error
+	RemoveRoleFromUserByName(ctx context.Context, arg RemoveRoleFromUserByNameParams) (int64, error)
+	RenameAPIKey(ctx context.Context, arg RenameAPIKeyParams) (int64, error)
+	RevokeAPIKey(ctx context.Context, arg RevokeAPIKeyParams) (int64, error)
+	RevokeRefreshToken(ctx context.Context, tokenHash string) (int64, error)
+	RevokeRefreshTokens(ctx context.Context, arg RevokeRefreshTokensParams) error
+	RotateServiceAccountSecret(ctx context.Context, arg RotateServiceAccountSecretParams) (int64, error)
+	TouchAPIKey(ctx context.Context, id uint64) error
+	TouchServiceAccount(ctx context.Context, id uint64) error
+	UpdateServiceAccount(ctx context.Context, arg UpdateServiceAccountParams) (int64, error)
+	UpdateUser(ctx context.Context, arg UpdateUserParams) error
 	UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams) error
-	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (int64, error)
+	UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) error
 	UpdateUsername(ctx context.Context, arg UpdateUsernameParams) error
+	UpsertOAuthConsent(ctx context.Context, arg UpsertOAuthConsentParams) error
+	UpsertUserIdentity(ctx context.Context, arg UpsertUserIdentityParams) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,12 @@
+-- name: CreateActionType :exec
+INSERT INTO action_types (name, description)
+VALUES ($1, $2);
+
+-- name: GetActionType :one
+SELECT name, description, created_at FROM action_types WHERE name = $1;
+
+-- name: ListActionTypes :many
+SELECT name, description, created_at FROM action_types ORDER BY name;
+
+-- name: DeleteActionType :exec
+DELETE FROM action_types WHERE name = $1;
\ No newline at end of file
@@ -0,0 +1,28 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (user_id, name, token_prefix, token_hash, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id;
+
+-- name: GetAPIKeyByTokenHash :one
+SELECT id, user_id, name, token_prefix, token_hash, scopes, expires_at, last_used_at, revoked_at, created_at, updated_at
+FROM api_keys
+WHERE token_hash = $1;
+
+-- name: ListAPIKeys :many
+SELECT id, user_id, name, token_prefix, token_hash, scopes, expires_at, last_used_at, revoked_at, created_at, updated_at
+FROM api_keys
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: RenameAPIKey :execrows
+UPDATE api_keys
+SET name = $3, updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: RevokeAPIKey :execrows
+UPDATE api_keys
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys SET last_used_at = NOW() WHERE id = $1;
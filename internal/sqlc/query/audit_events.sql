@@ -0,0 +1,29 @@
+-- name: CreateAuditEvent :one
+INSERT INTO audit_events (occurred_at, actor_type, actor_id, action, target_type, target_id, outcome, reason, ip_address, request_id, before, after, prev_hash, hash)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+RETURNING id;
+
+-- name: GetLastAuditEventHash :one
+SELECT hash FROM audit_events ORDER BY id DESC LIMIT 1;
+
+-- name: ListAuditEvents :many
+SELECT id, occurred_at, actor_type, actor_id, action, target_type, target_id, outcome, reason, ip_address, request_id, before, after, prev_hash, hash
+FROM audit_events
+WHERE (sqlc.narg(actor_type)::varchar IS NULL OR actor_type = sqlc.narg(actor_type))
+  AND (sqlc.narg(actor_id)::varchar IS NULL OR actor_id = sqlc.narg(actor_id))
+  AND (sqlc.narg(action)::varchar IS NULL OR action = sqlc.narg(action))
+  AND (sqlc.narg(target_type)::varchar IS NULL OR target_type = sqlc.narg(target_type))
+  AND (sqlc.narg(target_id)::varchar IS NULL OR target_id = sqlc.narg(target_id))
+  AND (sqlc.narg(outcome)::varchar IS NULL OR outcome = sqlc.narg(outcome))
+  AND (sqlc.narg(since)::timestamptz IS NULL OR occurred_at >= sqlc.narg(since))
+  AND (sqlc.narg(until)::timestamptz IS NULL OR occurred_at < sqlc.narg(until))
+  AND (sqlc.narg(before_id)::bigint IS NULL OR id < sqlc.narg(before_id))
+ORDER BY id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListAuditEventsAfter :many
+SELECT id, occurred_at, actor_type, actor_id, action, target_type, target_id, outcome, reason, ip_address, request_id, before, after, prev_hash, hash
+FROM audit_events
+WHERE id > $1
+ORDER BY id
+LIMIT $2;
@@ -0,0 +1,98 @@
+-- name: CreateOAuthClient :one
+INSERT INTO oauth_clients (client_id, secret_hash, name, redirect_uris, scopes)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id;
+
+-- name: GetOAuthClient :one
+SELECT id, client_id, secret_hash, name, redirect_uris, scopes, created_at, updated_at
+FROM oauth_clients
+WHERE client_id = $1;
+
+-- name: ListOAuthClients :many
+SELECT id, client_id, secret_hash, name, redirect_uris, scopes, created_at, updated_at
+FROM oauth_clients
+ORDER BY client_id;
+
+-- name: DeleteOAuthClient :execrows
+DELETE FROM oauth_clients WHERE client_id = $1;
+
+-- name: CreateAuthorizationCode :exec
+INSERT INTO oauth_authorization_codes (code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at, nonce)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: ConsumeAuthorizationCode :one
+DELETE FROM oauth_authorization_codes
+WHERE code_hash = $1
+RETURNING code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at, created_at, nonce;
+
+-- name: DeleteExpiredAuthorizationCodes :exec
+DELETE FROM oauth_authorization_codes WHERE expires_at < NOW();
+
+-- name: GetOAuthConsent :one
+SELECT user_id, client_id, scopes, granted_at
+FROM oauth_consents
+WHERE user_id = $1 AND client_id = $2;
+
+-- name: ListOAuthConsents :many
+SELECT user_id, client_id, scopes, granted_at
+FROM oauth_consents
+WHERE user_id = $1
+ORDER BY client_id;
+
+-- name: UpsertOAuthConsent :exec
+INSERT INTO oauth_consents (user_id, client_id, scopes)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, client_id) DO UPDATE
+SET scopes = EXCLUDED.scopes, granted_at = NOW();
+
+-- name: DeleteOAuthConsent :execrows
+DELETE FROM oauth_consents WHERE user_id = $1 AND client_id = $2;
+
+-- name: CreateRefreshToken :exec
+INSERT INTO oauth_refresh_tokens (token_hash, client_id, user_id, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetRefreshToken :one
+SELECT token_hash, client_id, user_id, scopes, expires_at, revoked_at, created_at
+FROM oauth_refresh_tokens
+WHERE token_hash = $1;
+
+-- name: RevokeRefreshToken :execrows
+UPDATE oauth_refresh_tokens
+SET revoked_at = NOW()
+WHERE token_hash = $1 AND revoked_at IS NULL;
+
+-- name: RevokeRefreshTokens :exec
+UPDATE oauth_refresh_tokens
+SET revoked_at = NOW()
+WHERE user_id = $1 AND client_id = $2 AND revoked_at IS NULL;
+
+-- name: CreateDeviceCode :exec
+INSERT INTO oauth_device_codes (device_code_hash, user_code, client_id, scopes, poll_interval, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: GetDeviceCode :one
+SELECT device_code_hash, user_code, client_id, scopes, status, approved_by, poll_interval, last_polled_at, expires_at, created_at
+FROM oauth_device_codes
+WHERE device_code_hash = $1;
+
+-- name: GetDeviceCodeByUserCode :one
+SELECT device_code_hash, user_code, client_id, scopes, status, approved_by, poll_interval, last_polled_at, expires_at, created_at
+FROM oauth_device_codes
+WHERE user_code = $1;
+
+-- name: DecideDeviceCode :execrows
+UPDATE oauth_device_codes
+SET status = $2, approved_by = $3
+WHERE user_code = $1 AND status = 'pending' AND expires_at > NOW();
+
+-- name: RecordDeviceCodePoll :exec
+UPDATE oauth_device_codes
+SET last_polled_at = NOW(), poll_interval = $2
+WHERE device_code_hash = $1;
+
+-- name: DeleteDeviceCode :execrows
+DELETE FROM oauth_device_codes WHERE device_code_hash = $1;
+
+-- name: DeleteExpiredDeviceCodes :exec
+DELETE FROM oauth_device_codes WHERE expires_at < NOW();
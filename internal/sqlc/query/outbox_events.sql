@@ -0,0 +1,27 @@
+-- name: ClaimOutboxEvents :many
+SELECT id, event_id, event_type, version, aggregate_type, aggregate_id, payload, created_at, attempts, next_attempt_at, last_error, published_at
+FROM outbox_events
+WHERE published_at IS NULL AND next_attempt_at <= NOW()
+ORDER BY id
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: CreateOutboxEvent :exec
+INSERT INTO outbox_events (event_id, event_type, version, aggregate_type, aggregate_id, payload)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: DeletePublishedOutboxEvents :execrows
+DELETE FROM outbox_events
+WHERE published_at < $1;
+
+-- name: MarkOutboxEventFailed :exec
+UPDATE outbox_events
+SET attempts = attempts + 1,
+    last_error = $2,
+    next_attempt_at = NOW() + INTERVAL '1 second' * POWER(2, LEAST(attempts, 8))
+WHERE id = $1;
+
+-- name: MarkOutboxEventPublished :exec
+UPDATE outbox_events
+SET published_at = NOW()
+WHERE id = $1;
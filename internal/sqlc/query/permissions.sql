@@ -1,9 +1,12 @@
 -- name: CreatePermission :exec
-INSERT INTO permissions (name, description, resource, action)
-VALUES ($1, $2, $3, $4);
+INSERT INTO permissions (name, description, resource, action, attr_condition, time_window, resource_pattern, effect)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
 
 -- name: GetPermissionByID :one
-SELECT name, description, resource, action FROM permissions WHERE id = $1;
+SELECT name, description, resource, action, attr_condition, time_window, resource_pattern, effect FROM permissions WHERE id = $1;
 
 -- name: DeletePermission :exec
-DELETE FROM permissions WHERE id = $1;
\ No newline at end of file
+DELETE FROM permissions WHERE id = $1;
+
+-- name: ListPermissions :many
+SELECT * FROM permissions ORDER BY id;
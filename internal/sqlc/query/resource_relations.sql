@@ -0,0 +1,18 @@
+-- name: AddResourceRelation :exec
+INSERT INTO resource_relations (resource_type, resource_id, relation, user_id)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveResourceRelation :exec
+DELETE FROM resource_relations
+WHERE resource_type = $1 AND resource_id = $2 AND relation = $3 AND user_id = $4;
+
+-- name: GetUserResourceRelations :many
+SELECT relation
+FROM resource_relations
+WHERE resource_type = $1 AND resource_id = $2 AND user_id = $3;
+
+-- name: ListResourceRelations :many
+SELECT resource_type, resource_id, relation, user_id, created_at
+FROM resource_relations
+WHERE resource_type = $1 AND resource_id = $2;
\ No newline at end of file
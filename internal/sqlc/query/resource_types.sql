@@ -0,0 +1,12 @@
+-- name: CreateResourceType :exec
+INSERT INTO resource_types (name, description)
+VALUES ($1, $2);
+
+-- name: GetResourceType :one
+SELECT name, description, created_at FROM resource_types WHERE name = $1;
+
+-- name: ListResourceTypes :many
+SELECT name, description, created_at FROM resource_types ORDER BY name;
+
+-- name: DeleteResourceType :exec
+DELETE FROM resource_types WHERE name = $1;
\ No newline at end of file
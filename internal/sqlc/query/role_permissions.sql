@@ -10,4 +10,20 @@ WHERE role_id = $1 AND permission_id = $2;
 SELECT p.*
 FROM permissions p
          JOIN role_permissions rp ON p.id = rp.permission_id
-WHERE rp.role_id = $1;
\ No newline at end of file
+WHERE rp.role_id = $1;
+
+-- name: AssignPermissionToRoleByName :execrows
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, sqlc.arg(permission_id) FROM roles r WHERE r.name = sqlc.arg(role_name)
+ON CONFLICT (role_id, permission_id) DO UPDATE SET role_id = EXCLUDED.role_id;
+
+-- name: RemovePermissionFromRoleByName :execrows
+DELETE FROM role_permissions rp
+USING roles r
+WHERE rp.role_id = r.id AND r.name = sqlc.arg(role_name) AND rp.permission_id = sqlc.arg(permission_id);
+
+-- name: GetPermissionRoleNames :many
+SELECT r.name
+FROM roles r
+         JOIN role_permissions rp ON r.id = rp.role_id
+WHERE rp.permission_id = $1;
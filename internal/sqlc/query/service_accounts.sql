@@ -0,0 +1,62 @@
+-- name: CreateServiceAccount :one
+INSERT INTO service_accounts (name, description, client_id, secret_hash, scopes)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id;
+
+-- name: GetServiceAccount :one
+SELECT id, name, description, client_id, secret_hash, previous_secret_hash, previous_secret_expires_at, scopes, disabled, last_used_at, created_at, updated_at
+FROM service_accounts
+WHERE id = $1;
+
+-- name: GetServiceAccountByClientID :one
+SELECT id, name, description, client_id, secret_hash, previous_secret_hash, previous_secret_expires_at, scopes, disabled, last_used_at, created_at, updated_at
+FROM service_accounts
+WHERE client_id = $1;
+
+-- name: ListServiceAccounts :many
+SELECT id, name, description, client_id, secret_hash, previous_secret_hash, previous_secret_expires_at, scopes, disabled, last_used_at, created_at, updated_at
+FROM service_accounts
+ORDER BY name;
+
+-- name: UpdateServiceAccount :execrows
+UPDATE service_accounts
+SET description = $2, scopes = $3, disabled = $4, updated_at = NOW()
+WHERE id = $1;
+
+-- name: RotateServiceAccountSecret :execrows
+UPDATE service_accounts
+SET previous_secret_hash = secret_hash,
+    previous_secret_expires_at = sqlc.arg(previous_secret_expires_at),
+    secret_hash = sqlc.arg(secret_hash),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id);
+
+-- name: TouchServiceAccount :exec
+UPDATE service_accounts SET last_used_at = NOW() WHERE id = $1;
+
+-- name: DeleteServiceAccount :execrows
+DELETE FROM service_accounts WHERE id = $1;
+
+-- name: AssignRoleToServiceAccount :one
+INSERT INTO service_account_roles (service_account_id, role_id)
+SELECT sqlc.arg(service_account_id), r.id FROM roles r WHERE r.name = sqlc.arg(role_name)
+ON CONFLICT (service_account_id, role_id) DO UPDATE SET role_id = EXCLUDED.role_id
+RETURNING (xmax = 0)::boolean AS inserted;
+
+-- name: RemoveRoleFromServiceAccount :execrows
+DELETE FROM service_account_roles sar
+USING roles r
+WHERE sar.role_id = r.id AND sar.service_account_id = sqlc.arg(service_account_id) AND r.name = sqlc.arg(role_name);
+
+-- name: GetServiceAccountRoles :many
+SELECT r.*
+FROM roles r
+         JOIN service_account_roles sar ON r.id = sar.role_id
+WHERE sar.service_account_id = $1;
+
+-- name: ListServiceAccountRoleNames :many
+SELECT sar.service_account_id, r.name AS role_name
+FROM service_account_roles sar
+         JOIN roles r ON r.id = sar.role_id
+         JOIN service_accounts sa ON sa.id = sar.service_account_id
+WHERE NOT sa.disabled;
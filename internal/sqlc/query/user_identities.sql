@@ -0,0 +1,30 @@
+-- name: CreateUserIdentity :one
+INSERT INTO user_identities (user_id, provider, subject, email)
+VALUES ($1, $2, $3, $4)
+RETURNING id;
+
+-- name: DeleteUserIdentity :execrows
+DELETE FROM user_identities
+WHERE user_id = $1 AND provider = $2;
+
+-- name: FindUserIdentity :one
+SELECT id, user_id, provider, subject, email, linked_at, access_token, refresh_token, token_expires_at, updated_at
+FROM user_identities
+WHERE provider = $1 AND subject = $2;
+
+-- name: ListUserIdentities :many
+SELECT id, user_id, provider, subject, email, linked_at, access_token, refresh_token, token_expires_at, updated_at
+FROM user_identities
+WHERE user_id = $1
+ORDER BY provider;
+
+-- name: UpsertUserIdentity :execrows
+INSERT INTO user_identities (user_id, provider, subject, email, access_token, refresh_token, token_expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (provider, subject) DO UPDATE
+SET email = EXCLUDED.email,
+    access_token = EXCLUDED.access_token,
+    refresh_token = COALESCE(EXCLUDED.refresh_token, user_identities.refresh_token),
+    token_expires_at = EXCLUDED.token_expires_at,
+    updated_at = NOW()
+WHERE user_identities.user_id = EXCLUDED.user_id;
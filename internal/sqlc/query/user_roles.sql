@@ -11,3 +11,20 @@ SELECT r.*
 FROM roles r
          JOIN user_roles ur ON r.id = ur.role_id
 WHERE ur.user_id = $1;
+
+-- name: AssignRoleToUserByName :one
+INSERT INTO user_roles (user_id, role_id)
+SELECT sqlc.arg(user_id), r.id FROM roles r WHERE r.name = sqlc.arg(role_name)
+ON CONFLICT (user_id, role_id) DO UPDATE SET role_id = EXCLUDED.role_id
+RETURNING (xmax = 0)::boolean AS inserted;
+
+-- name: RemoveRoleFromUserByName :execrows
+DELETE FROM user_roles ur
+USING roles r
+WHERE ur.role_id = r.id AND ur.user_id = sqlc.arg(user_id) AND r.name = sqlc.arg(role_name);
+
+-- name: CountUsersWithRole :one
+SELECT COUNT(*)
+FROM user_roles ur
+         JOIN roles r ON r.id = ur.role_id
+WHERE r.name = $1;
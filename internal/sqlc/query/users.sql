@@ -1,26 +1,44 @@
 -- name: CreateUser :one
-INSERT INTO users (username, password_hash, email, firebase_uid, provider, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+INSERT INTO users (username, password_hash, email, phone, firebase_uid, provider, display_name, photo_url, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW())
 RETURNING id;
 
 -- name: FindUserByID :one
-SELECT username, password_hash, email, created_at, updated_at  FROM users WHERE id = $1;
+SELECT id, username, password_hash, email, phone, firebase_uid, provider, display_name, photo_url, created_at, updated_at, last_sign_in_at, disabled_at
+FROM users WHERE id = $1;
 
 -- name: FindUserByUsername :one
-SELECT id, password_hash, email, created_at, updated_at  FROM users WHERE username = $1;
+SELECT id, username, password_hash, email, phone, firebase_uid, provider, display_name, photo_url, created_at, updated_at, last_sign_in_at, disabled_at
+FROM users WHERE username = $1;
 
 -- name: FindUserByEmail :one
-SELECT id, password_hash, username, firebase_uid, provider, created_at, updated_at  FROM users WHERE email = $1;
+SELECT id, username, password_hash, email, phone, firebase_uid, provider, display_name, photo_url, created_at, updated_at, last_sign_in_at, disabled_at
+FROM users WHERE email = $1;
 
 -- name: FindUserByFirebaseUID :one
-SELECT id, password_hash, username, email, created_at, updated_at  FROM users WHERE firebase_uid = $1;
+SELECT id, username, password_hash, email, phone, firebase_uid, provider, display_name, photo_url, created_at, updated_at, last_sign_in_at, disabled_at
+FROM users WHERE firebase_uid = $1;
+
+-- name: UpdateUser :exec
+UPDATE users
+SET username = $2, email = $3, phone = $4, firebase_uid = $5, provider = $6, display_name = $7, photo_url = $8, updated_at = NOW()
+WHERE id = $1;
+
+-- name: UpdateUserProfile :exec
+UPDATE users
+SET display_name = COALESCE(sqlc.narg(display_name), display_name),
+    photo_url = COALESCE(sqlc.narg(photo_url), photo_url),
+    phone = COALESCE(sqlc.narg(phone), phone),
+    last_sign_in_at = NOW(),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id);
 
 -- name: UpdateUsername :exec
 UPDATE users
 SET username = $2, updated_at = NOW()
 WHERE id = $1;
 
--- name: UpdateUserPassword :exec
+-- name: UpdateUserPassword :execrows
 UPDATE users
 SET password_hash = $2, updated_at = NOW()
 WHERE id = $1;
@@ -30,8 +48,19 @@ UPDATE users
 SET email = $2, updated_at = NOW()
 WHERE id = $1;
 
+-- name: DisableUser :execrows
+UPDATE users
+SET disabled_at = COALESCE(disabled_at, NOW()), updated_at = NOW()
+WHERE id = $1;
+
+-- name: EnableUser :execrows
+UPDATE users
+SET disabled_at = NULL, updated_at = NOW()
+WHERE id = $1;
+
 -- name: ListUsers :many
-SELECT id, username, email, created_at, updated_at FROM users;
+SELECT id, username, password_hash, email, phone, firebase_uid, provider, display_name, photo_url, created_at, updated_at, last_sign_in_at, disabled_at
+FROM users;
 
--- name: DeleteUser :exec
-DELETE FROM users WHERE id = $1;
\ No newline at end of file
+-- name: DeleteUser :execrows
+DELETE FROM users WHERE id = $1;
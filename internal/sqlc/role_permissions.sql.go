@@ -24,8 +24,54 @@ func (q *Queries) AssignPermissionToRole(ctx context.Context, arg AssignPermissi
 	return err
 }
 
+const assignPermissionToRoleByName = `-- name: AssignPermissionToRoleByName :execrows
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, $1 FROM roles r WHERE r.name = $2
+ON CONFLICT (role_id, permission_id) DO UPDATE SET role_id = EXCLUDED.role_id
+`
+
+type AssignPermissionToRoleByNameParams struct {
+	PermissionID uint64 `json:"permissionId"`
+	RoleName     string `json:"roleName"`
+}
+
+func (q *Queries) AssignPermissionToRoleByName(ctx context.Context, arg AssignPermissionToRoleByNameParams) (int64, error) {
+	result, err := q.db.Exec(ctx, assignPermissionToRoleByName, arg.PermissionID, arg.RoleName)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getPermissionRoleNames = `-- name: GetPermissionRoleNames :many
+SELECT r.name
+FROM roles r
+         JOIN role_permissions rp ON r.id = rp.role_id
+WHERE rp.permission_id = $1
+`
+
+func (q *Queries) GetPermissionRoleNames(ctx context.Context, permissionID uint64) ([]string, error) {
+	rows, err := q.db.Query(ctx, getPermissionRoleNames, permissionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getRolePermissions = `-- name: GetRolePermissions :many
-SELECT p.id, p.name, p.description, p.resource, p.action, p.created_at, p.updated_at
+SELECT p.id, p.name, p.description, p.resource, p.action, p.created_at, p.updated_at, p.attr_condition, p.time_window, p.resource_pattern, p.effect
 FROM permissions p
          JOIN role_permissions rp ON p.id = rp.permission_id
 WHERE rp.role_id = $1
@@ -48,6 +94,10 @@ func (q *Queries) GetRolePermissions(ctx context.Context, roleID uint64) ([]*Per
 			&i.Action,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.AttrCondition,
+			&i.TimeWindow,
+			&i.ResourcePattern,
+			&i.Effect,
 		); err != nil {
 			return nil, err
 		}
@@ -73,3 +123,22 @@ func (q *Queries) RemovePermissionFromRole(ctx context.Context, arg RemovePermis
 	_, err := q.db.Exec(ctx, removePermissionFromRole, arg.RoleID, arg.PermissionID)
 	return err
 }
+
+const removePermissionFromRoleByName = `-- name: RemovePermissionFromRoleByName :execrows
+DELETE FROM role_permissions rp
+USING roles r
+WHERE rp.role_id = r.id AND r.name = $1 AND rp.permission_id = $2
+`
+
+type RemovePermissionFromRoleByNameParams struct {
+	RoleName     string `json:"roleName"`
+	PermissionID uint64 `json:"permissionId"`
+}
+
+func (q *Queries) RemovePermissionFromRoleByName(ctx context.Context, arg RemovePermissionFromRoleByNameParams) (int64, error) {
+	result, err := q.db.Exec(ctx, removePermissionFromRoleByName, arg.RoleName, arg.PermissionID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
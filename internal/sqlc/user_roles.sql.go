@@ -24,6 +24,39 @@ func (q *Queries) AssignRoleToUser(ctx context.Context, arg AssignRoleToUserPara
 	return err
 }
 
+const assignRoleToUserByName = `-- name: AssignRoleToUserByName :one
+INSERT INTO user_roles (user_id, role_id)
+SELECT $1, r.id FROM roles r WHERE r.name = $2
+ON CONFLICT (user_id, role_id) DO UPDATE SET role_id = EXCLUDED.role_id
+RETURNING (xmax = 0)::boolean AS inserted
+`
+
+type AssignRoleToUserByNameParams struct {
+	UserID   uint64 `json:"userId"`
+	RoleName string `json:"roleName"`
+}
+
+func (q *Queries) AssignRoleToUserByName(ctx context.Context, arg AssignRoleToUserByNameParams) (bool, error) {
+	row := q.db.QueryRow(ctx, assignRoleToUserByName, arg.UserID, arg.RoleName)
+	var inserted bool
+	err := row.Scan(&inserted)
+	return inserted, err
+}
+
+const countUsersWithRole = `-- name: CountUsersWithRole :one
+SELECT COUNT(*)
+FROM user_roles ur
+         JOIN roles r ON r.id = ur.role_id
+WHERE r.name = $1
+`
+
+func (q *Queries) CountUsersWithRole(ctx context.Context, name string) (int64, error) {
+	row := q.db.QueryRow(ctx, countUsersWithRole, name)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const getUserRoles = `-- name: GetUserRoles :many
 SELECT r.id, r.name, r.description, r.created_at, r.updated_at
 FROM roles r
@@ -71,3 +104,22 @@ func (q *Queries) RemoveRoleFromUser(ctx context.Context, arg RemoveRoleFromUser
 	_, err := q.db.Exec(ctx, removeRoleFromUser, arg.UserID, arg.RoleID)
 	return err
 }
+
+const removeRoleFromUserByName = `-- name: RemoveRoleFromUserByName :execrows
+DELETE FROM user_roles ur
+USING roles r
+WHERE ur.role_id = r.id AND ur.user_id = $1 AND r.name = $2
+`
+
+type RemoveRoleFromUserByNameParams struct {
+	UserID   uint64 `json:"userId"`
+	RoleName string `json:"roleName"`
+}
+
+func (q *Queries) RemoveRoleFromUserByName(ctx context.Context, arg RemoveRoleFromUserByNameParams) (int64, error) {
+	result, err := q.db.Exec(ctx, removeRoleFromUserByName, arg.UserID, arg.RoleName)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -7,13 +7,11 @@ package sqlc
 
 import (
 	"context"
-
-	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (username, password_hash, email, firebase_uid, provider, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+INSERT INTO users (username, password_hash, email, phone, firebase_uid, provider, display_name, photo_url, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW())
 RETURNING id
 `
 
@@ -21,8 +19,11 @@ type CreateUserParams struct {
 	Username     string       `json:"username"`
 	PasswordHash string       `json:"passwordHash"`
 	Email        string       `json:"email"`
+	Phone        string       `json:"phone"`
 	FirebaseUid  *string      `json:"firebaseUid"`
 	Provider     ProviderType `json:"provider"`
+	DisplayName  *string      `json:"displayName"`
+	PhotoUrl     *string      `json:"photoUrl"`
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (uint64, error) {
@@ -30,156 +31,189 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (uint64,
 		arg.Username,
 		arg.PasswordHash,
 		arg.Email,
+		arg.Phone,
 		arg.FirebaseUid,
 		arg.Provider,
+		arg.DisplayName,
+		arg.PhotoUrl,
 	)
 	var id uint64
 	err := row.Scan(&id)
 	return id, err
 }
 
-const deleteUser = `-- name: DeleteUser :exec
+const deleteUser = `-- name: DeleteUser :execrows
 DELETE FROM users WHERE id = $1
 `
 
-func (q *Queries) DeleteUser(ctx context.Context, id uint64) error {
-	_, err := q.db.Exec(ctx, deleteUser, id)
-	return err
+func (q *Queries) DeleteUser(ctx context.Context, id uint64) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
-const findUserByEmail = `-- name: FindUserByEmail :one
-SELECT id, password_hash, username, firebase_uid, provider, created_at, updated_at  FROM users WHERE email = $1
+const disableUser = `-- name: DisableUser :execrows
+UPDATE users
+SET disabled_at = COALESCE(disabled_at, NOW()), updated_at = NOW()
+WHERE id = $1
 `
 
-type FindUserByEmailRow struct {
-	ID           uint64             `json:"id"`
-	PasswordHash string             `json:"passwordHash"`
-	Username     string             `json:"username"`
-	FirebaseUid  *string            `json:"firebaseUid"`
-	Provider     ProviderType       `json:"provider"`
-	CreatedAt    pgtype.Timestamptz `json:"createdAt"`
-	UpdatedAt    pgtype.Timestamptz `json:"updatedAt"`
+func (q *Queries) DisableUser(ctx context.Context, id uint64) (int64, error) {
+	result, err := q.db.Exec(ctx, disableUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const enableUser = `-- name: EnableUser :execrows
+UPDATE users
+SET disabled_at = NULL, updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) EnableUser(ctx context.Context, id uint64) (int64, error) {
+	result, err := q.db.Exec(ctx, enableUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
-func (q *Queries) FindUserByEmail(ctx context.Context, email string) (*FindUserByEmailRow, error) {
+const findUserByEmail = `-- name: FindUserByEmail :one
+SELECT id, username, password_hash, email, phone, firebase_uid, provider, display_name, photo_url, created_at, updated_at, last_sign_in_at, disabled_at
+FROM users WHERE email = $1
+`
+
+func (q *Queries) FindUserByEmail(ctx context.Context, email string) (*User, error) {
 	row := q.db.QueryRow(ctx, findUserByEmail, email)
-	var i FindUserByEmailRow
+	var i User
 	err := row.Scan(
 		&i.ID,
-		&i.PasswordHash,
 		&i.Username,
+		&i.PasswordHash,
+		&i.Email,
+		&i.Phone,
 		&i.FirebaseUid,
 		&i.Provider,
+		&i.DisplayName,
+		&i.PhotoUrl,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.LastSignInAt,
+		&i.DisabledAt,
 	)
 	return &i, err
 }
 
 const findUserByFirebaseUID = `-- name: FindUserByFirebaseUID :one
-SELECT id, password_hash, username, email, created_at, updated_at  FROM users WHERE firebase_uid = $1
+SELECT id, username, password_hash, email, phone, firebase_uid, provider, display_name, photo_url, created_at, updated_at, last_sign_in_at, disabled_at
+FROM users WHERE firebase_uid = $1
 `
 
-type FindUserByFirebaseUIDRow struct {
-	ID           uint64             `json:"id"`
-	PasswordHash string             `json:"passwordHash"`
-	Username     string             `json:"username"`
-	Email        string             `json:"email"`
-	CreatedAt    pgtype.Timestamptz `json:"createdAt"`
-	UpdatedAt    pgtype.Timestamptz `json:"updatedAt"`
-}
-
-func (q *Queries) FindUserByFirebaseUID(ctx context.Context, firebaseUid *string) (*FindUserByFirebaseUIDRow, error) {
+func (q *Queries) FindUserByFirebaseUID(ctx context.Context, firebaseUid *string) (*User, error) {
 	row := q.db.QueryRow(ctx, findUserByFirebaseUID, firebaseUid)
-	var i FindUserByFirebaseUIDRow
+	var i User
 	err := row.Scan(
 		&i.ID,
-		&i.PasswordHash,
 		&i.Username,
+		&i.PasswordHash,
 		&i.Email,
+		&i.Phone,
+		&i.FirebaseUid,
+		&i.Provider,
+		&i.DisplayName,
+		&i.PhotoUrl,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.LastSignInAt,
+		&i.DisabledAt,
 	)
 	return &i, err
 }
 
 const findUserByID = `-- name: FindUserByID :one
-SELECT username, password_hash, email, created_at, updated_at  FROM users WHERE id = $1
+SELECT id, username, password_hash, email, phone, firebase_uid, provider, display_name, photo_url, created_at, updated_at, last_sign_in_at, disabled_at
+FROM users WHERE id = $1
 `
 
-type FindUserByIDRow struct {
-	Username     string             `json:"username"`
-	PasswordHash string             `json:"passwordHash"`
-	Email        string             `json:"email"`
-	CreatedAt    pgtype.Timestamptz `json:"createdAt"`
-	UpdatedAt    pgtype.Timestamptz `json:"updatedAt"`
-}
-
-func (q *Queries) FindUserByID(ctx context.Context, id uint64) (*FindUserByIDRow, error) {
+func (q *Queries) FindUserByID(ctx context.Context, id uint64) (*User, error) {
 	row := q.db.QueryRow(ctx, findUserByID, id)
-	var i FindUserByIDRow
+	var i User
 	err := row.Scan(
+		&i.ID,
 		&i.Username,
 		&i.PasswordHash,
 		&i.Email,
+		&i.Phone,
+		&i.FirebaseUid,
+		&i.Provider,
+		&i.DisplayName,
+		&i.PhotoUrl,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.LastSignInAt,
+		&i.DisabledAt,
 	)
 	return &i, err
 }
 
 const findUserByUsername = `-- name: FindUserByUsername :one
-SELECT id, password_hash, email, created_at, updated_at  FROM users WHERE username = $1
+SELECT id, username, password_hash, email, phone, firebase_uid, provider, display_name, photo_url, created_at, updated_at, last_sign_in_at, disabled_at
+FROM users WHERE username = $1
 `
 
-type FindUserByUsernameRow struct {
-	ID           uint64             `json:"id"`
-	PasswordHash string             `json:"passwordHash"`
-	Email        string             `json:"email"`
-	CreatedAt    pgtype.Timestamptz `json:"createdAt"`
-	UpdatedAt    pgtype.Timestamptz `json:"updatedAt"`
-}
-
-func (q *Queries) FindUserByUsername(ctx context.Context, username string) (*FindUserByUsernameRow, error) {
+func (q *Queries) FindUserByUsername(ctx context.Context, username string) (*User, error) {
 	row := q.db.QueryRow(ctx, findUserByUsername, username)
-	var i FindUserByUsernameRow
+	var i User
 	err := row.Scan(
 		&i.ID,
+		&i.Username,
 		&i.PasswordHash,
 		&i.Email,
+		&i.Phone,
+		&i.FirebaseUid,
+		&i.Provider,
+		&i.DisplayName,
+		&i.PhotoUrl,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.LastSignInAt,
+		&i.DisabledAt,
 	)
 	return &i, err
 }
 
 const listUsers = `-- name: ListUsers :many
-SELECT id, username, email, created_at, updated_at FROM users
+SELECT id, username, password_hash, email, phone, firebase_uid, provider, display_name, photo_url, created_at, updated_at, last_sign_in_at, disabled_at
+FROM users
 `
 
-type ListUsersRow struct {
-	ID        uint64             `json:"id"`
-	Username  string             `json:"username"`
-	Email     string             `json:"email"`
-	CreatedAt pgtype.Timestamptz `json:"createdAt"`
-	UpdatedAt pgtype.Timestamptz `json:"updatedAt"`
-}
-
-func (q *Queries) ListUsers(ctx context.Context) ([]*ListUsersRow, error) {
+func (q *Queries) ListUsers(ctx context.Context) ([]*User, error) {
 	rows, err := q.db.Query(ctx, listUsers)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []*ListUsersRow{}
+	items := []*User{}
 	for rows.Next() {
-		var i ListUsersRow
+		var i User
 		if err := rows.Scan(
 			&i.ID,
 			&i.Username,
+			&i.PasswordHash,
 			&i.Email,
+			&i.Phone,
+			&i.FirebaseUid,
+			&i.Provider,
+			&i.DisplayName,
+			&i.PhotoUrl,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.LastSignInAt,
+			&i.DisabledAt,
 		); err != nil {
 			return nil, err
 		}
@@ -191,6 +225,37 @@ func (q *Queries) ListUsers(ctx context.Context) ([]*ListUsersRow, error) {
 	return items, nil
 }
 
+const updateUser = `-- name: UpdateUser :exec
+UPDATE users
+SET username = $2, email = $3, phone = $4, firebase_uid = $5, provider = $6, display_name = $7, photo_url = $8, updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateUserParams struct {
+	ID          uint64       `json:"id"`
+	Username    string       `json:"username"`
+	Email       string       `json:"email"`
+	Phone       string       `json:"phone"`
+	FirebaseUid *string      `json:"firebaseUid"`
+	Provider    ProviderType `json:"provider"`
+	DisplayName *string      `json:"displayName"`
+	PhotoUrl    *string      `json:"photoUrl"`
+}
+
+func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) error {
+	_, err := q.db.Exec(ctx, updateUser,
+		arg.ID,
+		arg.Username,
+		arg.Email,
+		arg.Phone,
+		arg.FirebaseUid,
+		arg.Provider,
+		arg.DisplayName,
+		arg.PhotoUrl,
+	)
+	return err
+}
+
 const updateUserEmail = `-- name: UpdateUserEmail :exec
 UPDATE users
 SET email = $2, updated_at = NOW()
@@ -207,7 +272,7 @@ func (q *Queries) UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams
 	return err
 }
 
-const updateUserPassword = `-- name: UpdateUserPassword :exec
+const updateUserPassword = `-- name: UpdateUserPassword :execrows
 UPDATE users
 SET password_hash = $2, updated_at = NOW()
 WHERE id = $1
@@ -218,8 +283,38 @@ type UpdateUserPasswordParams struct {
 	PasswordHash string `json:"passwordHash"`
 }
 
-func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
-	_, err := q.db.Exec(ctx, updateUserPassword, arg.ID, arg.PasswordHash)
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateUserPassword, arg.ID, arg.PasswordHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :exec
+UPDATE users
+SET display_name = COALESCE($1, display_name),
+    photo_url = COALESCE($2, photo_url),
+    phone = COALESCE($3, phone),
+    last_sign_in_at = NOW(),
+    updated_at = NOW()
+WHERE id = $4
+`
+
+type UpdateUserProfileParams struct {
+	DisplayName *string `json:"displayName"`
+	PhotoUrl    *string `json:"photoUrl"`
+	Phone       *string `json:"phone"`
+	ID          uint64  `json:"id"`
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) error {
+	_, err := q.db.Exec(ctx, updateUserProfile,
+		arg.DisplayName,
+		arg.PhotoUrl,
+		arg.Phone,
+		arg.ID,
+	)
 	return err
 }
 
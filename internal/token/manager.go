@@ -2,32 +2,56 @@ package token
 
 import (
 	"crypto/ed25519"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/o1egl/paseto"
+	appconfig "goflare.io/auth/internal/config"
 	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/models/enum"
+	"goflare.io/nexus"
 )
 
+// DefaultExpiration is the lifetime of the tokens issued at sign-in.
+const DefaultExpiration = 24 * time.Hour
+
 // Manager defines methods for token management.
 type Manager interface {
 
 	// GenerateToken generates a new token.
 	GenerateToken(userID uint64) (*models.PASETOToken, error)
 
-	// ValidateToken validates a token.
+	// GenerateScopedToken generates a token issued to a client with the given scopes and lifetime.
+	GenerateScopedToken(userID uint64, clientID string, scopes []string, expiration time.Duration) (*models.PASETOToken, error)
+
+	// GenerateServiceAccountToken generates a token for a service account with the given scopes and lifetime.
+	GenerateServiceAccountToken(serviceAccountID uint64, clientID string, scopes []string, expiration time.Duration) (*models.PASETOToken, error)
+
+	// GenerateDelegatedToken generates a token for a service acting for a user, restricted to an audience.
+	GenerateDelegatedToken(userID uint64, audience string, actor *models.Actor, scopes []string, expiration time.Duration) (*models.PASETOToken, error)
+
+	// ValidateToken validates a user token and returns the user ID.
 	ValidateToken(token string) (uint64, error)
 
+	// ParseToken validates a token of any subject type and returns its claims.
+	ParseToken(token string) (*models.PASETOToken, error)
+
 	// RevokeToken revokes a token.
 	RevokeToken(token string) error
+
+	// KeySet returns the public keys that verify tokens, for services that verify tokens without calling the service.
+	KeySet() *models.JSONWebKeySet
 }
 
 // PasetoManager implements Manager using PASETO.
 type PasetoManager struct {
-	publicKey  ed25519.PublicKey
-	privateKey ed25519.PrivateKey
-	expiration time.Duration
+	publicKey    ed25519.PublicKey
+	privateKey   ed25519.PrivateKey
+	previousKeys []ed25519.PublicKey
+	expiration   time.Duration
 }
 
 // NewPasetoManager creates a new instance of PasetoManager.
@@ -41,6 +65,20 @@ func NewPasetoManager(publicKey, privateKey string, expiration time.Duration) *P
 	}
 }
 
+// ProvideManager creates the PASETO token manager with the key pair from the nexus configuration and the public
+// keys of retired key pairs from the application configuration.
+func ProvideManager(config *nexus.Config, cfg *appconfig.Config) (Manager, error) {
+	manager := NewPasetoManager(config.Paseto.PublicKey, config.Paseto.PrivateKey, DefaultExpiration)
+	for i, encoded := range cfg.Tokens.PreviousPublicKeys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("tokens.previous_public_keys[%d] is not a base64-encoded Ed25519 public key", i)
+		}
+		manager.previousKeys = append(manager.previousKeys, key)
+	}
+	return manager, nil
+}
+
 // GenerateToken generates a new PASETO token.
 func (tm *PasetoManager) GenerateToken(userID uint64) (*models.PASETOToken, error) {
 	now := time.Now()
@@ -55,17 +93,142 @@ func (tm *PasetoManager) GenerateToken(userID uint64) (*models.PASETOToken, erro
 	return &models.PASETOToken{Token: token, ExpiresAt: exp}, nil
 }
 
-// ValidateToken validates a PASETO token.
+// GenerateScopedToken generates a PASETO token issued to an OAuth client, carrying the granted scopes.
+func (tm *PasetoManager) GenerateScopedToken(userID uint64, clientID string, scopes []string, expiration time.Duration) (*models.PASETOToken, error) {
+	now := time.Now()
+	exp := now.Add(expiration)
+	token, err := paseto.NewV2().Sign(tm.privateKey, models.PASETOToken{
+		UserID:    userID,
+		ClientID:  clientID,
+		Scopes:    scopes,
+		ExpiresAt: exp,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	return &models.PASETOToken{Token: token, ClientID: clientID, Scopes: scopes, ExpiresAt: exp}, nil
+}
+
+// GenerateServiceAccountToken generates a PASETO token for a service account. The token carries the service
+// account subject type, so it is never accepted where a user token is expected.
+func (tm *PasetoManager) GenerateServiceAccountToken(serviceAccountID uint64, clientID string, scopes []string, expiration time.Duration) (*models.PASETOToken, error) {
+	now := time.Now()
+	exp := now.Add(expiration)
+	token, err := paseto.NewV2().Sign(tm.privateKey, models.PASETOToken{
+		ClientID:         clientID,
+		Scopes:           scopes,
+		ExpiresAt:        exp,
+		SubjectType:      enum.SubjectServiceAccount,
+		ServiceAccountID: serviceAccountID,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	return &models.PASETOToken{
+		Token:            token,
+		ClientID:         clientID,
+		Scopes:           scopes,
+		ExpiresAt:        exp,
+		SubjectType:      enum.SubjectServiceAccount,
+		ServiceAccountID: serviceAccountID,
+	}, nil
+}
+
+// GenerateDelegatedToken generates a PASETO token with which a service acts for a user. The token records the
+// acting service in the act claim and the only service that should accept it in the aud claim. The client ID is
+// the one of the acting service.
+func (tm *PasetoManager) GenerateDelegatedToken(userID uint64, audience string, actor *models.Actor, scopes []string, expiration time.Duration) (*models.PASETOToken, error) {
+	now := time.Now()
+	exp := now.Add(expiration)
+	token, err := paseto.NewV2().Sign(tm.privateKey, models.PASETOToken{
+		UserID:    userID,
+		ClientID:  actor.Subject,
+		Scopes:    scopes,
+		ExpiresAt: exp,
+		Audience:  audience,
+		Actor:     actor,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	return &models.PASETOToken{
+		Token:     token,
+		UserID:    userID,
+		ClientID:  actor.Subject,
+		Scopes:    scopes,
+		ExpiresAt: exp,
+		Audience:  audience,
+		Actor:     actor,
+	}, nil
+}
+
+// ValidateToken validates a PASETO user token. Service account tokens are rejected, and so are delegated tokens
+// and tokens issued to clients, whose audience and scopes would be lost with only the user ID.
 func (tm *PasetoManager) ValidateToken(token string) (uint64, error) {
+	tokenData, err := tm.ParseToken(token)
+	if err != nil {
+		return 0, err
+	}
+	if tokenData.SubjectType != "" && tokenData.SubjectType != enum.SubjectUser {
+		return 0, fmt.Errorf("not a user token")
+	}
+	if tokenData.Actor != nil {
+		return 0, fmt.Errorf("delegated tokens are not accepted here")
+	}
+	if tokenData.ClientID != "" {
+		return 0, fmt.Errorf("tokens issued to a client are not accepted here")
+	}
+	return tokenData.UserID, nil
+}
+
+// ParseToken validates a PASETO token of any subject type and returns its claims. Tokens signed with a retired key
+// pair are accepted until they expire.
+func (tm *PasetoManager) ParseToken(token string) (*models.PASETOToken, error) {
 	var tokenData models.PASETOToken
 	err := paseto.NewV2().Verify(token, tm.publicKey, &tokenData, nil)
+	for _, key := range tm.previousKeys {
+		if err == nil {
+			break
+		}
+		tokenData = models.PASETOToken{}
+		err = paseto.NewV2().Verify(token, key, &tokenData, nil)
+	}
 	if err != nil {
-		return 0, fmt.Errorf("invalid token: %w", err)
+		return nil, fmt.Errorf("invalid token: %w", err)
 	}
 	if time.Now().After(tokenData.ExpiresAt) {
-		return 0, fmt.Errorf("token expired")
+		return nil, fmt.Errorf("token expired")
 	}
-	return tokenData.UserID, nil
+	return &tokenData, nil
+}
+
+// KeySet returns the Ed25519 public keys that verify v2.public PASETO tokens as a JSON Web Key Set (RFC 8037), the
+// current key first and then those of retired key pairs. The key ID is the JWK thumbprint of the key (RFC 7638).
+func (tm *PasetoManager) KeySet() *models.JSONWebKeySet {
+	set := &models.JSONWebKeySet{Keys: []models.JSONWebKey{}}
+	for _, key := range append([]ed25519.PublicKey{tm.publicKey}, tm.previousKeys...) {
+		if len(key) != ed25519.PublicKeySize {
+			continue
+		}
+
+		x := base64.RawURLEncoding.EncodeToString(key)
+		thumbprint, _ := json.Marshal(struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{Crv: "Ed25519", Kty: "OKP", X: x})
+		sum := sha256.Sum256(thumbprint)
+
+		set.Keys = append(set.Keys, models.JSONWebKey{
+			KeyType:   "OKP",
+			Use:       "sig",
+			Algorithm: "EdDSA",
+			KeyID:     base64.RawURLEncoding.EncodeToString(sum[:]),
+			Curve:     "Ed25519",
+			X:         x,
+		})
+	}
+	return set
 }
 
 // RevokeToken revokes a PASETO token.
@@ -0,0 +1,92 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"goflare.io/auth/internal/models"
+)
+
+func newTestManager(t *testing.T) *PasetoManager {
+	t.Helper()
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return NewPasetoManager(
+		base64.StdEncoding.EncodeToString(publicKey),
+		base64.StdEncoding.EncodeToString(privateKey),
+		time.Hour,
+	)
+}
+
+func TestValidateToken(t *testing.T) {
+	manager := newTestManager(t)
+
+	tests := []struct {
+		name     string
+		generate func() (*models.PASETOToken, error)
+		wantErr  bool
+	}{
+		{
+			name:     "user token",
+			generate: func() (*models.PASETOToken, error) { return manager.GenerateToken(1) },
+		},
+		{
+			name: "token issued to a client",
+			generate: func() (*models.PASETOToken, error) {
+				return manager.GenerateScopedToken(1, "client", []string{"USER:READ"}, time.Hour)
+			},
+			wantErr: true,
+		},
+		{
+			name: "service account token",
+			generate: func() (*models.PASETOToken, error) {
+				return manager.GenerateServiceAccountToken(1, "service", nil, time.Hour)
+			},
+			wantErr: true,
+		},
+		{
+			name: "delegated token",
+			generate: func() (*models.PASETOToken, error) {
+				return manager.GenerateDelegatedToken(1, "orders", &models.Actor{Subject: "service", ServiceAccountID: 2}, []string{"USER:READ"}, time.Hour)
+			},
+			wantErr: true,
+		},
+		{
+			name: "expired token",
+			generate: func() (*models.PASETOToken, error) {
+				return manager.GenerateScopedToken(1, "", nil, -time.Minute)
+			},
+			wantErr: true,
+		},
+		{
+			name: "token of another key",
+			generate: func() (*models.PASETOToken, error) {
+				return newTestManager(t).GenerateToken(1)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := tt.generate()
+			if err != nil {
+				t.Fatalf("failed to generate token: %v", err)
+			}
+
+			userID, err := manager.ValidateToken(token.Token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && userID != 1 {
+				t.Errorf("ValidateToken() = %d, want 1", userID)
+			}
+		})
+	}
+}
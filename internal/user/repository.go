@@ -4,11 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"go.uber.org/zap"
 
 	"goflare.io/auth/internal/models"
+	"goflare.io/auth/internal/outbox"
 	"goflare.io/auth/internal/sqlc"
 	"goflare.io/nexus/driver"
 )
@@ -16,12 +19,25 @@ import (
 // _ is a type assertion to ensure that the repository implements the Repository interface.
 var _ Repository = (*repository)(nil)
 
-// Repository defines the contract for the user repository.
+// Repository defines the contract for the user repository. Creating and deleting users, assigning roles and
+// changing passwords write the matching domain events to the outbox, in the same transaction.
 type Repository interface {
 
 	// CreateUser creates a new user.
 	CreateUser(ctx context.Context, user *models.User) (uint64, error)
 
+	// UpdateUser updates the account and profile fields of a user.
+	UpdateUser(ctx context.Context, user *models.User) error
+
+	// UpdatePassword sets the password hash of a user.
+	UpdatePassword(ctx context.Context, userID uint64, passwordHash string) error
+
+	// DeleteUser deletes a user.
+	DeleteUser(ctx context.Context, userID uint64) error
+
+	// UpsertFromProvider stores the profile and sealed tokens a provider returned at a sign-in.
+	UpsertFromProvider(ctx context.Context, profile *models.User, identity *models.UserIdentity) error
+
 	// FindUserByID finds a user by its ID.
 	FindUserByID(ctx context.Context, id uint64) (*models.User, error)
 
@@ -37,11 +53,35 @@ type Repository interface {
 	// AssignRoleToUserWithTx assigns a role to a user.
 	AssignRoleToUserWithTx(ctx context.Context, userID, roleID uint64) error
 
+	// AssignRole assigns the role with a name to a user.
+	AssignRole(ctx context.Context, userID uint64, roleName string) error
+
+	// RemoveRole removes the role with a name from a user.
+	RemoveRole(ctx context.Context, userID uint64, roleName string) error
+
+	// CountUsersWithRole counts the users the role with a name is assigned to.
+	CountUsersWithRole(ctx context.Context, roleName string) (int64, error)
+
+	// SetDisabled disables or enables a user.
+	SetDisabled(ctx context.Context, userID uint64, disabled bool) error
+
 	// FindUserRoles retrieves a user's roles.
 	FindUserRoles(ctx context.Context, userID uint64) ([]*models.Role, error)
 
 	// ListAllUsers lists all users.
 	ListAllUsers(ctx context.Context) ([]*models.User, error)
+
+	// LinkIdentity links an external identity to a user.
+	LinkIdentity(ctx context.Context, identity *models.UserIdentity) (uint64, error)
+
+	// UnlinkIdentity removes the identity of a provider from a user.
+	UnlinkIdentity(ctx context.Context, userID uint64, provider string) error
+
+	// FindIdentity finds the linked identity of a provider subject.
+	FindIdentity(ctx context.Context, provider, subject string) (*models.UserIdentity, error)
+
+	// ListUserIdentities lists the identities linked to a user.
+	ListUserIdentities(ctx context.Context, userID uint64) ([]*models.UserIdentity, error)
 }
 
 // repository is the implementation of the Repository interface.
@@ -58,15 +98,209 @@ func NewRepository(conn driver.PostgresPool, logger *zap.Logger) Repository {
 	}
 }
 
-// CreateUser creates a new user.
-func (r *repository) CreateUser(ctx context.Context, user *models.User) (uint64, error) {
-	return sqlc.New(r.conn).CreateUser(ctx, sqlc.CreateUserParams{
+// CreateUser creates a new user and writes its user.registered event.
+func (r *repository) CreateUser(ctx context.Context, user *models.User) (id uint64, err error) {
+	provider := sqlc.ProviderType(user.Provider)
+	if provider == "" {
+		provider = sqlc.ProviderTypeEmail
+	}
+
+	tx, err := r.conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				r.logger.Error("failed to rollback transaction", zap.Error(rbErr))
+			}
+		}
+	}()
+
+	queries := sqlc.New(r.conn).WithTx(tx)
+	id, err = queries.CreateUser(ctx, sqlc.CreateUserParams{
 		Username:     user.Username,
 		PasswordHash: user.PasswordHash,
 		Email:        user.Email,
-		FirebaseUid:  &user.FirebaseUID,
-		Provider:     sqlc.ProviderType(user.Provider),
+		Phone:        user.Phone,
+		FirebaseUid:  sqlc.Optional(user.FirebaseUID),
+		Provider:     provider,
+		DisplayName:  sqlc.Optional(user.DisplayName),
+		PhotoUrl:     sqlc.Optional(user.PhotoURL),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	registered := *user
+	registered.ID = id
+	registered.Provider = string(provider)
+	if err = outbox.Write(ctx, queries, outbox.UserRegistered(&registered, time.Now())); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return id, nil
+}
+
+// UpdateUser updates the account and profile fields of a user. The password is changed separately.
+func (r *repository) UpdateUser(ctx context.Context, user *models.User) error {
+	if user.ID == 0 {
+		return errors.New("id is required")
+	}
+
+	if err := sqlc.New(r.conn).UpdateUser(ctx, sqlc.UpdateUserParams{
+		ID:          user.ID,
+		Username:    user.Username,
+		Email:       user.Email,
+		Phone:       user.Phone,
+		FirebaseUid: sqlc.Optional(user.FirebaseUID),
+		Provider:    sqlc.ProviderType(user.Provider),
+		DisplayName: sqlc.Optional(user.DisplayName),
+		PhotoUrl:    sqlc.Optional(user.PhotoURL),
+	}); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return nil
+}
+
+// UpdatePassword sets the password hash of a user and writes its password.changed event.
+// Returns pgx.ErrNoRows if there is no such user.
+func (r *repository) UpdatePassword(ctx context.Context, userID uint64, passwordHash string) (err error) {
+	if passwordHash == "" {
+		return errors.New("password hash is required")
+	}
+
+	tx, err := r.conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				r.logger.Error("failed to rollback transaction", zap.Error(rbErr))
+			}
+		}
+	}()
+
+	queries := sqlc.New(r.conn).WithTx(tx)
+	updated, err := queries.UpdateUserPassword(ctx, sqlc.UpdateUserPasswordParams{
+		ID:           userID,
+		PasswordHash: passwordHash,
 	})
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("user %d not found: %w", userID, pgx.ErrNoRows)
+	}
+
+	if err = outbox.Write(ctx, queries, outbox.PasswordChanged(userID, time.Now())); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteUser deletes a user, with its roles and identities, and writes its user.deleted event.
+// Returns pgx.ErrNoRows if there is no such user.
+func (r *repository) DeleteUser(ctx context.Context, userID uint64) (err error) {
+	tx, err := r.conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				r.logger.Error("failed to rollback transaction", zap.Error(rbErr))
+			}
+		}
+	}()
+
+	queries := sqlc.New(r.conn).WithTx(tx)
+	deleted, err := queries.DeleteUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("user %d not found: %w", userID, pgx.ErrNoRows)
+	}
+
+	if err = outbox.Write(ctx, queries, outbox.UserDeleted(userID, time.Now())); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// UpsertFromProvider stores what a provider returned at a sign-in in one transaction: the display name, avatar
+// and phone of the user, where the provider returned them, the sign-in time, and the identity with its sealed tokens.
+// The identity must not be linked to another user.
+func (r *repository) UpsertFromProvider(ctx context.Context, profile *models.User, identity *models.UserIdentity) (err error) {
+	if profile.ID == 0 || profile.ID != identity.UserID {
+		return errors.New("profile and identity must belong to the same user")
+	}
+
+	tx, err := r.conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				r.logger.Error("failed to rollback transaction", zap.Error(rbErr))
+			}
+		}
+	}()
+
+	queries := sqlc.New(r.conn).WithTx(tx)
+	if err = queries.UpdateUserProfile(ctx, sqlc.UpdateUserProfileParams{
+		DisplayName: sqlc.Optional(profile.DisplayName),
+		PhotoUrl:    sqlc.Optional(profile.PhotoURL),
+		Phone:       sqlc.Optional(profile.Phone),
+		ID:          profile.ID,
+	}); err != nil {
+		return fmt.Errorf("failed to update user profile: %w", err)
+	}
+
+	var expiresAt pgtype.Timestamptz
+	if !identity.TokenExpiresAt.IsZero() {
+		expiresAt = pgtype.Timestamptz{Time: identity.TokenExpiresAt, Valid: true}
+	}
+
+	stored, err := queries.UpsertUserIdentity(ctx, sqlc.UpsertUserIdentityParams{
+		UserID:         identity.UserID,
+		Provider:       identity.Provider,
+		Subject:        identity.Subject,
+		Email:          identity.Email,
+		AccessToken:    identity.AccessToken,
+		RefreshToken:   identity.RefreshToken,
+		TokenExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store %s identity: %w", identity.Provider, err)
+	}
+	if stored == 0 {
+		return fmt.Errorf("%s identity is linked to another user", identity.Provider)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
 }
 
 // FindUserByID finds a user by its ID.
@@ -127,8 +361,8 @@ func (r *repository) FindUserByFirebaseUID(ctx context.Context, firebaseUID stri
 	return new(models.User).ConvertFromSQLCUser(sqlcUser), nil
 }
 
-// AssignRoleToUserWithTx assigns a role to a user.
-func (r *repository) AssignRoleToUserWithTx(ctx context.Context, userID, roleID uint64) error {
+// AssignRoleToUserWithTx assigns a role to a user and writes its role.assigned event.
+func (r *repository) AssignRoleToUserWithTx(ctx context.Context, userID, roleID uint64) (err error) {
 	tx, err := r.conn.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -150,6 +384,55 @@ func (r *repository) AssignRoleToUserWithTx(ctx context.Context, userID, roleID
 		return fmt.Errorf("failed to assign role to user: %w", err)
 	}
 
+	role, err := queries.GetRoleByID(ctx, roleID)
+	if err != nil {
+		return fmt.Errorf("failed to get role by id: %w", err)
+	}
+	if err = outbox.Write(ctx, queries, outbox.RoleAssigned(userID, role.Name, time.Now())); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// AssignRole assigns the role with a name to a user. Assigning a role twice is not an error, and writes the
+// role.assigned event only the first time.
+// Returns pgx.ErrNoRows if there is no such role.
+func (r *repository) AssignRole(ctx context.Context, userID uint64, roleName string) (err error) {
+	tx, err := r.conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				r.logger.Error("failed to rollback transaction", zap.Error(rbErr))
+			}
+		}
+	}()
+
+	queries := sqlc.New(r.conn).WithTx(tx)
+	inserted, err := queries.AssignRoleToUserByName(ctx, sqlc.AssignRoleToUserByNameParams{
+		UserID:   userID,
+		RoleName: roleName,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("role %s not found: %w", roleName, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to assign role %s: %w", roleName, err)
+	}
+
+	if inserted {
+		if err = outbox.Write(ctx, queries, outbox.RoleAssigned(userID, roleName, time.Now())); err != nil {
+			return err
+		}
+	}
+
 	if err = tx.Commit(ctx); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
@@ -157,6 +440,55 @@ func (r *repository) AssignRoleToUserWithTx(ctx context.Context, userID, roleID
 	return nil
 }
 
+// RemoveRole removes the role with a name from a user.
+// Returns pgx.ErrNoRows if the user does not have the role.
+func (r *repository) RemoveRole(ctx context.Context, userID uint64, roleName string) error {
+	removed, err := sqlc.New(r.conn).RemoveRoleFromUserByName(ctx, sqlc.RemoveRoleFromUserByNameParams{
+		UserID:   userID,
+		RoleName: roleName,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove role %s: %w", roleName, err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("user does not have role %s: %w", roleName, pgx.ErrNoRows)
+	}
+
+	return nil
+}
+
+// CountUsersWithRole counts the users the role with a name is assigned to.
+func (r *repository) CountUsersWithRole(ctx context.Context, roleName string) (int64, error) {
+	count, err := sqlc.New(r.conn).CountUsersWithRole(ctx, roleName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count users with role %s: %w", roleName, err)
+	}
+
+	return count, nil
+}
+
+// SetDisabled disables or enables a user. Disabling a disabled user keeps the time it was first disabled.
+// Returns pgx.ErrNoRows if there is no such user.
+func (r *repository) SetDisabled(ctx context.Context, userID uint64, disabled bool) error {
+	queries := sqlc.New(r.conn)
+
+	var updated int64
+	var err error
+	if disabled {
+		updated, err = queries.DisableUser(ctx, userID)
+	} else {
+		updated, err = queries.EnableUser(ctx, userID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update user status: %w", err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("user %d not found: %w", userID, pgx.ErrNoRows)
+	}
+
+	return nil
+}
+
 // FindUserRoles retrieves a user's roles.
 func (r *repository) FindUserRoles(ctx context.Context, userID uint64) ([]*models.Role, error) {
 	if userID == 0 {
@@ -190,3 +522,71 @@ func (r *repository) ListAllUsers(ctx context.Context) ([]*models.User, error) {
 
 	return users, nil
 }
+
+// LinkIdentity links an external identity to a user and returns the ID of the link.
+func (r *repository) LinkIdentity(ctx context.Context, identity *models.UserIdentity) (uint64, error) {
+	if identity.UserID == 0 || identity.Provider == "" || identity.Subject == "" {
+		return 0, errors.New("userID, provider and subject are required")
+	}
+
+	id, err := sqlc.New(r.conn).CreateUserIdentity(ctx, sqlc.CreateUserIdentityParams{
+		UserID:   identity.UserID,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to link %s identity: %w", identity.Provider, err)
+	}
+
+	return id, nil
+}
+
+// UnlinkIdentity removes the identity of a provider from a user.
+// Returns pgx.ErrNoRows if the user has no identity of the provider.
+func (r *repository) UnlinkIdentity(ctx context.Context, userID uint64, provider string) error {
+	removed, err := sqlc.New(r.conn).DeleteUserIdentity(ctx, sqlc.DeleteUserIdentityParams{
+		UserID:   userID,
+		Provider: provider,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to unlink %s identity: %w", provider, err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("no %s identity linked: %w", provider, pgx.ErrNoRows)
+	}
+
+	return nil
+}
+
+// FindIdentity finds the linked identity of a provider subject.
+func (r *repository) FindIdentity(ctx context.Context, provider, subject string) (*models.UserIdentity, error) {
+	if provider == "" || subject == "" {
+		return nil, errors.New("provider and subject are required")
+	}
+
+	sqlcIdentity, err := sqlc.New(r.conn).FindUserIdentity(ctx, sqlc.FindUserIdentityParams{
+		Provider: provider,
+		Subject:  subject,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s identity: %w", provider, err)
+	}
+
+	return new(models.UserIdentity).ConvertFromSQLCUserIdentity(sqlcIdentity), nil
+}
+
+// ListUserIdentities lists the identities linked to a user, ordered by provider.
+func (r *repository) ListUserIdentities(ctx context.Context, userID uint64) ([]*models.UserIdentity, error) {
+	sqlcIdentities, err := sqlc.New(r.conn).ListUserIdentities(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user identities: %w", err)
+	}
+
+	identities := make([]*models.UserIdentity, len(sqlcIdentities))
+	for i, sqlcIdentity := range sqlcIdentities {
+		identities[i] = new(models.UserIdentity).ConvertFromSQLCUserIdentity(sqlcIdentity)
+	}
+
+	return identities, nil
+}
@@ -0,0 +1,243 @@
+// Package client is the Go client of the auth service for downstream services. It wraps the AuthService gRPC API
+// and the REST endpoints with deadlines, retries and pooled connections, and verifies access tokens either locally,
+// with the public keys the service publishes, or remotely.
+//
+// A service that only verifies tokens needs BaseURL:
+//
+//	c, err := client.New(client.Config{BaseURL: "https://auth.example.com", LocalVerification: true})
+//	if err != nil {
+//		return err
+//	}
+//	defer c.Close()
+//
+//	principal, err := c.Verify(ctx, token)
+//
+// The gRPC API, which the permission checks use, only answers authenticated calls. A service calls it as a service
+// account, whose roles must grant READ on PERMISSION to check the permissions of others:
+//
+//	c, err := client.New(client.Config{
+//		BaseURL:      "https://auth.example.com",
+//		GRPCAddress:  "auth:50051",
+//		ClientID:     clientID,
+//		ClientSecret: clientSecret,
+//	})
+package client
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	authpb "goflare.io/auth/proto/pb/proto/auth"
+)
+
+const (
+	// DefaultTimeout is the deadline of a call whose context has none, unless configured otherwise.
+	DefaultTimeout = 5 * time.Second
+
+	// DefaultMaxRetries is how often a failed call is retried unless configured otherwise.
+	DefaultMaxRetries = 2
+
+	// DefaultRetryBackoff is the wait before the first retry unless configured otherwise. It doubles with every retry.
+	DefaultRetryBackoff = 100 * time.Millisecond
+
+	// DefaultMaxIdleConns is the number of idle HTTP connections kept to the service unless configured otherwise.
+	DefaultMaxIdleConns = 16
+
+	// DefaultKeyRefreshInterval is how often the public keys are fetched again for local verification unless
+	// configured otherwise.
+	DefaultKeyRefreshInterval = time.Hour
+)
+
+// ErrNotConfigured is returned by calls that need an API the client was not configured for.
+var ErrNotConfigured = errors.New("auth client: not configured")
+
+// Config configures a Client. At least one of BaseURL, GRPCAddress and PublicKeys is required.
+type Config struct {
+
+	// BaseURL is the base URL of the REST endpoints, e.g. https://auth.example.com.
+	BaseURL string
+
+	// GRPCAddress is the address of the AuthService gRPC API, e.g. auth:50051.
+	GRPCAddress string
+
+	// ClientID and ClientSecret are the credentials of the service account gRPC calls are made as. Its token is
+	// obtained from BaseURL with the client credentials grant and attached to every call that does not carry a
+	// token in its outgoing metadata already. Without them, only calls that carry a token and the public Login,
+	// Register and ValidateToken succeed.
+	ClientID     string
+	ClientSecret string
+
+	// GRPCConns is the number of gRPC connections calls are spread over. It defaults to one, which multiplexes
+	// every call over a single HTTP/2 connection.
+	GRPCConns int
+
+	// TLS configures TLS for the gRPC API. The connection is plaintext when it is nil.
+	TLS *tls.Config
+
+	// Timeout is the deadline of each attempt of a call whose context has no deadline. It defaults to five seconds.
+	Timeout time.Duration
+
+	// MaxRetries is how often a call that failed with a transient error is retried. It defaults to two; a negative
+	// value disables retries.
+	MaxRetries int
+
+	// RetryBackoff is the wait before the first retry, doubled with every retry. It defaults to 100 milliseconds.
+	RetryBackoff time.Duration
+
+	// MaxIdleConns is the number of idle HTTP connections kept to the service. It defaults to 16.
+	MaxIdleConns int
+
+	// HTTPClient replaces the pooled HTTP client the client creates.
+	HTTPClient *http.Client
+
+	// LocalVerification verifies access tokens with the public keys published at BaseURL instead of calling the
+	// service. API keys are always verified remotely, since only the service knows whether they were revoked.
+	LocalVerification bool
+
+	// PublicKeys are the Ed25519 keys access tokens are verified with locally, instead of the published ones. They
+	// enable local verification without BaseURL, e.g. for services that are given the PASETO public key directly.
+	PublicKeys []ed25519.PublicKey
+
+	// KeyRefreshInterval is how often the public keys are fetched again. It defaults to one hour.
+	KeyRefreshInterval time.Duration
+
+	// Audience is the client ID of the calling service. When set, delegated tokens are only accepted if they were
+	// issued for it.
+	Audience string
+}
+
+// Client calls the auth service. It is safe for concurrent use.
+type Client struct {
+	cfg      Config
+	http     *http.Client
+	conns    []*grpc.ClientConn
+	stubs    []authpb.AuthServiceClient
+	next     atomic.Uint64
+	verifier *verifier
+	tokens   *TokenSource
+}
+
+// New creates a client. gRPC connections are established lazily, on the first call.
+func New(cfg Config) (*Client, error) {
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	if len(cfg.PublicKeys) > 0 {
+		cfg.LocalVerification = true
+	}
+	if cfg.BaseURL == "" && cfg.GRPCAddress == "" && !cfg.LocalVerification {
+		return nil, fmt.Errorf("%w: BaseURL, GRPCAddress or PublicKeys is required", ErrNotConfigured)
+	}
+	if cfg.LocalVerification && cfg.BaseURL == "" && len(cfg.PublicKeys) == 0 {
+		return nil, fmt.Errorf("%w: local verification needs BaseURL to fetch the public keys", ErrNotConfigured)
+	}
+	if cfg.ClientID != "" && cfg.BaseURL == "" {
+		return nil, fmt.Errorf("%w: client credentials need BaseURL to obtain tokens", ErrNotConfigured)
+	}
+	if cfg.GRPCConns <= 0 {
+		cfg.GRPCConns = 1
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = DefaultRetryBackoff
+	}
+	if cfg.MaxIdleConns <= 0 {
+		cfg.MaxIdleConns = DefaultMaxIdleConns
+	}
+	if cfg.KeyRefreshInterval <= 0 {
+		cfg.KeyRefreshInterval = DefaultKeyRefreshInterval
+	}
+
+	c := &Client{cfg: cfg, http: cfg.HTTPClient}
+	if c.http == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.MaxIdleConns = cfg.MaxIdleConns
+		transport.MaxIdleConnsPerHost = cfg.MaxIdleConns
+		c.http = &http.Client{Transport: transport}
+	}
+
+	if cfg.ClientID != "" {
+		c.tokens = c.TokenSource(cfg.ClientID, cfg.ClientSecret)
+	}
+
+	if cfg.GRPCAddress != "" {
+		creds := insecure.NewCredentials()
+		if cfg.TLS != nil {
+			creds = credentials.NewTLS(cfg.TLS)
+		}
+		for range cfg.GRPCConns {
+			conn, err := grpc.NewClient(cfg.GRPCAddress,
+				grpc.WithTransportCredentials(creds),
+				grpc.WithChainUnaryInterceptor(c.retryInterceptor, c.deadlineInterceptor, c.credentialsInterceptor),
+			)
+			if err != nil {
+				_ = c.Close()
+				return nil, fmt.Errorf("failed to create gRPC connection: %w", err)
+			}
+			c.conns = append(c.conns, conn)
+			c.stubs = append(c.stubs, authpb.NewAuthServiceClient(conn))
+		}
+	}
+
+	if cfg.LocalVerification {
+		c.verifier = newVerifier(c)
+	}
+
+	return c, nil
+}
+
+// Close closes the gRPC connections and the idle HTTP connections.
+func (c *Client) Close() error {
+	var errs []error
+	for _, conn := range c.conns {
+		errs = append(errs, conn.Close())
+	}
+	c.http.CloseIdleConnections()
+	return errors.Join(errs...)
+}
+
+// withTimeout applies the configured timeout to a context without a deadline.
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.cfg.Timeout)
+}
+
+// retry calls attempt until it succeeds, fails with an error retryable does not accept, or the retries are used
+// up. The wait between attempts doubles every time, with jitter so that clients do not retry in lockstep.
+func (c *Client) retry(ctx context.Context, attempt func() error, retryable func(error) bool) error {
+	backoff := c.cfg.RetryBackoff
+	for i := 0; ; i++ {
+		err := attempt()
+		if err == nil || i >= c.cfg.MaxRetries || !retryable(err) {
+			return err
+		}
+
+		wait := backoff/2 + rand.N(backoff/2+1)
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(wait):
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,241 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	authpb "goflare.io/auth/proto/pb/proto/auth"
+)
+
+// GRPC returns the generated AuthService client for the calls the typed methods do not cover. Calls go through the
+// same deadline and retry handling. Successive calls spread the load over the configured connections.
+func (c *Client) GRPC() (authpb.AuthServiceClient, error) {
+	if len(c.stubs) == 0 {
+		return nil, fmt.Errorf("%w: GRPCAddress is required", ErrNotConfigured)
+	}
+	return c.stubs[c.next.Add(1)%uint64(len(c.stubs))], nil
+}
+
+// CheckPermission checks whether a user may perform an action on a resource type, e.g. UPDATE on ORDER.
+// Attributes are matched against the attribute conditions of the policies and may be nil.
+func (c *Client) CheckPermission(ctx context.Context, userID uint64, resource, action string, attributes map[string]any) (bool, error) {
+	stub, err := c.GRPC()
+	if err != nil {
+		return false, err
+	}
+	attrs, err := attributeStruct(attributes)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := stub.CheckPermission(ctx, &authpb.CheckPermissionRequest{
+		UserId:     userID,
+		Resource:   resource,
+		Action:     action,
+		Attributes: attrs,
+	})
+	if err != nil {
+		return false, err
+	}
+	return resp.GetAllowed(), nil
+}
+
+// CheckPrincipalPermission checks whether a verified principal may perform an action on a resource type. The scopes
+// of an API key or delegated token are checked locally first; only then is the service asked whether the roles of
+// the user or service account grant the permission.
+func (c *Client) CheckPrincipalPermission(ctx context.Context, principal *Principal, resource, action string) (bool, error) {
+	if !principal.Allows(resource, action) {
+		return false, nil
+	}
+
+	stub, err := c.GRPC()
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := stub.CheckPermission(ctx, &authpb.CheckPermissionRequest{
+		UserId:      principal.ID,
+		Resource:    resource,
+		Action:      action,
+		SubjectType: principal.Type,
+	})
+	if err != nil {
+		return false, err
+	}
+	return resp.GetAllowed(), nil
+}
+
+// CheckResourcePermission checks whether a user may perform an action on a single resource instance such as
+// order/42, taking the relations of the user to the instance into account.
+func (c *Client) CheckResourcePermission(ctx context.Context, userID uint64, resource, action string, attributes map[string]any) (bool, error) {
+	stub, err := c.GRPC()
+	if err != nil {
+		return false, err
+	}
+	attrs, err := attributeStruct(attributes)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := stub.CheckResourcePermission(ctx, &authpb.CheckResourcePermissionRequest{
+		UserId:     userID,
+		Resource:   resource,
+		Action:     action,
+		Attributes: attrs,
+	})
+	if err != nil {
+		return false, err
+	}
+	return resp.GetAllowed(), nil
+}
+
+// BatchCheckPermission checks many resource and action pairs for a user at once. The decisions are in the order of
+// the checks.
+func (c *Client) BatchCheckPermission(ctx context.Context, userID uint64, checks []*authpb.PermissionCheck, attributes map[string]any) ([]*authpb.PermissionDecision, error) {
+	stub, err := c.GRPC()
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := attributeStruct(attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := stub.BatchCheckPermission(ctx, &authpb.BatchCheckPermissionRequest{
+		UserId:     userID,
+		Checks:     checks,
+		Attributes: attrs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetDecisions(), nil
+}
+
+// ListPermissions lists every object and action a user is granted.
+func (c *Client) ListPermissions(ctx context.Context, userID uint64) ([]*authpb.GrantedPermission, error) {
+	stub, err := c.GRPC()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := stub.ListPermissions(ctx, &authpb.ListPermissionsRequest{UserId: userID})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetPermissions(), nil
+}
+
+// ValidateToken validates a user token with the gRPC API and returns the user it was issued to.
+func (c *Client) ValidateToken(ctx context.Context, token string) (*authpb.UserInfo, error) {
+	stub, err := c.GRPC()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := stub.ValidateToken(ctx, &authpb.ValidateTokenRequest{Token: token})
+	if err != nil {
+		return nil, err
+	}
+	if !resp.GetValid() {
+		return nil, ErrInvalidToken
+	}
+	return resp.GetUser(), nil
+}
+
+// deadlineInterceptor gives every attempt of a call without a deadline the configured timeout.
+func (c *Client) deadlineInterceptor(
+	ctx context.Context,
+	method string,
+	req, reply any,
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// credentialsInterceptor attaches the token of the configured service account to calls that carry none. A call the
+// service rejects as unauthenticated is made once more with a new token, in case the cached one was revoked.
+func (c *Client) credentialsInterceptor(
+	ctx context.Context,
+	method string,
+	req, reply any,
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	if c.tokens == nil {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	if md, _ := metadata.FromOutgoingContext(ctx); len(md.Get("authorization")) > 0 {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	for attempt := 0; ; attempt++ {
+		token, err := c.tokens.Token(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to obtain a token for the gRPC call: %w", err)
+		}
+
+		err = invoker(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token), method, req, reply, cc, opts...)
+		if status.Code(err) != codes.Unauthenticated || attempt > 0 {
+			return err
+		}
+		c.tokens.Invalidate(token)
+	}
+}
+
+// unsafeToRetry are the calls that create something, which a retry could create twice.
+var unsafeToRetry = map[string]bool{
+	authpb.AuthService_Register_FullMethodName:             true,
+	authpb.AuthService_RegisterResourceType_FullMethodName: true,
+	authpb.AuthService_RegisterActionType_FullMethodName:   true,
+}
+
+// retryInterceptor retries calls that failed because the service was unavailable or overloaded, except those in
+// unsafeToRetry.
+func (c *Client) retryInterceptor(
+	ctx context.Context,
+	method string,
+	req, reply any,
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	if unsafeToRetry[method] {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	return c.retry(ctx, func() error {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}, func(err error) bool {
+		switch status.Code(err) {
+		case codes.Unavailable, codes.ResourceExhausted, codes.Aborted:
+			return true
+		case codes.DeadlineExceeded:
+			// Only the attempt ran out of time if the caller's context is still alive.
+			return ctx.Err() == nil
+		}
+		return false
+	})
+}
+
+// attributeStruct converts request attributes to a protobuf Struct, or nil when there are none.
+func attributeStruct(attributes map[string]any) (*structpb.Struct, error) {
+	if len(attributes) == 0 {
+		return nil, nil
+	}
+	attrs, err := structpb.NewStruct(attributes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attributes: %w", err)
+	}
+	return attrs, nil
+}
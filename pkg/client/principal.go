@@ -0,0 +1,94 @@
+package client
+
+import (
+	"slices"
+	"time"
+)
+
+// Subject types of a principal.
+const (
+	// SubjectUser is a human user.
+	SubjectUser = "user"
+
+	// SubjectServiceAccount is a service account used for machine-to-machine calls.
+	SubjectServiceAccount = "service_account"
+)
+
+// Principal is the user or service account a token was issued to.
+type Principal struct {
+
+	// Type is the subject type, user or service_account.
+	Type string `json:"type"`
+
+	// ID is the ID of the user or service account.
+	ID uint64 `json:"id"`
+
+	// ClientID is the client the token was issued to, if any.
+	ClientID string `json:"client_id,omitempty"`
+
+	// Scopes are the scopes of the token, if it was issued to a client, or of the API key.
+	Scopes []string `json:"scopes,omitempty"`
+
+	// APIKeyID is the ID of the API key the user authenticated with, if any.
+	APIKeyID uint64 `json:"api_key_id,omitempty"`
+
+	// Audience is the service a delegated token is restricted to.
+	Audience string `json:"aud,omitempty"`
+
+	// Actor is the service acting for the user with a delegated token obtained by token exchange.
+	Actor *Actor `json:"act,omitempty"`
+
+	// ExpiresAt is when the token or API key expires. It is zero for API keys that do not expire.
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// Actor is the service that acts for the user of a delegated token, with the actor of the token it was exchanged
+// for nested in Actor.
+type Actor struct {
+
+	// Subject is the client ID of the service account acting for the user.
+	Subject string `json:"sub"`
+
+	// ServiceAccountID is the ID of the service account acting for the user.
+	ServiceAccountID uint64 `json:"service_account_id"`
+
+	// Actor is the previous actor, if the token was exchanged more than once.
+	Actor *Actor `json:"act,omitempty"`
+}
+
+// IsUser reports whether the principal is a user.
+func (p *Principal) IsUser() bool {
+	return p.Type == SubjectUser
+}
+
+// IsServiceAccount reports whether the principal is a service account.
+func (p *Principal) IsServiceAccount() bool {
+	return p.Type == SubjectServiceAccount
+}
+
+// IsAPIKey reports whether the principal is a user who authenticated with an API key.
+func (p *Principal) IsAPIKey() bool {
+	return p.APIKeyID != 0
+}
+
+// IsDelegated reports whether a service acts for the user with a token obtained by token exchange.
+func (p *Principal) IsDelegated() bool {
+	return p.Actor != nil
+}
+
+// IsClient reports whether an OAuth client acts for the user with a token issued by the authorization server.
+func (p *Principal) IsClient() bool {
+	return p.IsUser() && p.ClientID != ""
+}
+
+// Restricted reports whether the permissions of the principal are limited to the RESOURCE:ACTION pairs of its
+// scopes, as for service accounts, API keys, delegated tokens and tokens issued to clients.
+func (p *Principal) Restricted() bool {
+	return p.IsServiceAccount() || p.IsAPIKey() || p.IsDelegated() || p.IsClient()
+}
+
+// Allows reports whether the scopes of a restricted principal cover an action on a resource. Principals that are
+// not restricted are allowed everything their roles grant, which only the auth service can tell.
+func (p *Principal) Allows(resource, action string) bool {
+	return !p.Restricted() || slices.Contains(p.Scopes, resource+":"+action)
+}
@@ -0,0 +1,28 @@
+package client
+
+import "testing"
+
+func TestPrincipalAllows(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal Principal
+		want      bool
+	}{
+		{"user", Principal{Type: SubjectUser, ID: 1}, true},
+		{"API key within its scopes", Principal{Type: SubjectUser, ID: 1, APIKeyID: 1, Scopes: []string{"ORDER:READ"}}, true},
+		{"API key outside its scopes", Principal{Type: SubjectUser, ID: 1, APIKeyID: 1, Scopes: []string{"ORDER:UPDATE"}}, false},
+		{"client token within its scopes", Principal{Type: SubjectUser, ID: 1, ClientID: "client", Scopes: []string{"ORDER:READ"}}, true},
+		{"client token outside its scopes", Principal{Type: SubjectUser, ID: 1, ClientID: "client", Scopes: []string{"openid"}}, false},
+		{"service account within its scopes", Principal{Type: SubjectServiceAccount, ID: 2, ClientID: "service", Scopes: []string{"ORDER:READ"}}, true},
+		{"service account outside its scopes", Principal{Type: SubjectServiceAccount, ID: 2, ClientID: "service", Scopes: []string{"ORDER:UPDATE"}}, false},
+		{"delegated token outside its scopes", Principal{Type: SubjectUser, ID: 1, ClientID: "service", Actor: &Actor{Subject: "service"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.principal.Allows("ORDER", "READ"); got != tt.want {
+				t.Errorf("Allows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// APIKeyPrefix starts every API key. API keys are opaque and can only be verified by the service.
+	APIKeyPrefix = "flare_pat_"
+
+	// grantTypeTokenExchange is the token exchange grant of RFC 8693.
+	grantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+
+	// tokenTypeAccessToken is the token type identifier of access tokens (RFC 8693 section 3).
+	tokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+var (
+	// ErrInvalidToken is returned for a token that is malformed, expired, revoked or not signed by the service.
+	ErrInvalidToken = errors.New("invalid token")
+
+	// ErrWrongAudience is returned for a delegated token that was issued for another service.
+	ErrWrongAudience = errors.New("the token was issued for another audience")
+)
+
+// Error is an error response of the REST endpoints. OAuth 2.0 errors carry a code such as invalid_grant.
+type Error struct {
+
+	// StatusCode is the HTTP status of the response.
+	StatusCode int
+
+	// Code is the OAuth 2.0 error code, if any.
+	Code string `json:"error"`
+
+	// Description is the description of the error.
+	Description string `json:"error_description"`
+}
+
+// Error returns the status, code and description of the error.
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("auth service: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Code != "" {
+		msg += ": " + e.Code
+	}
+	if e.Description != "" {
+		msg += ": " + e.Description
+	}
+	return msg
+}
+
+// Token is an access token issued by the token endpoint.
+type Token struct {
+
+	// AccessToken is the access token.
+	AccessToken string `json:"access_token"`
+
+	// TokenType is always Bearer.
+	TokenType string `json:"token_type"`
+
+	// ExpiresIn is the lifetime of the access token in seconds.
+	ExpiresIn int64 `json:"expires_in"`
+
+	// Scope is the space-separated list of granted scopes.
+	Scope string `json:"scope"`
+
+	// IssuedTokenType is the type of the token issued by token exchange.
+	IssuedTokenType string `json:"issued_token_type,omitempty"`
+
+	// ExpiresAt is when the access token expires, computed from ExpiresIn when the token was received.
+	ExpiresAt time.Time `json:"-"`
+}
+
+// ClientCredentials obtains a token for a service account with the client credentials grant. Without scopes the
+// token gets every scope of the service account.
+func (c *Client) ClientCredentials(ctx context.Context, clientID, clientSecret string, scopes ...string) (*Token, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(scopes) > 0 {
+		form.Set("scope", strings.Join(scopes, " "))
+	}
+	return c.token(ctx, clientID, clientSecret, form)
+}
+
+// ExchangeToken trades the token of a user for a token with which the service account acts for the user at
+// audience, with the given scopes or every scope it may delegate (RFC 8693). The new token is short-lived and
+// records the service account as the actor.
+func (c *Client) ExchangeToken(ctx context.Context, clientID, clientSecret, subjectToken, audience string, scopes ...string) (*Token, error) {
+	form := url.Values{
+		"grant_type":         {grantTypeTokenExchange},
+		"subject_token":      {subjectToken},
+		"subject_token_type": {tokenTypeAccessToken},
+		"audience":           {audience},
+	}
+	if len(scopes) > 0 {
+		form.Set("scope", strings.Join(scopes, " "))
+	}
+	return c.token(ctx, clientID, clientSecret, form)
+}
+
+// Principal asks the service who a token or API key was issued to. It fails with ErrInvalidToken if the service
+// does not accept the token.
+func (c *Client) Principal(ctx context.Context, token string) (*Principal, error) {
+	var principal Principal
+	err := c.do(ctx, http.MethodGet, "/oauth2/principal", http.Header{"Authorization": {"Bearer " + token}}, nil, &principal)
+	var restErr *Error
+	if errors.As(err, &restErr) && restErr.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, restErr.Description)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &principal, nil
+}
+
+// Verify validates a token and returns the principal it was issued to. Access tokens are verified locally when
+// local verification is enabled; API keys, and every token otherwise, are verified by the service. Delegated
+// tokens are only accepted by the audience they were issued for.
+func (c *Client) Verify(ctx context.Context, token string) (*Principal, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, ErrInvalidToken
+	}
+
+	var principal *Principal
+	var err error
+	if c.verifier != nil && !strings.HasPrefix(token, APIKeyPrefix) {
+		principal, err = c.verifier.verify(ctx, token)
+	} else {
+		principal, err = c.Principal(ctx, token)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if principal.Audience != "" && principal.Audience != c.cfg.Audience {
+		return nil, ErrWrongAudience
+	}
+	return principal, nil
+}
+
+// token sends a request to the token endpoint, authenticating with HTTP Basic authentication.
+func (c *Client) token(ctx context.Context, clientID, clientSecret string, form url.Values) (*Token, error) {
+	header := http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}
+	header.Set("Authorization", "Basic "+basicCredentials(clientID, clientSecret))
+
+	var token Token
+	if err := c.do(ctx, http.MethodPost, "/oauth2/token", header, []byte(form.Encode()), &token); err != nil {
+		return nil, err
+	}
+	token.ExpiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	return &token, nil
+}
+
+// do sends a request to the REST endpoints and decodes the JSON response into out. Requests that fail in transit
+// or with 429, 502, 503 or 504 are retried; every request sent with do is safe to repeat.
+func (c *Client) do(ctx context.Context, method, path string, header http.Header, body []byte, out any) error {
+	if c.cfg.BaseURL == "" {
+		return fmt.Errorf("%w: BaseURL is required", ErrNotConfigured)
+	}
+
+	return c.retry(ctx, func() error {
+		attemptCtx, cancel := c.withTimeout(ctx)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(attemptCtx, method, c.cfg.BaseURL+path, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode >= 300 {
+			restErr := &Error{StatusCode: resp.StatusCode}
+			if json.Unmarshal(data, restErr) != nil || restErr.Code == "" {
+				restErr.Description = strings.TrimSpace(string(data))
+			}
+			return restErr
+		}
+
+		if err = json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to decode response of %s: %w", path, err)
+		}
+		return nil
+	}, func(err error) bool {
+		var restErr *Error
+		if errors.As(err, &restErr) {
+			switch restErr.StatusCode {
+			case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+				return true
+			}
+			return false
+		}
+		var transportErr *url.Error
+		return errors.As(err, &transportErr) && ctx.Err() == nil
+	})
+}
+
+// basicCredentials encodes client credentials for HTTP Basic authentication, form-encoding them first as
+// RFC 6749 section 2.3.1 requires.
+func basicCredentials(clientID, clientSecret string) string {
+	return base64.StdEncoding.EncodeToString([]byte(url.QueryEscape(clientID) + ":" + url.QueryEscape(clientSecret)))
+}
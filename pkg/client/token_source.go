@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// tokenRefreshMargin is how long before it expires a cached token is replaced, so that a token is not sent only to
+// expire in flight.
+const tokenRefreshMargin = 30 * time.Second
+
+// TokenSource supplies the access token of a service account, obtained with the client credentials grant. The token
+// is cached and obtained again shortly before it expires, or after Invalidate. It is safe for concurrent use.
+type TokenSource struct {
+	client       *Client
+	clientID     string
+	clientSecret string
+	scopes       []string
+
+	mu    sync.Mutex
+	token *Token
+}
+
+// TokenSource returns a TokenSource for a service account, with the given scopes or every scope of the account.
+func (c *Client) TokenSource(clientID, clientSecret string, scopes ...string) *TokenSource {
+	return &TokenSource{
+		client:       c,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		scopes:       scopes,
+	}
+}
+
+// Token returns the cached access token, obtaining a new one when there is none or it is about to expire.
+func (s *TokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != nil && time.Until(s.token.ExpiresAt) > tokenRefreshMargin {
+		return s.token.AccessToken, nil
+	}
+
+	token, err := s.client.ClientCredentials(ctx, s.clientID, s.clientSecret, s.scopes...)
+	if err != nil {
+		return "", err
+	}
+	s.token = token
+	return token.AccessToken, nil
+}
+
+// Invalidate discards the cached token if it is token, e.g. after a service rejected it, so that the next call of
+// Token obtains a new one. A token that was already replaced is left alone.
+func (s *TokenSource) Invalidate(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != nil && s.token.AccessToken == token {
+		s.token = nil
+	}
+}
@@ -0,0 +1,148 @@
+package client
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/o1egl/paseto"
+)
+
+// minKeyRefreshInterval is the least time between two fetches of the public keys forced by a token that none of
+// the known keys verifies, so that forged tokens cannot make the client hammer the service.
+const minKeyRefreshInterval = time.Minute
+
+// keySetPath is where the service publishes the public keys that verify access tokens.
+const keySetPath = "/.well-known/paseto-keys.json"
+
+// claims are the claims of an access token.
+type claims struct {
+	UserID           uint64
+	ExpiresAt        time.Time
+	ClientID         string
+	Scopes           []string
+	SubjectType      string
+	ServiceAccountID uint64
+	Audience         string `json:"aud"`
+	Actor            *Actor `json:"act"`
+}
+
+// jsonWebKeySet is the key set published by the service, of which only Ed25519 keys are used.
+type jsonWebKeySet struct {
+	Keys []struct {
+		KeyType string `json:"kty"`
+		Curve   string `json:"crv"`
+		X       string `json:"x"`
+	} `json:"keys"`
+}
+
+// verifier verifies access tokens locally with the public keys of the service, which it fetches again
+// periodically and whenever a token is signed by a key it does not know yet. Configured keys are never fetched.
+type verifier struct {
+	client    *Client
+	mu        sync.Mutex
+	keys      []ed25519.PublicKey
+	fetchedAt time.Time
+}
+
+// newVerifier creates a verifier that fetches the public keys with the client, unless they were configured.
+func newVerifier(client *Client) *verifier {
+	return &verifier{client: client, keys: client.cfg.PublicKeys}
+}
+
+// verify checks the signature and expiry of an access token and returns the principal it was issued to.
+func (v *verifier) verify(ctx context.Context, token string) (*Principal, error) {
+	keys, err := v.publicKeys(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenClaims, ok := verifyWith(keys, token)
+	if !ok {
+		if keys, err = v.publicKeys(ctx, true); err != nil {
+			return nil, err
+		}
+		if tokenClaims, ok = verifyWith(keys, token); !ok {
+			return nil, ErrInvalidToken
+		}
+	}
+	if !time.Now().Before(tokenClaims.ExpiresAt) {
+		return nil, fmt.Errorf("%w: token expired", ErrInvalidToken)
+	}
+
+	principal := &Principal{
+		Type:      SubjectUser,
+		ID:        tokenClaims.UserID,
+		ClientID:  tokenClaims.ClientID,
+		Scopes:    tokenClaims.Scopes,
+		Audience:  tokenClaims.Audience,
+		Actor:     tokenClaims.Actor,
+		ExpiresAt: tokenClaims.ExpiresAt,
+	}
+	if tokenClaims.SubjectType == SubjectServiceAccount {
+		principal.Type = SubjectServiceAccount
+		principal.ID = tokenClaims.ServiceAccountID
+	}
+	if principal.ID == 0 {
+		return nil, fmt.Errorf("%w: token has no subject", ErrInvalidToken)
+	}
+	return principal, nil
+}
+
+// publicKeys returns the public keys of the service, fetching them when they are stale, or when force is set and
+// they were not fetched within minKeyRefreshInterval. When a fetch fails, the keys fetched before remain in use.
+func (v *verifier) publicKeys(ctx context.Context, force bool) ([]ed25519.PublicKey, error) {
+	if len(v.client.cfg.PublicKeys) > 0 {
+		return v.client.cfg.PublicKeys, nil
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	age := time.Since(v.fetchedAt)
+	if v.keys != nil && age < v.client.cfg.KeyRefreshInterval && (!force || age < minKeyRefreshInterval) {
+		return v.keys, nil
+	}
+
+	var set jsonWebKeySet
+	if err := v.client.do(ctx, http.MethodGet, keySetPath, nil, nil, &set); err != nil {
+		if v.keys != nil {
+			return v.keys, nil
+		}
+		return nil, fmt.Errorf("failed to fetch public keys: %w", err)
+	}
+
+	keys := make([]ed25519.PublicKey, 0, len(set.Keys))
+	for _, key := range set.Keys {
+		if key.KeyType != "OKP" || key.Curve != "Ed25519" {
+			continue
+		}
+		x, err := base64.RawURLEncoding.DecodeString(key.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			continue
+		}
+		keys = append(keys, x)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("the service published no Ed25519 public key")
+	}
+
+	v.keys = keys
+	v.fetchedAt = time.Now()
+	return keys, nil
+}
+
+// verifyWith returns the claims of a v2.public PASETO token signed by one of keys.
+func verifyWith(keys []ed25519.PublicKey, token string) (*claims, bool) {
+	for _, key := range keys {
+		var tokenClaims claims
+		if paseto.NewV2().Verify(token, key, &tokenClaims, nil) == nil {
+			return &tokenClaims, true
+		}
+	}
+	return nil, false
+}
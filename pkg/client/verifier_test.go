@@ -0,0 +1,234 @@
+package client
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/o1egl/paseto"
+)
+
+// keyServer publishes a key set and counts how often it was fetched.
+type keyServer struct {
+	mu      sync.Mutex
+	keys    []ed25519.PublicKey
+	fail    bool
+	fetches int
+}
+
+func (s *keyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path != keySetPath {
+		http.NotFound(w, r)
+		return
+	}
+	s.fetches++
+	if s.fail {
+		http.Error(w, `{"error":"unavailable"}`, http.StatusBadRequest)
+		return
+	}
+
+	var set jsonWebKeySet
+	for _, key := range s.keys {
+		set.Keys = append(set.Keys, struct {
+			KeyType string `json:"kty"`
+			Curve   string `json:"crv"`
+			X       string `json:"x"`
+		}{"OKP", "Ed25519", base64.RawURLEncoding.EncodeToString(key)})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(set)
+}
+
+func (s *keyServer) set(keys []ed25519.PublicKey, fail bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys, s.fail = keys, fail
+}
+
+func (s *keyServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fetches
+}
+
+func newKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	return pub, priv
+}
+
+func sign(t *testing.T, priv ed25519.PrivateKey, tokenClaims claims) string {
+	t.Helper()
+	token, err := paseto.NewV2().Sign(priv, tokenClaims, nil)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	return token
+}
+
+func newLocalClient(t *testing.T, cfg Config) *Client {
+	t.Helper()
+	cfg.LocalVerification = true
+	cfg.MaxRetries = -1
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+func TestVerifierVerify(t *testing.T) {
+	pub, priv := newKey(t)
+	_, otherPriv := newKey(t)
+	server := &keyServer{keys: []ed25519.PublicKey{pub}}
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	c := newLocalClient(t, Config{BaseURL: srv.URL})
+	expiresAt := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		token    string
+		wantErr  bool
+		wantType string
+		wantID   uint64
+	}{
+		{
+			name:     "user token",
+			token:    sign(t, priv, claims{UserID: 1, ExpiresAt: expiresAt}),
+			wantType: SubjectUser,
+			wantID:   1,
+		},
+		{
+			name:     "service account token",
+			token:    sign(t, priv, claims{SubjectType: SubjectServiceAccount, ServiceAccountID: 2, ClientID: "service", ExpiresAt: expiresAt}),
+			wantType: SubjectServiceAccount,
+			wantID:   2,
+		},
+		{
+			name:    "expired token",
+			token:   sign(t, priv, claims{UserID: 1, ExpiresAt: time.Now().Add(-time.Minute)}),
+			wantErr: true,
+		},
+		{
+			name:    "token without a subject",
+			token:   sign(t, priv, claims{ExpiresAt: expiresAt}),
+			wantErr: true,
+		},
+		{
+			name:    "token signed by an unknown key",
+			token:   sign(t, otherPriv, claims{UserID: 1, ExpiresAt: expiresAt}),
+			wantErr: true,
+		},
+		{
+			name:    "malformed token",
+			token:   "v2.public.garbage",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := c.verifier.verify(context.Background(), tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("verify() error = %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("verify() error = %v", err)
+			}
+			if principal.Type != tt.wantType || principal.ID != tt.wantID {
+				t.Errorf("verify() = %s %d, want %s %d", principal.Type, principal.ID, tt.wantType, tt.wantID)
+			}
+		})
+	}
+
+	if got := server.count(); got != 1 {
+		t.Errorf("keys fetched %d times, want 1", got)
+	}
+}
+
+func TestVerifierKeyRefresh(t *testing.T) {
+	oldPub, oldPriv := newKey(t)
+	newPub, newPriv := newKey(t)
+	server := &keyServer{keys: []ed25519.PublicKey{oldPub}}
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	c := newLocalClient(t, Config{BaseURL: srv.URL})
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+	oldToken := sign(t, oldPriv, claims{UserID: 1, ExpiresAt: expiresAt})
+	newToken := sign(t, newPriv, claims{UserID: 1, ExpiresAt: expiresAt})
+
+	if _, err := c.verifier.verify(ctx, oldToken); err != nil {
+		t.Fatalf("verify() error = %v", err)
+	}
+
+	// The service rotated its key, but the keys were fetched within minKeyRefreshInterval.
+	server.set([]ed25519.PublicKey{newPub}, false)
+	for i := 0; i < 3; i++ {
+		if _, err := c.verifier.verify(ctx, newToken); !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("verify() error = %v, want ErrInvalidToken", err)
+		}
+	}
+	if got := server.count(); got != 1 {
+		t.Fatalf("keys fetched %d times within the throttle, want 1", got)
+	}
+
+	c.verifier.fetchedAt = time.Now().Add(-minKeyRefreshInterval)
+	if _, err := c.verifier.verify(ctx, newToken); err != nil {
+		t.Fatalf("verify() after the throttle error = %v", err)
+	}
+	if got := server.count(); got != 2 {
+		t.Fatalf("keys fetched %d times, want 2", got)
+	}
+
+	// A failed fetch keeps the keys fetched before.
+	server.set(nil, true)
+	c.verifier.fetchedAt = time.Now().Add(-DefaultKeyRefreshInterval)
+	if _, err := c.verifier.verify(ctx, newToken); err != nil {
+		t.Fatalf("verify() with a failing key set error = %v", err)
+	}
+	if got := server.count(); got != 3 {
+		t.Errorf("keys fetched %d times, want 3", got)
+	}
+}
+
+func TestVerifierConfiguredKeys(t *testing.T) {
+	pub, priv := newKey(t)
+	server := &keyServer{}
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	c := newLocalClient(t, Config{BaseURL: srv.URL, PublicKeys: []ed25519.PublicKey{pub}})
+	ctx := context.Background()
+
+	if _, err := c.verifier.verify(ctx, sign(t, priv, claims{UserID: 1, ExpiresAt: time.Now().Add(time.Hour)})); err != nil {
+		t.Fatalf("verify() error = %v", err)
+	}
+	_, otherPriv := newKey(t)
+	if _, err := c.verifier.verify(ctx, sign(t, otherPriv, claims{UserID: 1, ExpiresAt: time.Now().Add(time.Hour)})); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("verify() error = %v, want ErrInvalidToken", err)
+	}
+	if got := server.count(); got != 0 {
+		t.Errorf("keys fetched %d times, want 0", got)
+	}
+}
@@ -0,0 +1,87 @@
+package interceptor
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TokenSource supplies the token outgoing calls are sent with. *client.TokenSource implements it, obtaining and
+// refreshing the token of a service account.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenFunc adapts a function to a TokenSource, e.g. one that returns middleware.TokenFromContext.
+type TokenFunc func(ctx context.Context) (string, error)
+
+// Token calls f.
+func (f TokenFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// invalidator is implemented by token sources that cache tokens, such as *client.TokenSource.
+type invalidator interface {
+	Invalidate(token string)
+}
+
+// UnaryClient returns a client interceptor that sends unary calls with the token of source. A call rejected as
+// Unauthenticated is sent once more with a new token when source caches tokens, since the cached one may have been
+// revoked or signed by a retired key.
+func UnaryClient(source TokenSource) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		token, err := source.Token(ctx)
+		if err != nil {
+			return status.Errorf(codes.Unauthenticated, "failed to obtain a token: %v", err)
+		}
+
+		err = invoker(withToken(ctx, token), method, req, reply, cc, opts...)
+		cache, ok := source.(invalidator)
+		if !ok || status.Code(err) != codes.Unauthenticated {
+			return err
+		}
+
+		cache.Invalidate(token)
+		if token, err = source.Token(ctx); err != nil {
+			return status.Errorf(codes.Unauthenticated, "failed to obtain a token: %v", err)
+		}
+		return invoker(withToken(ctx, token), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClient returns a client interceptor that opens streams with the token of source. Streams are not opened
+// again when they are rejected, since messages may already have been sent.
+func StreamClient(source TokenSource) grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		token, err := source.Token(ctx)
+		if err != nil {
+			return nil, status.Errorf(codes.Unauthenticated, "failed to obtain a token: %v", err)
+		}
+		return streamer(withToken(ctx, token), desc, cc, method, opts...)
+	}
+}
+
+// withToken returns a copy of ctx whose outgoing metadata carries token as bearer token, replacing any other.
+func withToken(ctx context.Context, token string) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	md.Set("authorization", "Bearer "+token)
+	return metadata.NewOutgoingContext(ctx, md)
+}
@@ -0,0 +1,137 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// cachingSource hands out numbered tokens, issuing a new one only after the current one was invalidated.
+type cachingSource struct {
+	issued      int
+	invalidated []string
+	err         error
+}
+
+func (s *cachingSource) Token(context.Context) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	if s.issued == len(s.invalidated) {
+		s.issued++
+	}
+	return fmt.Sprintf("token-%d", s.issued), nil
+}
+
+func (s *cachingSource) Invalidate(token string) {
+	s.invalidated = append(s.invalidated, token)
+}
+
+// recordingInvoker records the tokens calls were sent with and rejects the tokens it does not accept.
+type recordingInvoker struct {
+	accepted map[string]bool
+	tokens   []string
+}
+
+func (i *recordingInvoker) invoke(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) != 1 {
+		return status.Errorf(codes.Internal, "%d authorization values", len(values))
+	}
+	i.tokens = append(i.tokens, values[0])
+	if !i.accepted[values[0]] {
+		return status.Error(codes.Unauthenticated, "invalid token")
+	}
+	return nil
+}
+
+func TestUnaryClient(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     func() TokenSource
+		accepted   []string
+		wantCode   codes.Code
+		wantTokens []string
+	}{
+		{
+			name:       "accepted token",
+			source:     func() TokenSource { return &cachingSource{} },
+			accepted:   []string{"Bearer token-1"},
+			wantCode:   codes.OK,
+			wantTokens: []string{"Bearer token-1"},
+		},
+		{
+			name:       "rejected cached token is replaced",
+			source:     func() TokenSource { return &cachingSource{} },
+			accepted:   []string{"Bearer token-2"},
+			wantCode:   codes.OK,
+			wantTokens: []string{"Bearer token-1", "Bearer token-2"},
+		},
+		{
+			name:       "new token is rejected too",
+			source:     func() TokenSource { return &cachingSource{} },
+			wantCode:   codes.Unauthenticated,
+			wantTokens: []string{"Bearer token-1", "Bearer token-2"},
+		},
+		{
+			name: "rejected token of a function is not retried",
+			source: func() TokenSource {
+				return TokenFunc(func(context.Context) (string, error) { return "forwarded", nil })
+			},
+			wantCode:   codes.Unauthenticated,
+			wantTokens: []string{"Bearer forwarded"},
+		},
+		{
+			name:     "source fails",
+			source:   func() TokenSource { return &cachingSource{err: errors.New("connection refused")} },
+			wantCode: codes.Unauthenticated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invoker := &recordingInvoker{accepted: map[string]bool{}}
+			for _, token := range tt.accepted {
+				invoker.accepted[token] = true
+			}
+
+			ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", "Bearer stale"))
+			err := UnaryClient(tt.source())(ctx, listOrders, nil, nil, nil, invoker.invoke)
+
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("code = %v, want %v (%v)", code, tt.wantCode, err)
+			}
+			if fmt.Sprint(invoker.tokens) != fmt.Sprint(tt.wantTokens) {
+				t.Errorf("tokens sent = %v, want %v", invoker.tokens, tt.wantTokens)
+			}
+		})
+	}
+}
+
+func TestStreamClient(t *testing.T) {
+	var sent []string
+	streamer := func(ctx context.Context, _ *grpc.StreamDesc, _ *grpc.ClientConn, _ string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		sent = md.Get("authorization")
+		return nil, nil
+	}
+
+	if _, err := StreamClient(&cachingSource{})(context.Background(), &grpc.StreamDesc{}, nil, listOrders, streamer); err != nil {
+		t.Fatalf("StreamClient() error = %v", err)
+	}
+	if len(sent) != 1 || sent[0] != "Bearer token-1" {
+		t.Errorf("authorization = %v, want [Bearer token-1]", sent)
+	}
+
+	source := &cachingSource{err: errors.New("connection refused")}
+	if _, err := StreamClient(source)(context.Background(), &grpc.StreamDesc{}, nil, listOrders, streamer); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("StreamClient() error = %v, want Unauthenticated", err)
+	}
+}
@@ -0,0 +1,188 @@
+// Package interceptor protects the gRPC services of downstream services with tokens issued by the auth service, as
+// package middleware does for HTTP handlers. The server interceptors verify the bearer token in the authorization
+// metadata, put the principal in the context, where middleware.PrincipalFromContext reads it, and enforce the
+// permissions declared per method. The client interceptors attach a token to every outgoing call.
+//
+//	auth, err := client.New(client.Config{BaseURL: "https://auth.example.com", GRPCAddress: "auth:9090", LocalVerification: true})
+//	if err != nil {
+//		return err
+//	}
+//	guard := interceptor.New(interceptor.Config{
+//		Verifier: auth,
+//		Checker:  auth,
+//		Permissions: map[string]interceptor.Permission{
+//			orderpb.OrderService_ListOrders_FullMethodName: {Resource: "ORDER", Action: "READ"},
+//		},
+//		Public: []string{healthpb.Health_Check_FullMethodName},
+//	})
+//	srv := grpc.NewServer(grpc.UnaryInterceptor(guard.Unary()), grpc.StreamInterceptor(guard.Stream()))
+package interceptor
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"goflare.io/auth/pkg/middleware"
+)
+
+// Permission is the action a principal must be allowed on a resource to call a method, e.g. READ on ORDER.
+type Permission struct {
+	Resource string
+	Action   string
+}
+
+// Config configures an Interceptor.
+type Config struct {
+
+	// Verifier validates tokens. It is required; *client.Client implements it.
+	Verifier middleware.Verifier
+
+	// Checker decides the permissions of Permissions. It is required when Permissions is not empty; *client.Client
+	// implements it.
+	Checker middleware.Checker
+
+	// Permissions maps full method names, e.g. /order.v1.OrderService/ListOrders, to the permission a call needs.
+	// Methods that are not listed only need a valid token, unless DenyUnlisted is set.
+	Permissions map[string]Permission
+
+	// Public are the full method names that may be called without a token, e.g. health checks.
+	Public []string
+
+	// DenyUnlisted refuses calls of methods that are neither public nor in Permissions, so that a method added
+	// without a declared permission is not open to every authenticated principal.
+	DenyUnlisted bool
+}
+
+// Interceptor authenticates and authorizes gRPC calls.
+type Interceptor struct {
+	verifier     middleware.Verifier
+	checker      middleware.Checker
+	permissions  map[string]Permission
+	public       map[string]bool
+	denyUnlisted bool
+}
+
+// New creates an interceptor. It panics without a Verifier, or without a Checker when permissions are declared,
+// since every call would fail.
+func New(cfg Config) *Interceptor {
+	if cfg.Verifier == nil {
+		panic("interceptor: a Verifier is required")
+	}
+	if len(cfg.Permissions) > 0 && cfg.Checker == nil {
+		panic("interceptor: a Checker is required for Permissions")
+	}
+
+	public := make(map[string]bool, len(cfg.Public))
+	for _, method := range cfg.Public {
+		public[method] = true
+	}
+	return &Interceptor{
+		verifier:     cfg.Verifier,
+		checker:      cfg.Checker,
+		permissions:  cfg.Permissions,
+		public:       public,
+		denyUnlisted: cfg.DenyUnlisted,
+	}
+}
+
+// Unary returns the server interceptor for unary calls.
+func (i *Interceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := i.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Stream returns the server interceptor for streaming calls. The permission is checked once, when the stream opens.
+func (i *Interceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := i.authorize(stream.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// authorize authenticates a call of method and checks its permission, returning the context with the principal
+// and the token. Calls of public methods are passed on without authentication.
+func (i *Interceptor) authorize(ctx context.Context, method string) (context.Context, error) {
+	if i.public[method] {
+		return ctx, nil
+	}
+
+	permission, listed := i.permissions[method]
+	if !listed && i.denyUnlisted {
+		return nil, status.Errorf(codes.PermissionDenied, "%s declares no permission", method)
+	}
+
+	token, err := tokenFromMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	principal, err := i.verifier.Verify(ctx, token)
+	if err != nil {
+		return nil, statusOf(err)
+	}
+	ctx = middleware.NewTokenContext(ctx, principal, token)
+
+	if !listed {
+		return ctx, nil
+	}
+	allowed, err := i.checker.CheckPrincipalPermission(ctx, principal, permission.Resource, permission.Action)
+	if err != nil {
+		return nil, statusOf(err)
+	}
+	if !allowed {
+		return nil, statusOf(middleware.ErrForbidden)
+	}
+	return ctx, nil
+}
+
+// tokenFromMetadata returns the bearer token of the authorization metadata of an incoming call.
+func tokenFromMetadata(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", statusOf(middleware.ErrMissingToken)
+	}
+
+	scheme, token, ok := strings.Cut(values[0], " ")
+	token = strings.TrimSpace(token)
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", status.Error(codes.Unauthenticated, "the authorization metadata is not a bearer token")
+	}
+	return token, nil
+}
+
+// statusOf converts an error of authentication or authorization to a gRPC status: Unauthenticated for a missing or
+// invalid token, PermissionDenied for a denied permission, and Unavailable, without the cause, otherwise.
+func statusOf(err error) error {
+	_, code := middleware.StatusOf(err)
+	switch code {
+	case "invalid_request", "invalid_token":
+		return status.Error(codes.Unauthenticated, err.Error())
+	case "insufficient_scope":
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return status.Error(codes.Unavailable, "the token could not be verified")
+}
+
+// serverStream is a server stream whose context carries the principal.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context with the principal.
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,179 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"goflare.io/auth/pkg/client"
+	"goflare.io/auth/pkg/middleware"
+)
+
+const (
+	listOrders  = "/order.v1.OrderService/ListOrders"
+	getOrder    = "/order.v1.OrderService/GetOrder"
+	healthCheck = "/grpc.health.v1.Health/Check"
+)
+
+// fakeVerifier accepts the tokens it knows and fails with err for every other token.
+type fakeVerifier struct {
+	principals map[string]*client.Principal
+	err        error
+}
+
+func (v *fakeVerifier) Verify(_ context.Context, token string) (*client.Principal, error) {
+	if principal, ok := v.principals[token]; ok {
+		return principal, nil
+	}
+	return nil, v.err
+}
+
+// fakeChecker allows the principals with the IDs it lists.
+type fakeChecker struct {
+	allowed map[uint64]bool
+	err     error
+}
+
+func (c *fakeChecker) CheckPrincipalPermission(_ context.Context, principal *client.Principal, _, _ string) (bool, error) {
+	if c.err != nil {
+		return false, c.err
+	}
+	return c.allowed[principal.ID], nil
+}
+
+// fakeServerStream is a server stream with only a context.
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func newInterceptor(verifyErr, checkErr error, denyUnlisted bool) *Interceptor {
+	return New(Config{
+		Verifier: &fakeVerifier{
+			principals: map[string]*client.Principal{
+				"user":    {Type: client.SubjectUser, ID: 1},
+				"service": {Type: client.SubjectServiceAccount, ID: 2},
+			},
+			err: verifyErr,
+		},
+		Checker:      &fakeChecker{allowed: map[uint64]bool{1: true}, err: checkErr},
+		Permissions:  map[string]Permission{listOrders: {Resource: "ORDER", Action: "READ"}},
+		Public:       []string{healthCheck},
+		DenyUnlisted: denyUnlisted,
+	})
+}
+
+func incoming(authorization string) context.Context {
+	if authorization == "" {
+		return context.Background()
+	}
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", authorization))
+}
+
+func TestInterceptorUnary(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		authorization string
+		verifyErr     error
+		checkErr      error
+		denyUnlisted  bool
+		wantCode      codes.Code
+		wantPrincipal bool
+	}{
+		{name: "permitted", method: listOrders, authorization: "Bearer user", wantCode: codes.OK, wantPrincipal: true},
+		{name: "denied", method: listOrders, authorization: "Bearer service", wantCode: codes.PermissionDenied},
+		{name: "unlisted method", method: getOrder, authorization: "Bearer service", wantCode: codes.OK, wantPrincipal: true},
+		{name: "unlisted method denied", method: getOrder, authorization: "Bearer user", denyUnlisted: true, wantCode: codes.PermissionDenied},
+		{name: "public method without a token", method: healthCheck, denyUnlisted: true, wantCode: codes.OK},
+		{name: "missing token", method: listOrders, wantCode: codes.Unauthenticated},
+		{name: "other scheme", method: listOrders, authorization: "Basic dXNlcjpwYXNz", wantCode: codes.Unauthenticated},
+		{name: "empty bearer token", method: listOrders, authorization: "Bearer ", wantCode: codes.Unauthenticated},
+		{name: "invalid token", method: listOrders, authorization: "Bearer forged", verifyErr: client.ErrInvalidToken, wantCode: codes.Unauthenticated},
+		{name: "verifier unavailable", method: listOrders, authorization: "Bearer forged", verifyErr: errors.New("connection refused"), wantCode: codes.Unavailable},
+		{name: "checker unavailable", method: listOrders, authorization: "Bearer user", checkErr: errors.New("connection refused"), wantCode: codes.Unavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard := newInterceptor(tt.verifyErr, tt.checkErr, tt.denyUnlisted)
+
+			var gotPrincipal bool
+			handler := func(ctx context.Context, _ any) (any, error) {
+				_, gotPrincipal = middleware.PrincipalFromContext(ctx)
+				return "reply", nil
+			}
+			_, err := guard.Unary()(incoming(tt.authorization), nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("code = %v, want %v (%v)", code, tt.wantCode, err)
+			}
+			if gotPrincipal != tt.wantPrincipal {
+				t.Errorf("principal in context = %v, want %v", gotPrincipal, tt.wantPrincipal)
+			}
+		})
+	}
+}
+
+func TestInterceptorStream(t *testing.T) {
+	tests := []struct {
+		name          string
+		authorization string
+		wantCode      codes.Code
+	}{
+		{"permitted", "Bearer user", codes.OK},
+		{"denied", "Bearer service", codes.PermissionDenied},
+		{"missing token", "", codes.Unauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard := newInterceptor(client.ErrInvalidToken, nil, false)
+
+			var token string
+			handler := func(_ any, stream grpc.ServerStream) error {
+				token, _ = middleware.TokenFromContext(stream.Context())
+				return nil
+			}
+			stream := &fakeServerStream{ctx: incoming(tt.authorization)}
+			err := guard.Stream()(nil, stream, &grpc.StreamServerInfo{FullMethod: listOrders}, handler)
+
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("code = %v, want %v (%v)", code, tt.wantCode, err)
+			}
+			if tt.wantCode == codes.OK && token != "user" {
+				t.Errorf("token in stream context = %q, want %q", token, "user")
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"without a verifier", Config{}},
+		{"permissions without a checker", Config{Verifier: &fakeVerifier{}, Permissions: map[string]Permission{listOrders: {"ORDER", "READ"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			New(tt.cfg)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+
+	"goflare.io/auth/pkg/client"
+)
+
+// contextKey is the type of the context keys of this package, so that they cannot collide with others.
+type contextKey int
+
+const (
+	// principalKey is the context key of the authenticated principal.
+	principalKey contextKey = iota
+
+	// tokenKey is the context key of the token the principal authenticated with.
+	tokenKey
+)
+
+// NewTokenContext returns a copy of ctx that carries the authenticated principal and the token it authenticated
+// with. The gRPC interceptors of package interceptor use it, so that handlers read both the same way.
+func NewTokenContext(ctx context.Context, principal *client.Principal, token string) context.Context {
+	return context.WithValue(NewContext(ctx, principal), tokenKey, token)
+}
+
+// NewContext returns a copy of ctx that carries an authenticated principal, e.g. for tests of handlers.
+func NewContext(ctx context.Context, principal *client.Principal) context.Context {
+	return context.WithValue(ctx, principalKey, principal)
+}
+
+// PrincipalFromContext returns the principal the middleware authenticated.
+func PrincipalFromContext(ctx context.Context) (*client.Principal, bool) {
+	principal, ok := ctx.Value(principalKey).(*client.Principal)
+	return principal, ok && principal != nil
+}
+
+// UserIDFromContext returns the ID of the user the middleware authenticated. It reports false for service accounts.
+func UserIDFromContext(ctx context.Context) (uint64, bool) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || !principal.IsUser() || principal.ID == 0 {
+		return 0, false
+	}
+	return principal.ID, true
+}
+
+// TokenFromContext returns the token the principal authenticated with, e.g. to exchange it for a token to call
+// another service with.
+func TokenFromContext(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(tokenKey).(string)
+	return token, ok && token != ""
+}
@@ -0,0 +1,210 @@
+// Package middleware protects the HTTP handlers of downstream services with tokens issued by the auth service.
+// Authenticate verifies the bearer token or session cookie of a request and puts the principal in the context;
+// RequirePermission also checks that the principal may perform an action on a resource.
+//
+// Tokens are verified by a Verifier, usually a *client.Client, which verifies access tokens locally with the
+// published public keys when client.Config.LocalVerification is set, and remotely otherwise:
+//
+//	auth, err := client.New(client.Config{BaseURL: "https://auth.example.com", GRPCAddress: "auth:9090", LocalVerification: true})
+//	if err != nil {
+//		return err
+//	}
+//	mw := middleware.New(middleware.Config{Verifier: auth, Checker: auth})
+//	mux.Handle("GET /orders", mw.RequirePermission("ORDER", "READ")(listOrders))
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"goflare.io/auth/pkg/client"
+)
+
+var (
+	// ErrMissingToken is passed to the ErrorHandler when a request carries no token.
+	ErrMissingToken = errors.New("missing token")
+
+	// ErrForbidden is passed to the ErrorHandler when the principal may not perform the required action.
+	ErrForbidden = errors.New("forbidden")
+)
+
+// Verifier validates a token and returns the principal it was issued to. *client.Client implements it.
+type Verifier interface {
+	Verify(ctx context.Context, token string) (*client.Principal, error)
+}
+
+// Checker decides whether a principal may perform an action on a resource. *client.Client implements it.
+type Checker interface {
+	CheckPrincipalPermission(ctx context.Context, principal *client.Principal, resource, action string) (bool, error)
+}
+
+// ErrorHandler writes the response of a request that failed authentication or authorization. err is
+// ErrMissingToken, ErrForbidden, or the error of the Verifier or Checker.
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// Config configures a Middleware.
+type Config struct {
+
+	// Verifier validates tokens. It is required.
+	Verifier Verifier
+
+	// Checker decides permissions for RequirePermission. It is required for RequirePermission.
+	Checker Checker
+
+	// CookieName is the cookie a token is read from when the request has no Authorization header. Tokens are only
+	// read from the Authorization header when it is empty.
+	CookieName string
+
+	// ErrorHandler writes the error responses. It defaults to DefaultErrorHandler.
+	ErrorHandler ErrorHandler
+}
+
+// Middleware authenticates and authorizes HTTP requests.
+type Middleware struct {
+	verifier     Verifier
+	checker      Checker
+	cookieName   string
+	errorHandler ErrorHandler
+}
+
+// New creates a middleware. It panics without a Verifier, since every request would fail.
+func New(cfg Config) *Middleware {
+	if cfg.Verifier == nil {
+		panic("middleware: a Verifier is required")
+	}
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = DefaultErrorHandler
+	}
+	return &Middleware{
+		verifier:     cfg.Verifier,
+		checker:      cfg.Checker,
+		cookieName:   cfg.CookieName,
+		errorHandler: cfg.ErrorHandler,
+	}
+}
+
+// Authenticate verifies the token of a request and passes it on with the principal and the token in the context.
+func (m *Middleware) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, ok := m.authenticate(w, r)
+		if !ok {
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequirePermission returns a middleware that authenticates a request, unless an outer Authenticate already did,
+// and passes it on only if the principal may perform action on resource, e.g. READ on ORDER.
+func (m *Middleware) RequirePermission(resource, action string) func(http.Handler) http.Handler {
+	if m.checker == nil {
+		panic("middleware: a Checker is required for RequirePermission")
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			principal, ok := PrincipalFromContext(ctx)
+			if !ok {
+				if ctx, ok = m.authenticate(w, r); !ok {
+					return
+				}
+				principal, _ = PrincipalFromContext(ctx)
+			}
+
+			allowed, err := m.checker.CheckPrincipalPermission(ctx, principal, resource, action)
+			if err != nil {
+				m.errorHandler(w, r, err)
+				return
+			}
+			if !allowed {
+				m.errorHandler(w, r, ErrForbidden)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// authenticate verifies the token of a request and returns the context with the principal, writing the error
+// response if there is no valid token.
+func (m *Middleware) authenticate(w http.ResponseWriter, r *http.Request) (context.Context, bool) {
+	token := m.token(r)
+	if token == "" {
+		m.errorHandler(w, r, ErrMissingToken)
+		return nil, false
+	}
+
+	principal, err := m.verifier.Verify(r.Context(), token)
+	if err != nil {
+		m.errorHandler(w, r, err)
+		return nil, false
+	}
+
+	return NewTokenContext(r.Context(), principal, token), true
+}
+
+// token returns the bearer token of the Authorization header, or the value of the session cookie when there is
+// no Authorization header.
+func (m *Middleware) token(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			return ""
+		}
+		return strings.TrimSpace(token)
+	}
+
+	if m.cookieName != "" {
+		if cookie, err := r.Cookie(m.cookieName); err == nil {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+// DefaultErrorHandler answers a missing or invalid token with 401 and a WWW-Authenticate challenge (RFC 6750
+// section 3), a denied permission with 403, and a failure to verify or check with 503. The body is a JSON object
+// with error and error_description fields; the cause of a 503 is not disclosed.
+func DefaultErrorHandler(w http.ResponseWriter, _ *http.Request, err error) {
+	status, code := StatusOf(err)
+	switch code {
+	case "invalid_request", "invalid_token":
+		challenge := `Bearer realm="api"`
+		if code == "invalid_token" {
+			challenge += `, error="invalid_token"`
+		}
+		w.Header().Set("WWW-Authenticate", challenge)
+	case "insufficient_scope":
+		w.Header().Set("WWW-Authenticate", `Bearer realm="api", error="insufficient_scope"`)
+	}
+
+	description := err.Error()
+	if status == http.StatusServiceUnavailable {
+		description = "the token could not be verified"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": code, "error_description": description})
+}
+
+// StatusOf returns the HTTP status and RFC 6750 error code of an error passed to an ErrorHandler, for handlers
+// that only change the format of the response.
+func StatusOf(err error) (int, string) {
+	var restErr *client.Error
+	switch {
+	case errors.Is(err, ErrMissingToken):
+		return http.StatusUnauthorized, "invalid_request"
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden, "insufficient_scope"
+	case errors.Is(err, client.ErrInvalidToken), errors.Is(err, client.ErrWrongAudience):
+		return http.StatusUnauthorized, "invalid_token"
+	case errors.As(err, &restErr) && restErr.StatusCode == http.StatusUnauthorized:
+		return http.StatusUnauthorized, "invalid_token"
+	}
+	return http.StatusServiceUnavailable, "temporarily_unavailable"
+}
@@ -0,0 +1,222 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"goflare.io/auth/pkg/client"
+)
+
+// fakeVerifier accepts the tokens it knows and fails with err for every other token.
+type fakeVerifier struct {
+	principals map[string]*client.Principal
+	err        error
+	calls      int
+}
+
+func (v *fakeVerifier) Verify(_ context.Context, token string) (*client.Principal, error) {
+	v.calls++
+	if principal, ok := v.principals[token]; ok {
+		return principal, nil
+	}
+	return nil, v.err
+}
+
+// fakeChecker allows the principals with the IDs it lists.
+type fakeChecker struct {
+	allowed map[uint64]bool
+	err     error
+}
+
+func (c *fakeChecker) CheckPrincipalPermission(_ context.Context, principal *client.Principal, _, _ string) (bool, error) {
+	if c.err != nil {
+		return false, c.err
+	}
+	return c.allowed[principal.ID], nil
+}
+
+// echoPrincipal answers with the token and principal it finds in the context.
+var echoPrincipal = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	principal, ok := PrincipalFromContext(r.Context())
+	token, _ := TokenFromContext(r.Context())
+	if !ok {
+		http.Error(w, "no principal", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("X-Token", token)
+	w.Header().Set("X-Principal", principal.Type)
+})
+
+func newVerifier(err error) *fakeVerifier {
+	return &fakeVerifier{
+		principals: map[string]*client.Principal{
+			"user":    {Type: client.SubjectUser, ID: 1},
+			"service": {Type: client.SubjectServiceAccount, ID: 2},
+		},
+		err: err,
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		name          string
+		authorization string
+		cookie        string
+		verifyErr     error
+		wantStatus    int
+		wantToken     string
+		wantChallenge string
+	}{
+		{name: "bearer token", authorization: "Bearer user", wantStatus: http.StatusOK, wantToken: "user"},
+		{name: "lower case scheme", authorization: "bearer  user ", wantStatus: http.StatusOK, wantToken: "user"},
+		{name: "session cookie", cookie: "service", wantStatus: http.StatusOK, wantToken: "service"},
+		{name: "header takes precedence over cookie", authorization: "Bearer user", cookie: "service", wantStatus: http.StatusOK, wantToken: "user"},
+		{name: "missing token", wantStatus: http.StatusUnauthorized, wantChallenge: `Bearer realm="api"`},
+		{name: "other scheme", authorization: "Basic dXNlcjpwYXNz", cookie: "user", wantStatus: http.StatusUnauthorized, wantChallenge: `Bearer realm="api"`},
+		{name: "invalid token", authorization: "Bearer forged", verifyErr: client.ErrInvalidToken, wantStatus: http.StatusUnauthorized, wantChallenge: `Bearer realm="api", error="invalid_token"`},
+		{name: "wrong audience", authorization: "Bearer forged", verifyErr: client.ErrWrongAudience, wantStatus: http.StatusUnauthorized, wantChallenge: `Bearer realm="api", error="invalid_token"`},
+		{name: "service unavailable", authorization: "Bearer forged", verifyErr: errors.New("connection refused"), wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mw := New(Config{Verifier: newVerifier(tt.verifyErr), CookieName: "session"})
+
+			r := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: "session", Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			mw.Authenticate(echoPrincipal).ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("X-Token"); got != tt.wantToken {
+				t.Errorf("token in context = %q, want %q", got, tt.wantToken)
+			}
+			if got := w.Header().Get("WWW-Authenticate"); got != tt.wantChallenge {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, tt.wantChallenge)
+			}
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		checkErr   error
+		outer      bool
+		wantStatus int
+	}{
+		{name: "allowed", token: "user", wantStatus: http.StatusOK},
+		{name: "allowed after an outer Authenticate", token: "user", outer: true, wantStatus: http.StatusOK},
+		{name: "denied", token: "service", wantStatus: http.StatusForbidden},
+		{name: "invalid token", token: "forged", wantStatus: http.StatusUnauthorized},
+		{name: "checker unavailable", token: "user", checkErr: errors.New("connection refused"), wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := newVerifier(client.ErrInvalidToken)
+			mw := New(Config{Verifier: verifier, Checker: &fakeChecker{allowed: map[uint64]bool{1: true}, err: tt.checkErr}})
+
+			handler := mw.RequirePermission("ORDER", "READ")(echoPrincipal)
+			if tt.outer {
+				handler = mw.Authenticate(handler)
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			r.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if verifier.calls != 1 {
+				t.Errorf("token verified %d times, want 1", verifier.calls)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        Config
+		permission bool
+	}{
+		{name: "without a verifier", cfg: Config{}},
+		{name: "RequirePermission without a checker", cfg: Config{Verifier: newVerifier(nil)}, permission: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			mw := New(tt.cfg)
+			if tt.permission {
+				mw.RequirePermission("ORDER", "READ")
+			}
+		})
+	}
+}
+
+func TestStatusOf(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"missing token", ErrMissingToken, http.StatusUnauthorized, "invalid_request"},
+		{"forbidden", ErrForbidden, http.StatusForbidden, "insufficient_scope"},
+		{"invalid token", client.ErrInvalidToken, http.StatusUnauthorized, "invalid_token"},
+		{"rejected by the service", &client.Error{StatusCode: http.StatusUnauthorized}, http.StatusUnauthorized, "invalid_token"},
+		{"service error", &client.Error{StatusCode: http.StatusInternalServerError}, http.StatusServiceUnavailable, "temporarily_unavailable"},
+		{"unreachable", errors.New("connection refused"), http.StatusServiceUnavailable, "temporarily_unavailable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, code := StatusOf(tt.err)
+			if status != tt.wantStatus || code != tt.wantCode {
+				t.Errorf("StatusOf() = %d %q, want %d %q", status, code, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestUserIDFromContext(t *testing.T) {
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		wantID uint64
+		wantOK bool
+	}{
+		{"user", NewContext(context.Background(), &client.Principal{Type: client.SubjectUser, ID: 1}), 1, true},
+		{"service account", NewContext(context.Background(), &client.Principal{Type: client.SubjectServiceAccount, ID: 2}), 0, false},
+		{"nil principal", NewContext(context.Background(), nil), 0, false},
+		{"no principal", context.Background(), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, ok := UserIDFromContext(tt.ctx)
+			if id != tt.wantID || ok != tt.wantOK {
+				t.Errorf("UserIDFromContext() = %d %v, want %d %v", id, ok, tt.wantID, tt.wantOK)
+			}
+		})
+	}
+}